@@ -1,6 +1,7 @@
 package main
 
 import (
+	"fmt"
 	"os"
 
 	"github.com/moov-io/identity/pkg/logging"
@@ -12,6 +13,14 @@ func main() {
 		Logger: logging.NewDefaultLogger().WithKeyValue("app", "identity"),
 	}
 
+	if len(os.Args) > 1 && os.Args[1] == "migrate" {
+		if err := migrate(env.Logger, os.Args[2:]); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		return
+	}
+
 	env, err := service.NewEnvironment(env)
 	if err != nil {
 		env.Logger.Fatal().LogError("Error loading up environment.", err)
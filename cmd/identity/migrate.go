@@ -0,0 +1,130 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+
+	"github.com/moov-io/identity/pkg/database"
+	"github.com/moov-io/identity/pkg/logging"
+	"github.com/moov-io/identity/pkg/service"
+)
+
+const migrateUsage = `usage: identity migrate <command>
+
+  up          run every migration that hasn't been yet
+  down N      roll back the last N migrations
+  goto V      run the migrations up or down to version V
+  version     print the version the database is at
+  force V     record the database as being at version V without running anything
+  check       list the rows that keep the unique indexes and foreign keys from being added`
+
+// migrate - Runs the migrations of the configured database as their own step instead of when the service starts.
+func migrate(logger logging.Logger, args []string) error {
+	if len(args) == 0 {
+		return errors.New(migrateUsage)
+	}
+
+	cfg, err := service.LoadConfig(logger)
+	if err != nil {
+		return err
+	}
+
+	db, err := database.New(context.Background(), logger, cfg.Database)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	migrator, err := database.NewMigrator(logger, db, cfg.Database)
+	if err != nil {
+		return err
+	}
+
+	command, args := args[0], args[1:]
+	switch command {
+	case "up":
+		if len(args) != 0 {
+			return errors.New(migrateUsage)
+		}
+		err = migrator.Up()
+
+	case "down":
+		steps, parseErr := migrateArg(args)
+		if parseErr != nil {
+			return parseErr
+		}
+		err = migrator.Down(steps)
+
+	case "goto":
+		version, parseErr := migrateArg(args)
+		if parseErr != nil {
+			return parseErr
+		}
+		if version < 1 {
+			return fmt.Errorf("version must be at least 1 but was %d", version)
+		}
+		err = migrator.Goto(uint(version))
+
+	case "force":
+		version, parseErr := migrateArg(args)
+		if parseErr != nil {
+			return parseErr
+		}
+		err = migrator.Force(version)
+
+	case "check":
+		if len(args) != 0 {
+			return errors.New(migrateUsage)
+		}
+
+		violations, err := database.CheckConstraints(context.Background(), db)
+		if err != nil {
+			return err
+		}
+		if len(violations) > 0 {
+			return violations
+		}
+
+		fmt.Println("No constraint violations")
+		return nil
+
+	case "version":
+		if len(args) != 0 {
+			return errors.New(migrateUsage)
+		}
+
+	default:
+		return errors.New(migrateUsage)
+	}
+	if err != nil {
+		return err
+	}
+
+	version, dirty, err := migrator.Version()
+	if err != nil {
+		return err
+	}
+
+	if dirty {
+		fmt.Printf("%d (dirty)\n", version)
+	} else {
+		fmt.Printf("%d\n", version)
+	}
+
+	return nil
+}
+
+func migrateArg(args []string) (int, error) {
+	if len(args) != 1 {
+		return 0, errors.New(migrateUsage)
+	}
+
+	n, err := strconv.Atoi(args[0])
+	if err != nil {
+		return 0, fmt.Errorf("%s is not a number\n\n%s", args[0], migrateUsage)
+	}
+
+	return n, nil
+}
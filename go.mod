@@ -4,6 +4,8 @@ go 1.14
 
 require (
 	github.com/asaskevich/govalidator v0.0.0-20200108200545-475eaeb16496
+	github.com/emersion/go-smtp v0.15.0
+	github.com/fsnotify/fsnotify v1.4.7
 	github.com/go-kit/kit v0.10.0
 	github.com/go-ozzo/ozzo-validation/v4 v4.2.2
 	github.com/go-sql-driver/mysql v1.5.0
@@ -12,6 +14,7 @@ require (
 	github.com/google/gofuzz v1.2.0
 	github.com/google/uuid v1.1.2
 	github.com/gorilla/mux v1.8.0
+	github.com/lib/pq v1.3.0
 	github.com/markbates/pkger v0.17.0
 	github.com/mattn/go-sqlite3 v2.0.3+incompatible
 	github.com/moov-io/authn v0.4.1
@@ -22,6 +25,7 @@ require (
 	github.com/spf13/viper v1.7.1
 	github.com/square/go-jose v2.5.1+incompatible
 	github.com/stretchr/testify v1.6.1
+	github.com/toorop/go-dkim v0.0.0-20201103131630-e1cd1a0a5208
 	golang.org/x/oauth2 v0.0.0-20200902213428-5d25da1a8d43
 	gopkg.in/gomail.v2 v2.0.0-20160411212932-81ebce5c23df
 )
@@ -0,0 +1 @@
+DROP TABLE credential_logins;
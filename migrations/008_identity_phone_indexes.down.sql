@@ -0,0 +1 @@
+DROP INDEX identity_phone_identity_id;
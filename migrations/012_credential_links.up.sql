@@ -0,0 +1,14 @@
+CREATE TABLE credential_links (
+    link_id         VARCHAR(36) NOT NULL,
+    tenant_id       VARCHAR(36) NOT NULL,
+    identity_id     VARCHAR(36) NOT NULL,
+
+    created_by      VARCHAR(36) NOT NULL,
+    created_on      TIMESTAMP NOT NULL,
+    expires_on      TIMESTAMP NOT NULL,
+    redeemed_on     TIMESTAMP DEFAULT NULL,
+
+    secret_code     VARCHAR(255) NOT NULL,
+
+    CONSTRAINT credential_link_pk PRIMARY KEY (link_id)
+);
@@ -0,0 +1 @@
+ALTER TABLE identity ADD merged_into VARCHAR(36) NULL DEFAULT NULL;
@@ -0,0 +1,11 @@
+CREATE TABLE registration_policies (
+    tenant_id           VARCHAR(36) NOT NULL,
+
+    mode                VARCHAR(20) NOT NULL,
+    require_approval    BOOLEAN DEFAULT false,
+
+    last_updated_on     TIMESTAMP NOT NULL,
+    last_updated_by     VARCHAR(36) NOT NULL,
+
+    CONSTRAINT registration_policy_pk PRIMARY KEY (tenant_id)
+);
@@ -0,0 +1 @@
+DROP TABLE registration_policy_domains;
@@ -0,0 +1,6 @@
+CREATE TABLE registration_policy_domains (
+    tenant_id       VARCHAR(36) NOT NULL,
+    domain          VARCHAR(255) NOT NULL,
+
+    CONSTRAINT registration_policy_domain_pk PRIMARY KEY (tenant_id, domain)
+);
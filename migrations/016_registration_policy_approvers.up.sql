@@ -0,0 +1,6 @@
+CREATE TABLE registration_policy_approvers (
+    tenant_id       VARCHAR(36) NOT NULL,
+    email           VARCHAR(255) NOT NULL,
+
+    CONSTRAINT registration_policy_approver_pk PRIMARY KEY (tenant_id, email)
+);
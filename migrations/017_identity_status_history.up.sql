@@ -0,0 +1,14 @@
+CREATE TABLE identity_status_history (
+    status_change_id    VARCHAR(36) NOT NULL,
+    tenant_id           VARCHAR(36) NOT NULL,
+    identity_id         VARCHAR(36) NOT NULL,
+
+    from_status         VARCHAR(20) NOT NULL,
+    to_status           VARCHAR(20) NOT NULL,
+    reason              VARCHAR(255) NOT NULL,
+
+    changed_by          VARCHAR(36) NOT NULL,
+    changed_on          TIMESTAMP NOT NULL,
+
+    CONSTRAINT identity_status_history_pk PRIMARY KEY (status_change_id)
+);
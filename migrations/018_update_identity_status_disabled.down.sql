@@ -0,0 +1,2 @@
+-- Only moved identities to the disabled status, which statuses they had before isn't kept so there's nothing to put back.
+SELECT 1;
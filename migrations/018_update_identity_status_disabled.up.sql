@@ -0,0 +1 @@
+UPDATE identity SET status = 'disabled' WHERE disabled_on IS NOT NULL OR status = 'rejected';
@@ -0,0 +1,2 @@
+-- Only moved identities with an unknown status to active, what they had before isn't kept so there's nothing to put back.
+SELECT 1;
@@ -0,0 +1 @@
+UPDATE identity SET status = 'active' WHERE status NOT IN ('pending', 'active', 'suspended', 'locked', 'disabled', 'deleted');
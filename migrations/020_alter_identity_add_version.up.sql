@@ -0,0 +1 @@
+ALTER TABLE identity ADD version BIGINT NOT NULL DEFAULT 1;
@@ -0,0 +1,12 @@
+CREATE TABLE identity_history (
+    identity_change_id  VARCHAR(36) NOT NULL,
+    tenant_id           VARCHAR(36) NOT NULL,
+    identity_id         VARCHAR(36) NOT NULL,
+
+    changes             TEXT NOT NULL,
+
+    changed_by          VARCHAR(36) NOT NULL,
+    changed_on          TIMESTAMP NOT NULL,
+
+    CONSTRAINT identity_history_pk PRIMARY KEY (identity_change_id)
+);
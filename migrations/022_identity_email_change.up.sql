@@ -0,0 +1,16 @@
+CREATE TABLE identity_email_change (
+    email_change_id     VARCHAR(36) NOT NULL,
+    tenant_id           VARCHAR(36) NOT NULL,
+    identity_id         VARCHAR(36) NOT NULL,
+
+    new_email           VARCHAR(255) NOT NULL,
+
+    requested_by        VARCHAR(36) NOT NULL,
+    requested_on        TIMESTAMP NOT NULL,
+    expires_on          TIMESTAMP NOT NULL,
+    confirmed_on        TIMESTAMP DEFAULT NULL,
+
+    secret_code         VARCHAR(255) NOT NULL,
+
+    CONSTRAINT identity_email_change_pk PRIMARY KEY (email_change_id)
+);
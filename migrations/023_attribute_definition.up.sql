@@ -0,0 +1,13 @@
+CREATE TABLE attribute_definition (
+    tenant_id           VARCHAR(36) NOT NULL,
+    name                VARCHAR(64) NOT NULL,
+
+    type                VARCHAR(10) NOT NULL,
+    enum_values         TEXT NOT NULL,
+    description         VARCHAR(255) DEFAULT NULL,
+
+    created_on          TIMESTAMP NOT NULL,
+    last_updated_on     TIMESTAMP NOT NULL,
+
+    CONSTRAINT attribute_definition_pk PRIMARY KEY (tenant_id, name)
+);
@@ -0,0 +1,9 @@
+CREATE TABLE identity_attribute (
+    identity_id         VARCHAR(36) NOT NULL,
+    tenant_id           VARCHAR(36) NOT NULL,
+    name                VARCHAR(64) NOT NULL,
+
+    value               VARCHAR(255) NOT NULL,
+
+    CONSTRAINT identity_attribute_pk PRIMARY KEY (identity_id, name)
+);
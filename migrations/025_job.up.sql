@@ -0,0 +1,13 @@
+CREATE TABLE job (
+    job_id              VARCHAR(36) NOT NULL,
+    tenant_id           VARCHAR(36) NOT NULL,
+    kind                VARCHAR(36) NOT NULL,
+    status              VARCHAR(36) NOT NULL,
+
+    created_by          VARCHAR(36) NOT NULL,
+    created_on          TIMESTAMP NOT NULL,
+    started_on          TIMESTAMP DEFAULT NULL,
+    completed_on        TIMESTAMP DEFAULT NULL,
+
+    CONSTRAINT job_pk PRIMARY KEY (job_id)
+);
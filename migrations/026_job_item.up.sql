@@ -0,0 +1,12 @@
+CREATE TABLE job_item (
+    job_id              VARCHAR(36) NOT NULL,
+    seq                 INTEGER NOT NULL,
+    item_key            VARCHAR(255) NOT NULL,
+    status              VARCHAR(36) NOT NULL,
+
+    result_id           VARCHAR(36) DEFAULT NULL,
+    error               VARCHAR(255) DEFAULT NULL,
+    completed_on        TIMESTAMP DEFAULT NULL,
+
+    CONSTRAINT job_item_pk PRIMARY KEY (job_id, seq)
+);
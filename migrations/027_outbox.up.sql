@@ -0,0 +1,18 @@
+CREATE TABLE outbox (
+    message_id          VARCHAR(36) NOT NULL,
+    tenant_id           VARCHAR(36) NOT NULL,
+    recipient           VARCHAR(255) NOT NULL,
+    template            VARCHAR(255) NOT NULL,
+    subject             VARCHAR(255) NOT NULL,
+    payload             TEXT NOT NULL,
+
+    status              VARCHAR(36) NOT NULL,
+    attempts            INTEGER NOT NULL,
+    last_error          VARCHAR(255) DEFAULT NULL,
+
+    created_on          TIMESTAMP NOT NULL,
+    next_attempt_on     TIMESTAMP NOT NULL,
+    sent_on             TIMESTAMP DEFAULT NULL,
+
+    CONSTRAINT outbox_pk PRIMARY KEY (message_id)
+);
@@ -0,0 +1 @@
+DROP INDEX outbox_status_next_attempt_on;
@@ -0,0 +1 @@
+CREATE INDEX outbox_status_next_attempt_on ON outbox (status, next_attempt_on);
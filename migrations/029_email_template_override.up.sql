@@ -0,0 +1,17 @@
+CREATE TABLE email_template_override (
+    tenant_id           VARCHAR(36) NOT NULL,
+    template            VARCHAR(64) NOT NULL,
+
+    sender_name         VARCHAR(64) DEFAULT NULL,
+    subject             VARCHAR(255) DEFAULT NULL,
+    logo_url            VARCHAR(255) DEFAULT NULL,
+    primary_color       VARCHAR(7) DEFAULT NULL,
+    background_color    VARCHAR(7) DEFAULT NULL,
+    html_body           TEXT DEFAULT NULL,
+    text_body           TEXT DEFAULT NULL,
+
+    updated_by          VARCHAR(36) NOT NULL,
+    updated_on          TIMESTAMP NOT NULL,
+
+    CONSTRAINT email_template_override_pk PRIMARY KEY (tenant_id, template)
+);
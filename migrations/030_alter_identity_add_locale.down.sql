@@ -0,0 +1 @@
+ALTER TABLE identity DROP COLUMN locale;
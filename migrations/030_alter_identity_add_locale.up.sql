@@ -0,0 +1 @@
+ALTER TABLE identity ADD locale VARCHAR(35) NULL DEFAULT NULL;
\ No newline at end of file
@@ -0,0 +1 @@
+DROP INDEX identity_tenant_id_email;
\ No newline at end of file
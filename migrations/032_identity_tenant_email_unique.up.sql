@@ -0,0 +1 @@
+CREATE UNIQUE INDEX identity_tenant_id_email ON identity (tenant_id, email) WHERE merged_into IS NULL;
\ No newline at end of file
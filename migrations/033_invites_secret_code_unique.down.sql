@@ -0,0 +1 @@
+DROP INDEX invites_secret_code;
\ No newline at end of file
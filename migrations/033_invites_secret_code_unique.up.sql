@@ -0,0 +1 @@
+CREATE UNIQUE INDEX invites_secret_code ON invites (secret_code);
\ No newline at end of file
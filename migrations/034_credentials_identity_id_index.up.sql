@@ -0,0 +1 @@
+CREATE INDEX credentials_identity_id ON credentials (identity_id);
\ No newline at end of file
@@ -0,0 +1 @@
+ALTER TABLE identity DROP CONSTRAINT identity_invite_fk;
\ No newline at end of file
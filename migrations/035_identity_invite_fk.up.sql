@@ -0,0 +1 @@
+ALTER TABLE identity ADD CONSTRAINT identity_invite_fk FOREIGN KEY (invite_id) REFERENCES invites (invite_id);
\ No newline at end of file
@@ -0,0 +1 @@
+ALTER TABLE identity_address ADD CONSTRAINT identity_address_identity_fk FOREIGN KEY (identity_id) REFERENCES identity (identity_id);
\ No newline at end of file
@@ -0,0 +1 @@
+ALTER TABLE identity_phone DROP CONSTRAINT identity_phone_identity_fk;
\ No newline at end of file
@@ -0,0 +1 @@
+ALTER TABLE identity_phone ADD CONSTRAINT identity_phone_identity_fk FOREIGN KEY (identity_id) REFERENCES identity (identity_id);
\ No newline at end of file
@@ -0,0 +1 @@
+ALTER TABLE credentials ADD CONSTRAINT credentials_identity_fk FOREIGN KEY (identity_id) REFERENCES identity (identity_id);
\ No newline at end of file
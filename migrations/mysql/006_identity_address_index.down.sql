@@ -0,0 +1 @@
+DROP INDEX identity_address_identity_id ON identity_address;
@@ -0,0 +1 @@
+ALTER TABLE identity DROP INDEX identity_tenant_id_email, DROP COLUMN unmerged_email;
\ No newline at end of file
@@ -0,0 +1,4 @@
+-- MySQL doesn't have partial indexes so the email is copied into a column only while the identity hasn't been merged away, the NULLs of merged ones don't collide.
+ALTER TABLE identity
+    ADD unmerged_email VARCHAR(255) AS (IF(merged_into IS NULL, email, NULL)) STORED,
+    ADD UNIQUE INDEX identity_tenant_id_email (tenant_id, unmerged_email);
\ No newline at end of file
@@ -0,0 +1 @@
+DROP INDEX invites_secret_code ON invites;
\ No newline at end of file
@@ -0,0 +1 @@
+DROP INDEX credentials_identity_id ON credentials;
\ No newline at end of file
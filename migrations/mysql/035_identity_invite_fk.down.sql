@@ -0,0 +1 @@
+ALTER TABLE identity DROP FOREIGN KEY identity_invite_fk;
\ No newline at end of file
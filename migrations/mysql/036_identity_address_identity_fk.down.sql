@@ -0,0 +1 @@
+ALTER TABLE identity_address DROP FOREIGN KEY identity_address_identity_fk;
\ No newline at end of file
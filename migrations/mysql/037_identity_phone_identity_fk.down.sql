@@ -0,0 +1 @@
+ALTER TABLE identity_phone DROP FOREIGN KEY identity_phone_identity_fk;
\ No newline at end of file
@@ -0,0 +1 @@
+ALTER TABLE credentials DROP FOREIGN KEY credentials_identity_fk;
\ No newline at end of file
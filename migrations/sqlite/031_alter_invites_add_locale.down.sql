@@ -0,0 +1,23 @@
+-- SQLite can't drop a column so the table is rebuilt without it.
+CREATE TABLE invites_down (
+    invite_id       VARCHAR(36) NOT NULL,
+    tenant_id       VARCHAR(36) NOT NULL,
+
+    email           VARCHAR(255) NOT NULL,
+    invited_by      VARCHAR(36) NOT NULL,
+    invited_on      TIMESTAMP NOT NULL,
+    redeemed_on     TIMESTAMP,
+    expires_on      TIMESTAMP NOT NULL,
+    disabled_on     TIMESTAMP DEFAULT NULL,
+    disabled_by     VARCHAR(36) DEFAULT NULL,
+
+    secret_code     VARCHAR(255) NOT NULL,
+
+    CONSTRAINT invite_id_pk PRIMARY KEY (invite_id)
+);
+
+INSERT INTO invites_down (invite_id, tenant_id, email, invited_by, invited_on, redeemed_on, expires_on, disabled_on, disabled_by, secret_code) SELECT invite_id, tenant_id, email, invited_by, invited_on, redeemed_on, expires_on, disabled_on, disabled_by, secret_code FROM invites;
+
+DROP TABLE invites;
+
+ALTER TABLE invites_down RENAME TO invites;
@@ -0,0 +1,39 @@
+-- SQLite can't add a constraint to a table so it's rebuilt with it.
+CREATE TABLE identity_up (
+    identity_id     VARCHAR(36) NOT NULL,
+    tenant_id       VARCHAR(36) NOT NULL,
+
+    first_name      VARCHAR(255) NOT NULL,
+    middle_name     VARCHAR(255),
+    last_name       VARCHAR(255) NOT NULL,
+    nick_name       VARCHAR(255),
+    suffix          VARCHAR(20),
+    birth_date      TIMESTAMP,
+    status          VARCHAR(20) NOT NULL,
+    email           VARCHAR(255) NOT NULL,
+    email_verified  BOOLEAN DEFAULT false,
+    image_url       VARCHAR(36),
+
+    registered_on   TIMESTAMP NOT NULL,
+    invite_id       VARCHAR(36),
+
+    disabled_on     TIMESTAMP,
+    disabled_by     VARCHAR(36),
+
+    last_updated_on TIMESTAMP NOT NULL,
+    photo_url       VARCHAR(255) NULL DEFAULT '',
+    merged_into     VARCHAR(36) NULL DEFAULT NULL,
+    version         BIGINT NOT NULL DEFAULT 1,
+    locale          VARCHAR(35) NULL DEFAULT NULL,
+
+    CONSTRAINT identity_pk PRIMARY KEY (identity_id),
+    CONSTRAINT identity_invite_fk FOREIGN KEY (invite_id) REFERENCES invites (invite_id)
+);
+
+INSERT INTO identity_up (identity_id, tenant_id, first_name, middle_name, last_name, nick_name, suffix, birth_date, status, email, email_verified, image_url, registered_on, invite_id, disabled_on, disabled_by, last_updated_on, photo_url, merged_into, version, locale) SELECT identity_id, tenant_id, first_name, middle_name, last_name, nick_name, suffix, birth_date, status, email, email_verified, image_url, registered_on, invite_id, disabled_on, disabled_by, last_updated_on, photo_url, merged_into, version, locale FROM identity;
+
+DROP TABLE identity;
+
+ALTER TABLE identity_up RENAME TO identity;
+
+CREATE UNIQUE INDEX identity_tenant_id_email ON identity (tenant_id, email) WHERE merged_into IS NULL;
\ No newline at end of file
@@ -0,0 +1,26 @@
+-- SQLite can't drop a constraint so the table is rebuilt without it.
+CREATE TABLE identity_address_down (
+    identity_id     VARCHAR(36) NOT NULL,
+    address_id      VARCHAR(36) NOT NULL,
+
+    type            VARCHAR(20) NOT NULL,
+    address_1       VARCHAR(255) NOT NULL,
+    address_2       VARCHAR(255),
+    city            VARCHAR(255) NOT NULL,
+    state           VARCHAR(2) NOT NULL,
+    postal_code     VARCHAR(10) NOT NULL,
+    country         VARCHAR(2) NOT NULL,
+    validated       BOOLEAN NOT NULL,
+
+    last_updated_on TIMESTAMP NOT NULL,
+
+    CONSTRAINT address_pk PRIMARY KEY (address_id)
+);
+
+INSERT INTO identity_address_down (identity_id, address_id, type, address_1, address_2, city, state, postal_code, country, validated, last_updated_on) SELECT identity_id, address_id, type, address_1, address_2, city, state, postal_code, country, validated, last_updated_on FROM identity_address;
+
+DROP TABLE identity_address;
+
+ALTER TABLE identity_address_down RENAME TO identity_address;
+
+CREATE INDEX identity_address_identity_id ON identity_address (identity_id);
\ No newline at end of file
@@ -0,0 +1,21 @@
+-- SQLite can't drop a constraint so the table is rebuilt without it.
+CREATE TABLE identity_phone_down (
+    identity_id     VARCHAR(36) NOT NULL,
+    phone_id        VARCHAR(36) NOT NULL,
+
+    type            VARCHAR(20) NOT NULL,
+    number          VARCHAR(15),
+    validated       BOOLEAN NOT NULL,
+
+    last_updated_on TIMESTAMP NOT NULL,
+
+    CONSTRAINT phone_pk PRIMARY KEY (phone_id)
+);
+
+INSERT INTO identity_phone_down (identity_id, phone_id, type, number, validated, last_updated_on) SELECT identity_id, phone_id, type, number, validated, last_updated_on FROM identity_phone;
+
+DROP TABLE identity_phone;
+
+ALTER TABLE identity_phone_down RENAME TO identity_phone;
+
+CREATE INDEX identity_phone_identity_id ON identity_phone (identity_id);
\ No newline at end of file
@@ -0,0 +1,22 @@
+-- SQLite can't drop a constraint so the table is rebuilt without it.
+CREATE TABLE credentials_down (
+    credential_id   VARCHAR(36) NOT NULL,
+    tenant_id       VARCHAR(36) NOT NULL,
+    identity_id     VARCHAR(36) NOT NULL,
+
+    created_on      TIMESTAMP NOT NULL,
+    last_used_on    TIMESTAMP NOT NULL,
+
+    disabled_on     TIMESTAMP DEFAULT NULL,
+    disabled_by     VARCHAR(36) DEFAULT NULL,
+
+    CONSTRAINT credential_id_pk PRIMARY KEY (credential_id, tenant_id)
+);
+
+INSERT INTO credentials_down (credential_id, tenant_id, identity_id, created_on, last_used_on, disabled_on, disabled_by) SELECT credential_id, tenant_id, identity_id, created_on, last_used_on, disabled_on, disabled_by FROM credentials;
+
+DROP TABLE credentials;
+
+ALTER TABLE credentials_down RENAME TO credentials;
+
+CREATE INDEX credentials_identity_id ON credentials (identity_id);
\ No newline at end of file
@@ -0,0 +1,23 @@
+-- SQLite can't add a constraint to a table so it's rebuilt with it.
+CREATE TABLE credentials_up (
+    credential_id   VARCHAR(36) NOT NULL,
+    tenant_id       VARCHAR(36) NOT NULL,
+    identity_id     VARCHAR(36) NOT NULL,
+
+    created_on      TIMESTAMP NOT NULL,
+    last_used_on    TIMESTAMP NOT NULL,
+
+    disabled_on     TIMESTAMP DEFAULT NULL,
+    disabled_by     VARCHAR(36) DEFAULT NULL,
+
+    CONSTRAINT credential_id_pk PRIMARY KEY (credential_id, tenant_id),
+    CONSTRAINT credentials_identity_fk FOREIGN KEY (identity_id) REFERENCES identity (identity_id)
+);
+
+INSERT INTO credentials_up (credential_id, tenant_id, identity_id, created_on, last_used_on, disabled_on, disabled_by) SELECT credential_id, tenant_id, identity_id, created_on, last_used_on, disabled_on, disabled_by FROM credentials;
+
+DROP TABLE credentials;
+
+ALTER TABLE credentials_up RENAME TO credentials;
+
+CREATE INDEX credentials_identity_id ON credentials (identity_id);
\ No newline at end of file
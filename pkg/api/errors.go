@@ -0,0 +1,106 @@
+package api
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	validation "github.com/go-ozzo/ozzo-validation/v4"
+	"github.com/moov-io/identity/pkg/apperr"
+	"github.com/moov-io/identity/pkg/client"
+)
+
+var kindStatus = map[apperr.Kind]int{
+	apperr.KindNotFound:             http.StatusNotFound,
+	apperr.KindConflict:             http.StatusConflict,
+	apperr.KindForbidden:            http.StatusForbidden,
+	apperr.KindUnauthorized:         http.StatusUnauthorized,
+	apperr.KindValidation:           http.StatusBadRequest,
+	apperr.KindExpired:              http.StatusGone,
+	apperr.KindPreconditionFailed:   http.StatusPreconditionFailed,
+	apperr.KindPreconditionRequired: http.StatusPreconditionRequired,
+	apperr.KindUnsupportedMediaType: http.StatusUnsupportedMediaType,
+}
+
+// EncodeError writes the error to the http response as an application/problem+json document with the status code for its kind.
+// Anything that isn't one of the typed errors is a 500 without any details so internals aren't leaked to the caller.
+func EncodeError(w http.ResponseWriter, err error) error {
+	problem := NewProblem(err)
+
+	w.Header().Set("Content-Type", "application/problem+json")
+	w.WriteHeader(int(problem.Status))
+
+	e := json.NewEncoder(w)
+	e.SetIndent("", "  ")
+	return e.Encode(problem)
+}
+
+// NewProblem - What's sent back to the caller for the error.
+func NewProblem(err error) client.Problem {
+	var typed *apperr.Error
+	var invalid validation.Errors
+
+	switch {
+	case errors.As(err, &typed):
+		status, ok := kindStatus[typed.Kind]
+		if !ok {
+			status = http.StatusInternalServerError
+		}
+		return problem(status, typed.Message, typed.Fields)
+
+	case errors.As(err, &invalid):
+		return problem(http.StatusBadRequest, "validation failed", ValidationFields(invalid))
+
+	case errors.Is(err, sql.ErrNoRows):
+		return problem(http.StatusNotFound, "not found", nil)
+
+	default:
+		return problem(http.StatusInternalServerError, "", nil)
+	}
+}
+
+func problem(status int, detail string, fields map[string]string) client.Problem {
+	return client.Problem{
+		Type:   "about:blank",
+		Title:  http.StatusText(status),
+		Status: int32(status),
+		Detail: detail,
+		Errors: fields,
+	}
+}
+
+// ValidationFields - Flattens the errors of nested structs and slices so each field is keyed by its path, like addresses.0.city
+func ValidationFields(errs validation.Errors) map[string]string {
+	fields := map[string]string{}
+	flattenValidation("", errs, fields)
+	return fields
+}
+
+func flattenValidation(prefix string, errs validation.Errors, fields map[string]string) {
+	for field, err := range errs {
+		if err == nil {
+			continue
+		}
+
+		path := field
+		if prefix != "" {
+			path = prefix + "." + field
+		}
+
+		if nested, ok := err.(validation.Errors); ok {
+			flattenValidation(path, nested, fields)
+			continue
+		}
+
+		fields[path] = err.Error()
+	}
+}
+
+// DecodeJSONRequest reads the json body of the request into i. A body that can't be read is a validation error.
+func DecodeJSONRequest(r *http.Request, i interface{}) error {
+	if err := json.NewDecoder(r.Body).Decode(i); err != nil {
+		return apperr.Validation("request body isn't valid json: "+err.Error(), nil)
+	}
+	return nil
+}
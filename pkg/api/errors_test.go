@@ -0,0 +1,82 @@
+package api
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	validation "github.com/go-ozzo/ozzo-validation/v4"
+	"github.com/moov-io/identity/pkg/apperr"
+	"github.com/moov-io/identity/pkg/client"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_EncodeError(t *testing.T) {
+	a := require.New(t)
+
+	w := httptest.NewRecorder()
+	a.NoError(EncodeError(w, fmt.Errorf("unable to confirm: %w", apperr.Expired("email change code is expired"))))
+
+	a.Equal(410, w.Code)
+	a.Equal("application/problem+json", w.Header().Get("Content-Type"))
+
+	problem := client.Problem{}
+	a.NoError(json.NewDecoder(w.Body).Decode(&problem))
+	a.Equal(client.Problem{
+		Type:   "about:blank",
+		Title:  "Gone",
+		Status: 410,
+		Detail: "email change code is expired",
+	}, problem)
+}
+
+func Test_EncodeError_Validation(t *testing.T) {
+	a := require.New(t)
+
+	w := httptest.NewRecorder()
+	a.NoError(EncodeError(w, validation.Errors{
+		"firstName": errors.New("cannot be blank"),
+		"addresses": validation.Errors{
+			"0": validation.Errors{"city": errors.New("cannot be blank")},
+		},
+		"lastName": nil,
+	}))
+
+	a.Equal(400, w.Code)
+
+	problem := client.Problem{}
+	a.NoError(json.NewDecoder(w.Body).Decode(&problem))
+	a.Equal(map[string]string{
+		"firstName":        "cannot be blank",
+		"addresses.0.city": "cannot be blank",
+	}, problem.Errors)
+}
+
+func Test_EncodeError_Untyped(t *testing.T) {
+	a := require.New(t)
+
+	w := httptest.NewRecorder()
+	a.NoError(EncodeError(w, sql.ErrNoRows))
+	a.Equal(404, w.Code)
+
+	// Nothing about the error makes it out
+	w = httptest.NewRecorder()
+	a.NoError(EncodeError(w, errors.New("dial tcp 10.0.0.1:3306: connection refused")))
+	a.Equal(500, w.Code)
+	a.NotContains(w.Body.String(), "10.0.0.1")
+}
+
+func Test_DecodeJSONRequest(t *testing.T) {
+	a := require.New(t)
+
+	found := client.SendInvite{}
+	a.NoError(DecodeJSONRequest(httptest.NewRequest("POST", "/", strings.NewReader(`{"email":"jane@example.com"}`)), &found))
+	a.Equal("jane@example.com", found.Email)
+
+	err := DecodeJSONRequest(httptest.NewRequest("POST", "/", strings.NewReader(`{"email":`)), &found)
+	a.True(apperr.Is(err, apperr.KindValidation))
+}
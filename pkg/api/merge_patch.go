@@ -0,0 +1,44 @@
+package api
+
+import (
+	"encoding/json"
+)
+
+// MergePatch - Applies a JSON Merge Patch (RFC 7396) document to a JSON document and returns the patched document.
+// Members of the patch that are null are removed from the document, objects are merged recursively and everything else replaces what was there.
+func MergePatch(doc []byte, patch []byte) ([]byte, error) {
+	var target interface{}
+	if err := json.Unmarshal(doc, &target); err != nil {
+		return nil, err
+	}
+
+	var changes interface{}
+	if err := json.Unmarshal(patch, &changes); err != nil {
+		return nil, err
+	}
+
+	return json.Marshal(mergePatch(target, changes))
+}
+
+func mergePatch(target interface{}, patch interface{}) interface{} {
+	changes, ok := patch.(map[string]interface{})
+	if !ok {
+		return patch
+	}
+
+	merged, ok := target.(map[string]interface{})
+	if !ok {
+		merged = map[string]interface{}{}
+	}
+
+	for k, v := range changes {
+		if v == nil {
+			delete(merged, k)
+			continue
+		}
+
+		merged[k] = mergePatch(merged[k], v)
+	}
+
+	return merged
+}
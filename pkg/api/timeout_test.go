@@ -0,0 +1,43 @@
+package api
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func Test_RequestTimeout(t *testing.T) {
+	a := require.New(t)
+
+	var deadline time.Time
+	var hasDeadline bool
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		deadline, hasDeadline = r.Context().Deadline()
+		<-r.Context().Done()
+		a.Equal(context.DeadlineExceeded, r.Context().Err())
+	})
+
+	start := time.Now()
+	RequestTimeout(10*time.Millisecond)(handler).ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/", nil))
+
+	a.True(hasDeadline)
+	a.WithinDuration(start.Add(10*time.Millisecond), deadline, time.Second)
+}
+
+func Test_RequestTimeout_Disabled(t *testing.T) {
+	a := require.New(t)
+
+	called := false
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		called = true
+		_, ok := r.Context().Deadline()
+		a.False(ok)
+	})
+
+	RequestTimeout(0)(handler).ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/", nil))
+	a.True(called)
+}
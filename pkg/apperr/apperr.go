@@ -0,0 +1,107 @@
+// Package apperr holds the kinds of errors the services return so the API can tell the caller what went wrong
+// and what they can do about it instead of only a status code.
+package apperr
+
+import (
+	"errors"
+	"sort"
+	"strings"
+)
+
+// Kind - What sort of failure it is, the API responds with a status code for each.
+type Kind string
+
+const (
+	KindNotFound             Kind = "not-found"
+	KindConflict             Kind = "conflict"
+	KindForbidden            Kind = "forbidden"
+	KindUnauthorized         Kind = "unauthorized"
+	KindValidation           Kind = "validation"
+	KindExpired              Kind = "expired"
+	KindPreconditionFailed   Kind = "precondition-failed"
+	KindPreconditionRequired Kind = "precondition-required"
+	KindUnsupportedMediaType Kind = "unsupported-media-type"
+)
+
+// Error - A failure the caller is able to act on.
+type Error struct {
+	Kind    Kind
+	Message string
+
+	// Problems with individual fields of a validation error keyed by their path, like addresses.0.city
+	Fields map[string]string
+}
+
+func (e *Error) Error() string {
+	if len(e.Fields) == 0 {
+		return e.Message
+	}
+
+	fields := []string{}
+	for field, problem := range e.Fields {
+		fields = append(fields, field+": "+problem)
+	}
+	sort.Strings(fields)
+
+	return e.Message + " (" + strings.Join(fields, "; ") + ")"
+}
+
+// NotFound - The thing asked for doesn't exist, or isn't visible to the caller.
+func NotFound(message string) error {
+	return &Error{Kind: KindNotFound, Message: message}
+}
+
+// Conflict - The request can't be done because of the state the thing is in.
+func Conflict(message string) error {
+	return &Error{Kind: KindConflict, Message: message}
+}
+
+// Forbidden - The caller is known but isn't allowed to do it.
+func Forbidden(message string) error {
+	return &Error{Kind: KindForbidden, Message: message}
+}
+
+// Unauthorized - The caller couldn't be identified.
+func Unauthorized(message string) error {
+	return &Error{Kind: KindUnauthorized, Message: message}
+}
+
+// Validation - What was sent in isn't valid, fields says what's wrong with each of the fields if its known.
+func Validation(message string, fields map[string]string) error {
+	return &Error{Kind: KindValidation, Message: message, Fields: fields}
+}
+
+// Expired - The code or link used was only good for a limited time.
+func Expired(message string) error {
+	return &Error{Kind: KindExpired, Message: message}
+}
+
+// PreconditionFailed - The version the change was made against isn't the current one.
+func PreconditionFailed(message string) error {
+	return &Error{Kind: KindPreconditionFailed, Message: message}
+}
+
+// PreconditionRequired - The change has to say which version it was made against.
+func PreconditionRequired(message string) error {
+	return &Error{Kind: KindPreconditionRequired, Message: message}
+}
+
+// UnsupportedMediaType - The body was sent in a format that isn't accepted.
+func UnsupportedMediaType(message string) error {
+	return &Error{Kind: KindUnsupportedMediaType, Message: message}
+}
+
+// KindOf - The kind of the first Error in the chain of err, if there is one.
+func KindOf(err error) (Kind, bool) {
+	var e *Error
+	if errors.As(err, &e) {
+		return e.Kind, true
+	}
+	return "", false
+}
+
+// Is - Whether err is or wraps an Error of the kind.
+func Is(err error, kind Kind) bool {
+	found, ok := KindOf(err)
+	return ok && found == kind
+}
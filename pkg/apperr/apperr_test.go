@@ -0,0 +1,34 @@
+package apperr
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func Test_Kind(t *testing.T) {
+	a := require.New(t)
+
+	err := fmt.Errorf("unable to add phone: %w", NotFound("identity not found"))
+
+	kind, ok := KindOf(err)
+	a.True(ok)
+	a.Equal(KindNotFound, kind)
+	a.True(Is(err, KindNotFound))
+	a.False(Is(err, KindConflict))
+
+	_, ok = KindOf(errors.New("connection refused"))
+	a.False(ok)
+}
+
+func Test_Error(t *testing.T) {
+	a := require.New(t)
+
+	a.Equal("invalid limit", Validation("invalid limit", nil).Error())
+	a.Equal("invalid query (limit: must be a number; q: cannot be blank)", Validation("invalid query", map[string]string{
+		"q":     "cannot be blank",
+		"limit": "must be a number",
+	}).Error())
+}
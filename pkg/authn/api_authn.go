@@ -1,7 +1,6 @@
 package authn
 
 import (
-	"encoding/json"
 	"net/http"
 	"strings"
 
@@ -10,6 +9,7 @@ import (
 	api "github.com/moov-io/identity/pkg/api"
 	"github.com/moov-io/identity/pkg/client"
 	log "github.com/moov-io/identity/pkg/logging"
+	"github.com/moov-io/identity/pkg/registration"
 )
 
 // authnAPIController - Controller for the AuthN verification routes.
@@ -44,6 +44,12 @@ func (c *authnAPIController) Routes() api.Routes {
 			Pattern:     "/authentication/register",
 			HandlerFunc: c.SubmitRegistration,
 		},
+		{
+			Name:        "LinkCredentials",
+			Method:      strings.ToUpper("Post"),
+			Pattern:     "/authentication/link",
+			HandlerFunc: c.LinkCredentials,
+		},
 	}
 }
 
@@ -59,8 +65,7 @@ func (c *authnAPIController) Authenticated(w http.ResponseWriter, r *http.Reques
 			validation.Field(&session.State, validation.Required),
 			validation.Field(&session.IP, validation.Required, is.IP),
 		); err != nil {
-			c.logger.Error().LogError("session validate failed", err)
-			w.WriteHeader(404)
+			api.EncodeError(w, c.logger.Error().LogError("session validate failed", err))
 			return
 		}
 
@@ -71,8 +76,7 @@ func (c *authnAPIController) Authenticated(w http.ResponseWriter, r *http.Reques
 
 		cookie, loggedIn, err := c.service.LoginWithCredentials(r, login, session.State, session.IP, session.ImageUrl)
 		if err != nil {
-			c.logger.Error().LogError("Not able to exchange login token for session token", err)
-			w.WriteHeader(404)
+			api.EncodeError(w, c.logger.Error().LogError("Not able to exchange login token for session token", err))
 			return
 		}
 
@@ -99,23 +103,24 @@ func (c *authnAPIController) SubmitRegistration(w http.ResponseWriter, r *http.R
 			validation.Field(&session.State, validation.Required),
 			validation.Field(&session.IP, validation.Required, is.IP),
 		); err != nil {
-			c.logger.Error().LogError("unable to validate session", err)
-			w.WriteHeader(404)
+			api.EncodeError(w, c.logger.Error().LogError("unable to validate session", err))
 			return
 		}
 
+		// Only the email the provider returned was verified by it, not one sent in the body.
+		sessionEmail := session.Register.Email
+
 		// Going to overwrite or use what they've already sent.
-		registration := &session.Register
+		register := &session.Register
 
-		if err := json.NewDecoder(r.Body).Decode(&registration); err != nil {
-			w.WriteHeader(400)
+		if err := api.DecodeJSONRequest(r, &register); err != nil {
+			api.EncodeError(w, err)
 			return
 		}
 
 		// Validate the registration
-		if err := registration.Validate(); err != nil {
-			s := http.StatusBadRequest
-			_ = api.EncodeJSONResponse(err, &s, w)
+		if err := register.Validate(); err != nil {
+			api.EncodeError(w, err)
 			return
 		}
 
@@ -129,10 +134,50 @@ func (c *authnAPIController) SubmitRegistration(w http.ResponseWriter, r *http.R
 
 		DeleteAuthnCookie(w)
 
-		cookie, loggedIn, err := c.service.RegisterWithCredentials(r, *registration, session.State, session.IP, isSignup)
+		emailVerified := session.EmailVerified && register.Email == sessionEmail
+
+		cookie, loggedIn, err := c.service.RegisterWithCredentials(r, *register, session.State, session.IP, isSignup, emailVerified)
+		if err != nil {
+			// Registered, but there's no session to hand out until its approved.
+			if err == registration.ErrRegistrationPendingApproval {
+				w.WriteHeader(202)
+				return
+			}
+
+			api.EncodeError(w, c.logger.Error().LogError("Unable to RegisterWithCredentials", err))
+			return
+		}
+
+		http.SetCookie(w, cookie)
+		api.EncodeJSONResponse(loggedIn, nil, w)
+	})
+}
+
+// LinkCredentials - Finishes a credential link started by a logged in identity by attaching the credential the user just authenticated with.
+func (c *authnAPIController) LinkCredentials(w http.ResponseWriter, r *http.Request) {
+	WithLoginSessionFromRequest(c.logger, w, r, []string{"link", "finished"}, func(session LoginSession) {
+		DeleteAuthnCookie(w)
+
+		// Validation the session
+		if err := validation.ValidateStruct(&session,
+			validation.Field(&session.CredentialID, validation.Required, is.UUID),
+			validation.Field(&session.TenantID, validation.Required, is.UUID),
+			validation.Field(&session.LinkCode, validation.Required),
+			validation.Field(&session.State, validation.Required),
+			validation.Field(&session.IP, validation.Required, is.IP),
+		); err != nil {
+			api.EncodeError(w, c.logger.Error().LogError("session validate failed", err))
+			return
+		}
+
+		login := client.Login{
+			CredentialID: session.CredentialID,
+			TenantID:     session.TenantID,
+		}
+
+		cookie, loggedIn, err := c.service.LinkWithCredentials(r, session.LinkCode, login, session.State, session.IP)
 		if err != nil {
-			c.logger.Error().LogError("Unable to RegisterWithCredentials", err)
-			w.WriteHeader(404)
+			api.EncodeError(w, c.logger.Error().LogError("Unable to link credentials", err))
 			return
 		}
 
@@ -2,6 +2,7 @@ package authn_test
 
 import (
 	"context"
+	"encoding/json"
 	"net/http/httptest"
 	"strings"
 	"testing"
@@ -14,7 +15,7 @@ import (
 func Test_Register(t *testing.T) {
 	s := Setup(t)
 
-	invite, code, err := s.invites.SendInvite(s.session, client.SendInvite{Email: "test@moovtest.io"})
+	invite, code, err := s.invites.SendInvite(context.Background(), s.session, client.SendInvite{Email: "test@moovtest.io"})
 	s.assert.Nil(err)
 
 	ls := LoginSession{}
@@ -87,13 +88,13 @@ func Test_Register_TwiceSameTenant(t *testing.T) {
 	c = s.NewClient(ls)
 	_, resp, err = c.AuthenticationApi.RegisterWithCredentials(context.Background(), ls.Register)
 	s.assert.NotNil(err)
-	s.assert.Equal(404, resp.StatusCode)
+	s.assert.Equal(409, resp.StatusCode)
 }
 
 func Test_Register_Success_Returns_ImageURL_If_Available(t *testing.T) {
 	s := Setup(t)
 
-	invite, code, err := s.invites.SendInvite(s.session, client.SendInvite{Email: "test@moovtest.io"})
+	invite, code, err := s.invites.SendInvite(context.Background(), s.session, client.SendInvite{Email: "test@moovtest.io"})
 	s.assert.Nil(err)
 
 	ls := LoginSession{}
@@ -115,7 +116,7 @@ func Test_Register_Success_Returns_ImageURL_If_Available(t *testing.T) {
 func Test_Register_Success_Returns_Empty_ImageURL(t *testing.T) {
 	s := Setup(t)
 
-	invite, code, err := s.invites.SendInvite(s.session, client.SendInvite{Email: "test@moovtest.io"})
+	invite, code, err := s.invites.SendInvite(context.Background(), s.session, client.SendInvite{Email: "test@moovtest.io"})
 	s.assert.Nil(err)
 
 	ls := LoginSession{}
@@ -149,7 +150,7 @@ func Test_Signup(t *testing.T) {
 func Test_Register_PartialJson(t *testing.T) {
 	s := Setup(t)
 
-	invite, code, err := s.invites.SendInvite(s.session, client.SendInvite{Email: "test@moovtest.io"})
+	invite, code, err := s.invites.SendInvite(context.Background(), s.session, client.SendInvite{Email: "test@moovtest.io"})
 	s.assert.Nil(err)
 
 	ls := LoginSession{}
@@ -170,7 +171,7 @@ func Test_Register_PartialJson(t *testing.T) {
 func Test_Register_PartialJsonOverride(t *testing.T) {
 	s := Setup(t)
 
-	invite, code, err := s.invites.SendInvite(s.session, client.SendInvite{Email: "test@moovtest.io"})
+	invite, code, err := s.invites.SendInvite(context.Background(), s.session, client.SendInvite{Email: "test@moovtest.io"})
 	s.assert.Nil(err)
 
 	ls := LoginSession{}
@@ -193,7 +194,7 @@ func Test_Register_PartialJsonOverride(t *testing.T) {
 func Test_Register_FailIfSessionMissingData(t *testing.T) {
 	s := Setup(t)
 
-	invite, code, err := s.invites.SendInvite(s.session, client.SendInvite{Email: "test@moovtest.io"})
+	invite, code, err := s.invites.SendInvite(context.Background(), s.session, client.SendInvite{Email: "test@moovtest.io"})
 	s.assert.Nil(err)
 
 	ls := LoginSession{}
@@ -215,11 +216,11 @@ func Test_Register_FailIfSessionMissingData(t *testing.T) {
 	i, ok := err.(client.GenericOpenAPIError)
 	s.assert.True(ok)
 
-	regErrs, ok := i.Model().(client.RegisterErrors)
+	problem, ok := i.Model().(client.Problem)
 	s.assert.True(ok)
 
 	// Check that the error is for the first name
-	s.assert.NotEmpty(regErrs.FirstName)
+	s.assert.NotEmpty(problem.Errors["firstName"])
 
 	// Lets call again with fixed data
 	loggedIn, resp, err = c.AuthenticationApi.RegisterWithCredentials(context.Background(), client.Register{
@@ -260,7 +261,7 @@ func Test_Register_Invalid_Scope(t *testing.T) {
 	c := s.NewClient(ls)
 	_, resp, err := c.AuthenticationApi.RegisterWithCredentials(context.Background(), ls.Register)
 	s.assert.NotNil(err)
-	s.assert.Equal(404, resp.StatusCode)
+	s.assert.Equal(403, resp.StatusCode)
 }
 
 func Test_Login_Failed(t *testing.T) {
@@ -285,7 +286,7 @@ func Test_Login_MissingTenant(t *testing.T) {
 
 	c := s.NewClient(ls)
 	_, resp, err := c.AuthenticationApi.Authenticated(context.Background())
-	s.assert.Equal(404, resp.StatusCode)
+	s.assert.Equal(400, resp.StatusCode)
 	s.assert.NotNil(err)
 }
 
@@ -298,7 +299,7 @@ func Test_Login_Invalid_Scope(t *testing.T) {
 
 	c := s.NewClient(ls)
 	_, resp, err := c.AuthenticationApi.Authenticated(context.Background())
-	s.assert.Equal(404, resp.StatusCode)
+	s.assert.Equal(403, resp.StatusCode)
 	s.assert.NotNil(err)
 }
 
@@ -352,7 +353,7 @@ func RegisterRandomIdentity(s Scope) LoginSession {
 	req.Header.Add("Origin", Host)
 
 	// First need to invite the user
-	invite, code, err := s.invites.SendInvite(s.session, client.SendInvite{Email: "test@moovtest.io"})
+	invite, code, err := s.invites.SendInvite(context.Background(), s.session, client.SendInvite{Email: "test@moovtest.io"})
 	if err != nil {
 		panic(err)
 	}
@@ -364,10 +365,96 @@ func RegisterRandomIdentity(s Scope) LoginSession {
 	registerSession.InviteCode = code
 	registerSession.Scopes = []string{"register"}
 
-	_, _, err = s.service.RegisterWithCredentials(req, registerSession.Register, registerSession.State, registerSession.IP, false)
+	_, _, err = s.service.RegisterWithCredentials(req, registerSession.Register, registerSession.State, registerSession.IP, false, false)
 	if err != nil {
 		panic(err)
 	}
 
 	return registerSession
 }
+
+func Test_Link_Success(t *testing.T) {
+	s := Setup(t)
+
+	registerSession := RegisterRandomIdentity(s)
+	identityID := s.IdentityIDOf(registerSession)
+
+	link := s.StartLink(registerSession.TenantID, identityID)
+
+	ls := LoginSession{}
+	s.fuzz.Fuzz(&ls)
+	ls.TenantID = registerSession.TenantID
+	ls.LinkCode = link.LinkCode
+	ls.Scopes = []string{"link", "finished"}
+
+	resp := s.Post(ls, "/authentication/link")
+	s.assert.Equal(200, resp.StatusCode)
+
+	loggedIn := client.LoggedIn{}
+	s.assert.Nil(json.NewDecoder(resp.Body).Decode(&loggedIn))
+	s.assert.Equal(identityID, loggedIn.IdentityID)
+	s.assert.Equal(ls.CredentialID, loggedIn.CredentialID)
+
+	// The new credential can now be used to login to the same identity.
+	loginSession := LoginSession{}
+	s.fuzz.Fuzz(&loginSession)
+	loginSession.CredentialID = ls.CredentialID
+	loginSession.TenantID = ls.TenantID
+	loginSession.Scopes = []string{"authenticate", "finished"}
+
+	c := s.NewClient(loginSession)
+	loggedIn, httpResp, err := c.AuthenticationApi.Authenticated(context.Background())
+	s.assert.Nil(err)
+	s.assert.Equal(200, httpResp.StatusCode)
+	s.assert.Equal(identityID, loggedIn.IdentityID)
+}
+
+func Test_Link_CredentialOwnedByOther(t *testing.T) {
+	s := Setup(t)
+
+	registerSession := RegisterRandomIdentity(s)
+	otherSession := RegisterRandomIdentity(s)
+
+	link := s.StartLink(registerSession.TenantID, s.IdentityIDOf(registerSession))
+
+	ls := LoginSession{}
+	s.fuzz.Fuzz(&ls)
+	ls.TenantID = registerSession.TenantID
+	ls.CredentialID = otherSession.CredentialID
+	ls.LinkCode = link.LinkCode
+	ls.Scopes = []string{"link", "finished"}
+
+	resp := s.Post(ls, "/authentication/link")
+	s.assert.Equal(409, resp.StatusCode)
+
+	// The link code is still good for a credential that isn't taken
+	ls.CredentialID = uuid.New().String()
+	resp = s.Post(ls, "/authentication/link")
+	s.assert.Equal(200, resp.StatusCode)
+}
+
+func Test_Link_InvalidCode(t *testing.T) {
+	s := Setup(t)
+
+	ls := LoginSession{}
+	s.fuzz.Fuzz(&ls)
+	ls.TenantID = s.session.TenantID.String()
+	ls.LinkCode = "doesnotexist"
+	ls.Scopes = []string{"link", "finished"}
+
+	resp := s.Post(ls, "/authentication/link")
+	s.assert.Equal(404, resp.StatusCode)
+}
+
+func Test_Link_Invalid_Scope(t *testing.T) {
+	s := Setup(t)
+
+	ls := LoginSession{}
+	s.fuzz.Fuzz(&ls)
+	ls.TenantID = s.session.TenantID.String()
+	ls.LinkCode = "doesnotexist"
+	ls.Scopes = []string{"register", "finished"}
+
+	resp := s.Post(ls, "/authentication/link")
+	s.assert.Equal(403, resp.StatusCode)
+}
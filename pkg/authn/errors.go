@@ -0,0 +1,15 @@
+package authn
+
+import "github.com/moov-io/identity/pkg/apperr"
+
+// ErrTenantMismatch is issued when the tenant being logged into isn't the one the invite, link, or identity belongs to.
+var ErrTenantMismatch = apperr.Forbidden("tenant doesn't match")
+
+// ErrCredentialRegistered is issued when registering with a credential that already has an identity in the tenant.
+var ErrCredentialRegistered = apperr.Conflict("credential is already registered with the tenant")
+
+// ErrSessionNotFound is issued when the login session cookie is missing or can't be read.
+var ErrSessionNotFound = apperr.Unauthorized("login session not found")
+
+// ErrSessionScope is issued when the login session wasn't started for the step being completed.
+var ErrSessionScope = apperr.Forbidden("login session doesn't allow this step")
@@ -4,6 +4,7 @@ import (
 	"context"
 	"net/http"
 
+	api "github.com/moov-io/identity/pkg/api"
 	"github.com/moov-io/identity/pkg/logging"
 	"github.com/moov-io/identity/pkg/stime"
 	"github.com/moov-io/tumbler/pkg/jwe"
@@ -31,7 +32,7 @@ func (s *Middleware) Handler(h http.Handler) http.Handler {
 		session, err := s.FromRequest(r)
 		if err != nil {
 			s.log.Error().LogError("Session error", err)
-			w.WriteHeader(404)
+			api.EncodeError(w, ErrSessionNotFound)
 			return
 		}
 
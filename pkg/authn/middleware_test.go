@@ -48,7 +48,7 @@ func Test_NoAuthnCookie(t *testing.T) {
 	recorder := httptest.NewRecorder()
 	endpoint.ServeHTTP(recorder, req)
 
-	s.assert.Equal(404, recorder.Result().StatusCode)
+	s.assert.Equal(401, recorder.Result().StatusCode)
 }
 
 func Test_Expired(t *testing.T) {
@@ -70,7 +70,7 @@ func Test_Expired(t *testing.T) {
 	recorder := httptest.NewRecorder()
 	endpoint.ServeHTTP(recorder, req)
 
-	s.assert.Equal(404, recorder.Result().StatusCode)
+	s.assert.Equal(401, recorder.Result().StatusCode)
 }
 
 func Test_NotBefore(t *testing.T) {
@@ -92,7 +92,7 @@ func Test_NotBefore(t *testing.T) {
 	recorder := httptest.NewRecorder()
 	endpoint.ServeHTTP(recorder, req)
 
-	s.assert.Equal(404, recorder.Result().StatusCode)
+	s.assert.Equal(401, recorder.Result().StatusCode)
 }
 
 func Test_Scope(t *testing.T) {
@@ -116,7 +116,7 @@ func Test_Scope(t *testing.T) {
 	recorder := httptest.NewRecorder()
 	endpoint.ServeHTTP(recorder, req)
 
-	s.assert.Equal(404, recorder.Result().StatusCode)
+	s.assert.Equal(401, recorder.Result().StatusCode)
 }
 
 func Test_Scope_Missing(t *testing.T) {
@@ -139,7 +139,7 @@ func Test_Scope_Missing(t *testing.T) {
 	recorder := httptest.NewRecorder()
 	endpoint.ServeHTTP(recorder, req)
 
-	s.assert.Equal(404, recorder.Result().StatusCode)
+	s.assert.Equal(401, recorder.Result().StatusCode)
 }
 
 func newEndpoint(s Scope, scopes *[]string, run func(loginSession authn.LoginSession)) http.Handler {
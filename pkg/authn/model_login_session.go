@@ -4,6 +4,7 @@ import (
 	"errors"
 	"net/http"
 
+	api "github.com/moov-io/identity/pkg/api"
 	identity "github.com/moov-io/identity/pkg/client"
 	log "github.com/moov-io/identity/pkg/logging"
 	"github.com/moov-io/tumbler/pkg/jwe"
@@ -43,6 +44,12 @@ type LoginSession struct {
 	// Login URL for the start of the flow
 	LoginURL string `json:"lu"`
 
+	// Provider verified the user has access to the email address
+	EmailVerified bool `json:"ev,omitempty"`
+
+	// Code from starting a credential link that the new credential gets attached with
+	LinkCode string `json:"lc,omitempty"`
+
 	// Store whatever we can get from the OIDC provider if the invite code isn't empty
 	identity.Register
 }
@@ -61,7 +68,7 @@ func WithLoginSessionFromRequest(l log.Logger, w http.ResponseWriter, r *http.Re
 	session, err := LoginSessionFromRequest(r)
 	if err != nil {
 		l.Error().LogError("LoginSessionFromRequest errored", err)
-		w.WriteHeader(404)
+		api.EncodeError(w, ErrSessionNotFound)
 		return
 	}
 
@@ -81,8 +88,8 @@ func WithLoginSessionFromRequest(l log.Logger, w http.ResponseWriter, r *http.Re
 	}
 
 	if !hasAllScopes {
-		l.Error().LogError("LoginSessionFromRequest missing scopes", err)
-		w.WriteHeader(404)
+		l.Error().LogError("LoginSessionFromRequest missing scopes", ErrSessionScope)
+		api.EncodeError(w, ErrSessionScope)
 		return
 	}
 
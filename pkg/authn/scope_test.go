@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 	"net/http"
+	"net/http/httptest"
 	"testing"
 	"time"
 
@@ -25,7 +26,10 @@ import (
 	"github.com/moov-io/identity/pkg/credentials"
 	"github.com/moov-io/identity/pkg/database"
 	"github.com/moov-io/identity/pkg/invites"
+	"github.com/moov-io/identity/pkg/jobs"
 	"github.com/moov-io/identity/pkg/notifications"
+	"github.com/moov-io/identity/pkg/outbox"
+	"github.com/moov-io/identity/pkg/registration"
 	sessionpkg "github.com/moov-io/identity/pkg/session"
 	"github.com/moov-io/identity/pkg/stime"
 	tmw "github.com/moov-io/tumbler/pkg/middleware"
@@ -50,7 +54,10 @@ func Setup(t *testing.T) Scope {
 
 	stime := stime.NewStaticTimeService()
 
-	notifications := notifications.NewMockNotificationsService(notifications.MockConfig{From: "noreply@moov.io"})
+	templates, err := notifications.NewTemplateRepository(logger)
+	a.Nil(err)
+
+	notifications := notifications.NewMockNotificationsService(notifications.MockConfig{From: "noreply@moov.io"}, templates)
 
 	invitesConfig := invites.Config{
 		Expiration: time.Hour,
@@ -60,11 +67,17 @@ func Setup(t *testing.T) Scope {
 
 	authnClient := authntestutils.NewMockAuthnClient()
 
-	identitiesRepo := identities.NewIdentityRepository(db)
-	identities := identities.NewIdentitiesService(stime, identitiesRepo)
+	outbox := outbox.NewOutboxService(outbox.Config{}, logger, stime, outbox.NewOutboxRepository(db), notifications)
+
+	identitiesRepo := identities.NewIdentityRepository(db, outbox)
+	identities, err := identities.NewIdentitiesService(identities.Config{}, stime, identitiesRepo)
+	if err != nil {
+		t.Error(err)
+	}
 
-	invitesRepo := invites.NewInvitesRepository(db)
-	invites, err := invites.NewInvitesService(invitesConfig, stime, invitesRepo, notifications, authnClient, identitiestestutils.NewSingleService(nil))
+	invitesRepo := invites.NewInvitesRepository(db, outbox)
+	jobs := jobs.NewJobsService(logger, stime, jobs.NewJobsRepository(db))
+	invites, err := invites.NewInvitesService(invitesConfig, stime, invitesRepo, authnClient, identitiestestutils.NewSingleService(nil), jobs)
 	a.Nil(err)
 
 	credsRepo := credentials.NewCredentialRepository(db)
@@ -74,7 +87,15 @@ func Setup(t *testing.T) Scope {
 	sessionJwe := jwe.NewJWEService(stime, sessionConfig.Expiration, identityKeys)
 	token := sessionpkg.NewTokenService(stime, sessionJwe, sessionConfig)
 
-	service := authn.NewAuthnService(logger, creds, identities, token, invites)
+	registrationRepo := registration.NewRegistrationRepository(db)
+	registrationConfig := registration.Config{
+		LoginURL:  "https://local.moov.io/login",
+		ReviewURL: "https://local.moov.io/pending",
+	}
+	registration, err := registration.NewRegistrationService(registrationConfig, stime, registrationRepo, identities)
+	a.Nil(err)
+
+	service := authn.NewAuthnService(logger, creds, identities, token, invites, registration)
 
 	authnJwe := jwe.NewJWEService(stime, sessionConfig.Expiration, webkeys.NewStaticJwksService(authnKeys))
 
@@ -96,7 +117,7 @@ func Setup(t *testing.T) Scope {
 			e.Register = client.Register{
 				CredentialID: uuid.New().String(),
 				InviteCode:   c.RandString(),
-				Email:        fmt.Sprintf("myemail.%d@example.com", c.Intn(1000)),
+				Email:        fmt.Sprintf("myemail.%s@example.com", uuid.New().String()),
 				FirstName:    c.RandString() + "fn",
 				LastName:     c.RandString() + "ln",
 			}
@@ -112,6 +133,9 @@ func Setup(t *testing.T) Scope {
 		logger:        logger,
 		service:       service,
 		invites:       invites,
+		identities:    identities,
+		credentials:   creds,
+		registration:  registration,
 		authnJwe:      authnJwe,
 		identityJwe:   sessionJwe,
 		//logOutput:     *output,
@@ -127,12 +151,19 @@ type Scope struct {
 	logger        log.Logger
 	service       authn.AuthenticationService
 	invites       invites.InvitesService
+	identities    identities.Service
+	credentials   credentials.CredentialsService
+	registration  registration.Service
 	authnJwe      jwe.JWEService
 	identityJwe   jwe.JWEService
 	//logOutput     strings.Builder
 }
 
 func (s *Scope) NewClient(loginSession authn.LoginSession) *client.APIClient {
+	return clienttest.NewTestClient(s.NewRouter(loginSession))
+}
+
+func (s *Scope) NewRouter(loginSession authn.LoginSession) *mux.Router {
 	testAuthnMiddleware := NewTestMiddleware(s.stime, loginSession)
 
 	controller := authn.NewAuthnAPIController(s.logger, s.service)
@@ -141,9 +172,42 @@ func (s *Scope) NewClient(loginSession authn.LoginSession) *client.APIClient {
 	api.AppendRouters(s.logger, routes, controller)
 	routes.Use(testAuthnMiddleware.Handler)
 
-	testAPI := clienttest.NewTestClient(routes)
+	return routes
+}
+
+// Post - Sends an empty POST to the authn routes with the LoginSession injected for routes the generated client doesn't cover.
+func (s *Scope) Post(loginSession authn.LoginSession, path string) *http.Response {
+	req := httptest.NewRequest("POST", "https://local.moov.io"+path, nil)
+	w := httptest.NewRecorder()
+	s.NewRouter(loginSession).ServeHTTP(w, req)
+	return w.Result()
+}
+
+// IdentityIDOf - Logs in with the LoginSession's credential to find out which identity it belongs to.
+func (s *Scope) IdentityIDOf(loginSession authn.LoginSession) string {
+	req := httptest.NewRequest("GET", "https://local.moov.io", nil)
+	login := client.Login{
+		CredentialID: loginSession.CredentialID,
+		TenantID:     loginSession.TenantID,
+	}
+
+	_, loggedIn, err := s.service.LoginWithCredentials(req, login, "state"+uuid.New().String(), loginSession.IP, nil)
+	s.assert.Nil(err)
+
+	return loggedIn.IdentityID
+}
+
+// StartLink - Starts a credential link as if the identity was logged in and requested it.
+func (s *Scope) StartLink(tenantID string, identityID string) *client.CredentialLink {
+	claims := tmwt.NewRandomClaims()
+	claims.TenantID = uuid.MustParse(tenantID)
+	iid := uuid.MustParse(identityID)
+	claims.IdentityID = &iid
+
+	link, err := s.credentials.StartLink(context.Background(), claims, identityID)
+	s.assert.Nil(err)
 
-	return testAPI
+	return link
 }
 
 // TestMiddleware - Handles injecting a session into a request for testing
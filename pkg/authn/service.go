@@ -11,21 +11,24 @@ import (
 	"github.com/moov-io/identity/pkg/identities"
 	"github.com/moov-io/identity/pkg/invites"
 	"github.com/moov-io/identity/pkg/logging"
+	"github.com/moov-io/identity/pkg/registration"
 	"github.com/moov-io/identity/pkg/session"
 )
 
 // AuthenticationApiServicer defines the api actions for the AuthenticationApi service
 type AuthenticationService interface {
 	LoginWithCredentials(req *http.Request, credentials client.Login, nonce string, ip string, photoURL *string) (*http.Cookie, *client.LoggedIn, error)
-	RegisterWithCredentials(*http.Request, client.Register, string, string, bool) (*http.Cookie, *client.LoggedIn, error)
+	RegisterWithCredentials(req *http.Request, register client.Register, nonce string, ip string, isSignup bool, emailVerified bool) (*http.Cookie, *client.LoggedIn, error)
+	LinkWithCredentials(req *http.Request, linkCode string, credentials client.Login, nonce string, ip string) (*http.Cookie, *client.LoggedIn, error)
 }
 
 type authnService struct {
-	log         logging.Logger
-	credentials credentials.CredentialsService
-	identities  identities.Service
-	token       session.TokenService
-	invites     invites.InvitesService
+	log          logging.Logger
+	credentials  credentials.CredentialsService
+	identities   identities.Service
+	token        session.TokenService
+	invites      invites.InvitesService
+	registration registration.Service
 }
 
 // NewAuthnService - Creates a default service that handles the registration and login
@@ -35,58 +38,89 @@ func NewAuthnService(
 	identities identities.Service,
 	token session.TokenService,
 	invites invites.InvitesService,
+	registration registration.Service,
 ) AuthenticationService {
 	return &authnService{
-		log:         log,
-		credentials: credentials,
-		identities:  identities,
-		token:       token,
-		invites:     invites,
+		log:          log,
+		credentials:  credentials,
+		identities:   identities,
+		token:        token,
+		invites:      invites,
+		registration: registration,
 	}
 }
 
 // RegisterWithCredentials - Register user based on OIDC credentials.  This is called by the OIDC client services we create to register the user with what  available information they have and obtain from the user.
-func (s *authnService) RegisterWithCredentials(req *http.Request, register client.Register, nonce string, ip string, isSignup bool) (*http.Cookie, *client.LoggedIn, error) {
+func (s *authnService) RegisterWithCredentials(req *http.Request, register client.Register, nonce string, ip string, isSignup bool, emailVerified bool) (*http.Cookie, *client.LoggedIn, error) {
+	ctx := req.Context()
+
 	logCtx := s.log.WithMap(map[string]string{
-		"tenant_id":     register.TenantID,
-		"credential_id": register.CredentialID,
-		"email":         register.Email,
-		"ip":            ip,
-		"is_sign_up":    fmt.Sprintf("%t", isSignup),
+		"tenant_id":      register.TenantID,
+		"credential_id":  register.CredentialID,
+		"email":          register.Email,
+		"ip":             ip,
+		"is_sign_up":     fmt.Sprintf("%t", isSignup),
+		"email_verified": fmt.Sprintf("%t", emailVerified),
 	})
 
+	// Check the tenants policy on how users are allowed to join.
+	decision, err := s.registration.Evaluate(ctx, register, isSignup, emailVerified)
+	if err != nil {
+		return nil, nil, logCtx.Error().LogError("Unable to evaluate registration policy", err)
+	}
+
 	var invite *client.Invite = nil
 
-	if !isSignup {
-		invite, err := s.invites.Redeem(register.InviteCode)
+	if decision.RequireInvite {
+		invite, err = s.invites.Redeem(ctx, register.InviteCode)
 		if err != nil {
-			return nil, nil, logCtx.Error().LogErrorF("Unable to redeem token", err)
+			return nil, nil, logCtx.Error().LogError("Unable to redeem token", err)
 		}
 
 		// Guard against possible inconsistencies in the tenantID
 		if register.TenantID != invite.TenantID {
-			return nil, nil, logCtx.Error().LogErrorF("register TenantID and Invite TenantID don't match")
+			return nil, nil, logCtx.Error().LogError("register TenantID and Invite TenantID don't match", ErrTenantMismatch)
 		}
 	}
 
 	// See if this credential is already registered with the tenant and if so bomb out.
-	found, err := s.credentials.Exists(register.CredentialID, register.TenantID)
+	found, err := s.credentials.Exists(ctx, register.CredentialID, register.TenantID)
 	if err != nil {
 		return nil, nil, err
 	} else if found {
-		return nil, nil, logCtx.Error().LogErrorF("credential already registered with tenant")
+		return nil, nil, logCtx.Error().LogError("credential already registered with tenant", ErrCredentialRegistered)
+	}
+
+	// Hold the identity until an admin approves it.
+	status := identities.StatusActive
+	if decision.RequireApproval {
+		status = identities.StatusPending
+	}
+
+	// The approvers are emailed along with the identity being saved.
+	var notify identities.Notify
+	if decision.RequireApproval {
+		notify, err = s.registration.NotifyApprovers(ctx, register.TenantID)
+		if err != nil {
+			return nil, nil, logCtx.Error().LogError("Unable to notify approvers", err)
+		}
 	}
 
 	// Create the identity so we can login with it and give the user access.
-	identity, err := s.identities.Register(register, invite)
+	identity, err := s.identities.Register(ctx, register, invite, status, notify)
 	if err != nil {
-		return nil, nil, logCtx.Error().LogErrorF("Unable to register identity", err)
+		return nil, nil, logCtx.Error().LogError("Unable to register identity", err)
 	}
 
 	// Register the credentials with the new Identity created.
-	creds, err := s.credentials.Register(identity.IdentityID, register.CredentialID, register.TenantID)
+	creds, err := s.credentials.Register(ctx, identity.IdentityID, register.CredentialID, register.TenantID)
 	if err != nil {
-		return nil, nil, logCtx.Error().LogErrorF("Unable to register credential", err)
+		return nil, nil, logCtx.Error().LogError("Unable to register credential", err)
+	}
+
+	if decision.RequireApproval {
+		logCtx.Info().Log("Registration is waiting on approval")
+		return nil, nil, registration.ErrRegistrationPendingApproval
 	}
 
 	// Using the new creds create the login object to log the user in.
@@ -98,8 +132,30 @@ func (s *authnService) RegisterWithCredentials(req *http.Request, register clien
 	return s.LoginWithCredentials(req, login, nonce, ip, register.ImageUrl)
 }
 
+// LinkWithCredentials - Attaches the credential the user just authenticated with to the identity that started the link and logs them in with it.
+func (s *authnService) LinkWithCredentials(req *http.Request, linkCode string, login client.Login, nonce string, ip string) (*http.Cookie, *client.LoggedIn, error) {
+	ctx := req.Context()
+
+	logCtx := s.log.WithMap(map[string]string{
+		"tenant_id":     login.TenantID,
+		"credential_id": login.CredentialID,
+		"ip":            ip,
+	})
+
+	// Scoped to the tenant being logged into, and guards against the credential already belonging to another identity.
+	creds, err := s.credentials.RedeemLink(ctx, linkCode, login.TenantID, login.CredentialID)
+	if err != nil {
+		return nil, nil, logCtx.Error().LogError("Unable to link credential", err)
+	}
+
+	login.CredentialID = creds.CredentialID
+	return s.LoginWithCredentials(req, login, nonce, ip, nil)
+}
+
 // LoginWithCredentials - Complete a login via a OIDC. Once the OIDC client service has authenticated their identity the client service will call  this endpoint to record and finish the login to get their token to use the API.  If the client service receives a 404 they must send them to registration if its allowed per the client or check for an invite for authenticated users email before sending to registration.
 func (s *authnService) LoginWithCredentials(req *http.Request, login client.Login, nonce string, ip string, photoURL *string) (*http.Cookie, *client.LoggedIn, error) {
+	ctx := req.Context()
+
 	logCtx := s.log.WithMap(map[string]string{
 		"tenant_id":     login.TenantID,
 		"credential_id": login.CredentialID,
@@ -107,20 +163,30 @@ func (s *authnService) LoginWithCredentials(req *http.Request, login client.Logi
 	})
 
 	// check if they exist in the credentials service and if its enabled.
-	credential, err := s.credentials.Login(login, nonce, ip)
+	credential, err := s.credentials.Login(ctx, login, nonce, ip)
 	if err != nil {
 		return nil, nil, logCtx.Error().LogError("Failed login", err)
 	}
 
 	logCtx = logCtx.With(api.NewCredentialLogContext(credential))
 
-	identity, err := s.identities.GetIdentityByID(credential.IdentityID)
+	identity, err := s.identities.GetIdentityByID(ctx, credential.IdentityID)
 	if err != nil {
 		return nil, nil, logCtx.Error().LogError("Could not find identity", err)
 	}
 
 	if identity.TenantID != credential.TenantID {
-		return nil, nil, logCtx.LogErrorF("guard triggered - identity and credential tenantID's don't match")
+		return nil, nil, logCtx.LogError("guard triggered - identity and credential tenantID's don't match", ErrTenantMismatch)
+	}
+
+	if identity.Status == identities.StatusPending {
+		return nil, nil, logCtx.Error().LogError("Identity is waiting on approval", registration.ErrRegistrationPendingApproval)
+	}
+
+	if !identities.CanLogin(identity.Status) {
+		return nil, nil, logCtx.Error().WithMap(map[string]string{
+			"status": identity.Status,
+		}).LogError("Identity status doesn't allow logins", identities.ErrIdentityNotActive)
 	}
 
 	if photoURL != nil && identity.ImageUrl != photoURL {
@@ -130,7 +196,7 @@ func (s *authnService) LoginWithCredentials(req *http.Request, login client.Logi
 			"identity_id": identity.IdentityID,
 		}).Log("Photo URL Updated")
 		identity.ImageUrl = photoURL
-		s.identities.UpdateInsecure(identity)
+		s.identities.UpdateInsecure(ctx, identity)
 	}
 
 	logCtx = logCtx.With(api.NewIdentityLogContext(identity))
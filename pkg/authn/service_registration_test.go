@@ -0,0 +1,147 @@
+package authn_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/uuid"
+	. "github.com/moov-io/identity/pkg/authn"
+	"github.com/moov-io/identity/pkg/client"
+	tmwt "github.com/moov-io/tumbler/pkg/middleware/middlewaretest"
+)
+
+func (s *Scope) SetPolicy(tenantID string, policy client.RegistrationPolicy) {
+	claims := tmwt.NewRandomClaims()
+	claims.TenantID = uuid.MustParse(tenantID)
+
+	_, err := s.registration.UpdatePolicy(context.Background(), claims, policy)
+	s.assert.Nil(err)
+}
+
+func Test_Policy_InviteOnly_IgnoresSignup(t *testing.T) {
+	s := Setup(t)
+
+	ls := LoginSession{}
+	s.fuzz.Fuzz(&ls)
+	ls.TenantID = uuid.New().String()
+	ls.Scopes = []string{"register", "finished", "signup"}
+
+	s.SetPolicy(ls.TenantID, client.RegistrationPolicy{Mode: "invite"})
+
+	c := s.NewClient(ls)
+	_, resp, err := c.AuthenticationApi.RegisterWithCredentials(context.Background(), ls.Register)
+	s.assert.NotNil(err)
+	s.assert.Equal(404, resp.StatusCode)
+}
+
+func Test_Policy_Signup_NoInvite(t *testing.T) {
+	s := Setup(t)
+
+	ls := LoginSession{}
+	s.fuzz.Fuzz(&ls)
+	ls.TenantID = uuid.New().String()
+	ls.InviteCode = ""
+	ls.Scopes = []string{"register", "finished"}
+
+	s.SetPolicy(ls.TenantID, client.RegistrationPolicy{Mode: "signup"})
+
+	c := s.NewClient(ls)
+	_, resp, err := c.AuthenticationApi.RegisterWithCredentials(context.Background(), ls.Register)
+	s.assert.Nil(err)
+	s.assert.Equal(200, resp.StatusCode)
+}
+
+func Test_Policy_DomainAutoJoin(t *testing.T) {
+	s := Setup(t)
+
+	ls := LoginSession{}
+	s.fuzz.Fuzz(&ls)
+	ls.TenantID = uuid.New().String()
+	ls.Email = "someone@example.com"
+	ls.EmailVerified = true
+	ls.Scopes = []string{"register", "finished"}
+
+	s.SetPolicy(ls.TenantID, client.RegistrationPolicy{Mode: "domain", AllowedDomains: []string{"Example.COM"}})
+
+	c := s.NewClient(ls)
+	_, resp, err := c.AuthenticationApi.RegisterWithCredentials(context.Background(), ls.Register)
+	s.assert.Nil(err)
+	s.assert.Equal(200, resp.StatusCode)
+}
+
+func Test_Policy_DomainAutoJoin_Unverified(t *testing.T) {
+	s := Setup(t)
+
+	ls := LoginSession{}
+	s.fuzz.Fuzz(&ls)
+	ls.TenantID = uuid.New().String()
+	ls.Email = "someone@example.com"
+	ls.EmailVerified = false
+	ls.Scopes = []string{"register", "finished"}
+
+	s.SetPolicy(ls.TenantID, client.RegistrationPolicy{Mode: "domain", AllowedDomains: []string{"example.com"}})
+
+	c := s.NewClient(ls)
+	_, resp, err := c.AuthenticationApi.RegisterWithCredentials(context.Background(), ls.Register)
+	s.assert.NotNil(err)
+	s.assert.Equal(404, resp.StatusCode)
+}
+
+func Test_Policy_DomainAutoJoin_ChangedEmail(t *testing.T) {
+	s := Setup(t)
+
+	ls := LoginSession{}
+	s.fuzz.Fuzz(&ls)
+	ls.TenantID = uuid.New().String()
+	ls.Email = "someone@gmail.com"
+	ls.EmailVerified = true
+	ls.Scopes = []string{"register", "finished"}
+
+	s.SetPolicy(ls.TenantID, client.RegistrationPolicy{Mode: "domain", AllowedDomains: []string{"example.com"}})
+
+	// The provider only verified the gmail address so this one still needs an invite
+	register := ls.Register
+	register.Email = "someone@example.com"
+
+	c := s.NewClient(ls)
+	_, resp, err := c.AuthenticationApi.RegisterWithCredentials(context.Background(), register)
+	s.assert.NotNil(err)
+	s.assert.Equal(404, resp.StatusCode)
+}
+
+func Test_Policy_RequireApproval(t *testing.T) {
+	s := Setup(t)
+
+	ls := LoginSession{}
+	s.fuzz.Fuzz(&ls)
+	ls.TenantID = uuid.New().String()
+	ls.Scopes = []string{"register", "finished"}
+
+	s.SetPolicy(ls.TenantID, client.RegistrationPolicy{Mode: "signup", RequireApproval: true})
+
+	c := s.NewClient(ls)
+	_, resp, _ := c.AuthenticationApi.RegisterWithCredentials(context.Background(), ls.Register)
+	s.assert.Equal(202, resp.StatusCode)
+	for _, cookie := range resp.Cookies() {
+		s.assert.NotEqual("moov", cookie.Name)
+	}
+
+	claims := tmwt.NewRandomClaims()
+	claims.TenantID = uuid.MustParse(ls.TenantID)
+	pending, err := s.registration.ListPending(context.Background(), claims)
+	s.assert.Nil(err)
+	s.assert.Len(pending, 1)
+	s.assert.Equal(ls.Email, pending[0].Email)
+
+	// Can't login until its approved
+	loginSession := LoginSession{}
+	s.fuzz.Fuzz(&loginSession)
+	loginSession.CredentialID = ls.CredentialID
+	loginSession.TenantID = ls.TenantID
+	loginSession.Scopes = []string{"authenticate", "finished"}
+
+	c = s.NewClient(loginSession)
+	_, resp, err = c.AuthenticationApi.Authenticated(context.Background())
+	s.assert.NotNil(err)
+	s.assert.Equal(403, resp.StatusCode)
+}
@@ -0,0 +1,51 @@
+package authn_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/uuid"
+	. "github.com/moov-io/identity/pkg/authn"
+	"github.com/moov-io/identity/pkg/client"
+	"github.com/moov-io/identity/pkg/identities"
+	tmwt "github.com/moov-io/tumbler/pkg/middleware/middlewaretest"
+)
+
+func Test_Login_GatedByStatus(t *testing.T) {
+	s := Setup(t)
+
+	ls := LoginSession{}
+	s.fuzz.Fuzz(&ls)
+	ls.TenantID = uuid.New().String()
+	ls.Scopes = []string{"register", "finished", "signup"}
+
+	c := s.NewClient(ls)
+	_, resp, err := c.AuthenticationApi.RegisterWithCredentials(context.Background(), ls.Register)
+	s.assert.Nil(err)
+	s.assert.Equal(200, resp.StatusCode)
+
+	identityID := s.IdentityIDOf(ls)
+
+	claims := tmwt.NewRandomClaims()
+	claims.TenantID = uuid.MustParse(ls.TenantID)
+
+	for _, status := range []string{identities.StatusSuspended, identities.StatusActive, identities.StatusLocked} {
+		_, err := s.identities.ChangeStatus(context.Background(), claims, identityID, status, client.ChangeStatus{Reason: "testing"}, nil)
+		s.assert.Nil(err)
+
+		loginSession := LoginSession{}
+		s.fuzz.Fuzz(&loginSession)
+		loginSession.CredentialID = ls.CredentialID
+		loginSession.TenantID = ls.TenantID
+		// Each login records its state as the nonce so a repeat from the fuzzer would be refused.
+		loginSession.State = uuid.New().String()
+		loginSession.Scopes = []string{"authenticate", "finished"}
+
+		_, resp, _ := s.NewClient(loginSession).AuthenticationApi.Authenticated(context.Background())
+		if status == identities.StatusActive {
+			s.assert.Equal(200, resp.StatusCode, status)
+		} else {
+			s.assert.Equal(403, resp.StatusCode, status)
+		}
+	}
+}
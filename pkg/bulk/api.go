@@ -0,0 +1,142 @@
+package bulk
+
+import (
+	"encoding/csv"
+	"errors"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+
+	validation "github.com/go-ozzo/ozzo-validation/v4"
+	api "github.com/moov-io/identity/pkg/api"
+	"github.com/moov-io/identity/pkg/apperr"
+	"github.com/moov-io/identity/pkg/logging"
+	tmw "github.com/moov-io/tumbler/pkg/middleware"
+)
+
+// A Controller binds http requests to an api service and writes the service results to the http response
+type controller struct {
+	logger  logging.Logger
+	service Service
+}
+
+// NewBulkController creates a default api controller. Its routes need to come before the identities ones
+// so /identities/export isn't taken for an identityID.
+func NewBulkController(logger logging.Logger, s Service) api.Router {
+	return &controller{
+		logger:  logger,
+		service: s,
+	}
+}
+
+// Routes returns all of the api route for the BulkController
+func (c *controller) Routes() api.Routes {
+	return api.Routes{
+		{
+			Name:        "ImportIdentities",
+			Method:      strings.ToUpper("Post"),
+			Pattern:     "/identities/import",
+			HandlerFunc: c.ImportIdentities,
+		},
+		{
+			Name:        "ExportIdentities",
+			Method:      strings.ToUpper("Get"),
+			Pattern:     "/identities/export",
+			HandlerFunc: c.ExportIdentities,
+		},
+	}
+}
+
+// ImportIdentities - Create identities or send invites for the people in a CSV or JSONL file
+func (c *controller) ImportIdentities(w http.ResponseWriter, r *http.Request) {
+	tmw.WithClaimsFromRequest(w, r, func(claims tmw.TumblerClaims) {
+		query := r.URL.Query()
+
+		mode := query.Get("mode")
+		if mode == "" {
+			mode = ModeIdentities
+		}
+
+		dryRun := false
+		if d := query.Get("dryRun"); d != "" {
+			var err error
+			if dryRun, err = strconv.ParseBool(d); err != nil {
+				errorHandling(w, validation.Errors{"dryRun": errors.New("must be true or false")})
+				return
+			}
+		}
+
+		result, err := c.service.Import(r.Context(), claims, mode, requestFormat(r), r.Body, dryRun)
+		if err != nil {
+			errorHandling(w, c.logger.LogError("unable to import identities", err))
+			return
+		}
+
+		api.EncodeJSONResponse(result, nil, w)
+	})
+}
+
+// ExportIdentities - Stream out every identity of the tenant with their phones and addresses
+func (c *controller) ExportIdentities(w http.ResponseWriter, r *http.Request) {
+	tmw.WithClaimsFromRequest(w, r, func(claims tmw.TumblerClaims) {
+		format := r.URL.Query().Get("format")
+		if format == "" {
+			format = FormatJSONL
+		}
+
+		switch format {
+		case FormatCSV:
+			w.Header().Set("Content-Type", "text/csv; charset=UTF-8")
+		case FormatJSONL:
+			w.Header().Set("Content-Type", "application/x-ndjson; charset=UTF-8")
+		default:
+			errorHandling(w, ErrUnknownFormat)
+			return
+		}
+		w.Header().Set("Content-Disposition", "attachment; filename=identities."+format)
+
+		// Headers are gone once the first identity is written so a failure part way through can only be logged.
+		if err := c.service.Export(r.Context(), claims, format, &flushWriter{w: w}); err != nil {
+			c.logger.LogError("unable to export identities", err)
+		}
+	})
+}
+
+// requestFormat - Format of the import from the query, or from the content type when its not there.
+func requestFormat(r *http.Request) string {
+	if format := r.URL.Query().Get("format"); format != "" {
+		return format
+	}
+
+	mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	switch mediaType {
+	case "text/csv":
+		return FormatCSV
+	case "application/x-ndjson", "application/jsonl":
+		return FormatJSONL
+	}
+	return ""
+}
+
+// flushWriter - Pushes each identity out to the client as its written instead of buffering the export.
+type flushWriter struct {
+	w http.ResponseWriter
+}
+
+func (f *flushWriter) Write(p []byte) (int, error) {
+	n, err := f.w.Write(p)
+	if flusher, ok := f.w.(http.Flusher); ok {
+		flusher.Flush()
+	}
+	return n, err
+}
+
+// errorHandling - A file that can't be parsed is a problem with what was sent in.
+func errorHandling(w http.ResponseWriter, err error) {
+	if errors.Is(err, csv.ErrFieldCount) || errors.As(err, new(*csv.ParseError)) {
+		err = apperr.Validation(err.Error(), nil)
+	}
+
+	api.EncodeError(w, err)
+}
@@ -0,0 +1,15 @@
+package bulk
+
+import "github.com/moov-io/identity/pkg/apperr"
+
+// ErrUnknownFormat is issued when an import or export is asked for in a format other than csv or jsonl.
+var ErrUnknownFormat = apperr.Validation("format must be csv or jsonl", nil)
+
+// ErrUnknownMode is issued when an import is asked to create something other than identities or invites.
+var ErrUnknownMode = apperr.Validation("mode must be identities or invites", nil)
+
+// ErrMissingEmailColumn is issued when the header of a CSV import doesn't have an email column.
+var ErrMissingEmailColumn = apperr.Validation("csv header is missing the email column", nil)
+
+// ErrImportTooLarge is issued when an import has more people than can be taken in one request.
+var ErrImportTooLarge = apperr.Validation("import has too many rows", nil)
@@ -0,0 +1,265 @@
+package bulk
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/moov-io/identity/pkg/client"
+)
+
+// Formats people can be imported and exported in
+const (
+	FormatCSV   = "csv"
+	FormatJSONL = "jsonl"
+)
+
+// Columns of the CSV format. Exports lead with the identityColumns which imports ignore.
+// A person with several phones or addresses takes up one row for each, in a row after another with the same email.
+var (
+	identityColumns = []string{"identityID", "status", "registeredOn"}
+	importColumns   = []string{
+		"email", "firstName", "middleName", "lastName", "nickName", "suffix", "birthDate",
+		"phoneNumber", "phoneType",
+		"addressType", "address1", "address2", "city", "state", "postalCode", "country",
+	}
+)
+
+// record - A person read from an import along with the line they start on.
+type record struct {
+	row      int64
+	identity client.ImportIdentity
+	err      error
+}
+
+// readImport - Reads all of the people out of the import.
+func readImport(format string, body io.Reader, max int) ([]record, error) {
+	switch format {
+	case FormatCSV:
+		return readCSV(body, max)
+	case FormatJSONL:
+		return readJSONL(body, max)
+	}
+	return nil, ErrUnknownFormat
+}
+
+func readJSONL(body io.Reader, max int) ([]record, error) {
+	scanner := bufio.NewScanner(body)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+
+	records := []record{}
+	line := int64(0)
+	for scanner.Scan() {
+		line++
+
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" {
+			continue
+		}
+
+		if len(records) == max {
+			return nil, ErrImportTooLarge
+		}
+
+		rec := record{row: line}
+		rec.err = json.Unmarshal([]byte(text), &rec.identity)
+		records = append(records, rec)
+	}
+
+	return records, scanner.Err()
+}
+
+func readCSV(body io.Reader, max int) ([]record, error) {
+	reader := csv.NewReader(body)
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if err == io.EOF {
+		return []record{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	columns := map[string]int{}
+	for i, name := range header {
+		columns[strings.TrimSpace(name)] = i
+	}
+	if _, ok := columns["email"]; !ok {
+		return nil, ErrMissingEmailColumn
+	}
+
+	records := []record{}
+	line := int64(1)
+	for {
+		fields, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		line++
+
+		get := func(name string) string {
+			if i, ok := columns[name]; ok && i < len(fields) {
+				return strings.TrimSpace(fields[i])
+			}
+			return ""
+		}
+
+		email := get("email")
+
+		// Continues the person from the row before with another phone or address.
+		if n := len(records); n > 0 && email != "" && strings.EqualFold(records[n-1].identity.Email, email) {
+			addContacts(&records[n-1].identity, get)
+			continue
+		}
+
+		if len(records) == max {
+			return nil, ErrImportTooLarge
+		}
+
+		identity := client.ImportIdentity{
+			Email:      email,
+			FirstName:  get("firstName"),
+			MiddleName: get("middleName"),
+			LastName:   get("lastName"),
+			NickName:   optional(get("nickName")),
+			Suffix:     optional(get("suffix")),
+			BirthDate:  optional(get("birthDate")),
+		}
+		addContacts(&identity, get)
+
+		records = append(records, record{row: line, identity: identity})
+	}
+
+	return records, nil
+}
+
+func addContacts(identity *client.ImportIdentity, get func(string) string) {
+	if number := get("phoneNumber"); number != "" || get("phoneType") != "" {
+		identity.Phones = append(identity.Phones, client.UpdatePhone{
+			Number: number,
+			Type:   get("phoneType"),
+		})
+	}
+
+	address := client.UpdateAddress{
+		Type:       get("addressType"),
+		Address1:   get("address1"),
+		Address2:   optional(get("address2")),
+		City:       get("city"),
+		State:      get("state"),
+		PostalCode: get("postalCode"),
+		Country:    get("country"),
+	}
+	if address != (client.UpdateAddress{}) {
+		identity.Addresses = append(identity.Addresses, address)
+	}
+}
+
+func optional(s string) *string {
+	if s == "" {
+		return nil
+	}
+	return &s
+}
+
+// exporter - Writes identities out one at a time in the format of the export.
+type exporter interface {
+	write(identity client.Identity) error
+	flush() error
+}
+
+func newExporter(format string, w io.Writer) (exporter, error) {
+	switch format {
+	case FormatCSV:
+		writer := csv.NewWriter(w)
+		if err := writer.Write(append(append([]string{}, identityColumns...), importColumns...)); err != nil {
+			return nil, err
+		}
+		return &csvExporter{writer: writer}, nil
+	case FormatJSONL:
+		return &jsonlExporter{encoder: json.NewEncoder(w)}, nil
+	}
+	return nil, ErrUnknownFormat
+}
+
+type jsonlExporter struct {
+	encoder *json.Encoder
+}
+
+func (e *jsonlExporter) write(identity client.Identity) error {
+	return e.encoder.Encode(identity)
+}
+
+func (e *jsonlExporter) flush() error {
+	return nil
+}
+
+type csvExporter struct {
+	writer *csv.Writer
+}
+
+func (e *csvExporter) write(identity client.Identity) error {
+	rows := len(identity.Phones)
+	if len(identity.Addresses) > rows {
+		rows = len(identity.Addresses)
+	}
+	if rows == 0 {
+		rows = 1
+	}
+
+	for i := 0; i < rows; i++ {
+		fields := []string{
+			identity.IdentityID,
+			identity.Status,
+			identity.RegisteredOn.Format(time.RFC3339),
+			identity.Email,
+			identity.FirstName,
+			identity.MiddleName,
+			identity.LastName,
+			value(identity.NickName),
+			value(identity.Suffix),
+			value(identity.BirthDate),
+		}
+
+		if i < len(identity.Phones) {
+			p := identity.Phones[i]
+			fields = append(fields, p.Number, p.Type)
+		} else {
+			fields = append(fields, "", "")
+		}
+
+		if i < len(identity.Addresses) {
+			a := identity.Addresses[i]
+			fields = append(fields, a.Type, a.Address1, value(a.Address2), a.City, a.State, a.PostalCode, a.Country)
+		} else {
+			fields = append(fields, "", "", "", "", "", "", "")
+		}
+
+		if err := e.writer.Write(fields); err != nil {
+			return err
+		}
+	}
+
+	e.writer.Flush()
+	return e.writer.Error()
+}
+
+func (e *csvExporter) flush() error {
+	e.writer.Flush()
+	return e.writer.Error()
+}
+
+func value(s *string) string {
+	if s == nil {
+		return ""
+	}
+	return *s
+}
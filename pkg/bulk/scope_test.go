@@ -0,0 +1,113 @@
+package bulk
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gorilla/mux"
+	api "github.com/moov-io/identity/pkg/api"
+	authntestutils "github.com/moov-io/identity/pkg/authn/testutils"
+	"github.com/moov-io/identity/pkg/database"
+	"github.com/moov-io/identity/pkg/identities"
+	identitiestestutils "github.com/moov-io/identity/pkg/identities/testutils"
+	"github.com/moov-io/identity/pkg/invites"
+	"github.com/moov-io/identity/pkg/jobs"
+	"github.com/moov-io/identity/pkg/logging"
+	"github.com/moov-io/identity/pkg/notifications"
+	"github.com/moov-io/identity/pkg/outbox"
+	"github.com/moov-io/identity/pkg/stime"
+	tmw "github.com/moov-io/tumbler/pkg/middleware"
+	tmwt "github.com/moov-io/tumbler/pkg/middleware/middlewaretest"
+	"github.com/stretchr/testify/require"
+)
+
+type Scope struct {
+	session    tmw.TumblerClaims
+	time       stime.StaticTimeService
+	identities identities.Service
+	invites    invites.InvitesService
+	service    Service
+	routes     *mux.Router
+}
+
+func NewScope(t *testing.T) Scope {
+	logging := logging.NewDefaultLogger()
+	session := tmwt.NewRandomClaims()
+	times := stime.NewStaticTimeService()
+
+	db, close, err := database.NewAndMigrate(database.InMemorySqliteConfig, nil, nil)
+	t.Cleanup(close)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	templates, err := notifications.NewTemplateRepository(logging)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	notifications := notifications.NewMockNotificationsService(notifications.MockConfig{
+		From: "noreply@moov.io",
+	}, templates)
+
+	outbox := outbox.NewOutboxService(outbox.Config{}, logging, times, outbox.NewOutboxRepository(db), notifications)
+
+	identitiesService, err := identities.NewIdentitiesService(identities.Config{}, times, identities.NewIdentityRepository(db, outbox))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	invitesConfig := invites.Config{
+		Expiration: time.Hour,
+		SendToHost: "http://local.moov.io",
+		SendToPath: "/invite",
+	}
+
+	// The inviter is looked up from the session so its stood in for.
+	invitesService, err := invites.NewInvitesService(invitesConfig, times, invites.NewInvitesRepository(db, outbox), authntestutils.NewMockAuthnClient(), identitiestestutils.NewSingleService(nil), jobs.NewJobsService(logging, times, jobs.NewJobsRepository(db)))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	service := NewBulkService(identitiesService, invitesService)
+
+	routes := mux.NewRouter()
+	api.AppendRouters(logging, routes, NewBulkController(logging, service))
+
+	testMiddleware := tmwt.NewTestMiddleware(times, session)
+	routes.Use(testMiddleware.Handler)
+
+	return Scope{
+		session:    session,
+		time:       times,
+		identities: identitiesService,
+		invites:    invitesService,
+		service:    service,
+		routes:     routes,
+	}
+}
+
+func Setup(t *testing.T) (*require.Assertions, Scope) {
+	return require.New(t), NewScope(t)
+}
+
+// Request - Sends the body through the routes as is with the content type passed in.
+func (s *Scope) Request(method string, path string, contentType string, body string) *http.Response {
+	var reader io.Reader
+	if body != "" {
+		reader = bytes.NewBufferString(body)
+	}
+
+	req := httptest.NewRequest(method, path, reader)
+	if contentType != "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+
+	rec := httptest.NewRecorder()
+	s.routes.ServeHTTP(rec, req)
+	return rec.Result()
+}
@@ -0,0 +1,252 @@
+package bulk
+
+import (
+	"context"
+	"errors"
+	"io"
+	"strings"
+
+	validation "github.com/go-ozzo/ozzo-validation/v4"
+	"github.com/go-ozzo/ozzo-validation/v4/is"
+	"github.com/moov-io/identity/pkg/client"
+	"github.com/moov-io/identity/pkg/identities"
+	"github.com/moov-io/identity/pkg/invites"
+	tmw "github.com/moov-io/tumbler/pkg/middleware"
+)
+
+// What a bulk import creates for each person
+const (
+	ModeIdentities = "identities"
+	ModeInvites    = "invites"
+)
+
+// Status of each row of an import report
+const (
+	RowCreated = "created"
+	RowValid   = "valid"
+	RowInvalid = "invalid"
+	RowFailed  = "failed"
+)
+
+const (
+	batchSize     = 100
+	maxImportRows = 10000
+
+	// Invites are sent during the request so fewer are taken, the same as a batch of invites.
+	maxInviteRows = 1000
+)
+
+// Service - Brings people into a tenant in bulk and takes its identities back out.
+type Service interface {
+	Import(ctx context.Context, claims tmw.TumblerClaims, mode string, format string, body io.Reader, dryRun bool) (*client.ImportReport, error)
+	Export(ctx context.Context, claims tmw.TumblerClaims, format string, w io.Writer) error
+}
+
+type service struct {
+	identities identities.Service
+	invites    invites.InvitesService
+}
+
+// NewBulkService - Creates the identities and sends the invites of imports through the services that normally would.
+func NewBulkService(identities identities.Service, invites invites.InvitesService) Service {
+	return &service{
+		identities: identities,
+		invites:    invites,
+	}
+}
+
+// Import - Validates every person of the import and creates an identity or sends an invite for each one that passes.
+// Identities are saved a batch at a time, when a batch fails every row in it is marked failed.
+// People with an email that already belongs to an identity of the tenant, or that came earlier in the import, are rejected.
+// On a dry run nothing is created and the rows that would have been are reported as valid.
+func (s *service) Import(ctx context.Context, claims tmw.TumblerClaims, mode string, format string, body io.Reader, dryRun bool) (*client.ImportReport, error) {
+	if mode != ModeIdentities && mode != ModeInvites {
+		return nil, ErrUnknownMode
+	}
+
+	max := maxImportRows
+	if mode == ModeInvites {
+		max = maxInviteRows
+	}
+
+	records, err := readImport(format, body, max)
+	if err != nil {
+		return nil, err
+	}
+
+	taken := map[string]bool{}
+	err = s.identities.ExportIdentities(ctx, claims, func(identity client.Identity) error {
+		taken[strings.ToLower(identity.Email)] = true
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	report := client.ImportReport{
+		Mode:   mode,
+		DryRun: dryRun,
+		Total:  int64(len(records)),
+		Rows:   []client.ImportRow{},
+	}
+
+	// Index into the report rows of the people that passed validation
+	valid := []int{}
+	for i := range records {
+		rec := &records[i]
+		row := client.ImportRow{
+			Row:   rec.row,
+			Email: rec.identity.Email,
+		}
+
+		err := rec.err
+		if err == nil {
+			err = validateRecord(mode, &rec.identity, taken)
+		}
+
+		if err != nil {
+			row.Status = RowInvalid
+			row.Errors = rowErrors(err)
+		} else {
+			row.Status = RowValid
+			valid = append(valid, i)
+		}
+
+		report.Rows = append(report.Rows, row)
+	}
+
+	if !dryRun && mode == ModeInvites {
+		s.sendInvites(ctx, claims, &report, records, valid)
+	} else if !dryRun {
+		for start := 0; start < len(valid); start += batchSize {
+			end := start + batchSize
+			if end > len(valid) {
+				end = len(valid)
+			}
+
+			s.importIdentities(ctx, claims, &report, records, valid[start:end])
+		}
+	}
+
+	for _, row := range report.Rows {
+		switch row.Status {
+		case RowCreated, RowValid:
+			report.Succeeded++
+		default:
+			report.Failed++
+		}
+	}
+
+	return &report, nil
+}
+
+func (s *service) importIdentities(ctx context.Context, claims tmw.TumblerClaims, report *client.ImportReport, records []record, batch []int) {
+	imports := []client.ImportIdentity{}
+	for _, i := range batch {
+		imports = append(imports, records[i].identity)
+	}
+
+	created, err := s.identities.ImportIdentities(ctx, claims, imports)
+	for n, i := range batch {
+		row := &report.Rows[i]
+		if err != nil {
+			row.Status = RowFailed
+			row.Errors = rowErrors(err)
+			continue
+		}
+
+		row.Status = RowCreated
+		row.IdentityID = &created[n].IdentityID
+	}
+}
+
+// sendInvites - Every row fails when who's sending the invites can't be found.
+func (s *service) sendInvites(ctx context.Context, claims tmw.TumblerClaims, report *client.ImportReport, records []record, valid []int) {
+	emails := []string{}
+	for _, i := range valid {
+		emails = append(emails, records[i].identity.Email)
+	}
+
+	n := 0
+	err := s.invites.SendInvites(ctx, claims, emails, func(email string, invite *client.Invite, err error) {
+		row := &report.Rows[valid[n]]
+		n++
+
+		if err != nil {
+			row.Status = RowFailed
+			row.Errors = rowErrors(err)
+			return
+		}
+
+		row.Status = RowCreated
+		row.InviteID = &invite.InviteID
+	})
+	if err != nil {
+		for _, i := range valid {
+			report.Rows[i].Status = RowFailed
+			report.Rows[i].Errors = rowErrors(err)
+		}
+	}
+}
+
+// validateRecord - Invites only need an email, identities are held to all of the import rules.
+// Claims the email so the same person further down the import is rejected.
+func validateRecord(mode string, identity *client.ImportIdentity, taken map[string]bool) error {
+	var err error
+	if mode == ModeInvites {
+		err = validation.Errors{"email": validation.Validate(identity.Email, validation.Required, is.EmailFormat)}.Filter()
+	} else {
+		err = identity.Validate()
+	}
+	if err != nil {
+		return err
+	}
+
+	email := strings.ToLower(identity.Email)
+	if taken[email] {
+		return validation.Errors{"email": errors.New("already belongs to an identity or an earlier row")}
+	}
+	taken[email] = true
+
+	return nil
+}
+
+// rowErrors - Flattens the errors into field paths like `phones.0.number`. Errors that aren't about a field are keyed by row.
+func rowErrors(err error) map[string]string {
+	errs := map[string]string{}
+	flattenErrors("", err, errs)
+	return errs
+}
+
+func flattenErrors(prefix string, err error, into map[string]string) {
+	fields, ok := err.(validation.Errors)
+	if !ok {
+		if prefix == "" {
+			prefix = "row"
+		}
+		into[prefix] = err.Error()
+		return
+	}
+
+	for k, e := range fields {
+		path := k
+		if prefix != "" {
+			path = prefix + "." + k
+		}
+		flattenErrors(path, e, into)
+	}
+}
+
+// Export - Writes every identity of the tenant along with its phones and addresses as they are read.
+func (s *service) Export(ctx context.Context, claims tmw.TumblerClaims, format string, w io.Writer) error {
+	exporter, err := newExporter(format, w)
+	if err != nil {
+		return err
+	}
+
+	if err := s.identities.ExportIdentities(ctx, claims, exporter.write); err != nil {
+		return err
+	}
+
+	return exporter.flush()
+}
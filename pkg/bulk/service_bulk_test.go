@@ -0,0 +1,209 @@
+package bulk
+
+import (
+	"bufio"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/moov-io/identity/pkg/client"
+)
+
+const peopleCSV = `email,firstName,lastName,birthDate,phoneNumber,phoneType,addressType,address1,city,state,postalCode,country
+ada@moov.io,Ada,Lovelace,1815-12-10,555-123-4567,mobile,primary,1 Main St,Anytown,CA,94105,US
+ada@moov.io,,,,555-765-4321,work,secondary,2 Side St,Anytown,CA,94105,US
+grace@moov.io,Grace,Hopper,,,,,,,,,
+not-an-email,Alan,Turing,,,,,,,,,
+ADA@moov.io,Ada,Again,,,,,,,,,
+bad@moov.io,B,Bad,,12,mobile,,,,,,
+`
+
+func Test_ImportCSV(t *testing.T) {
+	a, s := Setup(t)
+
+	report, err := s.service.Import(context.Background(), s.session, ModeIdentities, FormatCSV, strings.NewReader(peopleCSV), false)
+	a.Nil(err)
+	a.Equal(int64(5), report.Total)
+	a.Equal(int64(2), report.Succeeded)
+	a.Equal(int64(3), report.Failed)
+
+	rows := report.Rows
+	a.Equal(int64(2), rows[0].Row)
+	a.Equal(RowCreated, rows[0].Status)
+	a.NotNil(rows[0].IdentityID)
+
+	// the second line of ada was folded into the first
+	a.Equal(int64(4), rows[1].Row)
+	a.Equal(RowCreated, rows[1].Status)
+
+	a.Equal(RowInvalid, rows[2].Status)
+	a.Contains(rows[2].Errors, "email")
+
+	a.Equal(RowInvalid, rows[3].Status)
+	a.Contains(rows[3].Errors["email"], "earlier row")
+
+	a.Equal(RowInvalid, rows[4].Status)
+	a.Contains(rows[4].Errors, "firstName")
+	a.Contains(rows[4].Errors, "phones.0.number")
+
+	ada, err := s.identities.GetIdentity(context.Background(), s.session, *rows[0].IdentityID)
+	a.Nil(err)
+	a.Equal("Lovelace", ada.LastName)
+	a.Contains(*ada.BirthDate, "1815-12-10")
+	a.Len(ada.Phones, 2)
+	a.Len(ada.Addresses, 2)
+
+	// Importing again is rejected since the emails belong to identities now
+	report, err = s.service.Import(context.Background(), s.session, ModeIdentities, FormatCSV, strings.NewReader(peopleCSV), false)
+	a.Nil(err)
+	a.Equal(int64(0), report.Succeeded)
+	a.Contains(report.Rows[0].Errors, "email")
+}
+
+func Test_ImportDryRun(t *testing.T) {
+	a, s := Setup(t)
+
+	report, err := s.service.Import(context.Background(), s.session, ModeIdentities, FormatCSV, strings.NewReader(peopleCSV), true)
+	a.Nil(err)
+	a.True(report.DryRun)
+	a.Equal(int64(2), report.Succeeded)
+	a.Equal(RowValid, report.Rows[0].Status)
+	a.Nil(report.Rows[0].IdentityID)
+
+	found, err := s.identities.ListIdentities(context.Background(), s.session, nil)
+	a.Nil(err)
+	a.Empty(found)
+}
+
+func Test_ImportJSONL_Invites(t *testing.T) {
+	a, s := Setup(t)
+
+	body := `{"email":"ada@moov.io"}
+
+{"email":"grace@moov.io","firstName":"Grace"}
+{"email":
+{"email":"nope"}
+`
+
+	report, err := s.service.Import(context.Background(), s.session, ModeInvites, FormatJSONL, strings.NewReader(body), false)
+	a.Nil(err)
+	a.Equal(int64(4), report.Total)
+	a.Equal(int64(2), report.Succeeded)
+
+	a.Equal(RowCreated, report.Rows[0].Status)
+	a.NotNil(report.Rows[0].InviteID)
+
+	a.Equal(int64(3), report.Rows[1].Row)
+	a.Equal(RowCreated, report.Rows[1].Status)
+
+	a.Equal(RowInvalid, report.Rows[2].Status)
+	a.Contains(report.Rows[2].Errors, "row")
+
+	a.Equal(RowInvalid, report.Rows[3].Status)
+	a.Contains(report.Rows[3].Errors, "email")
+
+	invites, err := s.invites.ListInvites(context.Background(), s.session)
+	a.Nil(err)
+	a.Len(invites, 2)
+}
+
+func Test_ImportInvites_TooLarge(t *testing.T) {
+	a, s := Setup(t)
+
+	body := strings.Builder{}
+	for i := 0; i <= maxInviteRows; i++ {
+		body.WriteString(`{"email":"many@moov.io"}` + "\n")
+	}
+
+	_, err := s.service.Import(context.Background(), s.session, ModeInvites, FormatJSONL, strings.NewReader(body.String()), true)
+	a.Equal(ErrImportTooLarge, err)
+
+	// As many identities are still taken
+	_, err = s.service.Import(context.Background(), s.session, ModeIdentities, FormatJSONL, strings.NewReader(body.String()), true)
+	a.Nil(err)
+}
+
+func Test_ImportAPI(t *testing.T) {
+	a, s := Setup(t)
+
+	resp := s.Request("POST", "/identities/import?dryRun=true", "text/csv", peopleCSV)
+	a.Equal(200, resp.StatusCode)
+
+	report := client.ImportReport{}
+	a.Nil(json.NewDecoder(resp.Body).Decode(&report))
+	a.Equal(ModeIdentities, report.Mode)
+	a.True(report.DryRun)
+	a.Len(report.Rows, 5)
+
+	resp = s.Request("POST", "/identities/import", "application/pdf", peopleCSV)
+	a.Equal(400, resp.StatusCode)
+
+	resp = s.Request("POST", "/identities/import?mode=robots", "text/csv", peopleCSV)
+	a.Equal(400, resp.StatusCode)
+
+	resp = s.Request("POST", "/identities/import", "text/csv", "firstName,lastName\nAda,Lovelace\n")
+	a.Equal(400, resp.StatusCode)
+
+	resp = s.Request("POST", "/identities/import", "text/csv", "email\n\"ada@moov.io\n")
+	a.Equal(400, resp.StatusCode)
+}
+
+func Test_ExportAPI(t *testing.T) {
+	a, s := Setup(t)
+
+	_, err := s.service.Import(context.Background(), s.session, ModeIdentities, FormatCSV, strings.NewReader(peopleCSV), false)
+	a.Nil(err)
+
+	resp := s.Request("GET", "/identities/export", "", "")
+	a.Equal(200, resp.StatusCode)
+	a.Contains(resp.Header.Get("Content-Type"), "application/x-ndjson")
+
+	exported := []client.Identity{}
+	scanner := bufio.NewScanner(resp.Body)
+	for scanner.Scan() {
+		identity := client.Identity{}
+		a.Nil(json.Unmarshal(scanner.Bytes(), &identity))
+		exported = append(exported, identity)
+	}
+	a.Len(exported, 2)
+
+	resp = s.Request("GET", "/identities/export?format=csv", "", "")
+	a.Equal(200, resp.StatusCode)
+
+	lines, err := csv.NewReader(resp.Body).ReadAll()
+	a.Nil(err)
+	a.Equal(append(append([]string{}, identityColumns...), importColumns...), lines[0])
+	// ada takes up a row for each of her phones
+	a.Len(lines, 4)
+
+	resp = s.Request("GET", "/identities/export?format=xml", "", "")
+	a.Equal(400, resp.StatusCode)
+}
+
+func Test_ExportImport_RoundTrip(t *testing.T) {
+	a, s := Setup(t)
+
+	_, err := s.service.Import(context.Background(), s.session, ModeIdentities, FormatCSV, strings.NewReader(peopleCSV), false)
+	a.Nil(err)
+
+	for _, format := range []string{FormatCSV, FormatJSONL} {
+		out := &strings.Builder{}
+		a.Nil(s.service.Export(context.Background(), s.session, format, out))
+
+		// Into another tenant so the emails aren't taken
+		other := NewScope(t)
+		report, err := other.service.Import(context.Background(), other.session, ModeIdentities, format, strings.NewReader(out.String()), false)
+		a.Nil(err)
+		a.Equal(int64(2), report.Succeeded, format)
+
+		imported, err := other.identities.ListIdentities(context.Background(), other.session, nil)
+		a.Nil(err)
+		phones := 0
+		for _, i := range imported {
+			phones += len(i.Phones)
+		}
+		a.Equal(2, phones, format)
+	}
+}
@@ -293,10 +293,11 @@ func (a *IdentitiesApiService) ListIdentities(ctx _context.Context) ([]Identity,
 UpdateIdentity UpdateInsecure a specific Identity
  * @param ctx _context.Context - for authentication, logging, cancellation, deadlines, tracing, etc. Passed from http.Request or context.Background().
  * @param identityID ID of the Identity to lookup
+ * @param ifMatch ETag of the Identity when it was retrieved. The update is rejected if it changed since.
  * @param updateIdentity
 @return Identity
 */
-func (a *IdentitiesApiService) UpdateIdentity(ctx _context.Context, identityID string, updateIdentity UpdateIdentity) (Identity, *_nethttp.Response, error) {
+func (a *IdentitiesApiService) UpdateIdentity(ctx _context.Context, identityID string, ifMatch string, updateIdentity UpdateIdentity) (Identity, *_nethttp.Response, error) {
 	var (
 		localVarHTTPMethod   = _nethttp.MethodPut
 		localVarPostBody     interface{}
@@ -334,6 +335,7 @@ func (a *IdentitiesApiService) UpdateIdentity(ctx _context.Context, identityID s
 	if localVarHTTPHeaderAccept != "" {
 		localVarHeaderParams["Accept"] = localVarHTTPHeaderAccept
 	}
+	localVarHeaderParams["If-Match"] = parameterToString(ifMatch, "")
 	// body params
 	localVarPostBody = &updateIdentity
 	r, err := a.client.prepareRequest(ctx, localVarPath, localVarHTTPMethod, localVarPostBody, localVarHeaderParams, localVarQueryParams, localVarFormParams, localVarFormFileName, localVarFileName, localVarFileBytes)
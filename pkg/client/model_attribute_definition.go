@@ -0,0 +1,29 @@
+/*
+ * Moov Identity API
+ *
+ * Handles all identities for tracking the users of the Moov platform.
+ *
+ * API version: 0.0.1
+ * Generated by: OpenAPI Generator (https://openapi-generator.tech)
+ */
+
+package client
+
+import (
+	"time"
+)
+
+// AttributeDefinition A custom attribute the tenant can set on its identities
+type AttributeDefinition struct {
+	// UUID v4
+	TenantID string `json:"tenantID,omitempty"`
+	// Key of the attribute in the attributes of an identity
+	Name string `json:"name"`
+	// Type the values of the attribute have to be
+	Type string `json:"type"`
+	// Allowed values when the type is enum
+	EnumValues    []string  `json:"enumValues,omitempty"`
+	Description   *string   `json:"description,omitempty"`
+	CreatedOn     time.Time `json:"createdOn,omitempty"`
+	LastUpdatedOn time.Time `json:"lastUpdatedOn,omitempty"`
+}
@@ -0,0 +1,16 @@
+/*
+ * Moov Identity API
+ *
+ * Handles all identities for tracking the users of the Moov platform.
+ *
+ * API version: 0.0.1
+ * Generated by: OpenAPI Generator (https://openapi-generator.tech)
+ */
+
+package client
+
+// ChangeEmail Arguments to start changing the email of an identity
+type ChangeEmail struct {
+	// Email Address the identity is moving to
+	Email string `json:"email"`
+}
@@ -0,0 +1,16 @@
+/*
+ * Moov Identity API
+ *
+ * Handles all identities for tracking the users of the Moov platform.
+ *
+ * API version: 0.0.1
+ * Generated by: OpenAPI Generator (https://openapi-generator.tech)
+ */
+
+package client
+
+// ChangeStatus Arguments to move an identity to another status
+type ChangeStatus struct {
+	// Why the status of the identity is being changed
+	Reason string `json:"reason"`
+}
@@ -0,0 +1,15 @@
+/*
+ * Moov Identity API
+ *
+ * Handles all identities for tracking the users of the Moov platform.
+ *
+ * API version: 0.0.1
+ * Generated by: OpenAPI Generator (https://openapi-generator.tech)
+ */
+
+package client
+
+// ConfirmEmailChange Code sent to the new email address to confirm the change
+type ConfirmEmailChange struct {
+	Code string `json:"code"`
+}
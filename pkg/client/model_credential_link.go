@@ -0,0 +1,31 @@
+/*
+ * Moov Identity API
+ *
+ * Handles all identities for tracking the users of the Moov platform.
+ *
+ * API version: 0.0.1
+ * Generated by: OpenAPI Generator (https://openapi-generator.tech)
+ */
+
+package client
+
+import (
+	"time"
+)
+
+// CredentialLink Pending request to attach another OIDC credential to an existing identity
+type CredentialLink struct {
+	// UUID v4
+	LinkID string `json:"linkID,omitempty"`
+	// UUID v4
+	IdentityID string `json:"identityID,omitempty"`
+	// UUID v4
+	TenantID string `json:"tenantID,omitempty"`
+	// Code to hand to the AuthN service when starting the OIDC flow for the new provider
+	LinkCode string `json:"linkCode,omitempty"`
+	// UUID v4
+	CreatedBy  string     `json:"createdBy,omitempty"`
+	CreatedOn  time.Time  `json:"createdOn,omitempty"`
+	ExpiresOn  time.Time  `json:"expiresOn,omitempty"`
+	RedeemedOn *time.Time `json:"redeemedOn,omitempty"`
+}
@@ -0,0 +1,31 @@
+/*
+ * Moov Identity API
+ *
+ * Handles all identities for tracking the users of the Moov platform.
+ *
+ * API version: 0.0.1
+ * Generated by: OpenAPI Generator (https://openapi-generator.tech)
+ */
+
+package client
+
+import (
+	"time"
+)
+
+// EmailChange A requested change of email waiting to be confirmed from the new address
+type EmailChange struct {
+	// UUID v4
+	EmailChangeID string `json:"emailChangeID"`
+	// UUID v4
+	IdentityID string `json:"identityID"`
+	// UUID v4
+	TenantID string `json:"tenantID"`
+	// Email Address the identity is moving to
+	NewEmail string `json:"newEmail"`
+	// UUID v4
+	RequestedBy string     `json:"requestedBy"`
+	RequestedOn time.Time  `json:"requestedOn"`
+	ExpiresOn   time.Time  `json:"expiresOn"`
+	ConfirmedOn *time.Time `json:"confirmedOn,omitempty"`
+}
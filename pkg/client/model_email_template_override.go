@@ -0,0 +1,38 @@
+/*
+ * Moov Identity API
+ *
+ * Handles all identities for tracking the users of the Moov platform.
+ *
+ * API version: 0.0.1
+ * Generated by: OpenAPI Generator (https://openapi-generator.tech)
+ */
+
+package client
+
+import (
+	"time"
+)
+
+// EmailTemplateOverride How a tenant changed one of the emails. Anything left out comes from the built-in template.
+type EmailTemplateOverride struct {
+	// UUID v4
+	TenantID string `json:"tenantID"`
+	// Name of the email, like invite or welcome
+	Template string `json:"template"`
+	// Shown as the name of who the email is from
+	SenderName *string `json:"senderName,omitempty"`
+	// Template for the subject
+	Subject *string `json:"subject,omitempty"`
+	LogoURL *string `json:"logoURL,omitempty"`
+	// Hex color of buttons and links, like #0D80F2
+	PrimaryColor *string `json:"primaryColor,omitempty"`
+	// Hex color behind the body of the email
+	BackgroundColor *string `json:"backgroundColor,omitempty"`
+	// Template for the HTML body
+	Html *string `json:"html,omitempty"`
+	// Template for the plain text body
+	Text *string `json:"text,omitempty"`
+	// UUID v4
+	UpdatedBy string    `json:"updatedBy"`
+	UpdatedOn time.Time `json:"updatedOn"`
+}
@@ -0,0 +1,20 @@
+/*
+ * Moov Identity API
+ *
+ * Handles all identities for tracking the users of the Moov platform.
+ *
+ * API version: 0.0.1
+ * Generated by: OpenAPI Generator (https://openapi-generator.tech)
+ */
+
+package client
+
+// EmailTemplatePreview An email rendered with example values the way the tenant would send it
+type EmailTemplatePreview struct {
+	// Name of the email, like invite or welcome
+	Template   string `json:"template"`
+	SenderName string `json:"senderName,omitempty"`
+	Subject    string `json:"subject"`
+	Html       string `json:"html"`
+	Text       string `json:"text"`
+}
@@ -0,0 +1,20 @@
+/*
+ * Moov Identity API
+ *
+ * Handles all identities for tracking the users of the Moov platform.
+ *
+ * API version: 0.0.1
+ * Generated by: OpenAPI Generator (https://openapi-generator.tech)
+ */
+
+package client
+
+// FieldChange Value of a single field before and after an update
+type FieldChange struct {
+	// Path to the field. Phones and addresses are keyed by their ID, ie. phones.{phoneID}.number
+	Field string `json:"field"`
+	// Value before the update, missing if the field was added
+	Before *string `json:"before,omitempty"`
+	// Value after the update, missing if the field was removed
+	After *string `json:"after,omitempty"`
+}
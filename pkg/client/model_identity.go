@@ -41,4 +41,12 @@ type Identity struct {
 	// UUID v4
 	InviteID *string `json:"inviteID,omitempty"`
 	ImageUrl *string `json:"imageUrl,omitempty"`
+	// UUID v4
+	MergedInto *string `json:"mergedInto,omitempty"`
+	// Preferred language of the emails sent to the identity, like fr or fr-CA
+	Locale *string `json:"locale,omitempty"`
+	// Incremented on every change. Also returned as the ETag of the identity.
+	Version int64 `json:"version,omitempty"`
+	// Custom attributes of the identity keyed by the name of their definition
+	Attributes map[string]interface{} `json:"attributes,omitempty"`
 }
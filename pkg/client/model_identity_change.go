@@ -0,0 +1,28 @@
+/*
+ * Moov Identity API
+ *
+ * Handles all identities for tracking the users of the Moov platform.
+ *
+ * API version: 0.0.1
+ * Generated by: OpenAPI Generator (https://openapi-generator.tech)
+ */
+
+package client
+
+import (
+	"time"
+)
+
+// IdentityChange Fields of an identity that were changed by a single update
+type IdentityChange struct {
+	// UUID v4
+	IdentityChangeID string `json:"identityChangeID"`
+	// UUID v4
+	IdentityID string `json:"identityID"`
+	// UUID v4
+	TenantID string        `json:"tenantID"`
+	Changes  []FieldChange `json:"changes"`
+	// UUID v4
+	ChangedBy string    `json:"changedBy"`
+	ChangedOn time.Time `json:"changedOn"`
+}
@@ -0,0 +1,24 @@
+/*
+ * Moov Identity API
+ *
+ * Handles all identities for tracking the users of the Moov platform.
+ *
+ * API version: 0.0.1
+ * Generated by: OpenAPI Generator (https://openapi-generator.tech)
+ */
+
+package client
+
+// ImportIdentity A person to create an identity or send an invite for in a bulk import
+type ImportIdentity struct {
+	// Email Address
+	Email      string          `json:"email"`
+	FirstName  string          `json:"firstName,omitempty"`
+	MiddleName string          `json:"middleName,omitempty"`
+	LastName   string          `json:"lastName,omitempty"`
+	NickName   *string         `json:"nickName,omitempty"`
+	Suffix     *string         `json:"suffix,omitempty"`
+	BirthDate  *string         `json:"birthDate,omitempty"`
+	Phones     []UpdatePhone   `json:"phones,omitempty"`
+	Addresses  []UpdateAddress `json:"addresses,omitempty"`
+}
@@ -0,0 +1,23 @@
+/*
+ * Moov Identity API
+ *
+ * Handles all identities for tracking the users of the Moov platform.
+ *
+ * API version: 0.0.1
+ * Generated by: OpenAPI Generator (https://openapi-generator.tech)
+ */
+
+package client
+
+// ImportReport Outcome of a bulk import, row by row
+type ImportReport struct {
+	// What was created for each row, identities or invites
+	Mode   string `json:"mode"`
+	DryRun bool   `json:"dryRun"`
+	// Number of people in the import
+	Total int64 `json:"total"`
+	// Number of people that were created, or would have been on a dry run
+	Succeeded int64       `json:"succeeded"`
+	Failed    int64       `json:"failed"`
+	Rows      []ImportRow `json:"rows"`
+}
@@ -0,0 +1,25 @@
+/*
+ * Moov Identity API
+ *
+ * Handles all identities for tracking the users of the Moov platform.
+ *
+ * API version: 0.0.1
+ * Generated by: OpenAPI Generator (https://openapi-generator.tech)
+ */
+
+package client
+
+// ImportRow Outcome of one person of a bulk import
+type ImportRow struct {
+	// Line in the file the person starts on
+	Row   int64  `json:"row"`
+	Email string `json:"email,omitempty"`
+	// One of created, valid, invalid or failed
+	Status string `json:"status"`
+	// UUID v4
+	IdentityID *string `json:"identityID,omitempty"`
+	// UUID v4
+	InviteID *string `json:"inviteID,omitempty"`
+	// Why the row wasn't imported keyed by the field
+	Errors map[string]string `json:"errors,omitempty"`
+}
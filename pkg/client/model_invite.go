@@ -29,4 +29,6 @@ type Invite struct {
 	DisabledOn *time.Time `json:"disabledOn,omitempty"`
 	// UUID v4
 	DisabledBy *string `json:"disabledBy,omitempty"`
+	// Language the invite was sent in
+	Locale *string `json:"locale,omitempty"`
 }
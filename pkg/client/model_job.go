@@ -0,0 +1,35 @@
+/*
+ * Moov Identity API
+ *
+ * Handles all identities for tracking the users of the Moov platform.
+ *
+ * API version: 0.0.1
+ * Generated by: OpenAPI Generator (https://openapi-generator.tech)
+ */
+
+package client
+
+import (
+	"time"
+)
+
+// Job Work running in the background and how far along it is
+type Job struct {
+	// UUID v4
+	JobID string `json:"jobID"`
+	// UUID v4
+	TenantID string `json:"tenantID"`
+	// What the job is doing, like sending invites
+	Kind string `json:"kind"`
+	// One of pending, running or completed
+	Status    string `json:"status"`
+	Total     int64  `json:"total"`
+	Succeeded int64  `json:"succeeded"`
+	Failed    int64  `json:"failed"`
+	// UUID v4
+	CreatedBy   string     `json:"createdBy"`
+	CreatedOn   time.Time  `json:"createdOn"`
+	StartedOn   *time.Time `json:"startedOn,omitempty"`
+	CompletedOn *time.Time `json:"completedOn,omitempty"`
+	Items       []JobItem  `json:"items"`
+}
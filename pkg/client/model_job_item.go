@@ -0,0 +1,26 @@
+/*
+ * Moov Identity API
+ *
+ * Handles all identities for tracking the users of the Moov platform.
+ *
+ * API version: 0.0.1
+ * Generated by: OpenAPI Generator (https://openapi-generator.tech)
+ */
+
+package client
+
+import (
+	"time"
+)
+
+// JobItem Outcome of one piece of work of a job
+type JobItem struct {
+	// What the work was done for, like the email an invite was sent to
+	Key string `json:"key"`
+	// One of pending, succeeded or failed
+	Status string `json:"status"`
+	// ID of what was created, like the InviteID
+	ResultID    *string    `json:"resultID,omitempty"`
+	Error       *string    `json:"error,omitempty"`
+	CompletedOn *time.Time `json:"completedOn,omitempty"`
+}
@@ -0,0 +1,18 @@
+/*
+ * Moov Identity API
+ *
+ * Handles all identities for tracking the users of the Moov platform.
+ *
+ * API version: 0.0.1
+ * Generated by: OpenAPI Generator (https://openapi-generator.tech)
+ */
+
+package client
+
+// MergeIdentities Arguments to merge a duplicate identity into the survivor
+type MergeIdentities struct {
+	// UUID v4
+	DuplicateID string `json:"duplicateID"`
+	// Only report what would be moved without making any changes
+	DryRun bool `json:"dryRun,omitempty"`
+}
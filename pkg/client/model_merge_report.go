@@ -0,0 +1,34 @@
+/*
+ * Moov Identity API
+ *
+ * Handles all identities for tracking the users of the Moov platform.
+ *
+ * API version: 0.0.1
+ * Generated by: OpenAPI Generator (https://openapi-generator.tech)
+ */
+
+package client
+
+import (
+	"time"
+)
+
+// MergeReport Describes what was moved from the duplicate identity to the survivor
+type MergeReport struct {
+	// UUID v4
+	SurvivorID string `json:"survivorID"`
+	// UUID v4
+	DuplicateID string `json:"duplicateID"`
+	DryRun      bool   `json:"dryRun"`
+	// CredentialIDs moved to the survivor
+	Credentials []string `json:"credentials"`
+	// PhoneIDs moved to the survivor
+	Phones []string `json:"phones"`
+	// AddressIDs moved to the survivor
+	Addresses []string `json:"addresses"`
+	// Number of recorded logins that came along with the credentials
+	Logins   int64     `json:"logins"`
+	MergedOn time.Time `json:"mergedOn,omitempty"`
+	// UUID v4
+	MergedBy string `json:"mergedBy,omitempty"`
+}
@@ -0,0 +1,36 @@
+/*
+ * Moov Identity API
+ *
+ * Handles all identities for tracking the users of the Moov platform.
+ *
+ * API version: 0.0.1
+ * Generated by: OpenAPI Generator (https://openapi-generator.tech)
+ */
+
+package client
+
+import (
+	"time"
+)
+
+// OutboxMessage A notification waiting on, or done with, delivery. The rendered content isn't included since it can hold secrets like invite codes.
+type OutboxMessage struct {
+	// UUID v4
+	MessageID string `json:"messageID"`
+	// UUID v4
+	TenantID string `json:"tenantID"`
+	// Email address the message goes to
+	Recipient string `json:"recipient"`
+	// Name of the template the message is rendered with
+	Template string `json:"template"`
+	Subject  string `json:"subject"`
+	// One of pending, sent or dead
+	Status string `json:"status"`
+	// How many times delivery has been tried
+	Attempts int64 `json:"attempts"`
+	// Why the last delivery attempt failed
+	LastError     *string    `json:"lastError,omitempty"`
+	CreatedOn     time.Time  `json:"createdOn"`
+	NextAttemptOn time.Time  `json:"nextAttemptOn"`
+	SentOn        *time.Time `json:"sentOn,omitempty"`
+}
@@ -0,0 +1,24 @@
+/*
+ * Moov Identity API
+ *
+ * Handles all identities for tracking the users of the Moov platform.
+ *
+ * API version: 0.0.1
+ * Generated by: OpenAPI Generator (https://openapi-generator.tech)
+ */
+
+package client
+
+// Problem RFC 7807 problem document
+type Problem struct {
+	// URI identifying the type of problem
+	Type string `json:"type"`
+	// Short summary of the http status
+	Title string `json:"title"`
+	// Http status code of the response
+	Status int32 `json:"status"`
+	// Explanation of what went wrong with this request
+	Detail string `json:"detail,omitempty"`
+	// Problems with each of the fields keyed by their path, like addresses.0.city
+	Errors map[string]string `json:"errors,omitempty"`
+}
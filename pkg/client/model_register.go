@@ -27,4 +27,6 @@ type Register struct {
 	Email     string            `json:"email,omitempty"`
 	Phones    []RegisterPhone   `json:"phones,omitempty"`
 	Addresses []RegisterAddress `json:"addresses,omitempty"`
+	// Preferred language of the emails sent to the identity, like fr or fr-CA. Taken from the invite when left out.
+	Locale *string `json:"locale,omitempty"`
 }
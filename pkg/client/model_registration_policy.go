@@ -0,0 +1,31 @@
+/*
+ * Moov Identity API
+ *
+ * Handles all identities for tracking the users of the Moov platform.
+ *
+ * API version: 0.0.1
+ * Generated by: OpenAPI Generator (https://openapi-generator.tech)
+ */
+
+package client
+
+import (
+	"time"
+)
+
+// RegistrationPolicy How new users are allowed to register with a tenant
+type RegistrationPolicy struct {
+	// UUID v4
+	TenantID string `json:"tenantID,omitempty"`
+	// invite requires an invite code, signup lets anyone register and domain lets users with a verified email in one of the allowed domains register without an invite
+	Mode string `json:"mode"`
+	// Email domains that can join without an invite when the mode is domain
+	AllowedDomains []string `json:"allowedDomains,omitempty"`
+	// Registrations that didn't come from an invite are held until an admin approves them
+	RequireApproval bool `json:"requireApproval,omitempty"`
+	// Emails of the admins that are notified when a registration is waiting on approval
+	ApproverEmails []string  `json:"approverEmails,omitempty"`
+	LastUpdatedOn  time.Time `json:"lastUpdatedOn,omitempty"`
+	// UUID v4
+	LastUpdatedBy string `json:"lastUpdatedBy,omitempty"`
+}
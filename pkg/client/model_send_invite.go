@@ -13,4 +13,6 @@ package client
 type SendInvite struct {
 	// Email Address
 	Email string `json:"email,omitempty"`
+	// Language the invite is sent in, like fr or fr-CA
+	Locale *string `json:"locale,omitempty"`
 }
@@ -0,0 +1,17 @@
+/*
+ * Moov Identity API
+ *
+ * Handles all identities for tracking the users of the Moov platform.
+ *
+ * API version: 0.0.1
+ * Generated by: OpenAPI Generator (https://openapi-generator.tech)
+ */
+
+package client
+
+// SendInviteBatch Emails to send invites to in the background
+type SendInviteBatch struct {
+	Emails []string `json:"emails"`
+	// Language all the invites are sent in, like fr or fr-CA
+	Locale *string `json:"locale,omitempty"`
+}
@@ -0,0 +1,31 @@
+/*
+ * Moov Identity API
+ *
+ * Handles all identities for tracking the users of the Moov platform.
+ *
+ * API version: 0.0.1
+ * Generated by: OpenAPI Generator (https://openapi-generator.tech)
+ */
+
+package client
+
+import (
+	"time"
+)
+
+// StatusChange A change in the status of an identity
+type StatusChange struct {
+	// UUID v4
+	StatusChangeID string `json:"statusChangeID"`
+	// UUID v4
+	IdentityID string `json:"identityID"`
+	// UUID v4
+	TenantID   string `json:"tenantID"`
+	FromStatus string `json:"fromStatus"`
+	ToStatus   string `json:"toStatus"`
+	// Why the status of the identity was changed
+	Reason string `json:"reason"`
+	// UUID v4
+	ChangedBy string    `json:"changedBy"`
+	ChangedOn time.Time `json:"changedOn"`
+}
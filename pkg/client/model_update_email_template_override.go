@@ -0,0 +1,27 @@
+/*
+ * Moov Identity API
+ *
+ * Handles all identities for tracking the users of the Moov platform.
+ *
+ * API version: 0.0.1
+ * Generated by: OpenAPI Generator (https://openapi-generator.tech)
+ */
+
+package client
+
+// UpdateEmailTemplateOverride Changes to one of the emails of the tenant. Replaces whatever was set before.
+type UpdateEmailTemplateOverride struct {
+	// Shown as the name of who the email is from
+	SenderName *string `json:"senderName,omitempty"`
+	// Template for the subject
+	Subject *string `json:"subject,omitempty"`
+	LogoURL *string `json:"logoURL,omitempty"`
+	// Hex color of buttons and links, like #0D80F2
+	PrimaryColor *string `json:"primaryColor,omitempty"`
+	// Hex color behind the body of the email
+	BackgroundColor *string `json:"backgroundColor,omitempty"`
+	// Template for the HTML body
+	Html *string `json:"html,omitempty"`
+	// Template for the plain text body
+	Text *string `json:"text,omitempty"`
+}
@@ -17,7 +17,10 @@ type UpdateIdentity struct {
 	NickName   *string         `json:"nickName,omitempty"`
 	Suffix     *string         `json:"suffix,omitempty"`
 	BirthDate  *string         `json:"birthDate,omitempty"`
-	Status     string          `json:"status,omitempty"`
 	Phones     []UpdatePhone   `json:"phones,omitempty"`
 	Addresses  []UpdateAddress `json:"addresses,omitempty"`
+	// Preferred language of the emails sent to the identity, like fr or fr-CA
+	Locale *string `json:"locale,omitempty"`
+	// Custom attributes of the identity keyed by the name of their definition
+	Attributes map[string]interface{} `json:"attributes,omitempty"`
 }
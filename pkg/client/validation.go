@@ -49,6 +49,7 @@ func (a *Register) Validate() error {
 		validation.Field(&a.ImageUrl, is.URL),
 		validation.Field(&a.Phones),
 		validation.Field(&a.Addresses),
+		validation.Field(&a.Locale, validation.NilOrNotEmpty, locale),
 	)
 }
 
@@ -81,6 +82,7 @@ func (a *UpdateIdentity) Validate() error {
 		validation.Field(&a.BirthDate, validation.By(validateBirthDate)),
 		validation.Field(&a.Phones),
 		validation.Field(&a.Addresses),
+		validation.Field(&a.Locale, validation.NilOrNotEmpty, locale),
 	)
 }
 
@@ -102,3 +104,129 @@ func (a *UpdateAddress) Validate() error {
 		validation.Field(&a.Country, validation.Required, is.CountryCode2),
 	)
 }
+
+func (a *RegistrationPolicy) Validate() error {
+	return validation.ValidateStruct(a,
+		validation.Field(&a.Mode, validation.Required, validation.In("invite", "signup", "domain")),
+		validation.Field(&a.AllowedDomains,
+			validation.When(a.Mode == "domain", validation.Required),
+			validation.Each(validation.Required, is.Domain),
+		),
+		validation.Field(&a.ApproverEmails, validation.Each(validation.Required, is.Email)),
+	)
+}
+
+func (a *AttributeDefinition) Validate() error {
+	return validation.ValidateStruct(a,
+		validation.Field(&a.Name, validation.Required, validation.Match(regexp.MustCompile("^[a-zA-Z][a-zA-Z0-9_]{0,63}$"))),
+		validation.Field(&a.Type, validation.Required, validation.In("string", "number", "bool", "date", "enum")),
+		validation.Field(&a.EnumValues,
+			validation.When(a.Type == "enum", validation.Required, validation.Each(validation.Required, validation.Length(1, 255))).
+				Else(validation.Empty)),
+		validation.Field(&a.Description, validation.Length(1, 255)),
+	)
+}
+
+func (a *ChangeEmail) Validate() error {
+	return validation.ValidateStruct(a,
+		validation.Field(&a.Email, validation.Required, is.Email),
+	)
+}
+
+func (a *ConfirmEmailChange) Validate() error {
+	return validation.ValidateStruct(a,
+		validation.Field(&a.Code, validation.Required, validation.Length(1, 255)),
+	)
+}
+
+func (a *ChangeStatus) Validate() error {
+	return validation.ValidateStruct(a,
+		validation.Field(&a.Reason, validation.Required, validation.Length(1, 255)),
+	)
+}
+
+// Validate - Held to the rules of registering for the email and of updating an identity for everything else.
+// Phones and addresses are checked one by one so a bad one is reported along with its place in the list.
+func (a *ImportIdentity) Validate() error {
+	update := UpdateIdentity{
+		FirstName:  a.FirstName,
+		MiddleName: a.MiddleName,
+		LastName:   a.LastName,
+		NickName:   a.NickName,
+		Suffix:     a.Suffix,
+		BirthDate:  a.BirthDate,
+	}
+
+	errs := validation.Errors{}
+	if err := update.Validate(); err != nil {
+		fields, ok := err.(validation.Errors)
+		if !ok {
+			return err
+		}
+		for k, v := range fields {
+			errs[k] = v
+		}
+	}
+	a.BirthDate = update.BirthDate
+
+	// Only the format, is.Email looks up the MX record of every address which is too slow across a whole import.
+	if err := validation.Validate(a.Email, validation.Required, is.EmailFormat); err != nil {
+		errs["email"] = err
+	}
+
+	phones := validation.Errors{}
+	for i := range a.Phones {
+		if err := a.Phones[i].Validate(); err != nil {
+			phones[fmt.Sprint(i)] = err
+		}
+	}
+	if len(phones) > 0 {
+		errs["phones"] = phones
+	}
+
+	addresses := validation.Errors{}
+	for i := range a.Addresses {
+		if err := a.Addresses[i].Validate(); err != nil {
+			addresses[fmt.Sprint(i)] = err
+		}
+	}
+	if len(addresses) > 0 {
+		errs["addresses"] = addresses
+	}
+
+	return errs.Filter()
+}
+
+// Validate - Only the format of the emails is checked since is.Email looks up the MX record of each one.
+func (a *SendInviteBatch) Validate() error {
+	return validation.ValidateStruct(a,
+		validation.Field(&a.Emails, validation.Required, validation.Length(1, 1000), validation.Each(validation.Required, is.EmailFormat)),
+		validation.Field(&a.Locale, validation.NilOrNotEmpty, locale),
+	)
+}
+
+func (a *SendInvite) Validate() error {
+	return validation.ValidateStruct(a,
+		validation.Field(&a.Locale, validation.NilOrNotEmpty, locale),
+	)
+}
+
+// locale - Language tags like fr or fr-CA, underscores are accepted as well since thats how most systems spell them.
+var locale = validation.Match(regexp.MustCompile(`^[a-zA-Z]{2,3}([-_][a-zA-Z0-9]{2,8}){0,3}$`)).Error("must be a language tag like fr or fr-CA")
+
+var hexColor = regexp.MustCompile("^#[0-9a-fA-F]{6}$")
+
+// noLineBreaks - Keeps values that end up in email headers from starting new ones.
+var noLineBreaks = validation.Match(regexp.MustCompile(`^[^\r\n]*$`)).Error("must not contain line breaks")
+
+func (a *UpdateEmailTemplateOverride) Validate() error {
+	return validation.ValidateStruct(a,
+		validation.Field(&a.SenderName, validation.NilOrNotEmpty, validation.Length(1, 64), noLineBreaks),
+		validation.Field(&a.Subject, validation.NilOrNotEmpty, validation.Length(1, 255), noLineBreaks),
+		validation.Field(&a.LogoURL, validation.NilOrNotEmpty, is.URL, validation.Length(1, 255)),
+		validation.Field(&a.PrimaryColor, validation.NilOrNotEmpty, validation.Match(hexColor)),
+		validation.Field(&a.BackgroundColor, validation.NilOrNotEmpty, validation.Match(hexColor)),
+		validation.Field(&a.Html, validation.NilOrNotEmpty, validation.Length(1, 65535)),
+		validation.Field(&a.Text, validation.NilOrNotEmpty, validation.Length(1, 65535)),
+	)
+}
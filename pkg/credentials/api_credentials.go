@@ -1,7 +1,6 @@
 package credentials
 
 import (
-	"database/sql"
 	"net/http"
 	"strings"
 
@@ -35,6 +34,12 @@ func (c *credentialsApiController) Routes() api.Routes {
 			Pattern:     "/identities/{identityID}/credentials",
 			HandlerFunc: c.ListCredentials,
 		},
+		{
+			Name:        "StartCredentialLink",
+			Method:      strings.ToUpper("Post"),
+			Pattern:     "/identities/{identityID}/credentials/link",
+			HandlerFunc: c.StartCredentialLink,
+		},
 	}
 }
 
@@ -44,15 +49,9 @@ func (c *credentialsApiController) DisableCredentials(w http.ResponseWriter, r *
 		params := mux.Vars(r)
 		identityID := params["identityID"]
 		credentialID := params["credentialID"]
-		_, err := c.service.DisableCredentials(claims, identityID, credentialID)
+		_, err := c.service.DisableCredentials(r.Context(), claims, identityID, credentialID)
 		if err != nil {
-			switch err {
-			case sql.ErrNoRows:
-				w.WriteHeader(404)
-			default:
-				w.WriteHeader(500)
-			}
-
+			api.EncodeError(w, err)
 			return
 		}
 
@@ -65,12 +64,28 @@ func (c *credentialsApiController) ListCredentials(w http.ResponseWriter, r *htt
 	tmw.WithClaimsFromRequest(w, r, func(claims tmw.TumblerClaims) {
 		params := mux.Vars(r)
 		identityID := params["identityID"]
-		result, err := c.service.ListCredentials(claims, identityID)
+		result, err := c.service.ListCredentials(r.Context(), claims, identityID)
 		if err != nil {
-			w.WriteHeader(500)
+			api.EncodeError(w, err)
 			return
 		}
 
 		api.EncodeJSONResponse(result, nil, w)
 	})
 }
+
+// StartCredentialLink - Starts linking another OIDC credential to the logged in identity.
+func (c *credentialsApiController) StartCredentialLink(w http.ResponseWriter, r *http.Request) {
+	tmw.WithClaimsFromRequest(w, r, func(claims tmw.TumblerClaims) {
+		params := mux.Vars(r)
+		identityID := params["identityID"]
+		result, err := c.service.StartLink(r.Context(), claims, identityID)
+		if err != nil {
+			api.EncodeError(w, err)
+			return
+		}
+
+		status := http.StatusCreated
+		api.EncodeJSONResponse(result, &status, w)
+	})
+}
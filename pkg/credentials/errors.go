@@ -0,0 +1,24 @@
+package credentials
+
+import "github.com/moov-io/identity/pkg/apperr"
+
+// ErrCredentialInUse is issued when a credential being attached is already owned by another identity.
+var ErrCredentialInUse = apperr.Conflict("credential is registered to another identity")
+
+// ErrCredentialAlreadyLinked is issued when a credential being attached already belongs to the identity.
+var ErrCredentialAlreadyLinked = apperr.Conflict("credential is already linked to this identity")
+
+// ErrLinkNotAllowed is issued when a session tries to start a link for an identity other than its own.
+var ErrLinkNotAllowed = apperr.Forbidden("credentials can only be linked to the logged in identity")
+
+// ErrLinkNotFound is issued when there isn't an unused link code in the tenant.
+var ErrLinkNotFound = apperr.NotFound("link code not found")
+
+// ErrLinkCodeExpired is issued when the link code has expired.
+var ErrLinkCodeExpired = apperr.Expired("link code is expired")
+
+// ErrLinkCodeRedeemed is issued when the link code was already used.
+var ErrLinkCodeRedeemed = apperr.Conflict("link code was already redeemed")
+
+// ErrNonceReused is issued when a login is replayed with a nonce the credential already used.
+var ErrNonceReused = apperr.Conflict("login nonce was already used")
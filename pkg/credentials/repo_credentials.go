@@ -1,42 +1,49 @@
 package credentials
 
 import (
+	"context"
 	"database/sql"
 	"fmt"
 	"time"
 
 	"github.com/moov-io/identity/pkg/client"
+	"github.com/moov-io/identity/pkg/database"
 )
 
 type CredentialRepository interface {
-	list(identityID string, tenantID string) ([]client.Credential, error)
-	lookup(credentialID string, tenantID string) (*client.Credential, error)
+	list(ctx context.Context, identityID string, tenantID string) ([]client.Credential, error)
+	lookup(ctx context.Context, credentialID string, tenantID string) (*client.Credential, error)
 
-	get(identityID string, credentialID string, tenantID string) (*client.Credential, error)
-	add(credentials client.Credential) (*client.Credential, error)
-	update(updated client.Credential) (*client.Credential, error)
-	record(credentialID string, tenantID string, nonce string, ip string, at time.Time) error
+	get(ctx context.Context, identityID string, credentialID string, tenantID string) (*client.Credential, error)
+	add(ctx context.Context, credentials client.Credential) (*client.Credential, error)
+	update(ctx context.Context, updated client.Credential) (*client.Credential, error)
+	record(ctx context.Context, credentialID string, tenantID string, nonce string, ip string, at time.Time) error
+
+	addLink(ctx context.Context, link client.CredentialLink, secretCode string) (*client.CredentialLink, error)
+	getLinkByCode(ctx context.Context, tenantID string, code string) (*client.CredentialLink, error)
+	redeemLink(ctx context.Context, redeemed client.CredentialLink, credential client.Credential) (*client.Credential, error)
 }
 
 func NewCredentialRepository(db *sql.DB) CredentialRepository {
-	return &sqlCredsRepo{db: db}
+	return &sqlCredsRepo{db: db, dialect: database.DialectOf(db)}
 }
 
 type sqlCredsRepo struct {
-	db *sql.DB
+	db      *sql.DB
+	dialect database.Dialect
 }
 
-func (r *sqlCredsRepo) list(identityID string, tenantID string) ([]client.Credential, error) {
+func (r *sqlCredsRepo) list(ctx context.Context, identityID string, tenantID string) ([]client.Credential, error) {
 	qry := fmt.Sprintf(`
 		SELECT %s
 		FROM credentials
 		WHERE identity_id = ? AND tenant_id = ?
 	`, credentialSelect)
 
-	return r.queryScan(qry, identityID, tenantID)
+	return r.queryScan(ctx, qry, identityID, tenantID)
 }
 
-func (r *sqlCredsRepo) lookup(credentialID string, tenantID string) (*client.Credential, error) {
+func (r *sqlCredsRepo) lookup(ctx context.Context, credentialID string, tenantID string) (*client.Credential, error) {
 	qry := fmt.Sprintf(`
 		SELECT %s
 		FROM credentials
@@ -44,7 +51,7 @@ func (r *sqlCredsRepo) lookup(credentialID string, tenantID string) (*client.Cre
 		LIMIT 1
 	`, credentialSelect)
 
-	results, err := r.queryScan(qry, credentialID, tenantID)
+	results, err := r.queryScan(ctx, qry, credentialID, tenantID)
 	if err != nil {
 		return nil, err
 	}
@@ -56,7 +63,7 @@ func (r *sqlCredsRepo) lookup(credentialID string, tenantID string) (*client.Cre
 	return &results[0], nil
 }
 
-func (r *sqlCredsRepo) get(identityID string, credentialID string, tenantID string) (*client.Credential, error) {
+func (r *sqlCredsRepo) get(ctx context.Context, identityID string, credentialID string, tenantID string) (*client.Credential, error) {
 	qry := fmt.Sprintf(`
 		SELECT %s
 		FROM credentials
@@ -64,7 +71,7 @@ func (r *sqlCredsRepo) get(identityID string, credentialID string, tenantID stri
 		LIMIT 1
 	`, credentialSelect)
 
-	results, err := r.queryScan(qry, credentialID, tenantID, identityID)
+	results, err := r.queryScan(ctx, qry, credentialID, tenantID, identityID)
 	if err != nil {
 		return nil, err
 	}
@@ -76,7 +83,25 @@ func (r *sqlCredsRepo) get(identityID string, credentialID string, tenantID stri
 	return &results[0], nil
 }
 
-func (r *sqlCredsRepo) add(credentials client.Credential) (*client.Credential, error) {
+func (r *sqlCredsRepo) add(ctx context.Context, credentials client.Credential) (*client.Credential, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	if err := r.addTx(ctx, tx, credentials); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	return &credentials, nil
+}
+
+func (r *sqlCredsRepo) addTx(ctx context.Context, tx *sql.Tx, credentials client.Credential) error {
 	qry := `
 		INSERT INTO credentials(
 			credential_id, 
@@ -88,7 +113,7 @@ func (r *sqlCredsRepo) add(credentials client.Credential) (*client.Credential, e
 			disabled_by
 		) VALUES (?, ?, ?, ?, ?, ?, ?)
 	`
-	res, err := r.db.Exec(qry,
+	res, err := tx.ExecContext(ctx, r.dialect.Rebind(qry),
 		credentials.CredentialID,
 		credentials.TenantID,
 		credentials.IdentityID,
@@ -97,18 +122,22 @@ func (r *sqlCredsRepo) add(credentials client.Credential) (*client.Credential, e
 		credentials.DisabledOn,
 		credentials.DisabledBy)
 
+	// Registered by someone else between looking it up and saving it.
+	if database.UniqueViolation(err) {
+		return ErrCredentialInUse
+	}
 	if err != nil {
-		return nil, err
+		return err
 	}
 
 	if cnt, err := res.RowsAffected(); cnt != 1 || err != nil {
-		return nil, sql.ErrNoRows
+		return sql.ErrNoRows
 	}
 
-	return &credentials, nil
+	return nil
 }
 
-func (r *sqlCredsRepo) record(credentialID string, tenantID string, nonce string, ip string, at time.Time) error {
+func (r *sqlCredsRepo) record(ctx context.Context, credentialID string, tenantID string, nonce string, ip string, at time.Time) error {
 	qry := `
 		INSERT INTO credential_logins(
 			credential_id,
@@ -119,7 +148,7 @@ func (r *sqlCredsRepo) record(credentialID string, tenantID string, nonce string
 		) VALUES (?, ?, ?, ?, ?)
 	`
 
-	res, err := r.db.Exec(qry,
+	res, err := r.db.ExecContext(ctx, r.dialect.Rebind(qry),
 		credentialID,
 		tenantID,
 		nonce,
@@ -127,6 +156,9 @@ func (r *sqlCredsRepo) record(credentialID string, tenantID string, nonce string
 		at,
 	)
 
+	if database.UniqueViolation(err) {
+		return ErrNonceReused
+	}
 	if err != nil {
 		return err
 	}
@@ -138,7 +170,7 @@ func (r *sqlCredsRepo) record(credentialID string, tenantID string, nonce string
 	return nil
 }
 
-func (r *sqlCredsRepo) update(updated client.Credential) (*client.Credential, error) {
+func (r *sqlCredsRepo) update(ctx context.Context, updated client.Credential) (*client.Credential, error) {
 
 	qry := `
 		UPDATE credentials
@@ -151,7 +183,7 @@ func (r *sqlCredsRepo) update(updated client.Credential) (*client.Credential, er
 			tenant_id = ? AND
 			identity_id = ?
 	`
-	_, err := r.db.Exec(qry,
+	_, err := r.db.ExecContext(ctx, r.dialect.Rebind(qry),
 		updated.LastUsedOn,
 		updated.DisabledOn,
 		updated.DisabledBy,
@@ -177,8 +209,8 @@ var credentialSelect = `
 	disabled_by
 `
 
-func (r *sqlCredsRepo) queryScan(query string, args ...interface{}) ([]client.Credential, error) {
-	rows, err := r.db.Query(query, args...)
+func (r *sqlCredsRepo) queryScan(ctx context.Context, query string, args ...interface{}) ([]client.Credential, error) {
+	rows, err := r.db.QueryContext(ctx, r.dialect.Rebind(query), args...)
 	if err != nil {
 		return nil, err
 	}
@@ -0,0 +1,86 @@
+package credentials
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/moov-io/identity/pkg/client"
+	"github.com/moov-io/identity/pkg/database"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_Repository_AddGetUpdate(t *testing.T) {
+	ForEachDatabase(t, func(t *testing.T, a *require.Assertions, db *sql.DB, repository CredentialRepository) {
+		tenantID := uuid.New().String()
+		credential := testingCredential(client.CredentialLink{TenantID: tenantID, IdentityID: addTestingIdentity(t, db, tenantID)})
+
+		_, err := repository.add(context.Background(), credential)
+		a.NoError(err)
+
+		// Can't be registered a second time
+		_, err = repository.add(context.Background(), credential)
+		a.Equal(ErrCredentialInUse, err)
+
+		found, err := repository.get(context.Background(), credential.IdentityID, credential.CredentialID, tenantID)
+		a.NoError(err)
+		a.Equal(credential, *found)
+
+		found, err = repository.lookup(context.Background(), credential.CredentialID, tenantID)
+		a.NoError(err)
+		a.Equal(credential.IdentityID, found.IdentityID)
+
+		_, err = repository.lookup(context.Background(), credential.CredentialID, uuid.New().String())
+		a.Equal(sql.ErrNoRows, err)
+
+		disabledOn := credential.LastUsedOn.Add(time.Minute)
+		disabledBy := uuid.New().String()
+		credential.DisabledOn = &disabledOn
+		credential.DisabledBy = &disabledBy
+		_, err = repository.update(context.Background(), credential)
+		a.NoError(err)
+
+		list, err := repository.list(context.Background(), credential.IdentityID, tenantID)
+		a.NoError(err)
+		a.Len(list, 1)
+		a.Equal(credential, list[0])
+	})
+}
+
+func Test_Repository_Record(t *testing.T) {
+	ForEachDatabase(t, func(t *testing.T, a *require.Assertions, db *sql.DB, repository CredentialRepository) {
+		tenantID := uuid.New().String()
+		credential := testingCredential(client.CredentialLink{TenantID: tenantID, IdentityID: addTestingIdentity(t, db, tenantID)})
+
+		_, err := repository.add(context.Background(), credential)
+		a.NoError(err)
+
+		a.NoError(repository.record(context.Background(), credential.CredentialID, tenantID, "nonce", "127.0.0.1", time.Now()))
+
+		// The same login can't be replayed
+		err = repository.record(context.Background(), credential.CredentialID, tenantID, "nonce", "127.0.0.1", time.Now())
+		a.Equal(ErrNonceReused, err)
+	})
+}
+
+func ForEachDatabase(t *testing.T, run func(t *testing.T, a *require.Assertions, db *sql.DB, repository CredentialRepository)) {
+	database.ForEachDatabase(t, func(t *testing.T, db *sql.DB) {
+		run(t, require.New(t), db, NewCredentialRepository(db))
+	})
+}
+
+// addTestingIdentity - Stored so credentials can be registered to it.
+func addTestingIdentity(t *testing.T, db *sql.DB, tenantID string) string {
+	identityID := uuid.New().String()
+	now := time.Now().UTC().Round(time.Second)
+
+	_, err := db.Exec(database.DialectOf(db).Rebind(`
+		INSERT INTO identity (identity_id, tenant_id, first_name, last_name, status, email, registered_on, last_updated_on)
+		VALUES (?, ?, 'Jane', 'Doe', 'active', ?, ?, ?)
+	`), identityID, tenantID, identityID+"@example.com", now, now)
+	require.NoError(t, err)
+
+	return identityID
+}
@@ -0,0 +1,135 @@
+package credentials
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/moov-io/identity/pkg/client"
+)
+
+func (r *sqlCredsRepo) addLink(ctx context.Context, link client.CredentialLink, secretCode string) (*client.CredentialLink, error) {
+	qry := `
+		INSERT INTO credential_links(
+			link_id,
+			tenant_id,
+			identity_id,
+			created_by,
+			created_on,
+			expires_on,
+			redeemed_on,
+			secret_code
+		) VALUES (?, ?, ?, ?, ?, ?, ?, ?)
+	`
+
+	res, err := r.db.ExecContext(ctx, r.dialect.Rebind(qry),
+		link.LinkID,
+		link.TenantID,
+		link.IdentityID,
+		link.CreatedBy,
+		link.CreatedOn,
+		link.ExpiresOn,
+		link.RedeemedOn,
+		secretCode)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if cnt, err := res.RowsAffected(); cnt != 1 || err != nil {
+		return nil, sql.ErrNoRows
+	}
+
+	return &link, nil
+}
+
+func (r *sqlCredsRepo) getLinkByCode(ctx context.Context, tenantID string, code string) (*client.CredentialLink, error) {
+	qry := fmt.Sprintf(`
+		SELECT %s
+		FROM credential_links
+		WHERE tenant_id = ? AND secret_code = ?
+		LIMIT 1
+	`, linkSelect)
+
+	rows, err := r.db.QueryContext(ctx, r.dialect.Rebind(qry), tenantID, code)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	links := []client.CredentialLink{}
+	for rows.Next() {
+		link := client.CredentialLink{}
+		if err := rows.Scan(&link.LinkID, &link.TenantID, &link.IdentityID, &link.CreatedBy, &link.CreatedOn, &link.ExpiresOn, &link.RedeemedOn); err != nil {
+			return nil, err
+		}
+
+		links = append(links, link)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	if len(links) != 1 {
+		return nil, sql.ErrNoRows
+	}
+
+	return &links[0], nil
+}
+
+// redeemLink - Uses up the link code and attaches the credential together so neither happens without the other.
+// Only the first of two redemptions racing each other finds the code still unused.
+func (r *sqlCredsRepo) redeemLink(ctx context.Context, redeemed client.CredentialLink, credential client.Credential) (*client.Credential, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	qry := `
+		UPDATE credential_links
+		SET
+			redeemed_on = ?
+		WHERE
+			link_id = ? AND
+			tenant_id = ? AND
+			redeemed_on IS NULL
+	`
+
+	res, err := tx.ExecContext(ctx, r.dialect.Rebind(qry),
+		redeemed.RedeemedOn,
+
+		redeemed.LinkID,
+		redeemed.TenantID)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if cnt, err := res.RowsAffected(); err != nil {
+		return nil, err
+	} else if cnt != 1 {
+		return nil, ErrLinkNotFound
+	}
+
+	if err := r.addTx(ctx, tx, credential); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	return &credential, nil
+}
+
+var linkSelect = `
+	link_id,
+	tenant_id,
+	identity_id,
+	created_by,
+	created_on,
+	expires_on,
+	redeemed_on
+`
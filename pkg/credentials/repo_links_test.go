@@ -0,0 +1,68 @@
+package credentials
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/moov-io/identity/pkg/client"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_RedeemLink_OnlyOnce(t *testing.T) {
+	ForEachDatabase(t, func(t *testing.T, a *require.Assertions, db *sql.DB, repository CredentialRepository) {
+		link := addTestingLink(t, db, repository, "secret")
+
+		found, err := repository.getLinkByCode(context.Background(), link.TenantID, "secret")
+		a.NoError(err)
+		a.Equal(link.LinkID, found.LinkID)
+
+		_, err = repository.getLinkByCode(context.Background(), uuid.New().String(), "secret")
+		a.Equal(sql.ErrNoRows, err)
+
+		// Both looked up the link before either redeemed it.
+		now := time.Now()
+		found.RedeemedOn = &now
+
+		_, err = repository.redeemLink(context.Background(), *found, testingCredential(link))
+		a.NoError(err)
+
+		_, err = repository.redeemLink(context.Background(), *found, testingCredential(link))
+		a.Equal(ErrLinkNotFound, err)
+
+		creds, err := repository.list(context.Background(), link.IdentityID, link.TenantID)
+		a.NoError(err)
+		a.Len(creds, 1)
+	})
+}
+
+func addTestingLink(t *testing.T, db *sql.DB, repository CredentialRepository, code string) client.CredentialLink {
+	tenantID := uuid.New().String()
+	now := time.Now().UTC().Round(time.Second)
+
+	link, err := repository.addLink(context.Background(), client.CredentialLink{
+		LinkID:     uuid.New().String(),
+		TenantID:   tenantID,
+		IdentityID: addTestingIdentity(t, db, tenantID),
+		CreatedBy:  uuid.New().String(),
+		CreatedOn:  now,
+		ExpiresOn:  now.Add(time.Hour),
+	}, code)
+	require.NoError(t, err)
+
+	return *link
+}
+
+func testingCredential(link client.CredentialLink) client.Credential {
+	now := time.Now().UTC().Round(time.Second)
+
+	return client.Credential{
+		CredentialID: uuid.New().String(),
+		IdentityID:   link.IdentityID,
+		TenantID:     link.TenantID,
+		CreatedOn:    now,
+		LastUsedOn:   now,
+	}
+}
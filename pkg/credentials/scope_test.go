@@ -1,6 +1,8 @@
 package credentials_test
 
 import (
+	"context"
+	"database/sql"
 	"testing"
 
 	"github.com/google/uuid"
@@ -18,6 +20,7 @@ import (
 )
 
 type Scope struct {
+	db         *sql.DB
 	session    tmw.TumblerClaims
 	time       stime.StaticTimeService
 	repository CredentialRepository
@@ -51,6 +54,7 @@ func NewScope(t *testing.T) Scope {
 	testAPI := clienttest.NewTestClient(routes)
 
 	return Scope{
+		db:         db,
 		session:    session,
 		time:       times,
 		repository: repository,
@@ -62,12 +66,31 @@ func NewScope(t *testing.T) Scope {
 func Setup(t *testing.T) (*require.Assertions, Scope) {
 	a := require.New(t)
 	s := NewScope(t)
+	s.AddIdentity(s.session.IdentityID.String(), s.session.TenantID.String())
 	return a, s
 }
 
-func (s *Scope) RegisterRandom() (*client.Credential, error) {
+// RandomIdentity - Stored so credentials can be registered to it.
+func (s *Scope) RandomIdentity(tenantID string) string {
 	identityID := uuid.New().String()
+	s.AddIdentity(identityID, tenantID)
+	return identityID
+}
+
+// AddIdentity - Stores the identity so credentials can be registered or linked to it.
+func (s *Scope) AddIdentity(identityID string, tenantID string) {
+	_, err := s.db.Exec(`
+		INSERT INTO identity (identity_id, tenant_id, first_name, last_name, status, email, registered_on, last_updated_on)
+		VALUES (?, ?, 'Jane', 'Doe', 'active', ?, ?, ?)
+	`, identityID, tenantID, identityID+"@example.com", s.time.Now(), s.time.Now())
+	if err != nil {
+		panic(err)
+	}
+}
+
+func (s *Scope) RegisterRandom() (*client.Credential, error) {
+	identityID := s.RandomIdentity(s.session.TenantID.String())
 	credentialID := uuid.New().String()
 
-	return s.service.Register(identityID, credentialID, s.session.TenantID.String())
+	return s.service.Register(context.Background(), identityID, credentialID, s.session.TenantID.String())
 }
@@ -1,8 +1,14 @@
 package credentials
 
 import (
+	"context"
+	"crypto/rand"
 	"database/sql"
+	"encoding/base64"
+	"strings"
+	"time"
 
+	"github.com/google/uuid"
 	"github.com/moov-io/identity/pkg/client"
 	"github.com/moov-io/identity/pkg/stime"
 	tmw "github.com/moov-io/tumbler/pkg/middleware"
@@ -13,16 +19,22 @@ import (
 // while the service implementation can ignored with the .openapi-generator-ignore file
 // and updated with the logic required for the API.
 type CredentialsService interface {
-	DisableCredentials(auth tmw.TumblerClaims, identityID string, credentialID string) (*client.Credential, error)
-	ListCredentials(tmw.TumblerClaims, string) ([]client.Credential, error)
+	DisableCredentials(ctx context.Context, auth tmw.TumblerClaims, identityID string, credentialID string) (*client.Credential, error)
+	ListCredentials(context.Context, tmw.TumblerClaims, string) ([]client.Credential, error)
 
-	Exists(credentialID, tenantID string) (bool, error)
-	Register(identityID, credentialID, tenantID string) (*client.Credential, error)
+	Exists(ctx context.Context, credentialID, tenantID string) (bool, error)
+	Register(ctx context.Context, identityID, credentialID, tenantID string) (*client.Credential, error)
 
-	Login(client.Login, string, string) (*client.Credential, error)
-	Record(credentialID string, tenantID string, nonce string, ip string) error
+	Login(context.Context, client.Login, string, string) (*client.Credential, error)
+	Record(ctx context.Context, credentialID string, tenantID string, nonce string, ip string) error
+
+	StartLink(ctx context.Context, claims tmw.TumblerClaims, identityID string) (*client.CredentialLink, error)
+	RedeemLink(ctx context.Context, code string, tenantID string, credentialID string) (*client.Credential, error)
 }
 
+// How long a link code handed out by StartLink stays valid for the OIDC round trip.
+const linkExpiration = 15 * time.Minute
+
 // CredentialsService is a service that implents the logic for the CredentialsApiServicer
 // This service should implement the business logic for every endpoint for the CredentialsApi API.
 // Include any external packages or services that will be required by this service.
@@ -40,8 +52,8 @@ func NewCredentialsService(time stime.TimeService, repository CredentialReposito
 }
 
 // DisableCredentials - Disables a credential so it can&#39;t be used anymore to login
-func (s *credentialsService) DisableCredentials(auth tmw.TumblerClaims, identityID string, credentialID string) (*client.Credential, error) {
-	cred, err := s.repository.get(identityID, credentialID, auth.TenantID.String())
+func (s *credentialsService) DisableCredentials(ctx context.Context, auth tmw.TumblerClaims, identityID string, credentialID string) (*client.Credential, error) {
+	cred, err := s.repository.get(ctx, identityID, credentialID, auth.TenantID.String())
 	if err != nil {
 		return nil, err
 	}
@@ -51,7 +63,7 @@ func (s *credentialsService) DisableCredentials(auth tmw.TumblerClaims, identity
 	cred.DisabledOn = &now
 	cred.DisabledBy = &caller
 
-	saved, err := s.repository.update(*cred)
+	saved, err := s.repository.update(ctx, *cred)
 	if err != nil {
 		return nil, err
 	}
@@ -62,26 +74,26 @@ func (s *credentialsService) DisableCredentials(auth tmw.TumblerClaims, identity
 }
 
 // ListCredentials - List the credentials this user has used.
-func (s *credentialsService) ListCredentials(auth tmw.TumblerClaims, identityID string) ([]client.Credential, error) {
-	return s.repository.list(identityID, auth.TenantID.String())
+func (s *credentialsService) ListCredentials(ctx context.Context, auth tmw.TumblerClaims, identityID string) ([]client.Credential, error) {
+	return s.repository.list(ctx, identityID, auth.TenantID.String())
 }
 
-func (s *credentialsService) Login(login client.Login, nonce string, ip string) (*client.Credential, error) {
+func (s *credentialsService) Login(ctx context.Context, login client.Login, nonce string, ip string) (*client.Credential, error) {
 
 	// look into the repo for any matches
-	cred, err := s.repository.lookup(login.CredentialID, login.TenantID)
+	cred, err := s.repository.lookup(ctx, login.CredentialID, login.TenantID)
 	if err != nil {
 		return nil, err
 	}
 
 	// Record the login happened and that the nonce is unique.
-	err = s.Record(cred.CredentialID, cred.TenantID, nonce, ip)
+	err = s.Record(ctx, cred.CredentialID, cred.TenantID, nonce, ip)
 	if err != nil {
 		return nil, err
 	}
 
 	cred.LastUsedOn = s.time.Now()
-	saved, err := s.repository.update(*cred)
+	saved, err := s.repository.update(ctx, *cred)
 	if err != nil {
 		return nil, err
 	}
@@ -92,8 +104,8 @@ func (s *credentialsService) Login(login client.Login, nonce string, ip string)
 }
 
 // Record the login happened and that the nonce is unique.
-func (s *credentialsService) Record(credentialID string, tenantID string, nonce string, ip string) error {
-	err := s.repository.record(credentialID, tenantID, nonce, ip, s.time.Now())
+func (s *credentialsService) Record(ctx context.Context, credentialID string, tenantID string, nonce string, ip string) error {
+	err := s.repository.record(ctx, credentialID, tenantID, nonce, ip, s.time.Now())
 	if err != nil {
 		return err
 	}
@@ -101,8 +113,8 @@ func (s *credentialsService) Record(credentialID string, tenantID string, nonce
 	return nil
 }
 
-func (s *credentialsService) Exists(credentialID, tenantID string) (bool, error) {
-	_, err := s.repository.lookup(credentialID, tenantID)
+func (s *credentialsService) Exists(ctx context.Context, credentialID, tenantID string) (bool, error) {
+	_, err := s.repository.lookup(ctx, credentialID, tenantID)
 	if err == nil {
 		return true, nil
 	}
@@ -114,8 +126,40 @@ func (s *credentialsService) Exists(credentialID, tenantID string) (bool, error)
 	return false, err
 }
 
-func (s *credentialsService) Register(identityID, credentialID, tenantID string) (*client.Credential, error) {
-	cred := client.Credential{
+func (s *credentialsService) Register(ctx context.Context, identityID, credentialID, tenantID string) (*client.Credential, error) {
+	if err := s.ensureUnused(ctx, identityID, credentialID, tenantID); err != nil {
+		return nil, err
+	}
+
+	saved, err := s.repository.add(ctx, s.newCredential(identityID, credentialID, tenantID))
+	if err != nil {
+		return nil, err
+	}
+
+	// @TODO record new registered credential
+
+	// @TODO email that a new credential was registered
+
+	return saved, nil
+}
+
+// ensureUnused - Guards against attaching a credential that someone already logs in with.
+func (s *credentialsService) ensureUnused(ctx context.Context, identityID, credentialID, tenantID string) error {
+	existing, err := s.repository.lookup(ctx, credentialID, tenantID)
+	if err == nil {
+		if existing.IdentityID == identityID {
+			return ErrCredentialAlreadyLinked
+		}
+		return ErrCredentialInUse
+	} else if err != sql.ErrNoRows {
+		return err
+	}
+
+	return nil
+}
+
+func (s *credentialsService) newCredential(identityID, credentialID, tenantID string) client.Credential {
+	return client.Credential{
 		CredentialID: credentialID,
 		IdentityID:   identityID,
 		TenantID:     tenantID,
@@ -124,15 +168,75 @@ func (s *credentialsService) Register(identityID, credentialID, tenantID string)
 		DisabledBy:   nil,
 		DisabledOn:   nil,
 	}
+}
+
+// StartLink - Starts attaching another OIDC credential to the identity of the current session.
+// The code returned is handed to the AuthN service which returns it on the LoginSession when the flow finishes.
+func (s *credentialsService) StartLink(ctx context.Context, claims tmw.TumblerClaims, identityID string) (*client.CredentialLink, error) {
+	if claims.IdentityID == nil || claims.IdentityID.String() != identityID {
+		return nil, ErrLinkNotAllowed
+	}
 
-	saved, err := s.repository.add(cred)
+	code, err := generateLinkCode()
 	if err != nil {
 		return nil, err
 	}
 
-	// @TODO record new registered credential
+	link := client.CredentialLink{
+		LinkID:     uuid.New().String(),
+		TenantID:   claims.TenantID.String(),
+		IdentityID: identityID,
+		CreatedBy:  claims.Subject,
+		CreatedOn:  s.time.Now(),
+		ExpiresOn:  s.time.Now().Add(linkExpiration),
+		RedeemedOn: nil,
+	}
 
-	// @TODO email that a new credential was registered
+	saved, err := s.repository.addLink(ctx, link, code)
+	if err != nil {
+		return nil, err
+	}
 
+	saved.LinkCode = code
 	return saved, nil
 }
+
+// RedeemLink - Attaches the credential to the identity that started the link in the tenant. The code is only
+// used up along with saving the credential so one that can't be attached leaves the code for another try.
+func (s *credentialsService) RedeemLink(ctx context.Context, code string, tenantID string, credentialID string) (*client.Credential, error) {
+	link, err := s.repository.getLinkByCode(ctx, tenantID, strings.TrimSpace(code))
+	if err == sql.ErrNoRows {
+		return nil, ErrLinkNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if link.ExpiresOn.Before(s.time.Now()) {
+		return nil, ErrLinkCodeExpired
+	}
+
+	if link.RedeemedOn != nil {
+		return nil, ErrLinkCodeRedeemed
+	}
+
+	if err := s.ensureUnused(ctx, link.IdentityID, credentialID, tenantID); err != nil {
+		return nil, err
+	}
+
+	redeemedOn := s.time.Now()
+	link.RedeemedOn = &redeemedOn
+
+	return s.repository.redeemLink(ctx, *link, s.newCredential(link.IdentityID, credentialID, tenantID))
+}
+
+// Generate a large random crypto string to work as the link token
+func generateLinkCode() (string, error) {
+	b := make([]byte, 32)
+	_, err := rand.Read(b)
+	if err != nil {
+		return "", err
+	}
+
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
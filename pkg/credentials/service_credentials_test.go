@@ -1,6 +1,7 @@
 package credentials_test
 
 import (
+	"context"
 	"testing"
 	"time"
 
@@ -11,18 +12,18 @@ import (
 func Test_Register(t *testing.T) {
 	a, s := Setup(t)
 
-	identityID := uuid.New().String()
-	credentialID := uuid.New().String()
 	tenantID := uuid.New().String()
+	identityID := s.RandomIdentity(tenantID)
+	credentialID := uuid.New().String()
 
-	found, err := s.service.Exists(credentialID, tenantID)
+	found, err := s.service.Exists(context.Background(), credentialID, tenantID)
 	a.False(found)
 	a.Nil(err)
 
-	cred, err := s.service.Register(identityID, credentialID, tenantID)
+	cred, err := s.service.Register(context.Background(), identityID, credentialID, tenantID)
 	a.Nil(err)
 
-	found, err = s.service.Exists(credentialID, tenantID)
+	found, err = s.service.Exists(context.Background(), credentialID, tenantID)
 	a.True(found)
 	a.Nil(err)
 
@@ -37,7 +38,7 @@ func Test_Register(t *testing.T) {
 	a.Nil(cred.DisabledOn)
 
 	// register again should fail.
-	_, err = s.service.Register(identityID, credentialID, tenantID)
+	_, err = s.service.Register(context.Background(), identityID, credentialID, tenantID)
 	a.NotNil(err)
 
 }
@@ -54,7 +55,7 @@ func Test_List(t *testing.T) {
 	_, _ = s.RegisterRandom()
 	_, _ = s.RegisterRandom()
 
-	found, err := s.service.ListCredentials(s.session, cred.IdentityID)
+	found, err := s.service.ListCredentials(context.Background(), s.session, cred.IdentityID)
 	a.Nil(err)
 	a.Len(found, 1)
 	a.Contains(found, *cred)
@@ -73,7 +74,7 @@ func Test_Disable(t *testing.T) {
 	_, _ = s.RegisterRandom()
 	s.time.Change(s.time.Now().Add(time.Hour))
 
-	disabled, err := s.service.DisableCredentials(s.session, cred.IdentityID, cred.CredentialID)
+	disabled, err := s.service.DisableCredentials(context.Background(), s.session, cred.IdentityID, cred.CredentialID)
 	a.Nil(err)
 
 	a.Equal(cred.CredentialID, disabled.CredentialID)
@@ -86,7 +87,7 @@ func Test_Disable(t *testing.T) {
 	a.Equal(s.session.Subject, *disabled.DisabledBy)
 	a.Equal(s.time.Now(), *disabled.DisabledOn)
 
-	found, err := s.service.ListCredentials(s.session, cred.IdentityID)
+	found, err := s.service.ListCredentials(context.Background(), s.session, cred.IdentityID)
 	a.Nil(err)
 	a.Len(found, 1)
 	a.Contains(found, *disabled)
@@ -109,18 +110,18 @@ func Test_Login(t *testing.T) {
 	ip := "1.2.3.4"
 
 	// first login should work
-	loggedIn, err := s.service.Login(login, nonce, ip)
+	loggedIn, err := s.service.Login(context.Background(), login, nonce, ip)
 	a.Nil(err)
 
 	a.Equal(cred, loggedIn)
 
 	// Can't login twice with the same nonce
-	_, err = s.service.Login(login, nonce, ip)
+	_, err = s.service.Login(context.Background(), login, nonce, ip)
 	a.NotNil(err)
 
 	// Difference nonce and works again
 	nonce = uuid.New().String()
-	_, err = s.service.Login(login, nonce, ip)
+	_, err = s.service.Login(context.Background(), login, nonce, ip)
 	a.Nil(err)
 }
 
@@ -138,7 +139,7 @@ func Test_NoLogin(t *testing.T) {
 	ip := "1.2.3.4"
 
 	// first login should work
-	_, err := s.service.Login(login, nonce, ip)
+	_, err := s.service.Login(context.Background(), login, nonce, ip)
 	a.NotNil(err)
 }
 
@@ -151,6 +152,6 @@ func Test_DisableNonExistantCredential(t *testing.T) {
 	_, _ = s.RegisterRandom()
 	_, _ = s.RegisterRandom()
 
-	_, err := s.service.DisableCredentials(s.session, uuid.New().String(), uuid.New().String())
+	_, err := s.service.DisableCredentials(context.Background(), s.session, uuid.New().String(), uuid.New().String())
 	a.NotNil(err)
 }
@@ -0,0 +1,112 @@
+package credentials_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	. "github.com/moov-io/identity/pkg/credentials"
+)
+
+func Test_Register_CredentialOwnedByOther(t *testing.T) {
+	a, s := Setup(t)
+
+	cred, err := s.RegisterRandom()
+	a.Nil(err)
+
+	_, err = s.service.Register(context.Background(), s.RandomIdentity(cred.TenantID), cred.CredentialID, cred.TenantID)
+	a.Equal(ErrCredentialInUse, err)
+
+	_, err = s.service.Register(context.Background(), cred.IdentityID, cred.CredentialID, cred.TenantID)
+	a.Equal(ErrCredentialAlreadyLinked, err)
+}
+
+func Test_StartLink(t *testing.T) {
+	a, s := Setup(t)
+
+	link, err := s.service.StartLink(context.Background(), s.session, s.session.IdentityID.String())
+	a.Nil(err)
+
+	a.NotEmpty(link.LinkID)
+	a.NotEmpty(link.LinkCode)
+	a.Equal(s.session.IdentityID.String(), link.IdentityID)
+	a.Equal(s.session.TenantID.String(), link.TenantID)
+	a.Equal(s.time.Now(), link.CreatedOn)
+	a.True(link.ExpiresOn.After(s.time.Now()))
+	a.Nil(link.RedeemedOn)
+}
+
+func Test_StartLink_OtherIdentity(t *testing.T) {
+	a, s := Setup(t)
+
+	_, err := s.service.StartLink(context.Background(), s.session, uuid.New().String())
+	a.Equal(ErrLinkNotAllowed, err)
+}
+
+func Test_RedeemLink(t *testing.T) {
+	a, s := Setup(t)
+
+	link, err := s.service.StartLink(context.Background(), s.session, s.session.IdentityID.String())
+	a.Nil(err)
+
+	credentialID := uuid.New().String()
+	cred, err := s.service.RedeemLink(context.Background(), link.LinkCode, link.TenantID, credentialID)
+	a.Nil(err)
+	a.Equal(credentialID, cred.CredentialID)
+	a.Equal(link.IdentityID, cred.IdentityID)
+	a.Equal(link.TenantID, cred.TenantID)
+
+	_, err = s.service.RedeemLink(context.Background(), link.LinkCode, link.TenantID, uuid.New().String())
+	a.Equal(ErrLinkCodeRedeemed, err)
+}
+
+func Test_RedeemLink_Expired(t *testing.T) {
+	a, s := Setup(t)
+
+	link, err := s.service.StartLink(context.Background(), s.session, s.session.IdentityID.String())
+	a.Nil(err)
+
+	s.time.Change(s.time.Now().Add(time.Hour))
+
+	_, err = s.service.RedeemLink(context.Background(), link.LinkCode, link.TenantID, uuid.New().String())
+	a.Equal(ErrLinkCodeExpired, err)
+}
+
+func Test_RedeemLink_NotFound(t *testing.T) {
+	a, s := Setup(t)
+
+	_, err := s.service.RedeemLink(context.Background(), "doesnotexist", s.session.TenantID.String(), uuid.New().String())
+	a.Equal(ErrLinkNotFound, err)
+}
+
+func Test_RedeemLink_OtherTenant(t *testing.T) {
+	a, s := Setup(t)
+
+	link, err := s.service.StartLink(context.Background(), s.session, s.session.IdentityID.String())
+	a.Nil(err)
+
+	_, err = s.service.RedeemLink(context.Background(), link.LinkCode, uuid.New().String(), uuid.New().String())
+	a.Equal(ErrLinkNotFound, err)
+
+	// Still usable in the tenant it was started in
+	_, err = s.service.RedeemLink(context.Background(), link.LinkCode, link.TenantID, uuid.New().String())
+	a.Nil(err)
+}
+
+func Test_RedeemLink_CredentialInUse(t *testing.T) {
+	a, s := Setup(t)
+
+	other, err := s.RegisterRandom()
+	a.Nil(err)
+
+	link, err := s.service.StartLink(context.Background(), s.session, s.session.IdentityID.String())
+	a.Nil(err)
+
+	_, err = s.service.RedeemLink(context.Background(), link.LinkCode, link.TenantID, other.CredentialID)
+	a.Equal(ErrCredentialInUse, err)
+
+	// The code wasn't used up by the failed attempt
+	_, err = s.service.RedeemLink(context.Background(), link.LinkCode, link.TenantID, uuid.New().String())
+	a.Nil(err)
+}
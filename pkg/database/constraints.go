@@ -0,0 +1,171 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+)
+
+// The unique indexes and foreign keys are added by migrations 32 through 38. Rows already breaking one of them
+// would stop a migration partway through so they're looked for before any of those are run.
+const (
+	constraintsFirstVersion = 32
+	constraintsLastVersion  = 38
+)
+
+// ConstraintViolation - Rows that keep a unique index or foreign key from being added.
+type ConstraintViolation struct {
+	Constraint string
+	Table      string
+
+	// Duplicated value or the reference to a row that doesn't exist, like invite_id=...
+	Key  string
+	Rows int
+}
+
+func (v ConstraintViolation) String() string {
+	return fmt.Sprintf("%s: %d rows of %s with %s", v.Constraint, v.Rows, v.Table, v.Key)
+}
+
+// ConstraintViolations - Issued when rows need to be cleaned up before the constraints can be added.
+type ConstraintViolations []ConstraintViolation
+
+func (v ConstraintViolations) Error() string {
+	lines := []string{fmt.Sprintf("%d constraint violations need to be fixed before migrating", len(v))}
+	for _, violation := range v {
+		lines = append(lines, "  "+violation.String())
+	}
+	return strings.Join(lines, "\n")
+}
+
+type constraintCheck struct {
+	constraint string
+	table      string
+
+	// Selects the columns making up the key followed by the number of rows with it.
+	columns []string
+	query   string
+}
+
+var constraintChecks = []constraintCheck{
+	{
+		constraint: "identity_tenant_id_email",
+		table:      "identity",
+		columns:    []string{"tenant_id", "email"},
+		query: `
+			SELECT tenant_id, email, COUNT(*)
+			FROM identity
+			WHERE merged_into IS NULL
+			GROUP BY tenant_id, email
+			HAVING COUNT(*) > 1`,
+	},
+	{
+		constraint: "invites_secret_code",
+		table:      "invites",
+		columns:    []string{"secret_code"},
+		query: `
+			SELECT secret_code, COUNT(*)
+			FROM invites
+			GROUP BY secret_code
+			HAVING COUNT(*) > 1`,
+	},
+	{
+		constraint: "identity_invite_fk",
+		table:      "identity",
+		columns:    []string{"invite_id"},
+		query: `
+			SELECT identity.invite_id, COUNT(*)
+			FROM identity
+			LEFT JOIN invites ON invites.invite_id = identity.invite_id
+			WHERE identity.invite_id IS NOT NULL AND invites.invite_id IS NULL
+			GROUP BY identity.invite_id`,
+	},
+	{
+		constraint: "identity_address_identity_fk",
+		table:      "identity_address",
+		columns:    []string{"identity_id"},
+		query: `
+			SELECT identity_address.identity_id, COUNT(*)
+			FROM identity_address
+			LEFT JOIN identity ON identity.identity_id = identity_address.identity_id
+			WHERE identity.identity_id IS NULL
+			GROUP BY identity_address.identity_id`,
+	},
+	{
+		constraint: "identity_phone_identity_fk",
+		table:      "identity_phone",
+		columns:    []string{"identity_id"},
+		query: `
+			SELECT identity_phone.identity_id, COUNT(*)
+			FROM identity_phone
+			LEFT JOIN identity ON identity.identity_id = identity_phone.identity_id
+			WHERE identity.identity_id IS NULL
+			GROUP BY identity_phone.identity_id`,
+	},
+	{
+		constraint: "credentials_identity_fk",
+		table:      "credentials",
+		columns:    []string{"identity_id"},
+		query: `
+			SELECT credentials.identity_id, COUNT(*)
+			FROM credentials
+			LEFT JOIN identity ON identity.identity_id = credentials.identity_id
+			WHERE identity.identity_id IS NULL
+			GROUP BY credentials.identity_id`,
+	},
+}
+
+// CheckConstraints - Looks for duplicates and references to missing rows that would keep the unique indexes
+// and foreign keys from being added. Nothing is changed, the rows found have to be cleaned up by hand.
+func CheckConstraints(ctx context.Context, db *sql.DB) (ConstraintViolations, error) {
+	violations := ConstraintViolations{}
+
+	for _, check := range constraintChecks {
+		found, err := check.run(ctx, db)
+		if err != nil {
+			return nil, fmt.Errorf("checking %s: %w", check.constraint, err)
+		}
+		violations = append(violations, found...)
+	}
+
+	return violations, nil
+}
+
+func (c constraintCheck) run(ctx context.Context, db *sql.DB) ([]ConstraintViolation, error) {
+	rows, err := db.QueryContext(ctx, c.query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	violations := []ConstraintViolation{}
+	for rows.Next() {
+		values := make([]sql.NullString, len(c.columns))
+		count := 0
+
+		dest := []interface{}{}
+		for i := range values {
+			dest = append(dest, &values[i])
+		}
+		dest = append(dest, &count)
+
+		if err := rows.Scan(dest...); err != nil {
+			return nil, err
+		}
+
+		key := []string{}
+		for i, column := range c.columns {
+			key = append(key, column+"="+values[i].String)
+		}
+
+		violations = append(violations, ConstraintViolation{
+			Constraint: c.constraint,
+			Table:      c.table,
+			Key:        strings.Join(key, ", "),
+			Rows:       count,
+		})
+	}
+
+	return violations, rows.Err()
+}
@@ -0,0 +1,127 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	log "github.com/moov-io/identity/pkg/logging"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_CheckConstraints(t *testing.T) {
+	a := require.New(t)
+
+	db, close, err := NewAndMigrate(InMemorySqliteConfig, nil, nil)
+	a.NoError(err)
+	defer close()
+
+	violations, err := CheckConstraints(context.Background(), db)
+	a.NoError(err)
+	a.Empty(violations)
+
+	// Back to before the constraints were added so there can be rows breaking them.
+	m, err := NewMigrator(log.NewNopLogger(), db, InMemorySqliteConfig)
+	a.NoError(err)
+	a.NoError(m.Goto(constraintsFirstVersion - 1))
+
+	tenantID := uuid.New().String()
+	jane := insertConstraintsIdentity(t, db, tenantID, "jane@example.com", nil)
+	janet := insertConstraintsIdentity(t, db, tenantID, "jane@example.com", nil)
+
+	// Merged into jane so it can keep the email
+	merged := insertConstraintsIdentity(t, db, tenantID, "jane@example.com", nil)
+	_, err = db.Exec(`UPDATE identity SET merged_into = ? WHERE identity_id = ?`, jane, merged)
+	a.NoError(err)
+
+	missingInvite := uuid.New().String()
+	insertConstraintsIdentity(t, db, tenantID, "john@example.com", &missingInvite)
+
+	missingIdentity := uuid.New().String()
+	_, err = db.Exec(`
+		INSERT INTO credentials (credential_id, tenant_id, identity_id, created_on, last_used_on)
+		VALUES (?, ?, ?, ?, ?)
+	`, uuid.New().String(), tenantID, missingIdentity, time.Now(), time.Now())
+	a.NoError(err)
+
+	violations, err = CheckConstraints(context.Background(), db)
+	a.NoError(err)
+	a.ElementsMatch(ConstraintViolations{
+		{Constraint: "identity_tenant_id_email", Table: "identity", Key: "tenant_id=" + tenantID + ", email=jane@example.com", Rows: 2},
+		{Constraint: "identity_invite_fk", Table: "identity", Key: "invite_id=" + missingInvite, Rows: 1},
+		{Constraint: "credentials_identity_fk", Table: "credentials", Key: "identity_id=" + missingIdentity, Rows: 1},
+	}, violations)
+
+	// Nothing is run until they're cleaned up
+	err = m.Up()
+	a.True(errors.As(err, &violations))
+	a.Error(m.Goto(constraintsFirstVersion))
+
+	version, dirty, err := m.Version()
+	a.NoError(err)
+	a.False(dirty)
+	a.Equal(uint(constraintsFirstVersion-1), version)
+
+	_, err = db.Exec(`UPDATE identity SET email = 'janet@example.com' WHERE identity_id = ?`, janet)
+	a.NoError(err)
+	_, err = db.Exec(`UPDATE identity SET invite_id = NULL WHERE invite_id = ?`, missingInvite)
+	a.NoError(err)
+	_, err = db.Exec(`UPDATE credentials SET identity_id = ? WHERE identity_id = ?`, jane, missingIdentity)
+	a.NoError(err)
+
+	a.NoError(m.Up())
+	version, _, err = m.Version()
+	a.NoError(err)
+	a.Equal(uint(constraintsLastVersion), version)
+
+	// Only the identities that haven't been merged away have to have their own email
+	_, err = db.Exec(`UPDATE identity SET email = 'janet@example.com' WHERE identity_id = ?`, merged)
+	a.NoError(err)
+	_, err = db.Exec(`UPDATE identity SET merged_into = NULL WHERE identity_id = ?`, merged)
+	a.True(UniqueViolation(err))
+}
+
+func Test_CheckConstraints_BeforeMergedInto(t *testing.T) {
+	a := require.New(t)
+
+	db, close, err := NewAndMigrate(InMemorySqliteConfig, nil, nil)
+	a.NoError(err)
+	defer close()
+
+	// Back to before identities could be merged, the checks are run once it's been added.
+	m, err := NewMigrator(log.NewNopLogger(), db, InMemorySqliteConfig)
+	a.NoError(err)
+	a.NoError(m.Goto(8))
+
+	tenantID := uuid.New().String()
+	_, err = db.Exec(`
+		INSERT INTO identity (identity_id, tenant_id, first_name, last_name, status, email, registered_on, last_updated_on)
+		VALUES (?, ?, 'Jane', 'Doe', 'active', 'jane@example.com', ?, ?), (?, ?, 'Janet', 'Doe', 'active', 'jane@example.com', ?, ?)
+	`, uuid.New().String(), tenantID, time.Now(), time.Now(), uuid.New().String(), tenantID, time.Now(), time.Now())
+	a.NoError(err)
+
+	var violations ConstraintViolations
+	err = m.Up()
+	a.True(errors.As(err, &violations))
+	a.Len(violations, 1)
+
+	version, dirty, err := m.Version()
+	a.NoError(err)
+	a.False(dirty)
+	a.Equal(uint(constraintsFirstVersion-1), version)
+}
+
+func insertConstraintsIdentity(t *testing.T, db *sql.DB, tenantID string, email string, inviteID *string) string {
+	identityID := uuid.New().String()
+
+	_, err := db.Exec(`
+		INSERT INTO identity (identity_id, tenant_id, first_name, last_name, status, email, registered_on, last_updated_on, invite_id)
+		VALUES (?, ?, 'Jane', 'Doe', 'active', ?, ?, ?, ?)
+	`, identityID, tenantID, email, time.Now(), time.Now(), inviteID)
+	require.NoError(t, err)
+
+	return identityID
+}
@@ -15,6 +15,8 @@ import (
 func New(ctx context.Context, logger log.Logger, config DatabaseConfig) (*sql.DB, error) {
 	if config.MySql != nil {
 		return mysqlConnection(logger, config.MySql.User, config.MySql.Password, config.MySql.Address, config.DatabaseName).Connect(ctx)
+	} else if config.Postgres != nil {
+		return postgresConnection(logger, *config.Postgres, config.DatabaseName).Connect(ctx)
 	} else if config.SqlLite != nil {
 		return sqliteConnection(logger, config.SqlLite.Path).Connect(ctx)
 	}
@@ -51,5 +53,8 @@ func NewAndMigrate(config DatabaseConfig, logger log.Logger, ctx context.Context
 // UniqueViolation returns true when the provided error matches a database error
 // for duplicate entries (violating a unique table constraint).
 func UniqueViolation(err error) bool {
-	return MySQLUniqueViolation(err) || SqliteUniqueViolation(err)
+	if err == nil {
+		return false
+	}
+	return MySQLUniqueViolation(err) || PostgresUniqueViolation(err) || SqliteUniqueViolation(err)
 }
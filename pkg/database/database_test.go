@@ -38,6 +38,19 @@ func Test_NewAndMigration_MySql(t *testing.T) {
 	close()
 }
 
+func Test_NewAndMigration_Postgres(t *testing.T) {
+	if !docker.Enabled() {
+		t.SkipNow()
+	}
+
+	_, close, err := NewAndMigrate(TestDatabases["postgres"], nil, nil)
+	if err != nil {
+		fmt.Printf("%s", err.Error())
+		t.FailNow()
+	}
+	close()
+}
+
 func TestUniqueViolation(t *testing.T) {
 	err := errors.New(`problem upserting depository="282f6ffcd9ba5b029afbf2b739ee826e22d9df3b", userId="f25f48968da47ef1adb5b6531a1c2197295678ce": Error 1062: Duplicate entry '282f6ffcd9ba5b029afbf2b739ee826e22d9df3b' for key 'PRIMARY'`)
 	if !UniqueViolation(err) {
@@ -48,4 +61,13 @@ func TestUniqueViolation(t *testing.T) {
 	if !UniqueViolation(err) {
 		t.Error("should have matched unique violation")
 	}
+
+	err = errors.New(`pq: duplicate key value violates unique constraint "identity_pk"`)
+	if !UniqueViolation(err) {
+		t.Error("should have matched unique violation")
+	}
+
+	if UniqueViolation(nil) {
+		t.Error("nil isn't a unique violation")
+	}
 }
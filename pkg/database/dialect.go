@@ -0,0 +1,62 @@
+package database
+
+import (
+	"database/sql"
+	"strconv"
+	"strings"
+
+	gomysql "github.com/go-sql-driver/mysql"
+	"github.com/lib/pq"
+	"github.com/mattn/go-sqlite3"
+)
+
+// Dialect - The flavor of SQL a database speaks. Queries are written with ? placeholders and
+// rebound for the databases that number them instead.
+type Dialect string
+
+const (
+	SqliteDialect   Dialect = "sqlite"
+	MySqlDialect    Dialect = "mysql"
+	PostgresDialect Dialect = "postgres"
+)
+
+// DialectOf - Goes by the driver the connection was opened with.
+func DialectOf(db *sql.DB) Dialect {
+	switch db.Driver().(type) {
+	case *gomysql.MySQLDriver:
+		return MySqlDialect
+	case *pq.Driver:
+		return PostgresDialect
+	case *sqlite3.SQLiteDriver:
+		return SqliteDialect
+	}
+
+	return SqliteDialect
+}
+
+// Rebind - Rewrites the ? placeholders of the query into the ones the dialect uses, PostgreSQL numbers them $1, $2...
+// Question marks inside of quoted strings are left alone.
+func (d Dialect) Rebind(query string) string {
+	if d != PostgresDialect {
+		return query
+	}
+
+	rebound := strings.Builder{}
+	rebound.Grow(len(query) + 10)
+
+	n := 0
+	quoted := false
+	for _, c := range query {
+		switch {
+		case c == '\'':
+			quoted = !quoted
+		case c == '?' && !quoted:
+			n++
+			rebound.WriteString("$" + strconv.Itoa(n))
+			continue
+		}
+		rebound.WriteRune(c)
+	}
+
+	return rebound.String()
+}
@@ -0,0 +1,25 @@
+package database
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func Test_Dialect_Rebind(t *testing.T) {
+	a := require.New(t)
+
+	qry := `SELECT * FROM outbox WHERE ? = '' OR status = ? AND subject != 'why?' LIMIT ?`
+
+	a.Equal(qry, SqliteDialect.Rebind(qry))
+	a.Equal(qry, MySqlDialect.Rebind(qry))
+	a.Equal(`SELECT * FROM outbox WHERE $1 = '' OR status = $2 AND subject != 'why?' LIMIT $3`, PostgresDialect.Rebind(qry))
+}
+
+func Test_DialectOf(t *testing.T) {
+	db, close, err := NewAndMigrate(InMemorySqliteConfig, nil, nil)
+	require.NoError(t, err)
+	defer close()
+
+	require.Equal(t, SqliteDialect, DialectOf(db))
+}
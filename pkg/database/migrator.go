@@ -1,54 +1,200 @@
 package database
 
 import (
+	"context"
 	"database/sql"
 	"fmt"
+	"os"
 
 	migrate "github.com/golang-migrate/migrate/v4"
 	"github.com/golang-migrate/migrate/v4/database"
 	migmysql "github.com/golang-migrate/migrate/v4/database/mysql"
+	migpostgres "github.com/golang-migrate/migrate/v4/database/postgres"
 	migsqlite3 "github.com/golang-migrate/migrate/v4/database/sqlite3"
-	_ "github.com/golang-migrate/migrate/v4/source/pkger"
+	"github.com/golang-migrate/migrate/v4/source"
 	"github.com/markbates/pkger"
 	"github.com/moov-io/identity/pkg/logging"
 )
 
+// RunMigrations - Brings the database up to the latest migration and creates the search indexes.
 func RunMigrations(log logging.Logger, db *sql.DB, config DatabaseConfig) error {
 	log.Info().Log("Running Migrations")
 
+	m, err := NewMigrator(log, db, config)
+	if err != nil {
+		return err
+	}
+
+	if err := m.Up(); err != nil {
+		return err
+	}
+
+	log.Info().Log("Migrations complete")
+
+	return nil
+}
+
+// Migrator - Moves the schema of the database between the versions of the migrations.
+type Migrator struct {
+	log     logging.Logger
+	db      *sql.DB
+	source  source.Driver
+	migrate *migrate.Migrate
+}
+
+// NewMigrator - Reads the migrations built into the binary, using the ones written for the dialect of the database where there are any.
+func NewMigrator(log logging.Logger, db *sql.DB, config DatabaseConfig) (*Migrator, error) {
 	_ = pkger.Include("/migrations/")
 
 	driver, err := GetDriver(db, config)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
-	m, err := migrate.NewWithDatabaseInstance(
-		"pkger:///migrations/",
-		config.DatabaseName,
-		driver,
-	)
+	src, err := newMigrationSource("/migrations/", DialectOf(db))
 	if err != nil {
-		return log.Fatal().LogErrorF("Error running migration - %w", err)
+		return nil, log.Fatal().LogErrorF("Error reading migrations - %w", err)
+	}
+
+	m, err := migrate.NewWithInstance("pkger", src, config.DatabaseName, driver)
+	if err != nil {
+		return nil, log.Fatal().LogErrorF("Error running migration - %w", err)
+	}
+
+	return &Migrator{
+		log:     log,
+		db:      db,
+		source:  src,
+		migrate: m,
+	}, nil
+}
+
+// Up - Runs every migration that hasn't been yet and creates the search indexes.
+func (m *Migrator) Up() error {
+	if err := m.checkConstraints(constraintsLastVersion); err != nil {
+		return err
 	}
 
-	err = m.Up()
+	err := m.migrate.Up()
 	switch err {
 	case nil:
 	case migrate.ErrNoChange:
-		log.Info().Log("Database already at version")
+		m.log.Info().Log("Database already at version")
 	default:
-		return log.Fatal().LogErrorF("Error running migrations - %w", err)
+		return m.log.Fatal().LogErrorF("Error running migrations - %w", err)
 	}
 
-	log.Info().Log("Migrations complete")
+	if err := CreateSearchIndexes(m.db); err != nil {
+		return m.log.Fatal().LogErrorF("Error creating search indexes - %w", err)
+	}
 
 	return nil
 }
 
+// Down - Rolls back the last steps migrations that were run.
+func (m *Migrator) Down(steps int) error {
+	if steps < 1 {
+		return fmt.Errorf("number of migrations to roll back must be at least 1 but was %d", steps)
+	}
+
+	return m.result(m.migrate.Steps(-steps))
+}
+
+// Goto - Runs the migrations up or down until the database is at the version.
+func (m *Migrator) Goto(version uint) error {
+	if err := m.exists(version); err != nil {
+		return err
+	}
+
+	if err := m.checkConstraints(version); err != nil {
+		return err
+	}
+
+	return m.result(m.migrate.Migrate(version))
+}
+
+// Version - The last migration that was run and if it failed partway through. Its 0 when none have been run.
+func (m *Migrator) Version() (uint, bool, error) {
+	version, dirty, err := m.migrate.Version()
+	if err == migrate.ErrNilVersion {
+		return 0, false, nil
+	}
+
+	return version, dirty, err
+}
+
+// Force - Records the database as being at the version without running anything, for cleaning up after a
+// migration that failed partway through. A version of -1 records that no migrations have been run.
+func (m *Migrator) Force(version int) error {
+	if version >= 0 {
+		if err := m.exists(uint(version)); err != nil {
+			return err
+		}
+	}
+
+	return m.result(m.migrate.Force(version))
+}
+
+// exists - Errors for the versions that were skipped, like 9 and 10, so the database isn't moved to one of them.
+func (m *Migrator) exists(version uint) error {
+	r, _, err := m.source.ReadUp(version)
+	if os.IsNotExist(err) {
+		return fmt.Errorf("there isn't a migration %d", version)
+	} else if err != nil {
+		return err
+	}
+
+	return r.Close()
+}
+
+// checkConstraints - Refuses to run the migrations adding the unique indexes and foreign keys while there are rows
+// that break them, so the database isn't left dirty partway through.
+func (m *Migrator) checkConstraints(target uint) error {
+	current, _, err := m.Version()
+	if err != nil {
+		return err
+	}
+
+	if current >= constraintsLastVersion || target < constraintsFirstVersion {
+		return nil
+	}
+
+	// The checks read columns added along the way, like merged_into, so the migrations before them are run first.
+	if current < constraintsFirstVersion-1 {
+		if err := m.result(m.migrate.Migrate(constraintsFirstVersion - 1)); err != nil {
+			return err
+		}
+	}
+
+	violations, err := CheckConstraints(context.Background(), m.db)
+	if err != nil {
+		return m.log.Fatal().LogErrorF("Error checking constraints - %w", err)
+	}
+
+	if len(violations) > 0 {
+		return m.log.Fatal().LogErrorF("Error running migrations - %w", violations)
+	}
+
+	return nil
+}
+
+func (m *Migrator) result(err error) error {
+	switch err {
+	case nil:
+		return nil
+	case migrate.ErrNoChange:
+		m.log.Info().Log("Database already at version")
+		return nil
+	default:
+		return m.log.Fatal().LogErrorF("Error running migrations - %w", err)
+	}
+}
+
 func GetDriver(db *sql.DB, config DatabaseConfig) (database.Driver, error) {
 	if config.MySql != nil {
 		return MySqlDriver(db)
+	} else if config.Postgres != nil {
+		return PostgresDriver(db)
 	} else if config.SqlLite != nil {
 		return Sqlite3Driver(db)
 	}
@@ -60,6 +206,10 @@ func MySqlDriver(db *sql.DB) (database.Driver, error) {
 	return migmysql.WithInstance(db, &migmysql.Config{})
 }
 
+func PostgresDriver(db *sql.DB) (database.Driver, error) {
+	return migpostgres.WithInstance(db, &migpostgres.Config{})
+}
+
 func Sqlite3Driver(db *sql.DB) (database.Driver, error) {
 	return migsqlite3.WithInstance(db, &migsqlite3.Config{})
 }
@@ -0,0 +1,71 @@
+package database
+
+import (
+	"io"
+	"net/http"
+	"os"
+	"path"
+
+	"github.com/golang-migrate/migrate/v4/source"
+	"github.com/golang-migrate/migrate/v4/source/httpfs"
+	"github.com/markbates/pkger"
+)
+
+// migrationSource - The migrations shared by every database, along with a directory named after the dialect for the
+// ones that have to be written differently for it. Those are used in place of the shared ones of the same version.
+type migrationSource struct {
+	source.Driver
+	dialect source.Driver
+}
+
+func newMigrationSource(dir string, dialect Dialect) (source.Driver, error) {
+	shared, err := httpfs.New(pkgerFS{}, dir)
+	if err != nil {
+		return nil, err
+	}
+
+	dialectDir := path.Join(dir, string(dialect))
+	if _, err := pkger.Stat(dialectDir); err != nil {
+		return shared, nil
+	}
+
+	overrides, err := httpfs.New(pkgerFS{}, dialectDir)
+	if err != nil {
+		return nil, err
+	}
+
+	return &migrationSource{
+		Driver:  shared,
+		dialect: overrides,
+	}, nil
+}
+
+func (s *migrationSource) ReadUp(version uint) (io.ReadCloser, string, error) {
+	r, identifier, err := s.dialect.ReadUp(version)
+	if os.IsNotExist(err) {
+		return s.Driver.ReadUp(version)
+	}
+
+	return r, identifier, err
+}
+
+func (s *migrationSource) ReadDown(version uint) (io.ReadCloser, string, error) {
+	r, identifier, err := s.dialect.ReadDown(version)
+	if os.IsNotExist(err) {
+		return s.Driver.ReadDown(version)
+	}
+
+	return r, identifier, err
+}
+
+// pkgerFS - Reads the files built into the binary.
+type pkgerFS struct{}
+
+func (pkgerFS) Open(name string) (http.File, error) {
+	f, err := pkger.Open(name)
+	if err != nil {
+		return nil, err
+	}
+
+	return f.(http.File), nil
+}
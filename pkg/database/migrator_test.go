@@ -0,0 +1,139 @@
+package database
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	log "github.com/moov-io/identity/pkg/logging"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_Migrations_HaveDowns(t *testing.T) {
+	a := require.New(t)
+
+	ups, err := filepath.Glob("../../migrations/*.up.sql")
+	a.NoError(err)
+	a.NotEmpty(ups)
+
+	for _, up := range ups {
+		name := strings.TrimSuffix(filepath.Base(up), ".up.sql")
+		_, err := os.Stat(strings.TrimSuffix(up, ".up.sql") + ".down.sql")
+		a.NoError(err, name)
+	}
+
+	// The ones written for a dialect have to replace one that's shared
+	overrides, err := filepath.Glob("../../migrations/*/*.sql")
+	a.NoError(err)
+	for _, override := range overrides {
+		_, err := os.Stat(filepath.Join("../../migrations", filepath.Base(override)))
+		a.NoError(err, override)
+	}
+}
+
+func Test_Migrator(t *testing.T) {
+	a := require.New(t)
+
+	db, close, err := NewAndMigrate(InMemorySqliteConfig, nil, nil)
+	a.NoError(err)
+	defer close()
+
+	m, err := NewMigrator(log.NewNopLogger(), db, InMemorySqliteConfig)
+	a.NoError(err)
+
+	latest, dirty, err := m.Version()
+	a.NoError(err)
+	a.False(dirty)
+	a.Equal(uint(38), latest)
+
+	// Nothing left to run
+	a.NoError(m.Up())
+
+	identityID := uuid.New().String()
+	_, err = db.Exec(`
+		INSERT INTO identity (identity_id, tenant_id, first_name, last_name, status, email, registered_on, last_updated_on, photo_url, merged_into, version, locale)
+		VALUES (?, ?, 'Jane', 'Doe', 'active', 'jane@example.com', ?, ?, 'https://example.com/jane.png', NULL, 3, 'fr')
+	`, identityID, uuid.New().String(), time.Now(), time.Now())
+	a.NoError(err)
+
+	a.NoError(m.Down(1))
+	version, _, err := m.Version()
+	a.NoError(err)
+	a.Equal(latest-1, version)
+
+	// Rolling back the columns added to identity keeps its rows
+	a.NoError(m.Goto(4))
+	version, _, err = m.Version()
+	a.NoError(err)
+	a.Equal(uint(4), version)
+
+	first := ""
+	a.NoError(db.QueryRow(`SELECT first_name FROM identity WHERE identity_id = ?`, identityID).Scan(&first))
+	a.Equal("Jane", first)
+
+	_, err = db.Exec(`SELECT photo_url FROM identity`)
+	a.Error(err)
+
+	a.NoError(m.Goto(1))
+	a.NoError(m.Down(1))
+	version, dirty, err = m.Version()
+	a.NoError(err)
+	a.False(dirty)
+	a.Equal(uint(0), version)
+
+	// Everything can be run again after being rolled back
+	a.NoError(m.Up())
+	version, _, err = m.Version()
+	a.NoError(err)
+	a.Equal(latest, version)
+
+	// Passes over the data only migrations both ways
+	a.NoError(m.Goto(17))
+	version, _, err = m.Version()
+	a.NoError(err)
+	a.Equal(uint(17), version)
+	a.NoError(m.Up())
+
+	a.NoError(m.Force(5))
+	version, dirty, err = m.Version()
+	a.NoError(err)
+	a.False(dirty)
+	a.Equal(uint(5), version)
+
+	// 9 and 10 were never used
+	a.EqualError(m.Force(10), "there isn't a migration 10")
+	a.EqualError(m.Goto(9), "there isn't a migration 9")
+	a.Error(m.Down(0))
+}
+
+func Test_MigrationSource_Dialect(t *testing.T) {
+	a := require.New(t)
+
+	src, err := newMigrationSource("/migrations/", MySqlDialect)
+	a.NoError(err)
+
+	r, _, err := src.ReadDown(6)
+	a.NoError(err)
+	defer r.Close()
+
+	content, err := ioutil.ReadAll(r)
+	a.NoError(err)
+	a.Contains(string(content), "ON identity_address")
+
+	// Falls back to the shared ones
+	r, _, err = src.ReadDown(1)
+	a.NoError(err)
+	defer r.Close()
+
+	content, err = ioutil.ReadAll(r)
+	a.NoError(err)
+	a.Contains(string(content), "DROP TABLE credentials")
+
+	// PostgreSQL doesn't have any of its own
+	_, err = newMigrationSource("/migrations/", PostgresDialect)
+	a.NoError(err)
+}
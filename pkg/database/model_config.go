@@ -1,9 +1,18 @@
 package database
 
+import "time"
+
 type DatabaseConfig struct {
 	MySql        *MySqlConfig
 	SqlLite      *SqlLiteConfig
+	Postgres     *PostgresConfig
 	DatabaseName string
+
+	// How long the queries for a single request are allowed to run before they're cancelled. Zero leaves them unbounded.
+	RequestTimeout time.Duration
+
+	// Leaves the migrations to be run with the migrate command instead of when starting up, like when they're a separate step of a deploy.
+	DisableAutoMigrate bool
 }
 
 type MySqlConfig struct {
@@ -12,6 +21,15 @@ type MySqlConfig struct {
 	Password string
 }
 
+type PostgresConfig struct {
+	Address  string
+	User     string
+	Password string
+
+	// Passed through to the driver, defaults to require. Use disable when running locally without TLS.
+	SSLMode string
+}
+
 type SqlLiteConfig struct {
 	Path string
 }
@@ -0,0 +1,205 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	kitprom "github.com/go-kit/kit/metrics/prometheus"
+	"github.com/lib/pq"
+	"github.com/moov-io/base/docker"
+	log "github.com/moov-io/identity/pkg/logging"
+	"github.com/ory/dockertest/v3"
+	stdprom "github.com/prometheus/client_golang/prometheus"
+)
+
+var (
+	postgresConnections = kitprom.NewGaugeFrom(stdprom.GaugeOpts{
+		Name: "postgres_connections",
+		Help: "How many PostgreSQL connections and what status they're in.",
+	}, []string{"state"})
+
+	// postgresErrUniqueViolation is the error code for duplicate entries
+	// https://www.postgresql.org/docs/current/errcodes-appendix.html
+	postgresErrUniqueViolation pq.ErrorCode = "23505"
+
+	maxActivePostgresConnections = func() int {
+		if v := os.Getenv("POSTGRES_MAX_CONNECTIONS"); v != "" {
+			if n, _ := strconv.ParseInt(v, 10, 32); n > 0 {
+				return int(n)
+			}
+		}
+		return 16
+	}()
+)
+
+type postgres struct {
+	dsn    string
+	logger log.Logger
+
+	connections *kitprom.Gauge
+}
+
+func (pg *postgres) Connect(ctx context.Context) (*sql.DB, error) {
+	db, err := sql.Open("postgres", pg.dsn)
+	if err != nil {
+		return nil, err
+	}
+	db.SetMaxOpenConns(maxActivePostgresConnections)
+
+	// Check out DB is up and working
+	if err := db.Ping(); err != nil {
+		return nil, err
+	}
+
+	// Setup metrics after the database is setup
+	go func() {
+		t := time.NewTicker(1 * time.Minute)
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-t.C:
+				stats := db.Stats()
+				pg.connections.With("state", "idle").Set(float64(stats.Idle))
+				pg.connections.With("state", "inuse").Set(float64(stats.InUse))
+				pg.connections.With("state", "open").Set(float64(stats.OpenConnections))
+			}
+		}
+	}()
+
+	return db, nil
+}
+
+func postgresConnection(logger log.Logger, config PostgresConfig, database string) *postgres {
+	timeout := "30"
+	if v := os.Getenv("POSTGRES_TIMEOUT"); v != "" {
+		timeout = v
+	}
+
+	sslMode := config.SSLMode
+	if sslMode == "" {
+		sslMode = "require"
+	}
+
+	params := url.Values{}
+	params.Set("connect_timeout", timeout)
+	params.Set("sslmode", sslMode)
+
+	dsn := url.URL{
+		Scheme:   "postgres",
+		User:     url.UserPassword(config.User, config.Password),
+		Host:     config.Address,
+		Path:     "/" + database,
+		RawQuery: params.Encode(),
+	}
+
+	return &postgres{
+		dsn:         dsn.String(),
+		logger:      logger,
+		connections: postgresConnections,
+	}
+}
+
+// TestPostgresDB is a wrapper around sql.DB for PostgreSQL connections designed for tests to provide
+// a clean database for each testcase.  Callers should cleanup with Close() when finished.
+type TestPostgresDB struct {
+	DB *sql.DB
+
+	container *dockertest.Resource
+
+	shutdown func() // context shutdown func
+}
+
+func (r *TestPostgresDB) Close() error {
+	r.shutdown()
+
+	// Verify all connections are closed before closing DB
+	if conns := r.DB.Stats().OpenConnections; conns != 0 {
+		panic(fmt.Sprintf("found %d open PostgreSQL connections", conns))
+	}
+
+	r.container.Close()
+
+	return r.DB.Close()
+}
+
+// CreateTestPostgresDB returns a TestPostgresDB which can be used in tests
+// as a clean postgres database.
+//
+// Callers should call close on the returned *TestPostgresDB.
+func CreateTestPostgresDB(t *testing.T) *TestPostgresDB {
+	if testing.Short() {
+		t.Skip("-short flag enabled")
+	}
+	if !docker.Enabled() {
+		t.Skip("Docker not enabled")
+	}
+
+	pool, err := dockertest.NewPool("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	resource, err := pool.RunWithOptions(&dockertest.RunOptions{
+		Repository: "postgres",
+		Tag:        "13",
+		Env: []string{
+			"POSTGRES_USER=moov",
+			"POSTGRES_PASSWORD=secret",
+			"POSTGRES_DB=identity",
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	config := PostgresConfig{
+		Address:  fmt.Sprintf("localhost:%s", resource.GetPort("5432/tcp")),
+		User:     "moov",
+		Password: "secret",
+		SSLMode:  "disable",
+	}
+
+	err = pool.Retry(func() error {
+		db, err := sql.Open("postgres", postgresConnection(log.NewNopLogger(), config, "identity").dsn)
+		if err != nil {
+			return err
+		}
+		defer db.Close()
+		return db.Ping()
+	})
+	if err != nil {
+		resource.Close()
+		t.Fatal(err)
+	}
+
+	ctx, cancelFunc := context.WithCancel(context.Background())
+
+	db, err := postgresConnection(log.NewNopLogger(), config, "identity").Connect(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Don't allow idle connections so we can verify all are closed at the end of testing
+	db.SetMaxIdleConns(0)
+
+	return &TestPostgresDB{DB: db, container: resource, shutdown: cancelFunc}
+}
+
+// PostgresUniqueViolation returns true when the provided error matches the PostgreSQL code
+// for duplicate entries (violating a unique table constraint).
+func PostgresUniqueViolation(err error) bool {
+	match := strings.Contains(err.Error(), "duplicate key value violates unique constraint")
+	var e *pq.Error
+	if errors.As(err, &e) {
+		return match || e.Code == postgresErrUniqueViolation
+	}
+	return match
+}
@@ -0,0 +1,138 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+// SearchIndexes - Full text indexes created along with the migrations. Their layout depends on
+// what the database supports so they can't be part of the migration files themselves.
+var SearchIndexes = []string{
+	"identity_search",
+}
+
+// SearchDocument - Text that can be searched for and the ID of what it belongs to.
+// Scope keeps documents of different tenants apart.
+type SearchDocument struct {
+	ID     string
+	Scope  string
+	Fields []string
+}
+
+// SearchResult - A document that matched a search. Higher scores are more relevant.
+type SearchResult struct {
+	ID    string
+	Score float64
+}
+
+// SearchIndex - Keeps the documents of a full text index in sync and ranks them against a query.
+// Every word of the query has to match the start of a word in the document, case is ignored.
+type SearchIndex interface {
+	Put(ctx context.Context, tx *sql.Tx, doc SearchDocument) error
+	Remove(ctx context.Context, tx *sql.Tx, id string) error
+	Search(ctx context.Context, scope string, query string, limit int) ([]SearchResult, error)
+	Empty(ctx context.Context) (bool, error)
+}
+
+// NewSearchIndex - Picks the strategy for the database the index was created in.
+// MySQL uses a FULLTEXT index, PostgreSQL a GIN index over a tsvector, SQLite uses FTS5 when its compiled in
+// (-tags sqlite_fts5) otherwise it falls back to matching words with LIKE. An index that already exists keeps the
+// strategy it was created with.
+func NewSearchIndex(db *sql.DB, name string) SearchIndex {
+	switch DialectOf(db) {
+	case MySqlDialect:
+		return &mysqlSearch{db: db, table: name}
+	case PostgresDialect:
+		return &postgresSearch{db: db, table: name}
+	}
+
+	if sqliteUsesFTS5(db, name) {
+		return &sqliteFTS5Search{db: db, table: name}
+	}
+	return &sqliteLikeSearch{db: db, table: name}
+}
+
+// CreateSearchIndexes - Creates the search indexes if they don't exist yet.
+func CreateSearchIndexes(db *sql.DB) error {
+	for _, name := range SearchIndexes {
+		if err := createSearchIndex(db, name); err != nil {
+			return fmt.Errorf("creating search index %s - %w", name, err)
+		}
+	}
+	return nil
+}
+
+func createSearchIndex(db *sql.DB, name string) error {
+	var qry string
+	switch NewSearchIndex(db, name).(type) {
+	case *postgresSearch:
+		return createPostgresSearchIndex(db, name)
+	case *mysqlSearch:
+		qry = fmt.Sprintf(`
+			CREATE TABLE IF NOT EXISTS %s (
+				doc_id      VARCHAR(36) NOT NULL,
+				scope       VARCHAR(36) NOT NULL,
+				content     TEXT NOT NULL,
+
+				CONSTRAINT %s_pk PRIMARY KEY (doc_id),
+				FULLTEXT KEY %s_content (content)
+			) ENGINE=InnoDB
+		`, name, name, name)
+	case *sqliteFTS5Search:
+		qry = fmt.Sprintf(`CREATE VIRTUAL TABLE IF NOT EXISTS %s USING fts5(doc_id UNINDEXED, scope UNINDEXED, content)`, name)
+	default:
+		qry = fmt.Sprintf(`
+			CREATE TABLE IF NOT EXISTS %s (
+				doc_id      VARCHAR(36) NOT NULL,
+				scope       VARCHAR(36) NOT NULL,
+				content     TEXT NOT NULL,
+
+				CONSTRAINT %s_pk PRIMARY KEY (doc_id)
+			)
+		`, name, name)
+	}
+
+	_, err := db.Exec(qry)
+	return err
+}
+
+// SearchTerms - Lower cases the text and splits it into words on anything that isn't a letter or number.
+func SearchTerms(text string) []string {
+	return strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsNumber(r)
+	})
+}
+
+func searchContent(doc SearchDocument) string {
+	terms := []string{}
+	for _, f := range doc.Fields {
+		terms = append(terms, SearchTerms(f)...)
+	}
+	return strings.Join(terms, " ")
+}
+
+func searchEmpty(ctx context.Context, db *sql.DB, table string) (bool, error) {
+	cnt := 0
+	if err := db.QueryRowContext(ctx, fmt.Sprintf(`SELECT COUNT(*) FROM %s`, table)).Scan(&cnt); err != nil {
+		return false, err
+	}
+	return cnt == 0, nil
+}
+
+func scanSearchResults(rows *sql.Rows) ([]SearchResult, error) {
+	defer rows.Close()
+
+	results := []SearchResult{}
+	for rows.Next() {
+		r := SearchResult{}
+		if err := rows.Scan(&r.ID, &r.Score); err != nil {
+			return nil, err
+		}
+		results = append(results, r)
+	}
+
+	return results, rows.Err()
+}
@@ -0,0 +1,58 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+)
+
+// mysqlSearch - Ranks with the relevance MATCH ... AGAINST returns for the FULLTEXT index.
+type mysqlSearch struct {
+	db    *sql.DB
+	table string
+}
+
+func (s *mysqlSearch) Put(ctx context.Context, tx *sql.Tx, doc SearchDocument) error {
+	_, err := tx.ExecContext(ctx, fmt.Sprintf(`
+		INSERT INTO %s (doc_id, scope, content) VALUES (?, ?, ?)
+		ON DUPLICATE KEY UPDATE scope = VALUES(scope), content = VALUES(content)
+	`, s.table), doc.ID, doc.Scope, searchContent(doc))
+	return err
+}
+
+func (s *mysqlSearch) Remove(ctx context.Context, tx *sql.Tx, id string) error {
+	_, err := tx.ExecContext(ctx, fmt.Sprintf(`DELETE FROM %s WHERE doc_id = ?`, s.table), id)
+	return err
+}
+
+func (s *mysqlSearch) Search(ctx context.Context, scope string, query string, limit int) ([]SearchResult, error) {
+	terms := SearchTerms(query)
+	if len(terms) == 0 {
+		return []SearchResult{}, nil
+	}
+
+	// Boolean mode so every word is required and can match by prefix.
+	match := []string{}
+	for _, t := range terms {
+		match = append(match, "+"+t+"*")
+	}
+	against := strings.Join(match, " ")
+
+	rows, err := s.db.QueryContext(ctx, fmt.Sprintf(`
+		SELECT doc_id, MATCH (content) AGAINST (? IN BOOLEAN MODE) AS score
+		FROM %s
+		WHERE scope = ? AND MATCH (content) AGAINST (? IN BOOLEAN MODE)
+		ORDER BY score DESC, doc_id
+		LIMIT ?
+	`, s.table), against, scope, against, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	return scanSearchResults(rows)
+}
+
+func (s *mysqlSearch) Empty(ctx context.Context) (bool, error) {
+	return searchEmpty(ctx, s.db, s.table)
+}
@@ -0,0 +1,76 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+)
+
+// postgresSearch - Ranks with ts_rank against a GIN index of the content. The simple configuration
+// is used so words aren't stemmed or dropped as stop words, matching how the other databases search.
+type postgresSearch struct {
+	db    *sql.DB
+	table string
+}
+
+func createPostgresSearchIndex(db *sql.DB, name string) error {
+	_, err := db.Exec(fmt.Sprintf(`
+		CREATE TABLE IF NOT EXISTS %s (
+			doc_id      VARCHAR(36) NOT NULL,
+			scope       VARCHAR(36) NOT NULL,
+			content     TEXT NOT NULL,
+
+			CONSTRAINT %s_pk PRIMARY KEY (doc_id)
+		)
+	`, name, name))
+	if err != nil {
+		return err
+	}
+
+	_, err = db.Exec(fmt.Sprintf(`CREATE INDEX IF NOT EXISTS %s_content ON %s USING GIN (to_tsvector('simple', content))`, name, name))
+	return err
+}
+
+func (s *postgresSearch) Put(ctx context.Context, tx *sql.Tx, doc SearchDocument) error {
+	_, err := tx.ExecContext(ctx, fmt.Sprintf(`
+		INSERT INTO %s (doc_id, scope, content) VALUES ($1, $2, $3)
+		ON CONFLICT (doc_id) DO UPDATE SET scope = EXCLUDED.scope, content = EXCLUDED.content
+	`, s.table), doc.ID, doc.Scope, searchContent(doc))
+	return err
+}
+
+func (s *postgresSearch) Remove(ctx context.Context, tx *sql.Tx, id string) error {
+	_, err := tx.ExecContext(ctx, fmt.Sprintf(`DELETE FROM %s WHERE doc_id = $1`, s.table), id)
+	return err
+}
+
+func (s *postgresSearch) Search(ctx context.Context, scope string, query string, limit int) ([]SearchResult, error) {
+	terms := SearchTerms(query)
+	if len(terms) == 0 {
+		return []SearchResult{}, nil
+	}
+
+	// Terms are only letters and numbers so they are safe to use as prefix matches.
+	match := []string{}
+	for _, t := range terms {
+		match = append(match, t+":*")
+	}
+
+	rows, err := s.db.QueryContext(ctx, fmt.Sprintf(`
+		SELECT doc_id, ts_rank(to_tsvector('simple', content), to_tsquery('simple', $1)) AS score
+		FROM %s
+		WHERE scope = $2 AND to_tsvector('simple', content) @@ to_tsquery('simple', $1)
+		ORDER BY score DESC, doc_id
+		LIMIT $3
+	`, s.table), strings.Join(match, " & "), scope, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	return scanSearchResults(rows)
+}
+
+func (s *postgresSearch) Empty(ctx context.Context) (bool, error) {
+	return searchEmpty(ctx, s.db, s.table)
+}
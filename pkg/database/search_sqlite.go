@@ -0,0 +1,150 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+func sqliteUsesFTS5(db *sql.DB, table string) bool {
+	existing := ""
+	err := db.QueryRow(`SELECT sql FROM sqlite_master WHERE name = ?`, table).Scan(&existing)
+	if err == nil {
+		return strings.Contains(strings.ToLower(existing), "using fts5")
+	}
+
+	enabled := 0
+	if err := db.QueryRow(`SELECT sqlite_compileoption_used('ENABLE_FTS5')`).Scan(&enabled); err != nil {
+		return false
+	}
+	return enabled == 1
+}
+
+// sqliteFTS5Search - Ranks with the bm25 function of the FTS5 extension.
+type sqliteFTS5Search struct {
+	db    *sql.DB
+	table string
+}
+
+func (s *sqliteFTS5Search) Put(ctx context.Context, tx *sql.Tx, doc SearchDocument) error {
+	if err := s.Remove(ctx, tx, doc.ID); err != nil {
+		return err
+	}
+
+	_, err := tx.ExecContext(ctx, fmt.Sprintf(`INSERT INTO %s (doc_id, scope, content) VALUES (?, ?, ?)`, s.table), doc.ID, doc.Scope, searchContent(doc))
+	return err
+}
+
+func (s *sqliteFTS5Search) Remove(ctx context.Context, tx *sql.Tx, id string) error {
+	_, err := tx.ExecContext(ctx, fmt.Sprintf(`DELETE FROM %s WHERE doc_id = ?`, s.table), id)
+	return err
+}
+
+func (s *sqliteFTS5Search) Search(ctx context.Context, scope string, query string, limit int) ([]SearchResult, error) {
+	terms := SearchTerms(query)
+	if len(terms) == 0 {
+		return []SearchResult{}, nil
+	}
+
+	// Terms are only letters and numbers so they are safe to quote as prefix queries.
+	match := []string{}
+	for _, t := range terms {
+		match = append(match, fmt.Sprintf(`content:"%s"*`, t))
+	}
+
+	rows, err := s.db.QueryContext(ctx, fmt.Sprintf(`
+		SELECT doc_id, -bm25(%s) AS score
+		FROM %s
+		WHERE %s MATCH ? AND scope = ?
+		ORDER BY score DESC, doc_id
+		LIMIT ?
+	`, s.table, s.table, s.table), strings.Join(match, " AND "), scope, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	return scanSearchResults(rows)
+}
+
+func (s *sqliteFTS5Search) Empty(ctx context.Context) (bool, error) {
+	return searchEmpty(ctx, s.db, s.table)
+}
+
+// sqliteLikeSearch - Used when SQLite is built without FTS5. Narrows the documents down with LIKE
+// then ranks them on how many words matched whole instead of by prefix.
+type sqliteLikeSearch struct {
+	db    *sql.DB
+	table string
+}
+
+func (s *sqliteLikeSearch) Put(ctx context.Context, tx *sql.Tx, doc SearchDocument) error {
+	if err := s.Remove(ctx, tx, doc.ID); err != nil {
+		return err
+	}
+
+	// Padded so every word, including the first, is preceded by a space.
+	_, err := tx.ExecContext(ctx, fmt.Sprintf(`INSERT INTO %s (doc_id, scope, content) VALUES (?, ?, ?)`, s.table), doc.ID, doc.Scope, " "+searchContent(doc)+" ")
+	return err
+}
+
+func (s *sqliteLikeSearch) Remove(ctx context.Context, tx *sql.Tx, id string) error {
+	_, err := tx.ExecContext(ctx, fmt.Sprintf(`DELETE FROM %s WHERE doc_id = ?`, s.table), id)
+	return err
+}
+
+func (s *sqliteLikeSearch) Search(ctx context.Context, scope string, query string, limit int) ([]SearchResult, error) {
+	terms := SearchTerms(query)
+	if len(terms) == 0 {
+		return []SearchResult{}, nil
+	}
+
+	where := []string{"scope = ?"}
+	args := []interface{}{scope}
+	for _, t := range terms {
+		where = append(where, "content LIKE ?")
+		args = append(args, "% "+t+"%")
+	}
+
+	rows, err := s.db.QueryContext(ctx, fmt.Sprintf(`SELECT doc_id, content FROM %s WHERE %s`, s.table, strings.Join(where, " AND ")), args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	results := []SearchResult{}
+	for rows.Next() {
+		id, content := "", ""
+		if err := rows.Scan(&id, &content); err != nil {
+			return nil, err
+		}
+
+		score := 0.0
+		for _, t := range terms {
+			score += float64(strings.Count(content, " "+t+" "))*2 + float64(strings.Count(content, " "+t))
+		}
+
+		results = append(results, SearchResult{ID: id, Score: score})
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	sort.SliceStable(results, func(i, j int) bool {
+		if results[i].Score == results[j].Score {
+			return results[i].ID < results[j].ID
+		}
+		return results[i].Score > results[j].Score
+	})
+
+	if len(results) > limit {
+		results = results[:limit]
+	}
+
+	return results, nil
+}
+
+func (s *sqliteLikeSearch) Empty(ctx context.Context) (bool, error) {
+	return searchEmpty(ctx, s.db, s.table)
+}
@@ -0,0 +1,144 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+
+	"github.com/moov-io/base/docker"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_SearchTerms(t *testing.T) {
+	a := require.New(t)
+	a.Equal([]string{"jane", "doe", "jane", "doe", "moov", "io", "555", "1234"}, SearchTerms("Jane  DOE <jane.doe@moov.io> (555) 1234"))
+	a.Empty(SearchTerms(" -- "))
+}
+
+func Test_Search_Sqlite(t *testing.T) {
+	db, close, err := NewAndMigrate(InMemorySqliteConfig, nil, nil)
+	require.NoError(t, err)
+	defer close()
+
+	testSearchIndex(t, db, NewSearchIndex(db, "identity_search"))
+	testSearchIndex(t, db, &sqliteLikeSearch{db: db, table: "identity_search_like"})
+}
+
+func Test_Search_MySql(t *testing.T) {
+	if !docker.Enabled() {
+		t.SkipNow()
+	}
+
+	config := DatabaseConfig{
+		DatabaseName: "identity",
+		MySql: &MySqlConfig{
+			Address:  "tcp(localhost:4306)",
+			User:     "identity",
+			Password: "identity",
+		},
+	}
+
+	db, close, err := NewAndMigrate(config, nil, nil)
+	require.NoError(t, err)
+	defer close()
+
+	_, err = db.Exec("DELETE FROM identity_search")
+	require.NoError(t, err)
+
+	testSearchIndex(t, db, NewSearchIndex(db, "identity_search"))
+}
+
+func Test_Search_Postgres(t *testing.T) {
+	if !docker.Enabled() {
+		t.SkipNow()
+	}
+
+	db, close, err := NewAndMigrate(TestDatabases["postgres"], nil, nil)
+	require.NoError(t, err)
+	defer close()
+
+	_, err = db.Exec("DELETE FROM identity_search")
+	require.NoError(t, err)
+
+	testSearchIndex(t, db, NewSearchIndex(db, "identity_search"))
+}
+
+func testSearchIndex(t *testing.T, db *sql.DB, index SearchIndex) {
+	a := require.New(t)
+	ctx := context.Background()
+
+	if like, ok := index.(*sqliteLikeSearch); ok {
+		a.NoError(createSearchIndex(db, like.table))
+	}
+
+	empty, err := index.Empty(ctx)
+	a.NoError(err)
+	a.True(empty)
+
+	put := func(docs ...SearchDocument) {
+		tx, err := db.Begin()
+		a.NoError(err)
+		for _, d := range docs {
+			a.NoError(index.Put(ctx, tx, d))
+		}
+		a.NoError(tx.Commit())
+	}
+
+	put(
+		SearchDocument{ID: "1", Scope: "tenant", Fields: []string{"Jane", "Doe", "jane@moov.io"}},
+		SearchDocument{ID: "2", Scope: "tenant", Fields: []string{"Janet", "Smith", "jsmith@moov.io"}},
+		SearchDocument{ID: "3", Scope: "other", Fields: []string{"Jane", "Doe", "jane@other.io"}},
+	)
+
+	results, err := index.Search(ctx, "tenant", "JANE", 10)
+	a.NoError(err)
+	a.Len(results, 2)
+	a.Equal("1", results[0].ID)
+	a.Equal("2", results[1].ID)
+
+	results, err = index.Search(ctx, "tenant", "jan smi", 10)
+	a.NoError(err)
+	a.Len(results, 1)
+	a.Equal("2", results[0].ID)
+
+	results, err = index.Search(ctx, "tenant", "jane", 1)
+	a.NoError(err)
+	a.Len(results, 1)
+
+	// Replacing the document drops the old content
+	put(SearchDocument{ID: "2", Scope: "tenant", Fields: []string{"Janet", "Jones"}})
+	results, err = index.Search(ctx, "tenant", "smith", 10)
+	a.NoError(err)
+	a.Empty(results)
+
+	tx, err := db.Begin()
+	a.NoError(err)
+	a.NoError(index.Remove(ctx, tx, "1"))
+	a.NoError(tx.Commit())
+
+	results, err = index.Search(ctx, "tenant", "doe", 10)
+	a.NoError(err)
+	a.Empty(results)
+
+	results, err = index.Search(ctx, "tenant", "!!", 10)
+	a.NoError(err)
+	a.Empty(results)
+
+	empty, err = index.Empty(ctx)
+	a.NoError(err)
+	a.False(empty)
+}
+
+func Test_Search_Cancelled(t *testing.T) {
+	a := require.New(t)
+
+	db, close, err := NewAndMigrate(InMemorySqliteConfig, nil, nil)
+	a.NoError(err)
+	defer close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	_, err = NewSearchIndex(db, "identity_search").Search(ctx, "tenant", "jane", 10)
+	a.Equal(context.Canceled, err)
+}
@@ -47,10 +47,16 @@ func (s *sqlite) Connect(ctx context.Context) (*sql.DB, error) {
 		}
 	})
 
-	db, err := sql.Open("sqlite3", s.path)
+	// SQLite leaves foreign keys unchecked unless they're turned on for the connection.
+	db, err := sql.Open("sqlite3", sqliteDSN(s.path))
 	if err != nil {
 		return nil, err
 	}
+
+	// Every connection to :memory: opens a new empty database so everyone has to share the one.
+	if s.path == ":memory:" {
+		db.SetMaxOpenConns(1)
+	}
 	if err := db.Ping(); err != nil {
 		return db, err
 	}
@@ -85,6 +91,14 @@ func sqliteConnection(logger log.Logger, path string) *sqlite {
 	}
 }
 
+// sqliteDSN - The path with foreign keys turned on, added to any options the path already has.
+func sqliteDSN(path string) string {
+	if strings.Contains(path, "?") {
+		return path + "&_foreign_keys=1"
+	}
+	return path + "?_foreign_keys=1"
+}
+
 func getSqlitePath() string {
 	path := os.Getenv("SQLITE_DB_PATH")
 	if path == "" || strings.Contains(path, "..") {
@@ -146,7 +160,7 @@ func CreateTestSqliteDB(t *testing.T) *TestSQLiteDB {
 func SqliteUniqueViolation(err error) bool {
 	match := strings.Contains(err.Error(), "UNIQUE constraint failed")
 	if e, ok := err.(sqlite3.Error); ok {
-		return match || e.Code == sqlite3.ErrConstraint
+		return match || e.ExtendedCode == sqlite3.ErrConstraintUnique || e.ExtendedCode == sqlite3.ErrConstraintPrimaryKey
 	}
 	return match
 }
@@ -6,6 +6,7 @@ import (
 	"runtime"
 	"testing"
 
+	"github.com/mattn/go-sqlite3"
 	log "github.com/moov-io/identity/pkg/logging"
 )
 
@@ -42,9 +43,41 @@ func TestSQLite__getSqlitePath(t *testing.T) {
 	}
 }
 
+func TestSQLite__DSN(t *testing.T) {
+	if v := sqliteDSN("identity.db"); v != "identity.db?_foreign_keys=1" {
+		t.Errorf("got %s", v)
+	}
+
+	if v := sqliteDSN("file:identity.db?cache=shared"); v != "file:identity.db?cache=shared&_foreign_keys=1" {
+		t.Errorf("got %s", v)
+	}
+
+	// Still enforced when the path has options of its own
+	ctx, cancelFunc := context.WithCancel(context.Background())
+	defer cancelFunc()
+
+	db, err := sqliteConnection(log.NewNopLogger(), "file::memory:?cache=shared").Connect(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	enabled := 0
+	if err := db.QueryRow("PRAGMA foreign_keys").Scan(&enabled); err != nil {
+		t.Fatal(err)
+	}
+	if enabled != 1 {
+		t.Error("foreign keys aren't enforced")
+	}
+}
+
 func TestSqliteUniqueViolation(t *testing.T) {
 	err := errors.New(`problem upserting depository="7d676c65eccd48090ff238a0d5e35eb6126c23f2", userId="80cfe1311d9eb7659d02cba9ee6cb04ed3739a85": UNIQUE constraint failed: depositories.depository_id`)
 	if !UniqueViolation(err) {
 		t.Error("should have matched unique violation")
 	}
+
+	if SqliteUniqueViolation(sqlite3.Error{Code: sqlite3.ErrConstraint, ExtendedCode: sqlite3.ErrConstraintForeignKey}) {
+		t.Error("foreign key violation shouldn't match")
+	}
 }
@@ -0,0 +1,59 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+
+	"github.com/moov-io/base/docker"
+	log "github.com/moov-io/identity/pkg/logging"
+)
+
+// TestDatabases - The databases repository tests run against. MySQL and PostgreSQL are the ones started by docker-compose.yml.
+var TestDatabases = map[string]DatabaseConfig{
+	"sqlite": InMemorySqliteConfig,
+	"mysql": {
+		DatabaseName: "identity",
+		MySql: &MySqlConfig{
+			Address:  "tcp(localhost:4306)",
+			User:     "identity",
+			Password: "identity",
+		},
+	},
+	"postgres": {
+		DatabaseName: "identity",
+		Postgres: &PostgresConfig{
+			Address:  "localhost:5432",
+			User:     "identity",
+			Password: "identity",
+			SSLMode:  "disable",
+		},
+	},
+}
+
+// ForEachDatabase - Runs the test against a migrated database of each kind. Only SQLite is used when docker isn't around.
+func ForEachDatabase(t *testing.T, run func(t *testing.T, db *sql.DB)) {
+	ForEachDatabaseConfig(t, func(t *testing.T, config DatabaseConfig, db *sql.DB) {
+		run(t, db)
+	})
+}
+
+// ForEachDatabaseConfig - ForEachDatabase along with the config of the database, for the tests running migrations on it.
+func ForEachDatabaseConfig(t *testing.T, run func(t *testing.T, config DatabaseConfig, db *sql.DB)) {
+	for name, config := range TestDatabases {
+		config := config
+		t.Run(name, func(t *testing.T) {
+			if config.SqlLite == nil && !docker.Enabled() {
+				t.SkipNow()
+			}
+
+			db, close, err := NewAndMigrate(config, log.NewNopLogger(), context.Background())
+			t.Cleanup(close)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			run(t, config, db)
+		})
+	}
+}
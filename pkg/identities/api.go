@@ -1,13 +1,17 @@
 package identities
 
 import (
-	"database/sql"
-	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
 	"net/http"
+	"strconv"
 	"strings"
 
+	validation "github.com/go-ozzo/ozzo-validation/v4"
 	"github.com/gorilla/mux"
 	api "github.com/moov-io/identity/pkg/api"
+	"github.com/moov-io/identity/pkg/apperr"
 	"github.com/moov-io/identity/pkg/client"
 	"github.com/moov-io/identity/pkg/logging"
 	tmw "github.com/moov-io/tumbler/pkg/middleware"
@@ -30,6 +34,12 @@ func NewIdentitiesController(logger logging.Logger, s Service) api.Router {
 // Routes returns all of the api route for the IdentitiesApiController
 func (c *controller) Routes() api.Routes {
 	return api.Routes{
+		{
+			Name:        "SearchIdentities",
+			Method:      strings.ToUpper("Get"),
+			Pattern:     "/identities/search",
+			HandlerFunc: c.SearchIdentities,
+		},
 		{
 			Name:        "DisableIdentity",
 			Method:      strings.ToUpper("Delete"),
@@ -54,17 +64,147 @@ func (c *controller) Routes() api.Routes {
 			Pattern:     "/identities/{identityID}",
 			HandlerFunc: c.UpdateIdentity,
 		},
+		{
+			Name:        "PatchIdentity",
+			Method:      strings.ToUpper("Patch"),
+			Pattern:     "/identities/{identityID}",
+			HandlerFunc: c.PatchIdentity,
+		},
+		{
+			Name:        "AddPhone",
+			Method:      strings.ToUpper("Post"),
+			Pattern:     "/identities/{identityID}/phones",
+			HandlerFunc: c.AddPhone,
+		},
+		{
+			Name:        "UpdatePhone",
+			Method:      strings.ToUpper("Put"),
+			Pattern:     "/identities/{identityID}/phones/{phoneID}",
+			HandlerFunc: c.UpdatePhone,
+		},
+		{
+			Name:        "RemovePhone",
+			Method:      strings.ToUpper("Delete"),
+			Pattern:     "/identities/{identityID}/phones/{phoneID}",
+			HandlerFunc: c.RemovePhone,
+		},
+		{
+			Name:        "AddAddress",
+			Method:      strings.ToUpper("Post"),
+			Pattern:     "/identities/{identityID}/addresses",
+			HandlerFunc: c.AddAddress,
+		},
+		{
+			Name:        "UpdateAddress",
+			Method:      strings.ToUpper("Put"),
+			Pattern:     "/identities/{identityID}/addresses/{addressID}",
+			HandlerFunc: c.UpdateAddress,
+		},
+		{
+			Name:        "RemoveAddress",
+			Method:      strings.ToUpper("Delete"),
+			Pattern:     "/identities/{identityID}/addresses/{addressID}",
+			HandlerFunc: c.RemoveAddress,
+		},
+		{
+			Name:        "MergeIdentities",
+			Method:      strings.ToUpper("Post"),
+			Pattern:     "/identities/{survivorID}/merge",
+			HandlerFunc: c.MergeIdentities,
+		},
+		{
+			Name:        "ActivateIdentity",
+			Method:      strings.ToUpper("Post"),
+			Pattern:     "/identities/{identityID}/activate",
+			HandlerFunc: c.ChangeStatus(StatusActive),
+		},
+		{
+			Name:        "SuspendIdentity",
+			Method:      strings.ToUpper("Post"),
+			Pattern:     "/identities/{identityID}/suspend",
+			HandlerFunc: c.ChangeStatus(StatusSuspended),
+		},
+		{
+			Name:        "LockIdentity",
+			Method:      strings.ToUpper("Post"),
+			Pattern:     "/identities/{identityID}/lock",
+			HandlerFunc: c.ChangeStatus(StatusLocked),
+		},
+		{
+			Name:        "DisableIdentityWithReason",
+			Method:      strings.ToUpper("Post"),
+			Pattern:     "/identities/{identityID}/disable",
+			HandlerFunc: c.ChangeStatus(StatusDisabled),
+		},
+		{
+			Name:        "DeleteIdentity",
+			Method:      strings.ToUpper("Post"),
+			Pattern:     "/identities/{identityID}/delete",
+			HandlerFunc: c.ChangeStatus(StatusDeleted),
+		},
+		{
+			Name:        "ListIdentityStatusHistory",
+			Method:      strings.ToUpper("Get"),
+			Pattern:     "/identities/{identityID}/status-history",
+			HandlerFunc: c.ListStatusHistory,
+		},
+		{
+			Name:        "ListIdentityHistory",
+			Method:      strings.ToUpper("Get"),
+			Pattern:     "/identities/{identityID}/history",
+			HandlerFunc: c.ListHistory,
+		},
+		{
+			Name:        "RequestEmailChange",
+			Method:      strings.ToUpper("Post"),
+			Pattern:     "/identities/{identityID}/email",
+			HandlerFunc: c.RequestEmailChange,
+		},
+		{
+			Name:        "ConfirmEmailChange",
+			Method:      strings.ToUpper("Post"),
+			Pattern:     "/identities/{identityID}/email/confirm",
+			HandlerFunc: c.ConfirmEmailChange,
+		},
+		{
+			Name:        "ListAttributeDefinitions",
+			Method:      strings.ToUpper("Get"),
+			Pattern:     "/attributes",
+			HandlerFunc: c.ListAttributeDefinitions,
+		},
+		{
+			Name:        "SaveAttributeDefinition",
+			Method:      strings.ToUpper("Put"),
+			Pattern:     "/attributes/{name}",
+			HandlerFunc: c.SaveAttributeDefinition,
+		},
+		{
+			Name:        "DeleteAttributeDefinition",
+			Method:      strings.ToUpper("Delete"),
+			Pattern:     "/attributes/{name}",
+			HandlerFunc: c.DeleteAttributeDefinition,
+		},
 	}
 }
 
-func errorHandling(w http.ResponseWriter, err error) {
-	switch err {
-	case sql.ErrNoRows:
-		w.WriteHeader(404)
-	default:
-		w.WriteHeader(500)
-		return
+func etag(version int64) string {
+	return fmt.Sprintf(`"%d"`, version)
+}
+
+// ifMatchVersion - Pulls the version out of the ETag sent back in the If-Match header.
+func ifMatchVersion(r *http.Request) (int64, error) {
+	ifMatch := strings.TrimPrefix(strings.TrimSpace(r.Header.Get("If-Match")), "W/")
+	if ifMatch == "" || ifMatch == "*" {
+		return 0, ErrVersionRequired
+	}
+
+	version, err := strconv.ParseInt(strings.Trim(ifMatch, `"`), 10, 64)
+	if err != nil {
+		// Not an ETag we handed out so it can't match.
+		return 0, ErrVersionConflict
 	}
+
+	return version, nil
 }
 
 // DisableIdentity - Disable an identity. Its left around for historical reporting
@@ -72,9 +212,9 @@ func (c *controller) DisableIdentity(w http.ResponseWriter, r *http.Request) {
 	tmw.WithClaimsFromRequest(w, r, func(claims tmw.TumblerClaims) {
 		params := mux.Vars(r)
 		identityID := params["identityID"]
-		err := c.service.DisableIdentity(claims, identityID)
+		err := c.service.DisableIdentity(r.Context(), claims, identityID)
 		if err != nil {
-			errorHandling(w, err)
+			api.EncodeError(w, err)
 			return
 		}
 
@@ -87,12 +227,13 @@ func (c *controller) GetIdentity(w http.ResponseWriter, r *http.Request) {
 	tmw.WithClaimsFromRequest(w, r, func(claims tmw.TumblerClaims) {
 		params := mux.Vars(r)
 		identityID := params["identityID"]
-		result, err := c.service.GetIdentity(claims, identityID)
+		result, err := c.service.GetIdentity(r.Context(), claims, identityID)
 		if err != nil {
-			errorHandling(w, err)
+			api.EncodeError(w, err)
 			return
 		}
 
+		w.Header().Set("ETag", etag(result.Version))
 		api.EncodeJSONResponse(result, nil, w)
 	})
 }
@@ -100,9 +241,33 @@ func (c *controller) GetIdentity(w http.ResponseWriter, r *http.Request) {
 // ListIdentities - List identities and associates userId
 func (c *controller) ListIdentities(w http.ResponseWriter, r *http.Request) {
 	tmw.WithClaimsFromRequest(w, r, func(claims tmw.TumblerClaims) {
-		result, err := c.service.ListIdentities(claims)
+		result, err := c.service.ListIdentities(r.Context(), claims, queryAttributes(r))
+		if err != nil {
+			api.EncodeError(w, err)
+			return
+		}
+
+		api.EncodeJSONResponse(result, nil, w)
+	})
+}
+
+// SearchIdentities - Search the identities of the tenant, most relevant first
+func (c *controller) SearchIdentities(w http.ResponseWriter, r *http.Request) {
+	tmw.WithClaimsFromRequest(w, r, func(claims tmw.TumblerClaims) {
+		query := r.URL.Query()
+
+		limit := 0
+		if l := query.Get("limit"); l != "" {
+			var err error
+			if limit, err = strconv.Atoi(l); err != nil {
+				api.EncodeError(w, validation.Errors{"limit": errors.New("must be a number")})
+				return
+			}
+		}
+
+		result, err := c.service.SearchIdentities(r.Context(), claims, query.Get("q"), limit)
 		if err != nil {
-			errorHandling(w, err)
+			api.EncodeError(w, c.logger.LogError("unable to search identities", err))
 			return
 		}
 
@@ -110,24 +275,375 @@ func (c *controller) ListIdentities(w http.ResponseWriter, r *http.Request) {
 	})
 }
 
+// queryAttributes - Pulls the attribute values to filter on out of the query, ie. `?attributes[department]=sales`
+func queryAttributes(r *http.Request) map[string]string {
+	filters := map[string]string{}
+	for key, values := range r.URL.Query() {
+		if strings.HasPrefix(key, "attributes[") && strings.HasSuffix(key, "]") && len(values) > 0 {
+			filters[key[len("attributes["):len(key)-1]] = values[0]
+		}
+	}
+	return filters
+}
+
 // UpdateIdentity - UpdateInsecure a specific Identity
 func (c *controller) UpdateIdentity(w http.ResponseWriter, r *http.Request) {
 	tmw.WithClaimsFromRequest(w, r, func(claims tmw.TumblerClaims) {
 		params := mux.Vars(r)
 		identityID := params["identityID"]
 
+		version, err := ifMatchVersion(r)
+		if err != nil {
+			api.EncodeError(w, err)
+			return
+		}
+
 		identity := &client.UpdateIdentity{}
-		if err := json.NewDecoder(r.Body).Decode(&identity); err != nil {
-			w.WriteHeader(400)
+		if err := api.DecodeJSONRequest(r, &identity); err != nil {
+			api.EncodeError(w, err)
+			return
+		}
+
+		result, err := c.service.UpdateIdentity(r.Context(), claims, identityID, version, *identity)
+		if err != nil {
+			api.EncodeError(w, c.logger.LogError("unable to update identity", err))
+			return
+		}
+
+		w.Header().Set("ETag", etag(result.Version))
+		api.EncodeJSONResponse(result, nil, w)
+	})
+}
+
+// PatchIdentity - Applies a JSON merge patch to a specific Identity
+func (c *controller) PatchIdentity(w http.ResponseWriter, r *http.Request) {
+	tmw.WithClaimsFromRequest(w, r, func(claims tmw.TumblerClaims) {
+		params := mux.Vars(r)
+		identityID := params["identityID"]
+
+		contentType := strings.TrimSpace(strings.Split(r.Header.Get("Content-Type"), ";")[0])
+		if contentType != "application/merge-patch+json" && contentType != "application/json" {
+			api.EncodeError(w, apperr.UnsupportedMediaType("patches must be sent as application/merge-patch+json"))
+			return
+		}
+
+		version, err := ifMatchVersion(r)
+		if err != nil {
+			api.EncodeError(w, err)
+			return
+		}
+
+		patch, err := ioutil.ReadAll(r.Body)
+		if err != nil {
+			api.EncodeError(w, apperr.Validation("unable to read the patch", nil))
+			return
+		}
+
+		result, err := c.service.PatchIdentity(r.Context(), claims, identityID, version, patch)
+		if err != nil {
+			api.EncodeError(w, c.logger.LogError("unable to patch identity", err))
+			return
+		}
+
+		w.Header().Set("ETag", etag(result.Version))
+		api.EncodeJSONResponse(result, nil, w)
+	})
+}
+
+// AddPhone - Adds a phone number to an Identity
+func (c *controller) AddPhone(w http.ResponseWriter, r *http.Request) {
+	tmw.WithClaimsFromRequest(w, r, func(claims tmw.TumblerClaims) {
+		params := mux.Vars(r)
+		identityID := params["identityID"]
+
+		phone := client.UpdatePhone{}
+		if err := api.DecodeJSONRequest(r, &phone); err != nil {
+			api.EncodeError(w, err)
+			return
+		}
+
+		result, err := c.service.AddPhone(r.Context(), claims, identityID, phone)
+		if err != nil {
+			api.EncodeError(w, c.logger.LogError("unable to add phone", err))
+			return
+		}
+
+		status := http.StatusCreated
+		api.EncodeJSONResponse(result, &status, w)
+	})
+}
+
+// UpdatePhone - Replaces a phone number of an Identity
+func (c *controller) UpdatePhone(w http.ResponseWriter, r *http.Request) {
+	tmw.WithClaimsFromRequest(w, r, func(claims tmw.TumblerClaims) {
+		params := mux.Vars(r)
+		identityID := params["identityID"]
+		phoneID := params["phoneID"]
+
+		phone := client.UpdatePhone{}
+		if err := api.DecodeJSONRequest(r, &phone); err != nil {
+			api.EncodeError(w, err)
+			return
+		}
+
+		result, err := c.service.UpdatePhone(r.Context(), claims, identityID, phoneID, phone)
+		if err != nil {
+			api.EncodeError(w, c.logger.LogError("unable to update phone", err))
+			return
+		}
+
+		api.EncodeJSONResponse(result, nil, w)
+	})
+}
+
+// RemovePhone - Removes a phone number from an Identity
+func (c *controller) RemovePhone(w http.ResponseWriter, r *http.Request) {
+	tmw.WithClaimsFromRequest(w, r, func(claims tmw.TumblerClaims) {
+		params := mux.Vars(r)
+		identityID := params["identityID"]
+		phoneID := params["phoneID"]
+
+		if err := c.service.RemovePhone(r.Context(), claims, identityID, phoneID); err != nil {
+			api.EncodeError(w, c.logger.LogError("unable to remove phone", err))
+			return
+		}
+
+		w.WriteHeader(204)
+	})
+}
+
+// AddAddress - Adds an address to an Identity
+func (c *controller) AddAddress(w http.ResponseWriter, r *http.Request) {
+	tmw.WithClaimsFromRequest(w, r, func(claims tmw.TumblerClaims) {
+		params := mux.Vars(r)
+		identityID := params["identityID"]
+
+		address := client.UpdateAddress{}
+		if err := api.DecodeJSONRequest(r, &address); err != nil {
+			api.EncodeError(w, err)
+			return
+		}
+
+		result, err := c.service.AddAddress(r.Context(), claims, identityID, address)
+		if err != nil {
+			api.EncodeError(w, c.logger.LogError("unable to add address", err))
+			return
+		}
+
+		status := http.StatusCreated
+		api.EncodeJSONResponse(result, &status, w)
+	})
+}
+
+// UpdateAddress - Replaces an address of an Identity
+func (c *controller) UpdateAddress(w http.ResponseWriter, r *http.Request) {
+	tmw.WithClaimsFromRequest(w, r, func(claims tmw.TumblerClaims) {
+		params := mux.Vars(r)
+		identityID := params["identityID"]
+		addressID := params["addressID"]
+
+		address := client.UpdateAddress{}
+		if err := api.DecodeJSONRequest(r, &address); err != nil {
+			api.EncodeError(w, err)
+			return
+		}
+
+		result, err := c.service.UpdateAddress(r.Context(), claims, identityID, addressID, address)
+		if err != nil {
+			api.EncodeError(w, c.logger.LogError("unable to update address", err))
+			return
+		}
+
+		api.EncodeJSONResponse(result, nil, w)
+	})
+}
+
+// RemoveAddress - Removes an address from an Identity
+func (c *controller) RemoveAddress(w http.ResponseWriter, r *http.Request) {
+	tmw.WithClaimsFromRequest(w, r, func(claims tmw.TumblerClaims) {
+		params := mux.Vars(r)
+		identityID := params["identityID"]
+		addressID := params["addressID"]
+
+		if err := c.service.RemoveAddress(r.Context(), claims, identityID, addressID); err != nil {
+			api.EncodeError(w, c.logger.LogError("unable to remove address", err))
+			return
+		}
+
+		w.WriteHeader(204)
+	})
+}
+
+// MergeIdentities - Merges a duplicate identity into the survivor and reports what was moved
+func (c *controller) MergeIdentities(w http.ResponseWriter, r *http.Request) {
+	tmw.WithClaimsFromRequest(w, r, func(claims tmw.TumblerClaims) {
+		params := mux.Vars(r)
+		survivorID := params["survivorID"]
+
+		merge := client.MergeIdentities{}
+		if err := api.DecodeJSONRequest(r, &merge); err != nil {
+			api.EncodeError(w, err)
+			return
+		}
+
+		result, err := c.service.MergeIdentities(r.Context(), claims, survivorID, merge)
+		if err != nil {
+			api.EncodeError(w, c.logger.LogError("unable to merge identities", err))
 			return
 		}
 
-		result, err := c.service.UpdateIdentity(claims, identityID, *identity)
+		api.EncodeJSONResponse(result, nil, w)
+	})
+}
+
+// ChangeStatus - Moves an identity to the status if its allowed from its current one
+func (c *controller) ChangeStatus(status string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		tmw.WithClaimsFromRequest(w, r, func(claims tmw.TumblerClaims) {
+			params := mux.Vars(r)
+			identityID := params["identityID"]
+
+			change := client.ChangeStatus{}
+			if err := api.DecodeJSONRequest(r, &change); err != nil {
+				api.EncodeError(w, err)
+				return
+			}
+
+			result, err := c.service.ChangeStatus(r.Context(), claims, identityID, status, change, nil)
+			if err != nil {
+				api.EncodeError(w, c.logger.LogError("unable to change identity status to "+status, err))
+				return
+			}
+
+			w.Header().Set("ETag", etag(result.Version))
+			api.EncodeJSONResponse(result, nil, w)
+		})
+	}
+}
+
+// ListStatusHistory - Lists every status the identity moved through
+func (c *controller) ListStatusHistory(w http.ResponseWriter, r *http.Request) {
+	tmw.WithClaimsFromRequest(w, r, func(claims tmw.TumblerClaims) {
+		params := mux.Vars(r)
+		identityID := params["identityID"]
+
+		result, err := c.service.ListStatusHistory(r.Context(), claims, identityID)
 		if err != nil {
-			errorHandling(w, c.logger.LogError("unable to update identity", err))
+			api.EncodeError(w, c.logger.LogError("unable to list identity status history", err))
 			return
 		}
 
 		api.EncodeJSONResponse(result, nil, w)
 	})
 }
+
+// ListHistory - Lists every update made to the identity and the fields it changed
+func (c *controller) ListHistory(w http.ResponseWriter, r *http.Request) {
+	tmw.WithClaimsFromRequest(w, r, func(claims tmw.TumblerClaims) {
+		params := mux.Vars(r)
+		identityID := params["identityID"]
+
+		result, err := c.service.ListHistory(r.Context(), claims, identityID)
+		if err != nil {
+			api.EncodeError(w, c.logger.LogError("unable to list identity history", err))
+			return
+		}
+
+		api.EncodeJSONResponse(result, nil, w)
+	})
+}
+
+// RequestEmailChange - Sends a confirmation link to the new email, the email isn't changed until its confirmed
+func (c *controller) RequestEmailChange(w http.ResponseWriter, r *http.Request) {
+	tmw.WithClaimsFromRequest(w, r, func(claims tmw.TumblerClaims) {
+		params := mux.Vars(r)
+		identityID := params["identityID"]
+
+		change := client.ChangeEmail{}
+		if err := api.DecodeJSONRequest(r, &change); err != nil {
+			api.EncodeError(w, err)
+			return
+		}
+
+		result, err := c.service.RequestEmailChange(r.Context(), claims, identityID, change)
+		if err != nil {
+			api.EncodeError(w, c.logger.LogError("unable to request email change", err))
+			return
+		}
+
+		status := http.StatusAccepted
+		api.EncodeJSONResponse(result, &status, w)
+	})
+}
+
+// ConfirmEmailChange - Swaps in the new email with the code that was sent to it
+func (c *controller) ConfirmEmailChange(w http.ResponseWriter, r *http.Request) {
+	tmw.WithClaimsFromRequest(w, r, func(claims tmw.TumblerClaims) {
+		params := mux.Vars(r)
+		identityID := params["identityID"]
+
+		confirm := client.ConfirmEmailChange{}
+		if err := api.DecodeJSONRequest(r, &confirm); err != nil {
+			api.EncodeError(w, err)
+			return
+		}
+
+		result, err := c.service.ConfirmEmailChange(r.Context(), claims, identityID, confirm)
+		if err != nil {
+			api.EncodeError(w, c.logger.LogError("unable to confirm email change", err))
+			return
+		}
+
+		w.Header().Set("ETag", etag(result.Version))
+		api.EncodeJSONResponse(result, nil, w)
+	})
+}
+
+// ListAttributeDefinitions - Lists the custom attributes of the tenant
+func (c *controller) ListAttributeDefinitions(w http.ResponseWriter, r *http.Request) {
+	tmw.WithClaimsFromRequest(w, r, func(claims tmw.TumblerClaims) {
+		result, err := c.service.ListAttributeDefinitions(r.Context(), claims)
+		if err != nil {
+			api.EncodeError(w, c.logger.LogError("unable to list attribute definitions", err))
+			return
+		}
+
+		api.EncodeJSONResponse(result, nil, w)
+	})
+}
+
+// SaveAttributeDefinition - Creates or updates a custom attribute of the tenant
+func (c *controller) SaveAttributeDefinition(w http.ResponseWriter, r *http.Request) {
+	tmw.WithClaimsFromRequest(w, r, func(claims tmw.TumblerClaims) {
+		params := mux.Vars(r)
+
+		definition := client.AttributeDefinition{}
+		if err := api.DecodeJSONRequest(r, &definition); err != nil {
+			api.EncodeError(w, err)
+			return
+		}
+		definition.Name = params["name"]
+
+		result, err := c.service.SaveAttributeDefinition(r.Context(), claims, definition)
+		if err != nil {
+			api.EncodeError(w, c.logger.LogError("unable to save attribute definition", err))
+			return
+		}
+
+		api.EncodeJSONResponse(result, nil, w)
+	})
+}
+
+// DeleteAttributeDefinition - Removes a custom attribute of the tenant and its values from the identities
+func (c *controller) DeleteAttributeDefinition(w http.ResponseWriter, r *http.Request) {
+	tmw.WithClaimsFromRequest(w, r, func(claims tmw.TumblerClaims) {
+		params := mux.Vars(r)
+
+		if err := c.service.DeleteAttributeDefinition(r.Context(), claims, params["name"]); err != nil {
+			api.EncodeError(w, c.logger.LogError("unable to delete attribute definition", err))
+			return
+		}
+
+		w.WriteHeader(http.StatusNoContent)
+	})
+}
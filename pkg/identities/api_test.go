@@ -9,6 +9,7 @@ import (
 	fuzz "github.com/google/gofuzz"
 	"github.com/google/uuid"
 	"github.com/moov-io/identity/pkg/client"
+	. "github.com/moov-io/identity/pkg/identities"
 )
 
 func Test_Register(t *testing.T) {
@@ -27,7 +28,7 @@ func Test_Register(t *testing.T) {
 	r.Addresses = make([]client.RegisterAddress, 1)
 	f.Fuzz(&r.Addresses[0])
 
-	i, err := s.service.Register(r, &invite)
+	i, err := s.service.Register(context.Background(), r, &invite, StatusActive, nil)
 	a.Nil(err)
 
 	a.Equal(invite.TenantID, i.TenantID)
@@ -82,6 +83,28 @@ func Test_GetAPI_NotFound(t *testing.T) {
 	a.Equal(404, resp.StatusCode)
 }
 
+func Test_GetAPI_OtherTenant(t *testing.T) {
+	a, s, f := Setup(t)
+
+	invite := s.RandomInvite()
+	invite.TenantID = uuid.New().String()
+
+	register := client.Register{}
+	f.Fuzz(&register)
+	other, err := s.service.Register(context.Background(), register, &invite, StatusActive, nil)
+	a.Nil(err)
+	a.Equal(invite.TenantID, other.TenantID)
+
+	// Same as if it didn't exist at all
+	_, resp, _ := s.api.IdentitiesApi.GetIdentity(context.Background(), other.IdentityID)
+	a.Equal(404, resp.StatusCode)
+
+	updates := client.UpdateIdentity{}
+	f.Fuzz(&updates)
+	_, resp, _ = s.api.IdentitiesApi.UpdateIdentity(context.Background(), other.IdentityID, ETag(*other), updates)
+	a.Equal(404, resp.StatusCode)
+}
+
 func Test_ListAPI(t *testing.T) {
 	a, s, f := Setup(t)
 
@@ -126,6 +149,7 @@ func Test_UpdateAPI(t *testing.T) {
 	updated, resp, err := s.api.IdentitiesApi.UpdateIdentity(
 		context.Background(),
 		identity.IdentityID,
+		ETag(identity),
 		updates,
 	)
 
@@ -141,6 +165,7 @@ func Test_UpdateAPI(t *testing.T) {
 	a.Equal(identity.DisabledOn, updated.DisabledOn)
 	a.Equal(identity.DisabledBy, updated.DisabledBy)
 	a.Equal(identity.InviteID, updated.InviteID)
+	a.Equal(identity.Status, updated.Status)
 
 	// These change
 	a.Equal(updated.LastUpdatedOn, s.time.Now())
@@ -151,7 +176,6 @@ func Test_UpdateAPI(t *testing.T) {
 	a.Equal(updates.NickName, updated.NickName)
 	a.Equal(updates.Suffix, updated.Suffix)
 	a.Equal(updates.BirthDate, updated.BirthDate)
-	a.Equal(updates.Status, updated.Status)
 
 	a.Len(updated.Phones, len(updates.Phones))
 	for idx := range updated.Phones {
@@ -198,6 +222,7 @@ func Test_UpdateAPI_NotFound(t *testing.T) {
 	_, resp, _ := s.api.IdentitiesApi.UpdateIdentity(
 		context.Background(),
 		uuid.New().String(),
+		`"1"`,
 		updates,
 	)
 
@@ -235,7 +260,7 @@ func RegisterIdentity(s Scope, f *fuzz.Fuzzer) client.Identity {
 	register := client.Register{}
 	f.Fuzz(&register)
 
-	identity, err := s.service.Register(register, &invite)
+	identity, err := s.service.Register(context.Background(), register, &invite, StatusActive, nil)
 	if err != nil {
 		fmt.Printf("%+v\n", err)
 		panic(err)
@@ -258,6 +283,7 @@ func Test_UpdateAPI_DoB_AllowDateFormat(t *testing.T) {
 	updated, resp, err := s.api.IdentitiesApi.UpdateIdentity(
 		context.Background(),
 		identity.IdentityID,
+		ETag(identity),
 		updates,
 	)
 
@@ -278,7 +304,7 @@ func Test_Register_DoB_AllowDateFormat(t *testing.T) {
 	dob := "1967-12-31"
 	register.BirthDate = &dob
 
-	identity, err := s.service.Register(register, &invite)
+	identity, err := s.service.Register(context.Background(), register, &invite, StatusActive, nil)
 	if err != nil {
 		fmt.Printf("%+v\n", err)
 		panic(err)
@@ -300,7 +326,7 @@ func Test_Register_DoB_AllowYearFormat(t *testing.T) {
 	dob := "1967"
 	register.BirthDate = &dob
 
-	identity, err := s.service.Register(register, &invite)
+	identity, err := s.service.Register(context.Background(), register, &invite, StatusActive, nil)
 	if err != nil {
 		fmt.Printf("%+v\n", err)
 		panic(err)
@@ -309,3 +335,75 @@ func Test_Register_DoB_AllowYearFormat(t *testing.T) {
 
 	a.Nil(identity.BirthDate)
 }
+
+func Test_GetAPI_ETag(t *testing.T) {
+	a, s, f := Setup(t)
+
+	identity := RegisterIdentity(s, f)
+	a.Equal(int64(1), identity.Version)
+
+	_, resp, err := s.api.IdentitiesApi.GetIdentity(context.Background(), identity.IdentityID)
+	a.Nil(err)
+	a.Equal(`"1"`, resp.Header.Get("ETag"))
+}
+
+func Test_UpdateAPI_IfMatchRequired(t *testing.T) {
+	a, s, f := Setup(t)
+
+	identity := RegisterIdentity(s, f)
+
+	updates := client.UpdateIdentity{}
+	f.Fuzz(&updates)
+
+	_, resp, _ := s.api.IdentitiesApi.UpdateIdentity(context.Background(), identity.IdentityID, "", updates)
+	a.Equal(428, resp.StatusCode)
+
+	_, resp, _ = s.api.IdentitiesApi.UpdateIdentity(context.Background(), identity.IdentityID, "*", updates)
+	a.Equal(428, resp.StatusCode)
+}
+
+func Test_UpdateAPI_VersionConflict(t *testing.T) {
+	a, s, f := Setup(t)
+
+	identity := RegisterIdentity(s, f)
+
+	updates := client.UpdateIdentity{}
+	f.Fuzz(&updates)
+
+	updated, resp, err := s.api.IdentitiesApi.UpdateIdentity(context.Background(), identity.IdentityID, ETag(identity), updates)
+	a.Nil(err)
+	a.Equal(200, resp.StatusCode)
+	a.Equal(identity.Version+1, updated.Version)
+	a.Equal(ETag(updated), resp.Header.Get("ETag"))
+
+	// second admin still working off of the original
+	f.Fuzz(&updates)
+	_, resp, _ = s.api.IdentitiesApi.UpdateIdentity(context.Background(), identity.IdentityID, ETag(identity), updates)
+	a.Equal(412, resp.StatusCode)
+
+	_, resp, _ = s.api.IdentitiesApi.UpdateIdentity(context.Background(), identity.IdentityID, `"garbage"`, updates)
+	a.Equal(412, resp.StatusCode)
+
+	// weak ETags are accepted
+	_, resp, err = s.api.IdentitiesApi.UpdateIdentity(context.Background(), identity.IdentityID, "W/"+ETag(updated), updates)
+	a.Nil(err)
+	a.Equal(200, resp.StatusCode)
+}
+
+func Test_UpdateInsecure_Stale(t *testing.T) {
+	a, s, f := Setup(t)
+
+	identity := RegisterIdentity(s, f)
+	stale := identity
+
+	nickName := "first"
+	identity.NickName = &nickName
+	_, err := s.service.UpdateInsecure(context.Background(), &identity)
+	a.Nil(err)
+
+	// checked in the update itself so a read that raced the first update is still caught
+	nickName = "second"
+	stale.NickName = &nickName
+	_, err = s.service.UpdateInsecure(context.Background(), &stale)
+	a.Equal(ErrVersionConflict, err)
+}
@@ -0,0 +1,54 @@
+package identities
+
+import "github.com/moov-io/identity/pkg/apperr"
+
+// ErrMergeSameIdentity is issued when an identity is asked to be merged into itself.
+var ErrMergeSameIdentity = apperr.Validation("identity can't be merged into itself", nil)
+
+// ErrIdentityAlreadyMerged is issued when either side of a merge was already merged into another identity.
+var ErrIdentityAlreadyMerged = apperr.Conflict("identity was already merged")
+
+// ErrMergeIntoDisabled is issued when the survivor of a merge is disabled.
+var ErrMergeIntoDisabled = apperr.Conflict("can't merge into a disabled identity")
+
+// ErrInvalidStatusTransition is issued when an identity can't move from its current status to the one requested.
+var ErrInvalidStatusTransition = apperr.Conflict("identity can't move to that status")
+
+// ErrIdentityNotActive is issued when an identity tries to login while it isn't active.
+var ErrIdentityNotActive = apperr.Forbidden("identity is not active")
+
+// ErrVersionConflict is issued when the identity was changed by someone else since the version being updated was read.
+var ErrVersionConflict = apperr.PreconditionFailed("identity was changed since it was retrieved")
+
+// ErrVersionRequired is issued when an update doesn't say which version of the identity it was made against.
+var ErrVersionRequired = apperr.PreconditionRequired("version of the identity being updated is required")
+
+// ErrInvalidPatch is issued when a merge patch isn't valid JSON or touches fields that can't be updated.
+var ErrInvalidPatch = apperr.Validation("invalid merge patch", nil)
+
+// ErrIdentityNotFound is issued when the identity belongs to another tenant, so its not given away that it exists.
+var ErrIdentityNotFound = apperr.NotFound("identity not found")
+
+// ErrPhoneNotFound is issued when the phone doesn't belong to the identity.
+var ErrPhoneNotFound = apperr.NotFound("phone not found")
+
+// ErrAddressNotFound is issued when the address doesn't belong to the identity.
+var ErrAddressNotFound = apperr.NotFound("address not found")
+
+// ErrEmailUnchanged is issued when an identity asks to change its email to the one it already has.
+var ErrEmailUnchanged = apperr.Validation("email is the same as the current one", nil)
+
+// ErrEmailTaken is issued when another identity of the tenant already has the email.
+var ErrEmailTaken = apperr.Conflict("email is already used by another identity")
+
+// ErrEmailChangeNotFound is issued when the confirmation code doesn't match an outstanding email change of the identity.
+var ErrEmailChangeNotFound = apperr.NotFound("email change not found")
+
+// ErrEmailChangeExpired is issued when the email change is confirmed after its code expired.
+var ErrEmailChangeExpired = apperr.Expired("email change code is expired")
+
+// ErrAttributeNotFound is issued when the tenant doesn't have a definition for the attribute.
+var ErrAttributeNotFound = apperr.NotFound("attribute not found")
+
+// ErrAttributeTypeChange is issued when an attribute definition is saved with a different type than it already has.
+var ErrAttributeTypeChange = apperr.Conflict("type of an attribute can't be changed")
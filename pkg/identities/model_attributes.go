@@ -0,0 +1,117 @@
+package identities
+
+import (
+	"errors"
+	"strconv"
+	"time"
+
+	"github.com/moov-io/identity/pkg/client"
+)
+
+// AttributeString - Any text up to 255 characters.
+const AttributeString = "string"
+
+// AttributeNumber - A JSON number.
+const AttributeNumber = "number"
+
+// AttributeBool - A JSON true or false.
+const AttributeBool = "bool"
+
+// AttributeDate - A date formatted as YYYY-MM-DD.
+const AttributeDate = "date"
+
+// AttributeEnum - One of the values listed on the definition.
+const AttributeEnum = "enum"
+
+const attributeDateFormat = "2006-01-02"
+
+// checkAttribute - Makes sure the value matches the type of the definition and returns it in its normalized form.
+func checkAttribute(definition client.AttributeDefinition, value interface{}) (interface{}, error) {
+	switch definition.Type {
+	case AttributeString:
+		s, ok := value.(string)
+		if !ok {
+			return nil, errors.New("must be a string")
+		}
+		if len(s) > 255 {
+			return nil, errors.New("must be no more than 255 characters")
+		}
+		return s, nil
+
+	case AttributeNumber:
+		switch n := value.(type) {
+		case float64:
+			return n, nil
+		case int:
+			return float64(n), nil
+		case int64:
+			return float64(n), nil
+		}
+		return nil, errors.New("must be a number")
+
+	case AttributeBool:
+		b, ok := value.(bool)
+		if !ok {
+			return nil, errors.New("must be true or false")
+		}
+		return b, nil
+
+	case AttributeDate:
+		s, ok := value.(string)
+		if !ok {
+			return nil, errors.New("must be a date formatted as YYYY-MM-DD")
+		}
+		d, err := time.Parse(attributeDateFormat, s)
+		if err != nil {
+			return nil, errors.New("must be a date formatted as YYYY-MM-DD")
+		}
+		return d.Format(attributeDateFormat), nil
+
+	case AttributeEnum:
+		s, ok := value.(string)
+		if ok {
+			for _, e := range definition.EnumValues {
+				if e == s {
+					return s, nil
+				}
+			}
+		}
+		return nil, errors.New("must be one of the values of the attribute")
+	}
+
+	return nil, errors.New("attribute has an unknown type")
+}
+
+// parseAttribute - Turns the stored or queried text of an attribute back into its value.
+// Doesn't check it against the definition so values saved before a definition changed can still be read.
+func parseAttribute(definition client.AttributeDefinition, text string) (interface{}, error) {
+	switch definition.Type {
+	case AttributeNumber:
+		n, err := strconv.ParseFloat(text, 64)
+		if err != nil {
+			return nil, errors.New("must be a number")
+		}
+		return n, nil
+	case AttributeBool:
+		b, err := strconv.ParseBool(text)
+		if err != nil {
+			return nil, errors.New("must be true or false")
+		}
+		return b, nil
+	}
+
+	return text, nil
+}
+
+// formatAttribute - Text an attribute value is stored and compared as.
+func formatAttribute(value interface{}) string {
+	switch v := value.(type) {
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case bool:
+		return strconv.FormatBool(v)
+	case string:
+		return v
+	}
+	return ""
+}
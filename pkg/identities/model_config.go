@@ -0,0 +1,15 @@
+package identities
+
+import (
+	"time"
+)
+
+// Config holds the configuration for the Identities package
+type Config struct {
+	// How long the code sent to confirm a change of email can be used for.
+	EmailChangeExpiration time.Duration
+
+	// URL sent to the new address to confirm a change of email. {{.TenantID}} is replaced with the tenant of the identity
+	// and the code is added as the `confirm_code` query parameter.
+	EmailChangeURL string
+}
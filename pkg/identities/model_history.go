@@ -0,0 +1,201 @@
+package identities
+
+import (
+	"sort"
+	"strconv"
+
+	"github.com/moov-io/identity/pkg/client"
+)
+
+// securityFields - Changes to these fields get the identity an email so they can tell if it wasn't them.
+var securityFields = map[string]bool{
+	"email":      true,
+	"firstName":  true,
+	"middleName": true,
+	"lastName":   true,
+	"suffix":     true,
+	"birthDate":  true,
+}
+
+type field struct {
+	name  string
+	value *string
+}
+
+// DiffIdentity - Lists every field that differs between the two versions of the identity.
+// Phones and addresses are matched up by their ID so adds and removes show up on their own.
+func DiffIdentity(before client.Identity, after client.Identity) []client.FieldChange {
+	changes := diffFields("", identityFields(before), identityFields(after))
+	changes = append(changes, diffFields("attributes.", attributeFields(before, after), attributeFields(after, before))...)
+
+	for _, a := range after.Phones {
+		var b []field
+		if p := phoneByID(before.Phones, a.PhoneID); p != nil {
+			b = phoneFields(*p)
+		}
+		changes = append(changes, diffFields("phones."+a.PhoneID+".", b, phoneFields(a))...)
+	}
+	for _, b := range before.Phones {
+		if phoneByID(after.Phones, b.PhoneID) == nil {
+			changes = append(changes, diffFields("phones."+b.PhoneID+".", phoneFields(b), nil)...)
+		}
+	}
+
+	for _, a := range after.Addresses {
+		var b []field
+		if p := addressByID(before.Addresses, a.AddressID); p != nil {
+			b = addressFields(*p)
+		}
+		changes = append(changes, diffFields("addresses."+a.AddressID+".", b, addressFields(a))...)
+	}
+	for _, b := range before.Addresses {
+		if addressByID(after.Addresses, b.AddressID) == nil {
+			changes = append(changes, diffFields("addresses."+b.AddressID+".", addressFields(b), nil)...)
+		}
+	}
+
+	return changes
+}
+
+// SecurityChanges - Filters the changes down to the ones the identity should be told about.
+func SecurityChanges(changes []client.FieldChange) []client.FieldChange {
+	security := []client.FieldChange{}
+	for _, c := range changes {
+		if securityFields[c.Field] {
+			security = append(security, c)
+		}
+	}
+	return security
+}
+
+// diffFields - Either side can be nil when the phone or address didn't exist on that side.
+func diffFields(prefix string, before []field, after []field) []client.FieldChange {
+	fields := after
+	if fields == nil {
+		fields = before
+	}
+
+	changes := []client.FieldChange{}
+	for i, f := range fields {
+		var b, a *string
+		if before != nil {
+			b = before[i].value
+		}
+		if after != nil {
+			a = after[i].value
+		}
+
+		if !sameValue(b, a) {
+			changes = append(changes, client.FieldChange{
+				Field:  prefix + f.name,
+				Before: b,
+				After:  a,
+			})
+		}
+	}
+
+	return changes
+}
+
+func identityFields(i client.Identity) []field {
+	return []field{
+		{"email", value(i.Email)},
+		{"emailVerified", flag(i.EmailVerified)},
+		{"firstName", value(i.FirstName)},
+		{"middleName", value(i.MiddleName)},
+		{"lastName", value(i.LastName)},
+		{"nickName", optional(i.NickName)},
+		{"suffix", optional(i.Suffix)},
+		{"birthDate", optional(i.BirthDate)},
+		{"imageUrl", optional(i.ImageUrl)},
+		{"locale", optional(i.Locale)},
+	}
+}
+
+// attributeFields - Lists the attributes set on either identity, in the same order for both, so removed ones diff as missing.
+func attributeFields(i client.Identity, other client.Identity) []field {
+	names := []string{}
+	for name := range i.Attributes {
+		names = append(names, name)
+	}
+	for name := range other.Attributes {
+		if _, ok := i.Attributes[name]; !ok {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	fields := []field{}
+	for _, name := range names {
+		var v *string
+		if a, ok := i.Attributes[name]; ok {
+			v = value(formatAttribute(a))
+		}
+		fields = append(fields, field{name, v})
+	}
+	return fields
+}
+
+func phoneFields(p client.Phone) []field {
+	return []field{
+		{"number", value(p.Number)},
+		{"type", value(p.Type)},
+		{"validated", flag(p.Validated)},
+	}
+}
+
+func addressFields(a client.Address) []field {
+	return []field{
+		{"type", value(a.Type)},
+		{"address1", value(a.Address1)},
+		{"address2", optional(a.Address2)},
+		{"city", value(a.City)},
+		{"state", value(a.State)},
+		{"postalCode", value(a.PostalCode)},
+		{"country", value(a.Country)},
+		{"validated", flag(a.Validated)},
+	}
+}
+
+func phoneByID(phones []client.Phone, phoneID string) *client.Phone {
+	for i := range phones {
+		if phones[i].PhoneID == phoneID {
+			return &phones[i]
+		}
+	}
+	return nil
+}
+
+func addressByID(addresses []client.Address, addressID string) *client.Address {
+	for i := range addresses {
+		if addresses[i].AddressID == addressID {
+			return &addresses[i]
+		}
+	}
+	return nil
+}
+
+func value(s string) *string {
+	if s == "" {
+		return nil
+	}
+	return &s
+}
+
+func optional(s *string) *string {
+	if s == nil {
+		return nil
+	}
+	return value(*s)
+}
+
+func flag(b bool) *string {
+	return value(strconv.FormatBool(b))
+}
+
+func sameValue(a *string, b *string) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return *a == *b
+}
@@ -0,0 +1,24 @@
+package identities
+
+import (
+	"github.com/moov-io/identity/pkg/client"
+	"github.com/moov-io/identity/pkg/notifications"
+)
+
+// Notice - An email about an identity. Its added to the outbox in the transaction of the change its about, so its
+// only sent if the change is saved and not being able to send it doesn't fail the change.
+type Notice struct {
+	To    string
+	Email notifications.EmailTemplate
+}
+
+// Notify - Builds the emails to send about the identity as its changed.
+type Notify func(identity client.Identity) []Notice
+
+// notices - Nothing is sent when there's no Notify.
+func (n Notify) notices(identity client.Identity) []Notice {
+	if n == nil {
+		return nil
+	}
+	return n(identity)
+}
@@ -0,0 +1,44 @@
+package identities
+
+// StatusPending - Identity registered but is waiting on an admin to approve it before it can login.
+const StatusPending = "pending"
+
+// StatusActive - Identity is in good standing and can login.
+const StatusActive = "active"
+
+// StatusSuspended - Identity was put on hold by an admin and can't login until reactivated.
+const StatusSuspended = "suspended"
+
+// StatusLocked - Identity was locked out, usually for security reasons, and can't login until reactivated.
+const StatusLocked = "locked"
+
+// StatusDisabled - Identity is turned off. Its left around for historical reporting.
+const StatusDisabled = "disabled"
+
+// StatusDeleted - Identity was removed. Nothing can move it out of this status.
+const StatusDeleted = "deleted"
+
+// statusTransitions - The statuses each status is allowed to move to.
+var statusTransitions = map[string][]string{
+	StatusPending:   {StatusActive, StatusDisabled, StatusDeleted},
+	StatusActive:    {StatusSuspended, StatusLocked, StatusDisabled, StatusDeleted},
+	StatusSuspended: {StatusActive, StatusDisabled, StatusDeleted},
+	StatusLocked:    {StatusActive, StatusDisabled, StatusDeleted},
+	StatusDisabled:  {StatusActive, StatusDeleted},
+	StatusDeleted:   {},
+}
+
+// CanTransition - Reports if an identity in the from status is allowed to move to the to status.
+func CanTransition(from string, to string) bool {
+	for _, s := range statusTransitions[from] {
+		if s == to {
+			return true
+		}
+	}
+	return false
+}
+
+// CanLogin - Reports if an identity in the status is allowed to login.
+func CanLogin(status string) bool {
+	return status == StatusActive
+}
@@ -0,0 +1,304 @@
+package identities
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/moov-io/identity/pkg/client"
+)
+
+func (r *sqlIdentityRepo) listAttributeDefinitions(ctx context.Context, tenantID string) ([]client.AttributeDefinition, error) {
+	qry := fmt.Sprintf(`
+		SELECT %s
+		FROM attribute_definition
+		WHERE tenant_id = ?
+		ORDER BY name
+	`, attributeDefinitionSelect)
+
+	return r.queryScanAttributeDefinitions(ctx, qry, tenantID)
+}
+
+func (r *sqlIdentityRepo) getAttributeDefinition(ctx context.Context, tenantID string, name string) (*client.AttributeDefinition, error) {
+	qry := fmt.Sprintf(`
+		SELECT %s
+		FROM attribute_definition
+		WHERE tenant_id = ? AND name = ?
+		LIMIT 1
+	`, attributeDefinitionSelect)
+
+	definitions, err := r.queryScanAttributeDefinitions(ctx, qry, tenantID, name)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(definitions) != 1 {
+		return nil, sql.ErrNoRows
+	}
+
+	return &definitions[0], nil
+}
+
+// saveAttributeDefinition - Creates the definition or overwrites the one with the same name.
+func (r *sqlIdentityRepo) saveAttributeDefinition(ctx context.Context, definition client.AttributeDefinition) (*client.AttributeDefinition, error) {
+	enumValues, err := json.Marshal(definition.EnumValues)
+	if err != nil {
+		return nil, err
+	}
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	res, err := tx.ExecContext(ctx, r.dialect.Rebind(`
+		UPDATE attribute_definition
+		SET
+			type = ?,
+			enum_values = ?,
+			description = ?,
+			last_updated_on = ?
+		WHERE
+			tenant_id = ? AND
+			name = ?
+	`),
+		definition.Type,
+		string(enumValues),
+		definition.Description,
+		definition.LastUpdatedOn,
+
+		definition.TenantID,
+		definition.Name)
+	if err != nil {
+		return nil, err
+	}
+
+	cnt, err := res.RowsAffected()
+	if err != nil {
+		return nil, err
+	}
+
+	if cnt == 0 {
+		_, err := tx.ExecContext(ctx, r.dialect.Rebind(`
+			INSERT INTO attribute_definition (
+				tenant_id,
+				name,
+				type,
+				enum_values,
+				description,
+				created_on,
+				last_updated_on
+			) VALUES (?, ?, ?, ?, ?, ?, ?)
+		`),
+			definition.TenantID,
+			definition.Name,
+			definition.Type,
+			string(enumValues),
+			definition.Description,
+			definition.CreatedOn,
+			definition.LastUpdatedOn)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	return &definition, nil
+}
+
+// deleteAttributeDefinition - Removes the definition along with the values identities had for it.
+func (r *sqlIdentityRepo) deleteAttributeDefinition(ctx context.Context, tenantID string, name string) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	res, err := tx.ExecContext(ctx, r.dialect.Rebind(`DELETE FROM attribute_definition WHERE tenant_id = ? AND name = ?`), tenantID, name)
+	if err != nil {
+		return err
+	}
+
+	if cnt, err := res.RowsAffected(); cnt != 1 || err != nil {
+		return sql.ErrNoRows
+	}
+
+	if _, err := tx.ExecContext(ctx, r.dialect.Rebind(`DELETE FROM identity_attribute WHERE tenant_id = ? AND name = ?`), tenantID, name); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// upsertAttributes - Replaces all the attributes of the identity with the ones it has now.
+func (r *sqlIdentityRepo) upsertAttributes(ctx context.Context, tx *sql.Tx, updated *client.Identity) error {
+	if _, err := tx.ExecContext(ctx, r.dialect.Rebind(`DELETE FROM identity_attribute WHERE identity_id = ?`), updated.IdentityID); err != nil {
+		return err
+	}
+
+	names := []string{}
+	for name := range updated.Attributes {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		_, err := tx.ExecContext(ctx, r.dialect.Rebind(`
+			INSERT INTO identity_attribute (
+				identity_id,
+				tenant_id,
+				name,
+				value
+			) VALUES (?, ?, ?, ?)
+		`),
+			updated.IdentityID,
+			updated.TenantID,
+			name,
+			formatAttribute(updated.Attributes[name]))
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// attachAttributes - Loads the attributes of the identities matching the where clause onto them.
+func (r *sqlIdentityRepo) attachAttributes(ctx context.Context, identities []client.Identity, where string, args ...interface{}) error {
+	qry := fmt.Sprintf(`
+		SELECT
+			identity_attribute.identity_id,
+			identity_attribute.value,
+			%s
+		FROM identity_attribute
+		INNER JOIN identity ON identity_attribute.identity_id = identity.identity_id
+		INNER JOIN attribute_definition ON
+			attribute_definition.tenant_id = identity_attribute.tenant_id AND
+			attribute_definition.name = identity_attribute.name
+		WHERE %s
+	`, attributeDefinitionSelect, where)
+
+	rows, err := r.db.QueryContext(ctx, r.dialect.Rebind(qry), args...)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	byID := map[string]*client.Identity{}
+	for i := range identities {
+		byID[identities[i].IdentityID] = &identities[i]
+	}
+
+	for rows.Next() {
+		identityID := ""
+		text := ""
+		definition := client.AttributeDefinition{}
+		enumValues := ""
+		if err := rows.Scan(
+			&identityID,
+			&text,
+			&definition.TenantID,
+			&definition.Name,
+			&definition.Type,
+			&enumValues,
+			&definition.Description,
+			&definition.CreatedOn,
+			&definition.LastUpdatedOn,
+		); err != nil {
+			return err
+		}
+
+		identity, ok := byID[identityID]
+		if !ok {
+			continue
+		}
+
+		value, err := parseAttribute(definition, text)
+		if err != nil {
+			return err
+		}
+
+		if identity.Attributes == nil {
+			identity.Attributes = map[string]interface{}{}
+		}
+		identity.Attributes[definition.Name] = value
+	}
+
+	return rows.Err()
+}
+
+func (r *sqlIdentityRepo) queryScanAttributeDefinitions(ctx context.Context, query string, args ...interface{}) ([]client.AttributeDefinition, error) {
+	rows, err := r.db.QueryContext(ctx, r.dialect.Rebind(query), args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	definitions := []client.AttributeDefinition{}
+	for rows.Next() {
+		definition := client.AttributeDefinition{}
+		enumValues := ""
+		if err := rows.Scan(
+			&definition.TenantID,
+			&definition.Name,
+			&definition.Type,
+			&enumValues,
+			&definition.Description,
+			&definition.CreatedOn,
+			&definition.LastUpdatedOn,
+		); err != nil {
+			return nil, err
+		}
+
+		if err := json.Unmarshal([]byte(enumValues), &definition.EnumValues); err != nil {
+			return nil, err
+		}
+
+		definitions = append(definitions, definition)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return definitions, nil
+}
+
+// attributeFilters - Adds a condition to the identity query for each attribute that has to match.
+func attributeFilters(filters map[string]string) (string, []interface{}) {
+	names := []string{}
+	for name := range filters {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	where := strings.Builder{}
+	args := []interface{}{}
+	for _, name := range names {
+		where.WriteString(`
+			AND EXISTS (
+				SELECT 1 FROM identity_attribute
+				WHERE identity_attribute.identity_id = identity.identity_id AND identity_attribute.name = ? AND identity_attribute.value = ?
+			)`)
+		args = append(args, name, filters[name])
+	}
+
+	return where.String(), args
+}
+
+var attributeDefinitionSelect = `
+	attribute_definition.tenant_id,
+	attribute_definition.name,
+	attribute_definition.type,
+	attribute_definition.enum_values,
+	attribute_definition.description,
+	attribute_definition.created_on,
+	attribute_definition.last_updated_on
+`
@@ -0,0 +1,151 @@
+package identities
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/moov-io/identity/pkg/client"
+)
+
+// addEmailChange - Saves the requested change along with its emails and drops any earlier ones the identity never confirmed.
+func (r *sqlIdentityRepo) addEmailChange(ctx context.Context, change client.EmailChange, secretCode string, notices []Notice) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	_, err = tx.ExecContext(ctx, r.dialect.Rebind(`
+		DELETE FROM identity_email_change
+		WHERE tenant_id = ? AND identity_id = ? AND confirmed_on IS NULL
+	`), change.TenantID, change.IdentityID)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.ExecContext(ctx, r.dialect.Rebind(`
+		INSERT INTO identity_email_change (
+			email_change_id,
+			tenant_id,
+			identity_id,
+			new_email,
+			requested_by,
+			requested_on,
+			expires_on,
+			confirmed_on,
+			secret_code
+		) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`),
+		change.EmailChangeID,
+		change.TenantID,
+		change.IdentityID,
+		change.NewEmail,
+		change.RequestedBy,
+		change.RequestedOn,
+		change.ExpiresOn,
+		change.ConfirmedOn,
+		secretCode)
+	if err != nil {
+		return err
+	}
+
+	if err := r.queue(ctx, tx, change.TenantID, notices); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// getEmailChange - Finds the outstanding email change of the identity the code was sent for.
+func (r *sqlIdentityRepo) getEmailChange(ctx context.Context, tenantID string, identityID string, secretCode string) (*client.EmailChange, error) {
+	qry := fmt.Sprintf(`
+		SELECT %s
+		FROM identity_email_change
+		WHERE tenant_id = ? AND identity_id = ? AND secret_code = ? AND confirmed_on IS NULL
+		LIMIT 1
+	`, emailChangeSelect)
+
+	change := client.EmailChange{}
+	err := r.db.QueryRowContext(ctx, r.dialect.Rebind(qry), tenantID, identityID, secretCode).Scan(
+		&change.EmailChangeID,
+		&change.TenantID,
+		&change.IdentityID,
+		&change.NewEmail,
+		&change.RequestedBy,
+		&change.RequestedOn,
+		&change.ExpiresOn,
+		&change.ConfirmedOn,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return &change, nil
+}
+
+// getEmailChangeCode - The secret code sent out to confirm the change.
+func (r *sqlIdentityRepo) getEmailChangeCode(ctx context.Context, tenantID string, emailChangeID string) (string, error) {
+	qry := `
+		SELECT secret_code
+		FROM identity_email_change
+		WHERE tenant_id = ? AND email_change_id = ?
+		LIMIT 1
+	`
+
+	code := ""
+	err := r.db.QueryRowContext(ctx, r.dialect.Rebind(qry), tenantID, emailChangeID).Scan(&code)
+	return code, err
+}
+
+// confirmEmailChange - Swaps in the new email, records it in the history, marks the change as confirmed and queues
+// the emails about it in one transaction.
+func (r *sqlIdentityRepo) confirmEmailChange(ctx context.Context, updated client.Identity, history client.IdentityChange, change client.EmailChange, notices []Notice) (*client.Identity, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	res, err := tx.ExecContext(ctx, r.dialect.Rebind(`
+		UPDATE identity_email_change
+		SET confirmed_on = ?
+		WHERE email_change_id = ? AND tenant_id = ? AND confirmed_on IS NULL
+	`), change.ConfirmedOn, change.EmailChangeID, change.TenantID)
+	if err != nil {
+		return nil, err
+	}
+
+	// Confirmed by someone else while we were working on it.
+	if cnt, err := res.RowsAffected(); cnt != 1 || err != nil {
+		return nil, ErrEmailChangeNotFound
+	}
+
+	if err := r.updateTx(ctx, tx, &updated); err != nil {
+		return nil, err
+	}
+
+	if err := r.insertIdentityChange(ctx, tx, history); err != nil {
+		return nil, err
+	}
+
+	if err := r.queue(ctx, tx, change.TenantID, notices); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	return &updated, nil
+}
+
+var emailChangeSelect = `
+	email_change_id,
+	tenant_id,
+	identity_id,
+	new_email,
+	requested_by,
+	requested_on,
+	expires_on,
+	confirmed_on
+`
@@ -0,0 +1,85 @@
+package identities
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/moov-io/identity/pkg/client"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_Repository_EmailChange(t *testing.T) {
+	ForEachDatabase(t, func(t *testing.T, a *require.Assertions, repository Repository) {
+		added, err := repository.add(context.Background(), newTestIdentity(uuid.New().String(), "Jane", "Doe"))
+		a.NoError(err)
+
+		first := newTestEmailChange(*added)
+		a.NoError(repository.addEmailChange(context.Background(), first, "first-code", nil))
+
+		// Asking again drops the change that wasn't confirmed.
+		second := newTestEmailChange(*added)
+		a.NoError(repository.addEmailChange(context.Background(), second, "second-code", nil))
+
+		_, err = repository.getEmailChange(context.Background(), added.TenantID, added.IdentityID, "first-code")
+		a.Equal(sql.ErrNoRows, err)
+
+		_, err = repository.getEmailChange(context.Background(), uuid.New().String(), added.IdentityID, "second-code")
+		a.Equal(sql.ErrNoRows, err)
+
+		found, err := repository.getEmailChange(context.Background(), added.TenantID, added.IdentityID, "second-code")
+		a.NoError(err)
+		a.Equal(second.EmailChangeID, found.EmailChangeID)
+		a.Equal(second.NewEmail, found.NewEmail)
+
+		confirmedOn := found.RequestedOn.Add(time.Minute)
+		found.ConfirmedOn = &confirmedOn
+
+		before, after := added.Email, found.NewEmail
+		added.Email = found.NewEmail
+		added.LastUpdatedOn = confirmedOn
+		history := client.IdentityChange{
+			IdentityChangeID: uuid.New().String(),
+			IdentityID:       added.IdentityID,
+			TenantID:         added.TenantID,
+			Changes:          []client.FieldChange{{Field: "email", Before: &before, After: &after}},
+			ChangedBy:        added.IdentityID,
+			ChangedOn:        confirmedOn,
+		}
+
+		_, err = repository.confirmEmailChange(context.Background(), *added, history, *found, nil)
+		a.NoError(err)
+
+		identity, err := repository.get(context.Background(), added.IdentityID)
+		a.NoError(err)
+		a.Equal(found.NewEmail, identity.Email)
+
+		changes, err := repository.listHistory(context.Background(), added.TenantID, added.IdentityID)
+		a.NoError(err)
+		a.Len(changes, 1)
+
+		// Only confirmed the once.
+		history.IdentityChangeID = uuid.New().String()
+		_, err = repository.confirmEmailChange(context.Background(), *identity, history, *found, nil)
+		a.Equal(ErrEmailChangeNotFound, err)
+
+		_, err = repository.getEmailChange(context.Background(), added.TenantID, added.IdentityID, "second-code")
+		a.Equal(sql.ErrNoRows, err)
+	})
+}
+
+func newTestEmailChange(identity client.Identity) client.EmailChange {
+	now := time.Now().UTC().Round(time.Second)
+
+	return client.EmailChange{
+		EmailChangeID: uuid.New().String(),
+		IdentityID:    identity.IdentityID,
+		TenantID:      identity.TenantID,
+		NewEmail:      uuid.New().String() + "@moov.io",
+		RequestedBy:   identity.IdentityID,
+		RequestedOn:   now,
+		ExpiresOn:     now.Add(time.Hour),
+	}
+}
@@ -0,0 +1,115 @@
+package identities
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+
+	"github.com/moov-io/identity/pkg/client"
+)
+
+// updateWithHistory - Saves the identity, the fields that changed and the emails about them in one transaction.
+func (r *sqlIdentityRepo) updateWithHistory(ctx context.Context, updated client.Identity, change client.IdentityChange, notices []Notice) (*client.Identity, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	if err := r.updateTx(ctx, tx, &updated); err != nil {
+		return nil, err
+	}
+
+	if err := r.insertIdentityChange(ctx, tx, change); err != nil {
+		return nil, err
+	}
+
+	if err := r.queue(ctx, tx, change.TenantID, notices); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	return &updated, nil
+}
+
+func (r *sqlIdentityRepo) listHistory(ctx context.Context, tenantID string, identityID string) ([]client.IdentityChange, error) {
+	qry := fmt.Sprintf(`
+		SELECT %s
+		FROM identity_history
+		WHERE tenant_id = ? AND identity_id = ?
+		ORDER BY changed_on, identity_change_id
+	`, identityChangeSelect)
+
+	rows, err := r.db.QueryContext(ctx, r.dialect.Rebind(qry), tenantID, identityID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	history := []client.IdentityChange{}
+	for rows.Next() {
+		item := client.IdentityChange{}
+		changes := ""
+		if err := rows.Scan(
+			&item.IdentityChangeID,
+			&item.TenantID,
+			&item.IdentityID,
+			&changes,
+			&item.ChangedBy,
+			&item.ChangedOn,
+		); err != nil {
+			return nil, err
+		}
+
+		if err := json.Unmarshal([]byte(changes), &item.Changes); err != nil {
+			return nil, err
+		}
+
+		history = append(history, item)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return history, nil
+}
+
+func (r *sqlIdentityRepo) insertIdentityChange(ctx context.Context, tx *sql.Tx, change client.IdentityChange) error {
+	changes, err := json.Marshal(change.Changes)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.ExecContext(ctx, r.dialect.Rebind(`
+		INSERT INTO identity_history (
+			identity_change_id,
+			tenant_id,
+			identity_id,
+			changes,
+			changed_by,
+			changed_on
+		) VALUES (?, ?, ?, ?, ?, ?)
+	`),
+		change.IdentityChangeID,
+		change.TenantID,
+		change.IdentityID,
+		string(changes),
+		change.ChangedBy,
+		change.ChangedOn)
+
+	return err
+}
+
+var identityChangeSelect = `
+	identity_change_id,
+	tenant_id,
+	identity_id,
+	changes,
+	changed_by,
+	changed_on
+`
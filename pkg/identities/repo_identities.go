@@ -1,38 +1,87 @@
 package identities
 
 import (
+	"context"
 	"database/sql"
 	"fmt"
 
 	api "github.com/moov-io/identity/pkg/api"
 	"github.com/moov-io/identity/pkg/client"
+	"github.com/moov-io/identity/pkg/database"
+	"github.com/moov-io/identity/pkg/outbox"
 )
 
 // Repository - Used for interacting identities on the data store
 type Repository interface {
-	list(tenantID api.TenantID) ([]client.Identity, error)
-	get(identityID string) (*client.Identity, error)
-	update(updated client.Identity) (*client.Identity, error)
-	add(identity client.Identity) (*client.Identity, error)
+	list(ctx context.Context, tenantID api.TenantID, attributes map[string]string) ([]client.Identity, error)
+	listByStatus(ctx context.Context, tenantID api.TenantID, status string) ([]client.Identity, error)
+	get(ctx context.Context, identityID string) (*client.Identity, error)
+	update(ctx context.Context, updated client.Identity) (*client.Identity, error)
+	add(ctx context.Context, identity client.Identity) (*client.Identity, error)
+	register(ctx context.Context, identity client.Identity, change *client.StatusChange, notices []Notice) (*client.Identity, error)
+
+	merge(ctx context.Context, survivor client.Identity, duplicate client.Identity, change *client.StatusChange, dryRun bool) (*client.MergeReport, error)
+
+	changeStatus(ctx context.Context, updated client.Identity, change client.StatusChange, notices []Notice) (*client.Identity, error)
+	listStatusHistory(ctx context.Context, tenantID string, identityID string) ([]client.StatusChange, error)
+
+	updateWithHistory(ctx context.Context, updated client.Identity, change client.IdentityChange, notices []Notice) (*client.Identity, error)
+	listHistory(ctx context.Context, tenantID string, identityID string) ([]client.IdentityChange, error)
+
+	addEmailChange(ctx context.Context, change client.EmailChange, secretCode string, notices []Notice) error
+	getEmailChange(ctx context.Context, tenantID string, identityID string, secretCode string) (*client.EmailChange, error)
+	getEmailChangeCode(ctx context.Context, tenantID string, emailChangeID string) (string, error)
+	confirmEmailChange(ctx context.Context, updated client.Identity, history client.IdentityChange, change client.EmailChange, notices []Notice) (*client.Identity, error)
+
+	listAttributeDefinitions(ctx context.Context, tenantID string) ([]client.AttributeDefinition, error)
+	getAttributeDefinition(ctx context.Context, tenantID string, name string) (*client.AttributeDefinition, error)
+	saveAttributeDefinition(ctx context.Context, definition client.AttributeDefinition) (*client.AttributeDefinition, error)
+	deleteAttributeDefinition(ctx context.Context, tenantID string, name string) error
+
+	search(ctx context.Context, tenantID string, query string, limit int) ([]client.Identity, error)
+	buildSearchIndex(ctx context.Context) error
+
+	addBatch(ctx context.Context, identities []client.Identity) ([]client.Identity, error)
+	listPage(ctx context.Context, tenantID string, after string, limit int) ([]client.Identity, error)
 }
 
-// NewIdentityRepository - Builds a new repository tied to the DB passed in.
-func NewIdentityRepository(db *sql.DB) Repository {
-	return &sqlIdentityRepo{db: db}
+// NewIdentityRepository - Builds a new repository tied to the DB passed in. The outbox has to be on the same DB
+// so the emails about a change are added in its transaction.
+func NewIdentityRepository(db *sql.DB, outbox outbox.Outbox) Repository {
+	return &sqlIdentityRepo{
+		db:          db,
+		dialect:     database.DialectOf(db),
+		searchIndex: database.NewSearchIndex(db, "identity_search"),
+		outbox:      outbox,
+	}
 }
 
 type sqlIdentityRepo struct {
-	db *sql.DB
+	db          *sql.DB
+	dialect     database.Dialect
+	searchIndex database.SearchIndex
+	outbox      outbox.Outbox
+}
+
+// list - Lists the identities of the tenant that have all of the attribute values passed in.
+func (r *sqlIdentityRepo) list(ctx context.Context, tenantID api.TenantID, attributes map[string]string) ([]client.Identity, error) {
+	filters, args := attributeFilters(attributes)
+	return r.listWhere(ctx, tenantID, filters, args)
+}
+
+// listByStatus - Lists the identities of the tenant that are in the status passed in.
+func (r *sqlIdentityRepo) listByStatus(ctx context.Context, tenantID api.TenantID, status string) ([]client.Identity, error) {
+	return r.listWhere(ctx, tenantID, "AND identity.status = ?", []interface{}{status})
 }
 
-func (r *sqlIdentityRepo) list(tenantID api.TenantID) ([]client.Identity, error) {
+func (r *sqlIdentityRepo) listWhere(ctx context.Context, tenantID api.TenantID, filters string, args []interface{}) ([]client.Identity, error) {
 	qry := fmt.Sprintf(`
 		SELECT %s
 		FROM identity
-		WHERE identity.tenant_id = ?
-	`, identitySelect)
+		WHERE identity.tenant_id = ? %s
+	`, identitySelect, filters)
 
-	identities, err := r.queryScanIdentity(qry, tenantID.String())
+	identities, err := r.queryScanIdentity(ctx, qry, append([]interface{}{tenantID.String()}, args...)...)
 	if err != nil {
 		return nil, err
 	}
@@ -48,7 +97,7 @@ func (r *sqlIdentityRepo) list(tenantID api.TenantID) ([]client.Identity, error)
 		WHERE identity.tenant_id = ?
 	`, addressSelect)
 
-	addresses, err := r.queryScanAddresses(qry, tenantID.String())
+	addresses, err := r.queryScanAddresses(ctx, qry, tenantID.String())
 	if err != nil {
 		return nil, err
 	}
@@ -60,11 +109,15 @@ func (r *sqlIdentityRepo) list(tenantID api.TenantID) ([]client.Identity, error)
 		WHERE identity.tenant_id = ?
 	`, phoneSelect)
 
-	phones, err := r.queryScanPhone(qry, tenantID.String())
+	phones, err := r.queryScanPhone(ctx, qry, tenantID.String())
 	if err != nil {
 		return nil, err
 	}
 
+	if err := r.attachAttributes(ctx, identities, "identity.tenant_id = ?", tenantID.String()); err != nil {
+		return nil, err
+	}
+
 	for idx := range identities {
 		i := &identities[idx]
 
@@ -84,7 +137,7 @@ func (r *sqlIdentityRepo) list(tenantID api.TenantID) ([]client.Identity, error)
 	return identities, nil
 }
 
-func (r *sqlIdentityRepo) get(identityID string) (*client.Identity, error) {
+func (r *sqlIdentityRepo) get(ctx context.Context, identityID string) (*client.Identity, error) {
 
 	qry := fmt.Sprintf(`
 		SELECT %s
@@ -93,7 +146,7 @@ func (r *sqlIdentityRepo) get(identityID string) (*client.Identity, error) {
 		LIMIT 1
 	`, identitySelect)
 
-	identities, err := r.queryScanIdentity(qry, identityID)
+	identities, err := r.queryScanIdentity(ctx, qry, identityID)
 	if err != nil {
 		return nil, err
 	}
@@ -109,7 +162,7 @@ func (r *sqlIdentityRepo) get(identityID string) (*client.Identity, error) {
 		WHERE identity.identity_id = ?
 	`, addressSelect)
 
-	addresses, err := r.queryScanAddresses(qry, identityID)
+	addresses, err := r.queryScanAddresses(ctx, qry, identityID)
 	if err != nil {
 		return nil, err
 	}
@@ -121,7 +174,7 @@ func (r *sqlIdentityRepo) get(identityID string) (*client.Identity, error) {
 		WHERE identity.identity_id = ?
 	`, phoneSelect)
 
-	phones, err := r.queryScanPhone(qry, identityID)
+	phones, err := r.queryScanPhone(ctx, qry, identityID)
 	if err != nil {
 		return nil, err
 	}
@@ -133,16 +186,33 @@ func (r *sqlIdentityRepo) get(identityID string) (*client.Identity, error) {
 	identities[0].Phones = phones
 	identities[0].Addresses = addresses
 
+	if err := r.attachAttributes(ctx, identities, "identity.identity_id = ?", identityID); err != nil {
+		return nil, err
+	}
+
 	return &identities[0], nil
 }
 
-func (r *sqlIdentityRepo) update(updated client.Identity) (*client.Identity, error) {
-	tx, err := r.db.Begin()
+func (r *sqlIdentityRepo) update(ctx context.Context, updated client.Identity) (*client.Identity, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
 	if err != nil {
 		return nil, err
 	}
 	defer tx.Rollback()
 
+	if err := r.updateTx(ctx, tx, &updated); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	return &updated, nil
+}
+
+// updateTx - Saves the identity along with its phones and addresses, bumps its version and reindexes it for search.
+func (r *sqlIdentityRepo) updateTx(ctx context.Context, tx *sql.Tx, updated *client.Identity) error {
 	qry := `
 		UPDATE identity
 		SET
@@ -153,16 +223,22 @@ func (r *sqlIdentityRepo) update(updated client.Identity) (*client.Identity, err
 			suffix = ?,
 			birth_date = ?,
 			status = ?,
+			email = ?,
+			email_verified = ?,
 			disabled_on = ?,
 			disabled_by = ?,
 			last_updated_on = ?,
-			photo_url = ?
+			photo_url = ?,
+			merged_into = ?,
+			locale = ?,
+			version = ?
 		WHERE
 			tenant_id = ? AND
-			identity_id = ?
+			identity_id = ? AND
+			version = ?
 	`
 
-	res, err := tx.Exec(qry,
+	res, err := tx.ExecContext(ctx, r.dialect.Rebind(qry),
 		updated.FirstName,
 		updated.MiddleName,
 		updated.LastName,
@@ -170,45 +246,120 @@ func (r *sqlIdentityRepo) update(updated client.Identity) (*client.Identity, err
 		updated.Suffix,
 		updated.BirthDate,
 		updated.Status,
+		updated.Email,
+		updated.EmailVerified,
 		updated.DisabledOn,
 		updated.DisabledBy,
 		updated.LastUpdatedOn,
 		updated.ImageUrl,
+		updated.MergedInto,
+		updated.Locale,
+		updated.Version+1,
 
 		updated.TenantID,
-		updated.IdentityID)
+		updated.IdentityID,
+		updated.Version)
+	if database.UniqueViolation(err) {
+		return ErrEmailTaken
+	}
 	if err != nil {
-		return nil, err
+		return err
 	}
 
 	cnt, err := res.RowsAffected()
 	if err != nil {
-		return nil, err
+		return err
 	}
 	if cnt != 1 {
-		return nil, sql.ErrNoRows
+		// Tell apart the identity missing and someone else changing it first.
+		exists := 0
+		if err := tx.QueryRowContext(ctx, r.dialect.Rebind(`SELECT COUNT(*) FROM identity WHERE tenant_id = ? AND identity_id = ?`), updated.TenantID, updated.IdentityID).Scan(&exists); err != nil {
+			return err
+		}
+
+		if exists == 0 {
+			return sql.ErrNoRows
+		}
+
+		return ErrVersionConflict
+	}
+
+	updated.Version++
+
+	if err := r.upsertAddresses(ctx, tx, updated); err != nil {
+		return err
+	}
+
+	if err := r.upsertPhones(ctx, tx, updated); err != nil {
+		return err
 	}
 
-	if err := r.upsertAddresses(tx, &updated); err != nil {
+	if err := r.upsertAttributes(ctx, tx, updated); err != nil {
+		return err
+	}
+
+	return r.searchIndex.Put(ctx, tx, searchDocument(*updated))
+}
+
+func (r *sqlIdentityRepo) add(ctx context.Context, identity client.Identity) (*client.Identity, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
 		return nil, err
 	}
+	defer tx.Rollback()
 
-	if err := r.upsertPhones(tx, &updated); err != nil {
+	if err := r.addTx(ctx, tx, &identity); err != nil {
 		return nil, err
 	}
 
-	tx.Commit()
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
 
-	return &updated, nil
+	return &identity, nil
 }
 
-func (r *sqlIdentityRepo) add(identity client.Identity) (*client.Identity, error) {
-	tx, err := r.db.Begin()
+// register - Adds the identity along with the change to the status it starts out in, when that needs recording, and the emails about it.
+func (r *sqlIdentityRepo) register(ctx context.Context, identity client.Identity, change *client.StatusChange, notices []Notice) (*client.Identity, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
 	if err != nil {
 		return nil, err
 	}
 	defer tx.Rollback()
 
+	if err := r.addTx(ctx, tx, &identity); err != nil {
+		return nil, err
+	}
+
+	if change != nil {
+		if err := r.insertStatusChange(ctx, tx, *change); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := r.queue(ctx, tx, identity.TenantID, notices); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	return &identity, nil
+}
+
+// queue - Adds the emails to the outbox in the transaction of the change they're about.
+func (r *sqlIdentityRepo) queue(ctx context.Context, tx *sql.Tx, tenantID string, notices []Notice) error {
+	for _, n := range notices {
+		if err := r.outbox.Email(ctx, tx, tenantID, n.To, n.Email); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// addTx - Inserts the identity along with its phones, addresses and attributes and indexes it for search.
+func (r *sqlIdentityRepo) addTx(ctx context.Context, tx *sql.Tx, identity *client.Identity) error {
 	qry := `
 		INSERT INTO identity(
 			identity_id, 
@@ -227,11 +378,16 @@ func (r *sqlIdentityRepo) add(identity client.Identity) (*client.Identity, error
 			disabled_on,
 			disabled_by,
 			last_updated_on,
-			photo_url
-		) VALUES (?,?,?,?,?,?,?,?,?,?,?,?,?,?,?,?,?)
+			photo_url,
+			merged_into,
+			locale,
+			version
+		) VALUES (?,?,?,?,?,?,?,?,?,?,?,?,?,?,?,?,?,?,?,?)
 	`
 
-	res, err := tx.Exec(qry,
+	identity.Version = 1
+
+	res, err := tx.ExecContext(ctx, r.dialect.Rebind(qry),
 		identity.IdentityID,
 		identity.TenantID,
 		identity.FirstName,
@@ -248,30 +404,38 @@ func (r *sqlIdentityRepo) add(identity client.Identity) (*client.Identity, error
 		identity.DisabledOn,
 		identity.DisabledBy,
 		identity.LastUpdatedOn,
-		identity.ImageUrl)
+		identity.ImageUrl,
+		identity.MergedInto,
+		identity.Locale,
+		identity.Version)
+	if database.UniqueViolation(err) {
+		return ErrEmailTaken
+	}
 	if err != nil {
-		return nil, err
+		return err
 	}
 
 	cnt, err := res.RowsAffected()
 	if err != nil {
-		return nil, err
+		return err
 	}
 	if cnt != 1 {
-		return nil, sql.ErrNoRows
+		return sql.ErrNoRows
 	}
 
-	if err := r.upsertAddresses(tx, &identity); err != nil {
-		return nil, err
+	if err := r.upsertAddresses(ctx, tx, identity); err != nil {
+		return err
 	}
 
-	if err := r.upsertPhones(tx, &identity); err != nil {
-		return nil, err
+	if err := r.upsertPhones(ctx, tx, identity); err != nil {
+		return err
 	}
 
-	tx.Commit()
+	if err := r.upsertAttributes(ctx, tx, identity); err != nil {
+		return err
+	}
 
-	return &identity, nil
+	return r.searchIndex.Put(ctx, tx, searchDocument(*identity))
 }
 
 // Matches the order pulled in by the rows.Scan below in queryScanIdentity
@@ -292,11 +456,14 @@ var identitySelect = `
 	identity.disabled_on, 
 	identity.disabled_by,
 	identity.last_updated_on,
-	identity.photo_url
+	identity.photo_url,
+	identity.merged_into,
+	identity.locale,
+	identity.version
 `
 
-func (r *sqlIdentityRepo) queryScanIdentity(query string, args ...interface{}) ([]client.Identity, error) {
-	rows, err := r.db.Query(query, args...)
+func (r *sqlIdentityRepo) queryScanIdentity(ctx context.Context, query string, args ...interface{}) ([]client.Identity, error) {
+	rows, err := r.db.QueryContext(ctx, r.dialect.Rebind(query), args...)
 	if err != nil {
 		return nil, err
 	}
@@ -323,6 +490,9 @@ func (r *sqlIdentityRepo) queryScanIdentity(query string, args ...interface{}) (
 			&item.DisabledBy,
 			&item.LastUpdatedOn,
 			&item.ImageUrl,
+			&item.MergedInto,
+			&item.Locale,
+			&item.Version,
 		); err != nil {
 			return nil, err
 		}
@@ -351,8 +521,8 @@ var addressSelect = `
 	identity_address.validated
 `
 
-func (r *sqlIdentityRepo) queryScanAddresses(query string, args ...interface{}) ([]client.Address, error) {
-	rows, err := r.db.Query(query, args...)
+func (r *sqlIdentityRepo) queryScanAddresses(ctx context.Context, query string, args ...interface{}) ([]client.Address, error) {
+	rows, err := r.db.QueryContext(ctx, r.dialect.Rebind(query), args...)
 	if err != nil {
 		return nil, err
 	}
@@ -395,8 +565,8 @@ var phoneSelect = `
 	identity_phone.validated
 `
 
-func (r *sqlIdentityRepo) queryScanPhone(query string, args ...interface{}) ([]client.Phone, error) {
-	rows, err := r.db.Query(query, args...)
+func (r *sqlIdentityRepo) queryScanPhone(ctx context.Context, query string, args ...interface{}) ([]client.Phone, error) {
+	rows, err := r.db.QueryContext(ctx, r.dialect.Rebind(query), args...)
 	if err != nil {
 		return nil, err
 	}
@@ -425,7 +595,7 @@ func (r *sqlIdentityRepo) queryScanPhone(query string, args ...interface{}) ([]c
 	return items, nil
 }
 
-func (r *sqlIdentityRepo) upsertAddresses(tx *sql.Tx, updated *client.Identity) error {
+func (r *sqlIdentityRepo) upsertAddresses(ctx context.Context, tx *sql.Tx, updated *client.Identity) error {
 
 	updateQry := `
 		UPDATE identity_address
@@ -445,7 +615,7 @@ func (r *sqlIdentityRepo) upsertAddresses(tx *sql.Tx, updated *client.Identity)
 	`
 
 	for _, a := range updated.Addresses {
-		c, err := tx.Exec(updateQry,
+		c, err := tx.ExecContext(ctx, r.dialect.Rebind(updateQry),
 			a.Type,
 			a.Address1,
 			a.Address2,
@@ -485,7 +655,7 @@ func (r *sqlIdentityRepo) upsertAddresses(tx *sql.Tx, updated *client.Identity)
 				) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)	
 			`
 
-			_, err := tx.Exec(insertQry,
+			_, err := tx.ExecContext(ctx, r.dialect.Rebind(insertQry),
 				updated.IdentityID,
 				a.AddressID,
 				a.Type,
@@ -505,14 +675,14 @@ func (r *sqlIdentityRepo) upsertAddresses(tx *sql.Tx, updated *client.Identity)
 	}
 
 	// cleanout non-updated addresses
-	if _, err := tx.Exec(`DELETE FROM identity_address WHERE identity_id = ? AND last_updated_on < ?`, updated.IdentityID, updated.LastUpdatedOn); err != nil {
+	if _, err := tx.ExecContext(ctx, r.dialect.Rebind(`DELETE FROM identity_address WHERE identity_id = ? AND last_updated_on < ?`), updated.IdentityID, updated.LastUpdatedOn); err != nil {
 		return err
 	}
 
 	return nil
 }
 
-func (r *sqlIdentityRepo) upsertPhones(tx *sql.Tx, updated *client.Identity) error {
+func (r *sqlIdentityRepo) upsertPhones(ctx context.Context, tx *sql.Tx, updated *client.Identity) error {
 
 	updateQry := `
 		UPDATE identity_phone
@@ -527,7 +697,7 @@ func (r *sqlIdentityRepo) upsertPhones(tx *sql.Tx, updated *client.Identity) err
 	`
 
 	for _, p := range updated.Phones {
-		c, err := tx.Exec(updateQry,
+		c, err := tx.ExecContext(ctx, r.dialect.Rebind(updateQry),
 			p.Type,
 			p.Number,
 			p.Validated,
@@ -557,7 +727,7 @@ func (r *sqlIdentityRepo) upsertPhones(tx *sql.Tx, updated *client.Identity) err
 				) VALUES (?, ?, ?, ?, ?, ?)	
 			`
 
-			_, err := tx.Exec(insertQry,
+			_, err := tx.ExecContext(ctx, r.dialect.Rebind(insertQry),
 				updated.IdentityID,
 				p.PhoneID,
 				p.Type,
@@ -572,7 +742,7 @@ func (r *sqlIdentityRepo) upsertPhones(tx *sql.Tx, updated *client.Identity) err
 	}
 
 	// cleanout non-updated phones
-	if _, err := tx.Exec(`DELETE FROM identity_phone WHERE identity_id = ? AND last_updated_on < ?`, updated.IdentityID, updated.LastUpdatedOn); err != nil {
+	if _, err := tx.ExecContext(ctx, r.dialect.Rebind(`DELETE FROM identity_phone WHERE identity_id = ? AND last_updated_on < ?`), updated.IdentityID, updated.LastUpdatedOn); err != nil {
 		return err
 	}
 
@@ -0,0 +1,379 @@
+package identities
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/moov-io/identity/pkg/api"
+	"github.com/moov-io/identity/pkg/client"
+	"github.com/moov-io/identity/pkg/database"
+	"github.com/moov-io/identity/pkg/logging"
+	"github.com/moov-io/identity/pkg/notifications"
+	"github.com/moov-io/identity/pkg/outbox"
+	"github.com/moov-io/identity/pkg/stime"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_Repository_AddGetList(t *testing.T) {
+	ForEachDatabase(t, func(t *testing.T, a *require.Assertions, repository Repository) {
+		tenantID := uuid.New()
+		identity := newTestIdentity(tenantID.String(), "Jane", "Doe")
+
+		_, err := repository.add(context.Background(), identity)
+		a.NoError(err)
+
+		found, err := repository.get(context.Background(), identity.IdentityID)
+		a.NoError(err)
+		a.Equal(identity.Email, found.Email)
+		a.Equal(int64(1), found.Version)
+		a.Len(found.Addresses, 1)
+		a.Equal(identity.Addresses[0].City, found.Addresses[0].City)
+		a.Len(found.Phones, 1)
+		a.Equal(identity.Phones[0].Number, found.Phones[0].Number)
+
+		_, err = repository.add(context.Background(), newTestIdentity(uuid.New().String(), "John", "Doe"))
+		a.NoError(err)
+
+		list, err := repository.list(context.Background(), api.TenantID(tenantID), nil)
+		a.NoError(err)
+		a.Len(list, 1)
+		a.Equal(identity.IdentityID, list[0].IdentityID)
+
+		_, err = repository.get(context.Background(), uuid.New().String())
+		a.Equal(sql.ErrNoRows, err)
+	})
+}
+
+func Test_Repository_UpdateVersion(t *testing.T) {
+	ForEachDatabase(t, func(t *testing.T, a *require.Assertions, repository Repository) {
+		identity := newTestIdentity(uuid.New().String(), "Jane", "Doe")
+
+		added, err := repository.add(context.Background(), identity)
+		a.NoError(err)
+
+		added.FirstName = "Janet"
+		added.LastUpdatedOn = added.LastUpdatedOn.Add(time.Second)
+		updated, err := repository.update(context.Background(), *added)
+		a.NoError(err)
+		a.Equal(int64(2), updated.Version)
+
+		// Saving over a version thats already been replaced
+		_, err = repository.update(context.Background(), *added)
+		a.Equal(ErrVersionConflict, err)
+	})
+}
+
+func Test_Repository_AttributeFilters(t *testing.T) {
+	ForEachDatabase(t, func(t *testing.T, a *require.Assertions, repository Repository) {
+		tenantID := uuid.New()
+		now := time.Now().UTC().Round(time.Second)
+
+		_, err := repository.saveAttributeDefinition(context.Background(), client.AttributeDefinition{
+			TenantID:      tenantID.String(),
+			Name:          "team",
+			Type:          AttributeString,
+			EnumValues:    []string{},
+			CreatedOn:     now,
+			LastUpdatedOn: now,
+		})
+		a.NoError(err)
+
+		jane := newTestIdentity(tenantID.String(), "Jane", "Doe")
+		jane.Attributes = map[string]interface{}{"team": "payments"}
+		_, err = repository.add(context.Background(), jane)
+		a.NoError(err)
+
+		john := newTestIdentity(tenantID.String(), "John", "Doe")
+		john.Attributes = map[string]interface{}{"team": "risk"}
+		_, err = repository.add(context.Background(), john)
+		a.NoError(err)
+
+		list, err := repository.list(context.Background(), api.TenantID(tenantID), map[string]string{"team": "payments"})
+		a.NoError(err)
+		a.Len(list, 1)
+		a.Equal(jane.IdentityID, list[0].IdentityID)
+		a.Equal("payments", list[0].Attributes["team"])
+	})
+}
+
+func Test_Repository_ListByStatus(t *testing.T) {
+	ForEachDatabase(t, func(t *testing.T, a *require.Assertions, repository Repository) {
+		tenantID := uuid.New()
+
+		jane := newTestIdentity(tenantID.String(), "Jane", "Doe")
+		jane.Status = StatusPending
+		_, err := repository.add(context.Background(), jane)
+		a.NoError(err)
+
+		john := newTestIdentity(tenantID.String(), "John", "Doe")
+		john.Status = StatusActive
+		_, err = repository.add(context.Background(), john)
+		a.NoError(err)
+
+		list, err := repository.listByStatus(context.Background(), api.TenantID(tenantID), StatusPending)
+		a.NoError(err)
+		a.Len(list, 1)
+		a.Equal(jane.IdentityID, list[0].IdentityID)
+
+		list, err = repository.listByStatus(context.Background(), api.TenantID(tenantID), StatusDisabled)
+		a.NoError(err)
+		a.Empty(list)
+	})
+}
+
+func Test_Repository_PageAndSearch(t *testing.T) {
+	ForEachDatabase(t, func(t *testing.T, a *require.Assertions, repository Repository) {
+		tenantID := uuid.New().String()
+
+		added, err := repository.addBatch(context.Background(), []client.Identity{
+			newTestIdentity(tenantID, "Jane", "Doe"),
+			newTestIdentity(tenantID, "John", "Smith"),
+			newTestIdentity(tenantID, "Janet", "Jones"),
+		})
+		a.NoError(err)
+		a.Len(added, 3)
+
+		page, err := repository.listPage(context.Background(), tenantID, "", 2)
+		a.NoError(err)
+		a.Len(page, 2)
+
+		rest, err := repository.listPage(context.Background(), tenantID, page[1].IdentityID, 2)
+		a.NoError(err)
+		a.Len(rest, 1)
+
+		found, err := repository.search(context.Background(), tenantID, "jan", 10)
+		a.NoError(err)
+		a.Len(found, 2)
+
+		found, err = repository.search(context.Background(), tenantID, "smith", 10)
+		a.NoError(err)
+		a.Len(found, 1)
+		a.Equal("John", found[0].FirstName)
+	})
+}
+
+func Test_Repository_History(t *testing.T) {
+	ForEachDatabase(t, func(t *testing.T, a *require.Assertions, repository Repository) {
+		identity := newTestIdentity(uuid.New().String(), "Jane", "Doe")
+
+		added, err := repository.add(context.Background(), identity)
+		a.NoError(err)
+
+		added.Status = "suspended"
+		added.LastUpdatedOn = added.LastUpdatedOn.Add(time.Second)
+		_, err = repository.changeStatus(context.Background(), *added, client.StatusChange{
+			StatusChangeID: uuid.New().String(),
+			IdentityID:     added.IdentityID,
+			TenantID:       added.TenantID,
+			FromStatus:     "active",
+			ToStatus:       "suspended",
+			Reason:         "testing",
+			ChangedBy:      uuid.New().String(),
+			ChangedOn:      added.LastUpdatedOn,
+		}, nil)
+		a.NoError(err)
+
+		statuses, err := repository.listStatusHistory(context.Background(), added.TenantID, added.IdentityID)
+		a.NoError(err)
+		a.Len(statuses, 1)
+		a.Equal("suspended", statuses[0].ToStatus)
+
+		found, err := repository.get(context.Background(), added.IdentityID)
+		a.NoError(err)
+
+		before, after := found.LastName, "Smith"
+		found.LastName = after
+		found.LastUpdatedOn = found.LastUpdatedOn.Add(time.Second)
+		_, err = repository.updateWithHistory(context.Background(), *found, client.IdentityChange{
+			IdentityChangeID: uuid.New().String(),
+			IdentityID:       found.IdentityID,
+			TenantID:         found.TenantID,
+			Changes:          []client.FieldChange{{Field: "lastName", Before: &before, After: &after}},
+			ChangedBy:        uuid.New().String(),
+			ChangedOn:        found.LastUpdatedOn,
+		}, nil)
+		a.NoError(err)
+
+		changes, err := repository.listHistory(context.Background(), found.TenantID, found.IdentityID)
+		a.NoError(err)
+		a.Len(changes, 1)
+		a.Equal("lastName", changes[0].Changes[0].Field)
+	})
+}
+
+func Test_Repository_Cancelled(t *testing.T) {
+	ForEachDatabase(t, func(t *testing.T, a *require.Assertions, repository Repository) {
+		identity := newTestIdentity(uuid.New().String(), "Jane", "Doe")
+
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+
+		_, err := repository.add(ctx, identity)
+		a.Equal(context.Canceled, err)
+
+		_, err = repository.get(context.Background(), identity.IdentityID)
+		a.Equal(sql.ErrNoRows, err)
+	})
+}
+
+func Test_Repository_EmailTaken(t *testing.T) {
+	ForEachDatabase(t, func(t *testing.T, a *require.Assertions, repository Repository) {
+		tenantID := uuid.New().String()
+
+		jane, err := repository.add(context.Background(), newTestIdentity(tenantID, "Jane", "Doe"))
+		a.NoError(err)
+
+		duplicate := newTestIdentity(tenantID, "Janet", "Doe")
+		duplicate.Email = jane.Email
+		_, err = repository.add(context.Background(), duplicate)
+		a.Equal(ErrEmailTaken, err)
+
+		// Other tenants can have the same email
+		other := newTestIdentity(uuid.New().String(), "Jane", "Doe")
+		other.Email = jane.Email
+		_, err = repository.add(context.Background(), other)
+		a.NoError(err)
+
+		john, err := repository.add(context.Background(), newTestIdentity(tenantID, "John", "Doe"))
+		a.NoError(err)
+
+		john.Email = jane.Email
+		_, err = repository.update(context.Background(), *john)
+		a.Equal(ErrEmailTaken, err)
+	})
+}
+
+func Test_Repository_MergedKeepsEmail(t *testing.T) {
+	database.ForEachDatabaseConfig(t, func(t *testing.T, config database.DatabaseConfig, db *sql.DB) {
+		a := require.New(t)
+
+		// Back to before the unique index on the email so there can be two identities with it.
+		migrator, err := database.NewMigrator(logging.NewNopLogger(), db, config)
+		a.NoError(err)
+		a.NoError(migrator.Goto(31))
+
+		repository := NewIdentityRepository(db, newTestOutbox(t, db))
+		tenantID := uuid.New().String()
+
+		survivor, err := repository.add(context.Background(), newTestIdentity(tenantID, "Jane", "Doe"))
+		a.NoError(err)
+
+		duplicate := newTestIdentity(tenantID, "Jane", "Doe")
+		duplicate.Email = survivor.Email
+		_, err = repository.add(context.Background(), duplicate)
+		a.NoError(err)
+
+		duplicate.Status = StatusDisabled
+		duplicate.MergedInto = &survivor.IdentityID
+		_, err = repository.merge(context.Background(), *survivor, duplicate, nil, false)
+		a.NoError(err)
+
+		violations, err := database.CheckConstraints(context.Background(), db)
+		a.NoError(err)
+		a.Empty(violations)
+
+		a.NoError(migrator.Up())
+
+		// Still held by the survivor
+		taken := newTestIdentity(tenantID, "Janet", "Doe")
+		taken.Email = survivor.Email
+		_, err = repository.add(context.Background(), taken)
+		a.Equal(ErrEmailTaken, err)
+	})
+}
+
+func Test_Repository_Notices(t *testing.T) {
+	database.ForEachDatabase(t, func(t *testing.T, db *sql.DB) {
+		a := require.New(t)
+
+		queued := newTestOutbox(t, db)
+		repository := NewIdentityRepository(db, queued)
+
+		added, err := repository.add(context.Background(), newTestIdentity(uuid.New().String(), "Jane", "Doe"))
+		a.NoError(err)
+
+		welcome := notifications.NewWelcomeEmail("https://local.moov.io/login", *added)
+		notices := []Notice{{To: added.Email, Email: &welcome}}
+
+		change := client.StatusChange{
+			StatusChangeID: uuid.New().String(),
+			IdentityID:     added.IdentityID,
+			TenantID:       added.TenantID,
+			FromStatus:     StatusPending,
+			ToStatus:       StatusSuspended,
+			Reason:         "testing",
+			ChangedBy:      uuid.New().String(),
+			ChangedOn:      added.LastUpdatedOn,
+		}
+
+		// Not pending so nothing is saved and the email goes with it
+		_, err = repository.changeStatus(context.Background(), *added, change, notices)
+		a.Equal(ErrInvalidStatusTransition, err)
+
+		messages, err := queued.ListMessages(context.Background(), outbox.StatusPending)
+		a.NoError(err)
+		a.Empty(messages)
+
+		change.FromStatus = StatusActive
+		_, err = repository.changeStatus(context.Background(), *added, change, notices)
+		a.NoError(err)
+
+		messages, err = queued.ListMessages(context.Background(), outbox.StatusPending)
+		a.NoError(err)
+		a.Len(messages, 1)
+		a.Equal(added.Email, messages[0].Recipient)
+		a.Equal(added.TenantID, messages[0].TenantID)
+	})
+}
+
+func ForEachDatabase(t *testing.T, run func(t *testing.T, a *require.Assertions, repository Repository)) {
+	database.ForEachDatabase(t, func(t *testing.T, db *sql.DB) {
+		run(t, require.New(t), NewIdentityRepository(db, newTestOutbox(t, db)))
+	})
+}
+
+// newTestOutbox - Holds onto the emails queued along with the changes to the identities.
+func newTestOutbox(t *testing.T, db *sql.DB) outbox.Service {
+	templates, err := notifications.NewTemplateRepository(logging.NewNopLogger())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	sent := notifications.NewMockNotificationsService(notifications.MockConfig{From: "noreply@moov.io"}, templates)
+	return outbox.NewOutboxService(outbox.Config{}, logging.NewNopLogger(), stime.NewStaticTimeService(), outbox.NewOutboxRepository(db), sent)
+}
+
+func newTestIdentity(tenantID string, firstName string, lastName string) client.Identity {
+	identityID := uuid.New().String()
+	now := time.Now().UTC().Round(time.Second)
+
+	return client.Identity{
+		IdentityID:    identityID,
+		TenantID:      tenantID,
+		FirstName:     firstName,
+		LastName:      lastName,
+		Status:        "active",
+		Email:         uuid.New().String() + "@moov.io",
+		RegisteredOn:  now,
+		LastUpdatedOn: now,
+		Addresses: []client.Address{{
+			IdentityID: identityID,
+			AddressID:  uuid.New().String(),
+			Type:       "primary",
+			Address1:   "123 Main St",
+			City:       "Des Moines",
+			State:      "IA",
+			PostalCode: "50309",
+			Country:    "US",
+		}},
+		Phones: []client.Phone{{
+			IdentityID: identityID,
+			PhoneID:    uuid.New().String(),
+			Number:     "555-555-5555",
+			Type:       "mobile",
+		}},
+	}
+}
@@ -0,0 +1,99 @@
+package identities
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/moov-io/identity/pkg/client"
+)
+
+// addBatch - Adds all of the identities in one transaction, either all of them are saved or none are.
+func (r *sqlIdentityRepo) addBatch(ctx context.Context, identities []client.Identity) ([]client.Identity, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	for i := range identities {
+		if err := r.addTx(ctx, tx, &identities[i]); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	return identities, nil
+}
+
+// listPage - Up to limit identities of the tenant with an ID after the one passed in, ordered by ID.
+// Passing in the last ID of a page returns the next one so the whole tenant can be read without holding it in memory.
+func (r *sqlIdentityRepo) listPage(ctx context.Context, tenantID string, after string, limit int) ([]client.Identity, error) {
+	qry := fmt.Sprintf(`
+		SELECT %s
+		FROM identity
+		WHERE identity.tenant_id = ? AND identity.identity_id > ?
+		ORDER BY identity.identity_id
+		LIMIT ?
+	`, identitySelect)
+
+	identities, err := r.queryScanIdentity(ctx, qry, tenantID, after, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(identities) == 0 {
+		return identities, nil
+	}
+
+	ids := []interface{}{}
+	for _, i := range identities {
+		ids = append(ids, i.IdentityID)
+	}
+	in := "identity.identity_id IN (?" + strings.Repeat(",?", len(ids)-1) + ")"
+
+	addresses, err := r.queryScanAddresses(ctx, fmt.Sprintf(`
+		SELECT %s
+		FROM identity_address
+		INNER JOIN identity ON identity_address.identity_id = identity.identity_id
+		WHERE %s
+	`, addressSelect, in), ids...)
+	if err != nil {
+		return nil, err
+	}
+
+	phones, err := r.queryScanPhone(ctx, fmt.Sprintf(`
+		SELECT %s
+		FROM identity_phone
+		INNER JOIN identity ON identity_phone.identity_id = identity.identity_id
+		WHERE %s
+	`, phoneSelect, in), ids...)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := r.attachAttributes(ctx, identities, in, ids...); err != nil {
+		return nil, err
+	}
+
+	for idx := range identities {
+		i := &identities[idx]
+
+		for _, a := range addresses {
+			if a.IdentityID == i.IdentityID {
+				i.Addresses = append(i.Addresses, a)
+			}
+		}
+
+		for _, p := range phones {
+			if p.IdentityID == i.IdentityID {
+				i.Phones = append(i.Phones, p)
+			}
+		}
+	}
+
+	return identities, nil
+}
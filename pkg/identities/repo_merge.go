@@ -0,0 +1,184 @@
+package identities
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/moov-io/identity/pkg/client"
+)
+
+// merge - Moves everything hanging off the duplicate over to the survivor and disables the duplicate in one transaction.
+// The status change of the duplicate is recorded when there is one.
+// When dryRun is set the report is built from the same queries but nothing is written.
+func (r *sqlIdentityRepo) merge(ctx context.Context, survivor client.Identity, duplicate client.Identity, change *client.StatusChange, dryRun bool) (*client.MergeReport, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	report := client.MergeReport{
+		SurvivorID:  survivor.IdentityID,
+		DuplicateID: duplicate.IdentityID,
+		DryRun:      dryRun,
+		MergedOn:    survivor.LastUpdatedOn,
+	}
+	if duplicate.DisabledBy != nil {
+		report.MergedBy = *duplicate.DisabledBy
+	}
+
+	report.Credentials, err = r.queryIDs(ctx, tx, `
+		SELECT credential_id
+		FROM credentials
+		WHERE identity_id = ? AND tenant_id = ?
+	`, duplicate.IdentityID, duplicate.TenantID)
+	if err != nil {
+		return nil, err
+	}
+
+	report.Phones, err = r.queryIDs(ctx, tx, `
+		SELECT phone_id
+		FROM identity_phone
+		WHERE identity_id = ?
+	`, duplicate.IdentityID)
+	if err != nil {
+		return nil, err
+	}
+
+	report.Addresses, err = r.queryIDs(ctx, tx, `
+		SELECT address_id
+		FROM identity_address
+		WHERE identity_id = ?
+	`, duplicate.IdentityID)
+	if err != nil {
+		return nil, err
+	}
+
+	// Login history is keyed by the credential so it follows the credentials over.
+	err = tx.QueryRowContext(ctx, r.dialect.Rebind(`
+		SELECT COUNT(*)
+		FROM credential_logins
+		INNER JOIN credentials ON
+			credential_logins.credential_id = credentials.credential_id AND
+			credential_logins.tenant_id = credentials.tenant_id
+		WHERE credentials.identity_id = ? AND credentials.tenant_id = ?
+	`), duplicate.IdentityID, duplicate.TenantID).Scan(&report.Logins)
+	if err != nil {
+		return nil, err
+	}
+
+	if dryRun {
+		return &report, nil
+	}
+
+	if _, err := tx.ExecContext(ctx, r.dialect.Rebind(`
+		UPDATE credentials
+		SET identity_id = ?
+		WHERE identity_id = ? AND tenant_id = ?
+	`), survivor.IdentityID, duplicate.IdentityID, duplicate.TenantID); err != nil {
+		return nil, err
+	}
+
+	// Bump last_updated_on so the upserts of the survivor don't clean them back out.
+	if _, err := tx.ExecContext(ctx, r.dialect.Rebind(`
+		UPDATE identity_phone
+		SET identity_id = ?, last_updated_on = ?
+		WHERE identity_id = ?
+	`), survivor.IdentityID, survivor.LastUpdatedOn, duplicate.IdentityID); err != nil {
+		return nil, err
+	}
+
+	if _, err := tx.ExecContext(ctx, r.dialect.Rebind(`
+		UPDATE identity_address
+		SET identity_id = ?, last_updated_on = ?
+		WHERE identity_id = ?
+	`), survivor.IdentityID, survivor.LastUpdatedOn, duplicate.IdentityID); err != nil {
+		return nil, err
+	}
+
+	res, err := tx.ExecContext(ctx, r.dialect.Rebind(`
+		UPDATE identity
+		SET
+			status = ?,
+			disabled_on = ?,
+			disabled_by = ?,
+			merged_into = ?,
+			last_updated_on = ?,
+			version = version + 1
+		WHERE
+			tenant_id = ? AND
+			identity_id = ? AND
+			merged_into IS NULL
+	`),
+		duplicate.Status,
+		duplicate.DisabledOn,
+		duplicate.DisabledBy,
+		duplicate.MergedInto,
+		duplicate.LastUpdatedOn,
+
+		duplicate.TenantID,
+		duplicate.IdentityID)
+	if err != nil {
+		return nil, err
+	}
+
+	// Someone else merged it away while we were working on it.
+	if cnt, err := res.RowsAffected(); cnt != 1 || err != nil {
+		return nil, ErrIdentityAlreadyMerged
+	}
+
+	if change != nil {
+		if err := r.insertStatusChange(ctx, tx, *change); err != nil {
+			return nil, err
+		}
+	}
+
+	if _, err := tx.ExecContext(ctx, r.dialect.Rebind(`
+		UPDATE identity
+		SET last_updated_on = ?, version = version + 1
+		WHERE tenant_id = ? AND identity_id = ?
+	`), survivor.LastUpdatedOn, survivor.TenantID, survivor.IdentityID); err != nil {
+		return nil, err
+	}
+
+	// The survivor can now be found by the phones and addresses it took over, the duplicate not at all.
+	survivor.Phones = append(survivor.Phones, duplicate.Phones...)
+	survivor.Addresses = append(survivor.Addresses, duplicate.Addresses...)
+	if err := r.searchIndex.Put(ctx, tx, searchDocument(survivor)); err != nil {
+		return nil, err
+	}
+
+	if err := r.searchIndex.Remove(ctx, tx, duplicate.IdentityID); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	return &report, nil
+}
+
+func (r *sqlIdentityRepo) queryIDs(ctx context.Context, tx *sql.Tx, query string, args ...interface{}) ([]string, error) {
+	rows, err := tx.QueryContext(ctx, r.dialect.Rebind(query), args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	ids := []string{}
+	for rows.Next() {
+		id := ""
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+
+		ids = append(ids, id)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return ids, nil
+}
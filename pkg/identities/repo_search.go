@@ -0,0 +1,131 @@
+package identities
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	"unicode"
+
+	"github.com/moov-io/identity/pkg/client"
+	"github.com/moov-io/identity/pkg/database"
+)
+
+// BuildSearchIndex - Indexes every identity when the search index is still empty, like the first time
+// it runs against a database that had identities before search existed.
+func BuildSearchIndex(ctx context.Context, repository Repository) error {
+	return repository.buildSearchIndex(ctx)
+}
+
+// search - Identities of the tenant matching every word of the query, most relevant first.
+func (r *sqlIdentityRepo) search(ctx context.Context, tenantID string, query string, limit int) ([]client.Identity, error) {
+	results, err := r.searchIndex.Search(ctx, tenantID, query, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	identities := []client.Identity{}
+	for _, res := range results {
+		identity, err := r.get(ctx, res.ID)
+		if err == sql.ErrNoRows {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		identities = append(identities, *identity)
+	}
+
+	return identities, nil
+}
+
+func (r *sqlIdentityRepo) buildSearchIndex(ctx context.Context) error {
+	empty, err := r.searchIndex.Empty(ctx)
+	if err != nil {
+		return err
+	}
+	if !empty {
+		return nil
+	}
+
+	rows, err := r.db.QueryContext(ctx, `SELECT identity_id FROM identity WHERE merged_into IS NULL`)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	ids := []string{}
+	for rows.Next() {
+		id := ""
+		if err := rows.Scan(&id); err != nil {
+			return err
+		}
+		ids = append(ids, id)
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	// Loaded before the transaction starts so they aren't read over a second connection while its open.
+	docs := []database.SearchDocument{}
+	for _, id := range ids {
+		identity, err := r.get(ctx, id)
+		if err != nil {
+			return err
+		}
+		docs = append(docs, searchDocument(*identity))
+	}
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	for _, doc := range docs {
+		if err := r.searchIndex.Put(ctx, tx, doc); err != nil {
+			return fmt.Errorf("indexing identity %s - %w", doc.ID, err)
+		}
+	}
+
+	return tx.Commit()
+}
+
+// searchDocument - What an identity can be found by. Phone numbers are added as just their digits as well
+// so they match however they were punctuated.
+func searchDocument(identity client.Identity) database.SearchDocument {
+	fields := []string{
+		identity.FirstName,
+		identity.MiddleName,
+		identity.LastName,
+		identity.Email,
+	}
+
+	if identity.NickName != nil {
+		fields = append(fields, *identity.NickName)
+	}
+
+	for _, p := range identity.Phones {
+		fields = append(fields, p.Number, phoneDigits(p.Number))
+	}
+
+	for _, a := range identity.Addresses {
+		fields = append(fields, a.PostalCode)
+	}
+
+	return database.SearchDocument{
+		ID:     identity.IdentityID,
+		Scope:  identity.TenantID,
+		Fields: fields,
+	}
+}
+
+func phoneDigits(number string) string {
+	return strings.Map(func(r rune) rune {
+		if unicode.IsDigit(r) {
+			return r
+		}
+		return -1
+	}, number)
+}
@@ -0,0 +1,139 @@
+package identities
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/moov-io/identity/pkg/client"
+)
+
+// changeStatus - Moves the identity to its new status and records the change along with its emails in one transaction.
+// The update only applies while the identity is still in the status the change started from.
+func (r *sqlIdentityRepo) changeStatus(ctx context.Context, updated client.Identity, change client.StatusChange, notices []Notice) (*client.Identity, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	res, err := tx.ExecContext(ctx, r.dialect.Rebind(`
+		UPDATE identity
+		SET
+			status = ?,
+			disabled_on = ?,
+			disabled_by = ?,
+			last_updated_on = ?,
+			version = version + 1
+		WHERE
+			tenant_id = ? AND
+			identity_id = ? AND
+			status = ?
+	`),
+		change.ToStatus,
+		updated.DisabledOn,
+		updated.DisabledBy,
+		updated.LastUpdatedOn,
+
+		change.TenantID,
+		change.IdentityID,
+		change.FromStatus)
+	if err != nil {
+		return nil, err
+	}
+
+	// Someone else changed the status while we were working on it.
+	if cnt, err := res.RowsAffected(); cnt != 1 || err != nil {
+		return nil, ErrInvalidStatusTransition
+	}
+
+	if err := r.insertStatusChange(ctx, tx, change); err != nil {
+		return nil, err
+	}
+
+	if err := r.queue(ctx, tx, change.TenantID, notices); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	return r.get(ctx, updated.IdentityID)
+}
+
+func (r *sqlIdentityRepo) listStatusHistory(ctx context.Context, tenantID string, identityID string) ([]client.StatusChange, error) {
+	qry := fmt.Sprintf(`
+		SELECT %s
+		FROM identity_status_history
+		WHERE tenant_id = ? AND identity_id = ?
+		ORDER BY changed_on, status_change_id
+	`, statusChangeSelect)
+
+	rows, err := r.db.QueryContext(ctx, r.dialect.Rebind(qry), tenantID, identityID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	history := []client.StatusChange{}
+	for rows.Next() {
+		item := client.StatusChange{}
+		if err := rows.Scan(
+			&item.StatusChangeID,
+			&item.TenantID,
+			&item.IdentityID,
+			&item.FromStatus,
+			&item.ToStatus,
+			&item.Reason,
+			&item.ChangedBy,
+			&item.ChangedOn,
+		); err != nil {
+			return nil, err
+		}
+
+		history = append(history, item)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return history, nil
+}
+
+func (r *sqlIdentityRepo) insertStatusChange(ctx context.Context, tx *sql.Tx, change client.StatusChange) error {
+	_, err := tx.ExecContext(ctx, r.dialect.Rebind(`
+		INSERT INTO identity_status_history (
+			status_change_id,
+			tenant_id,
+			identity_id,
+			from_status,
+			to_status,
+			reason,
+			changed_by,
+			changed_on
+		) VALUES (?, ?, ?, ?, ?, ?, ?, ?)
+	`),
+		change.StatusChangeID,
+		change.TenantID,
+		change.IdentityID,
+		change.FromStatus,
+		change.ToStatus,
+		change.Reason,
+		change.ChangedBy,
+		change.ChangedOn)
+
+	return err
+}
+
+var statusChangeSelect = `
+	status_change_id,
+	tenant_id,
+	identity_id,
+	from_status,
+	to_status,
+	reason,
+	changed_by,
+	changed_on
+`
@@ -1,7 +1,15 @@
 package identities_test
 
 import (
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
 	"testing"
+	"time"
 
 	fuzz "github.com/google/gofuzz"
 	"github.com/google/uuid"
@@ -13,6 +21,8 @@ import (
 	. "github.com/moov-io/identity/pkg/identities"
 	identitiestestutils "github.com/moov-io/identity/pkg/identities/testutils"
 	"github.com/moov-io/identity/pkg/logging"
+	"github.com/moov-io/identity/pkg/notifications"
+	"github.com/moov-io/identity/pkg/outbox"
 	"github.com/moov-io/identity/pkg/stime"
 	tmw "github.com/moov-io/tumbler/pkg/middleware"
 	tmwt "github.com/moov-io/tumbler/pkg/middleware/middlewaretest"
@@ -20,10 +30,14 @@ import (
 )
 
 type Scope struct {
+	db         *sql.DB
+	routes     *mux.Router
 	session    tmw.TumblerClaims
 	time       stime.StaticTimeService
 	repository Repository
 	service    Service
+	outbox     outbox.Service
+	sent       *flakyNotifications
 	api        *client.APIClient
 }
 
@@ -38,9 +52,31 @@ func NewScope(t *testing.T) Scope {
 		t.Error(err)
 	}
 
-	repository := NewIdentityRepository(db)
+	templates, err := notifications.NewTemplateRepository(logging)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Records the emails so the tests can check who got notified and what they said
+	sent := &flakyNotifications{
+		MockNotificationsService: notifications.NewMockNotificationsService(notifications.MockConfig{From: "noreply@moov.io"}, templates),
+	}
+	outbox := outbox.NewOutboxService(outbox.Config{}, logging, times, outbox.NewOutboxRepository(db), sent)
+
+	repository := NewIdentityRepository(db, outbox)
+
+	config := Config{
+		EmailChangeExpiration: time.Hour,
+		EmailChangeURL:        "https://local.moov.io/tenants/{{.TenantID}}/email/confirm",
+	}
+
+	service, err := NewIdentitiesService(config, times, repository)
+	if err != nil {
+		t.Error(err)
+	}
 
-	service := NewIdentitiesService(times, repository)
+	// The confirmation link isn't stored with the email so it has to be filled back in as its sent
+	outbox.Fill((&notifications.EmailChangeConfirmEmail{}).TemplateName(), service.FillEmail)
 
 	controller := NewIdentitiesController(logging, service)
 
@@ -53,10 +89,14 @@ func NewScope(t *testing.T) Scope {
 	testAPI := clienttest.NewTestClient(routes)
 
 	return Scope{
+		db:         db,
+		routes:     routes,
 		session:    session,
 		time:       times,
 		repository: repository,
 		service:    service,
+		outbox:     outbox,
+		sent:       sent,
 		api:        testAPI,
 	}
 }
@@ -68,9 +108,67 @@ func Setup(t *testing.T) (*require.Assertions, Scope, *fuzz.Fuzzer) {
 	return a, s, f
 }
 
+// Sent - Delivers whatever is waiting in the outbox and returns every email sent so far.
+func (s *Scope) Sent() []notifications.SentEmail {
+	if _, err := s.outbox.Dispatch(context.Background()); err != nil {
+		panic(err)
+	}
+	return s.sent.Sent()
+}
+
+// RandomInvite - Stored so identities registered with it can reference it.
 func (s *Scope) RandomInvite() client.Invite {
-	return client.Invite{
+	invite := client.Invite{
 		InviteID: uuid.New().String(),
 		TenantID: s.session.TenantID.String(),
 	}
+
+	_, err := s.db.Exec(`
+		INSERT INTO invites (invite_id, tenant_id, email, invited_by, invited_on, expires_on, secret_code)
+		VALUES (?, ?, ?, ?, ?, ?, ?)
+	`, invite.InviteID, invite.TenantID, invite.InviteID+"@example.com", s.session.IdentityID.String(), s.time.Now(), s.time.Now().Add(time.Hour), invite.InviteID)
+	if err != nil {
+		panic(err)
+	}
+
+	return invite
+}
+
+// Request - Sends a request through the routes for the endpoints the generated client doesn't cover.
+func (s *Scope) Request(method string, path string, body interface{}, headers map[string]string) *http.Response {
+	buf := &bytes.Buffer{}
+	if body != nil {
+		if err := json.NewEncoder(buf).Encode(body); err != nil {
+			panic(err)
+		}
+	}
+
+	req := httptest.NewRequest(method, "https://local.moov.io"+path, buf)
+	req.Header.Set("Content-Type", "application/json")
+	for k, v := range headers {
+		req.Header.Set(k, v)
+	}
+
+	w := httptest.NewRecorder()
+	s.routes.ServeHTTP(w, req)
+
+	return w.Result()
+}
+
+// ETag - What the API returns as the ETag for the identity.
+func ETag(identity client.Identity) string {
+	return fmt.Sprintf(`"%d"`, identity.Version)
+}
+
+// flakyNotifications - Records the emails like the mock does, unless err is set and then sending fails with it.
+type flakyNotifications struct {
+	notifications.MockNotificationsService
+	err error
+}
+
+func (n *flakyNotifications) SendEmail(ctx context.Context, to string, email notifications.EmailTemplate) error {
+	if n.err != nil {
+		return n.err
+	}
+	return n.MockNotificationsService.SendEmail(ctx, to, email)
 }
@@ -0,0 +1,145 @@
+package identities
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	validation "github.com/go-ozzo/ozzo-validation/v4"
+	"github.com/moov-io/identity/pkg/client"
+	tmw "github.com/moov-io/tumbler/pkg/middleware"
+)
+
+// ListAttributeDefinitions - Lists the custom attributes the tenant set up for its identities.
+func (s *service) ListAttributeDefinitions(ctx context.Context, claims tmw.TumblerClaims) ([]client.AttributeDefinition, error) {
+	return s.repository.listAttributeDefinitions(ctx, claims.TenantID.String())
+}
+
+// SaveAttributeDefinition - Creates or updates a custom attribute of the tenant. Its type can't change once its created.
+func (s *service) SaveAttributeDefinition(ctx context.Context, claims tmw.TumblerClaims, definition client.AttributeDefinition) (*client.AttributeDefinition, error) {
+	if err := definition.Validate(); err != nil {
+		return nil, err
+	}
+
+	definition.TenantID = claims.TenantID.String()
+	definition.CreatedOn = s.time.Now()
+	definition.LastUpdatedOn = s.time.Now()
+
+	existing, err := s.repository.getAttributeDefinition(ctx, definition.TenantID, definition.Name)
+	if err != nil && err != sql.ErrNoRows {
+		return nil, err
+	}
+
+	if existing != nil {
+		if existing.Type != definition.Type {
+			return nil, ErrAttributeTypeChange
+		}
+		definition.CreatedOn = existing.CreatedOn
+	}
+
+	return s.repository.saveAttributeDefinition(ctx, definition)
+}
+
+// DeleteAttributeDefinition - Removes a custom attribute of the tenant along with its values on every identity.
+func (s *service) DeleteAttributeDefinition(ctx context.Context, claims tmw.TumblerClaims, name string) error {
+	err := s.repository.deleteAttributeDefinition(ctx, claims.TenantID.String(), name)
+	if err == sql.ErrNoRows {
+		return ErrAttributeNotFound
+	}
+	return err
+}
+
+// checkAttributes - Validates the attributes against the definitions of the tenant.
+// Returns them normalized so they are saved and compared the same way every time.
+func (s *service) checkAttributes(ctx context.Context, tenantID string, attributes map[string]interface{}) (map[string]interface{}, error) {
+	if len(attributes) == 0 {
+		return nil, nil
+	}
+
+	definitions, err := s.definitionsByName(ctx, tenantID)
+	if err != nil {
+		return nil, err
+	}
+
+	checked := map[string]interface{}{}
+	errs := validation.Errors{}
+	for name, value := range attributes {
+		// Same as leaving it out in a merge patch.
+		if value == nil {
+			continue
+		}
+
+		definition, ok := definitions[name]
+		if !ok {
+			errs[name] = errors.New("attribute isn't defined")
+			continue
+		}
+
+		v, err := checkAttribute(definition, value)
+		if err != nil {
+			errs[name] = err
+			continue
+		}
+
+		checked[name] = v
+	}
+
+	if len(errs) > 0 {
+		return nil, validation.Errors{"attributes": errs}
+	}
+
+	return checked, nil
+}
+
+// attributeFilter - Turns the attribute values from a list query into the text they are stored as.
+func (s *service) attributeFilter(ctx context.Context, tenantID string, attributes map[string]string) (map[string]string, error) {
+	if len(attributes) == 0 {
+		return nil, nil
+	}
+
+	definitions, err := s.definitionsByName(ctx, tenantID)
+	if err != nil {
+		return nil, err
+	}
+
+	filter := map[string]string{}
+	errs := validation.Errors{}
+	for name, text := range attributes {
+		definition, ok := definitions[name]
+		if !ok {
+			errs[name] = errors.New("attribute isn't defined")
+			continue
+		}
+
+		value, err := parseAttribute(definition, text)
+		if err == nil {
+			value, err = checkAttribute(definition, value)
+		}
+		if err != nil {
+			errs[name] = err
+			continue
+		}
+
+		filter[name] = formatAttribute(value)
+	}
+
+	if len(errs) > 0 {
+		return nil, validation.Errors{"attributes": errs}
+	}
+
+	return filter, nil
+}
+
+func (s *service) definitionsByName(ctx context.Context, tenantID string) (map[string]client.AttributeDefinition, error) {
+	definitions, err := s.repository.listAttributeDefinitions(ctx, tenantID)
+	if err != nil {
+		return nil, err
+	}
+
+	byName := map[string]client.AttributeDefinition{}
+	for _, d := range definitions {
+		byName[d.Name] = d
+	}
+
+	return byName, nil
+}
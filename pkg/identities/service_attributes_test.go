@@ -0,0 +1,196 @@
+package identities_test
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/moov-io/identity/pkg/client"
+	. "github.com/moov-io/identity/pkg/identities"
+)
+
+func Test_AttributeDefinitionsAPI(t *testing.T) {
+	a, s, _ := Setup(t)
+
+	DefineAttributes(s)
+
+	resp := s.Request("GET", "/attributes", nil, nil)
+	a.Equal(200, resp.StatusCode)
+
+	definitions := []client.AttributeDefinition{}
+	a.Nil(json.NewDecoder(resp.Body).Decode(&definitions))
+	a.Len(definitions, 4)
+	a.Equal("active", definitions[0].Name)
+	a.Equal("department", definitions[1].Name)
+	a.Equal([]string{"sales", "engineering"}, definitions[1].EnumValues)
+	a.Equal(s.session.TenantID.String(), definitions[1].TenantID)
+
+	// enums need values to pick from
+	resp = s.Request("PUT", "/attributes/team", client.AttributeDefinition{Type: AttributeEnum}, nil)
+	a.Equal(400, resp.StatusCode)
+
+	resp = s.Request("PUT", "/attributes/bad-name", client.AttributeDefinition{Type: AttributeString}, nil)
+	a.Equal(400, resp.StatusCode)
+
+	resp = s.Request("PUT", "/attributes/level", client.AttributeDefinition{Type: AttributeString}, nil)
+	a.Equal(409, resp.StatusCode)
+
+	s.time.Add(time.Millisecond)
+	resp = s.Request("PUT", "/attributes/department", client.AttributeDefinition{Type: AttributeEnum, EnumValues: []string{"sales", "engineering", "support"}}, nil)
+	a.Equal(200, resp.StatusCode)
+
+	updated := client.AttributeDefinition{}
+	a.Nil(json.NewDecoder(resp.Body).Decode(&updated))
+	a.Len(updated.EnumValues, 3)
+	a.Equal(definitions[1].CreatedOn, updated.CreatedOn)
+	a.Equal(s.time.Now(), updated.LastUpdatedOn)
+
+	resp = s.Request("DELETE", "/attributes/department", nil, nil)
+	a.Equal(204, resp.StatusCode)
+
+	resp = s.Request("DELETE", "/attributes/department", nil, nil)
+	a.Equal(404, resp.StatusCode)
+}
+
+func Test_Attributes(t *testing.T) {
+	a, s, f := Setup(t)
+
+	DefineAttributes(s)
+
+	identity := RegisterIdentity(s, f)
+
+	s.time.Add(time.Millisecond)
+	patched, err := s.service.PatchIdentity(context.Background(), s.session, identity.IdentityID, identity.Version, []byte(`{"attributes":{"department":"sales","level":3,"active":true,"started":"2020-01-02"}}`))
+	a.Nil(err)
+	a.Equal(map[string]interface{}{"department": "sales", "level": 3.0, "active": true, "started": "2020-01-02"}, patched.Attributes)
+
+	found, err := s.service.GetIdentity(context.Background(), s.session, identity.IdentityID)
+	a.Nil(err)
+	a.Equal(patched.Attributes, found.Attributes)
+
+	// sub-resource changes leave them alone
+	s.time.Add(time.Millisecond)
+	_, err = s.service.AddPhone(context.Background(), s.session, identity.IdentityID, client.UpdatePhone{Number: "555-555-5555", Type: "mobile"})
+	a.Nil(err)
+
+	found, err = s.service.GetIdentity(context.Background(), s.session, identity.IdentityID)
+	a.Nil(err)
+	a.Equal(patched.Attributes, found.Attributes)
+
+	// null removes it
+	s.time.Add(time.Millisecond)
+	patched, err = s.service.PatchIdentity(context.Background(), s.session, identity.IdentityID, found.Version, []byte(`{"attributes":{"active":null}}`))
+	a.Nil(err)
+	a.NotContains(patched.Attributes, "active")
+
+	history, err := s.service.ListHistory(context.Background(), s.session, identity.IdentityID)
+	a.Nil(err)
+	sales := "sales"
+	active := "true"
+	a.Contains(history[0].Changes, client.FieldChange{Field: "attributes.department", After: &sales})
+	a.Contains(history[2].Changes, client.FieldChange{Field: "attributes.active", Before: &active})
+
+	// values go with the definition
+	a.Nil(s.service.DeleteAttributeDefinition(context.Background(), s.session, "department"))
+
+	found, err = s.service.GetIdentity(context.Background(), s.session, identity.IdentityID)
+	a.Nil(err)
+	a.Equal(map[string]interface{}{"level": 3.0, "started": "2020-01-02"}, found.Attributes)
+}
+
+func Test_Attributes_Invalid(t *testing.T) {
+	a, s, f := Setup(t)
+
+	DefineAttributes(s)
+
+	identity := RegisterIdentity(s, f)
+	headers := map[string]string{
+		"Content-Type": "application/merge-patch+json",
+		"If-Match":     ETag(identity),
+	}
+
+	resp := s.Request("PATCH", "/identities/"+identity.IdentityID, map[string]interface{}{
+		"attributes": map[string]interface{}{
+			"department": "marketing",
+			"level":      "three",
+			"started":    "01/02/2020",
+			"unknown":    "value",
+		},
+	}, headers)
+	a.Equal(400, resp.StatusCode)
+
+	body, err := ioutil.ReadAll(resp.Body)
+	a.Nil(err)
+	a.Contains(string(body), "department")
+	a.Contains(string(body), "level")
+	a.Contains(string(body), "started")
+	a.Contains(string(body), "unknown")
+
+	found, err := s.service.GetIdentity(context.Background(), s.session, identity.IdentityID)
+	a.Nil(err)
+	a.Nil(found.Attributes)
+}
+
+func Test_ListAPI_FilterAttributes(t *testing.T) {
+	a, s, f := Setup(t)
+
+	DefineAttributes(s)
+
+	sales := RegisterIdentity(s, f)
+	_, err := s.service.PatchIdentity(context.Background(), s.session, sales.IdentityID, sales.Version, []byte(`{"attributes":{"department":"sales","level":3}}`))
+	a.Nil(err)
+
+	engineering := RegisterIdentity(s, f)
+	_, err = s.service.PatchIdentity(context.Background(), s.session, engineering.IdentityID, engineering.Version, []byte(`{"attributes":{"department":"engineering","level":3}}`))
+	a.Nil(err)
+
+	list := func(filters url.Values) []client.Identity {
+		resp := s.Request("GET", "/identities?"+filters.Encode(), nil, nil)
+		a.Equal(200, resp.StatusCode)
+
+		found := []client.Identity{}
+		a.Nil(json.NewDecoder(resp.Body).Decode(&found))
+		return found
+	}
+
+	found := list(url.Values{"attributes[department]": {"sales"}})
+	a.Len(found, 1)
+	a.Equal(sales.IdentityID, found[0].IdentityID)
+	a.Equal("sales", found[0].Attributes["department"])
+
+	// numbers are compared by value
+	found = list(url.Values{"attributes[level]": {"3.0"}})
+	a.Len(found, 2)
+
+	found = list(url.Values{"attributes[level]": {"3"}, "attributes[department]": {"engineering"}})
+	a.Len(found, 1)
+	a.Equal(engineering.IdentityID, found[0].IdentityID)
+
+	found = list(url.Values{"attributes[level]": {"4"}})
+	a.Len(found, 0)
+
+	resp := s.Request("GET", "/identities?"+url.Values{"attributes[unknown]": {"x"}}.Encode(), nil, nil)
+	a.Equal(400, resp.StatusCode)
+
+	resp = s.Request("GET", "/identities?"+url.Values{"attributes[level]": {"three"}}.Encode(), nil, nil)
+	a.Equal(400, resp.StatusCode)
+}
+
+// DefineAttributes sets up one attribute of most types for the tenant of the session
+func DefineAttributes(s Scope) {
+	definitions := []client.AttributeDefinition{
+		{Name: "department", Type: AttributeEnum, EnumValues: []string{"sales", "engineering"}},
+		{Name: "level", Type: AttributeNumber},
+		{Name: "active", Type: AttributeBool},
+		{Name: "started", Type: AttributeDate},
+	}
+
+	for _, d := range definitions {
+		if _, err := s.service.SaveAttributeDefinition(context.Background(), s.session, d); err != nil {
+			panic(err)
+		}
+	}
+}
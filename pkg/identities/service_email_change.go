@@ -0,0 +1,160 @@
+package identities
+
+import (
+	"context"
+	"crypto/rand"
+	"database/sql"
+	"encoding/base64"
+	"net/url"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/moov-io/identity/pkg/client"
+	"github.com/moov-io/identity/pkg/notifications"
+	tmw "github.com/moov-io/tumbler/pkg/middleware"
+)
+
+// RequestEmailChange - Starts moving the identity to a new email. The new address gets a link to confirm it
+// and the current one gets a notice. Nothing changes on the identity until the change is confirmed.
+func (s *service) RequestEmailChange(ctx context.Context, claims tmw.TumblerClaims, identityID string, change client.ChangeEmail) (*client.EmailChange, error) {
+	if err := change.Validate(); err != nil {
+		return nil, err
+	}
+
+	identity, err := s.GetIdentity(ctx, claims, identityID)
+	if err != nil {
+		return nil, err
+	}
+
+	if strings.EqualFold(identity.Email, change.Email) {
+		return nil, ErrEmailUnchanged
+	}
+
+	code, err := generateEmailChangeCode()
+	if err != nil {
+		return nil, err
+	}
+
+	confirmURL, err := s.renderEmailChangeURL(identity.TenantID, code)
+	if err != nil {
+		return nil, err
+	}
+
+	emailChange := client.EmailChange{
+		EmailChangeID: uuid.New().String(),
+		TenantID:      identity.TenantID,
+		IdentityID:    identity.IdentityID,
+		NewEmail:      change.Email,
+		RequestedBy:   claims.Subject,
+		RequestedOn:   s.time.Now(),
+		ExpiresOn:     s.time.Now().Add(s.emailChangeExpiration),
+		ConfirmedOn:   nil,
+	}
+
+	confirm := notifications.NewEmailChangeConfirmEmail(confirmURL, *identity, change.Email)
+	confirm.EmailChangeID = emailChange.EmailChangeID
+	notice := notifications.NewEmailChangeNoticeEmail(*identity, change.Email)
+
+	notices := []Notice{
+		{To: change.Email, Email: &confirm},
+		{To: identity.Email, Email: &notice},
+	}
+
+	if err := s.repository.addEmailChange(ctx, emailChange, code, notices); err != nil {
+		return nil, err
+	}
+
+	return &emailChange, nil
+}
+
+// ConfirmEmailChange - Swaps in the new email once the code sent to it comes back.
+// Getting the code back proves access to the new address so it's marked as verified.
+func (s *service) ConfirmEmailChange(ctx context.Context, claims tmw.TumblerClaims, identityID string, confirm client.ConfirmEmailChange) (*client.Identity, error) {
+	if err := confirm.Validate(); err != nil {
+		return nil, err
+	}
+
+	identity, err := s.GetIdentity(ctx, claims, identityID)
+	if err != nil {
+		return nil, err
+	}
+
+	change, err := s.repository.getEmailChange(ctx, identity.TenantID, identity.IdentityID, strings.TrimSpace(confirm.Code))
+	if err == sql.ErrNoRows {
+		return nil, ErrEmailChangeNotFound
+	} else if err != nil {
+		return nil, err
+	}
+
+	if change.ExpiresOn.Before(s.time.Now()) {
+		return nil, ErrEmailChangeExpired
+	}
+
+	confirmedOn := s.time.Now()
+	change.ConfirmedOn = &confirmedOn
+
+	before := *identity
+
+	identity.Email = change.NewEmail
+	identity.EmailVerified = true
+	identity.LastUpdatedOn = s.time.Now()
+
+	changes := DiffIdentity(before, *identity)
+
+	return s.repository.confirmEmailChange(ctx, *identity, s.newIdentityChange(claims, *identity, changes), *change, s.changeNotices(before.Email, *identity, changes))
+}
+
+// FillEmail - Puts the link to confirm the change back into its email as its sent from the outbox.
+// The link isn't stored with the email since anyone with it can confirm the change.
+func (s *service) FillEmail(ctx context.Context, tenantID string, email notifications.EmailTemplate) error {
+	confirm, ok := email.(*notifications.EmailChangeConfirmEmail)
+	if !ok {
+		return nil
+	}
+
+	code, err := s.repository.getEmailChangeCode(ctx, tenantID, confirm.EmailChangeID)
+	if err != nil {
+		return err
+	}
+
+	confirmURL, err := s.renderEmailChangeURL(tenantID, code)
+	if err != nil {
+		return err
+	}
+
+	confirm.ConfirmURL = confirmURL
+	return nil
+}
+
+func (s *service) renderEmailChangeURL(tenantID string, code string) (string, error) {
+	data := struct {
+		TenantID string
+	}{tenantID}
+
+	rendered := strings.Builder{}
+	if err := s.emailChangeURL.Execute(&rendered, data); err != nil {
+		return "", err
+	}
+
+	confirmURL, err := url.Parse(rendered.String())
+	if err != nil {
+		return "", err
+	}
+
+	qry := confirmURL.Query()
+	qry.Add("confirm_code", code)
+	confirmURL.RawQuery = qry.Encode()
+
+	return confirmURL.String(), nil
+}
+
+// Generate a large random crypto string to work as the confirmation code
+func generateEmailChangeCode() (string, error) {
+	b := make([]byte, 32)
+	_, err := rand.Read(b)
+	if err != nil {
+		return "", err
+	}
+
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
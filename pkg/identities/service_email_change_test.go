@@ -0,0 +1,171 @@
+package identities_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/moov-io/identity/pkg/client"
+	. "github.com/moov-io/identity/pkg/identities"
+	"github.com/moov-io/identity/pkg/notifications"
+)
+
+func Test_EmailChange(t *testing.T) {
+	a, s, f := Setup(t)
+
+	identity := RegisterIdentity(s, f)
+
+	resp := s.Request("POST", "/identities/"+identity.IdentityID+"/email", client.ChangeEmail{Email: "new@example.com"}, nil)
+	a.Equal(202, resp.StatusCode)
+
+	change := client.EmailChange{}
+	a.Nil(json.NewDecoder(resp.Body).Decode(&change))
+	a.Equal("new@example.com", change.NewEmail)
+	a.Equal(s.time.Now().Add(time.Hour), change.ExpiresOn)
+	a.Nil(change.ConfirmedOn)
+
+	// confirmation goes to the new address and a notice to the old one
+	a.Len(s.Sent(), 2)
+	a.Len(SentTo(s, "new@example.com"), 1)
+
+	notice := SentTo(s, identity.Email)
+	a.Len(notice, 1)
+	_, ok := notice[0].Email.(*notifications.EmailChangeNoticeEmail)
+	a.True(ok)
+
+	code := ConfirmCode(s, "new@example.com")
+
+	// nothing changes until its confirmed
+	found, err := s.service.GetIdentity(context.Background(), s.session, identity.IdentityID)
+	a.Nil(err)
+	a.Equal(identity.Email, found.Email)
+
+	resp = s.Request("POST", "/identities/"+identity.IdentityID+"/email/confirm", client.ConfirmEmailChange{Code: "nope"}, nil)
+	a.Equal(404, resp.StatusCode)
+
+	s.time.Add(time.Millisecond)
+	resp = s.Request("POST", "/identities/"+identity.IdentityID+"/email/confirm", client.ConfirmEmailChange{Code: code}, nil)
+	a.Equal(200, resp.StatusCode)
+
+	updated := client.Identity{}
+	a.Nil(json.NewDecoder(resp.Body).Decode(&updated))
+	a.Equal("new@example.com", updated.Email)
+	a.True(updated.EmailVerified)
+	a.Equal(identity.Version+1, updated.Version)
+	a.Equal(ETag(updated), resp.Header.Get("ETag"))
+
+	// the old address is told about the change
+	a.Len(s.Sent(), 3)
+	a.Equal(identity.Email, s.Sent()[2].To)
+	_, ok = s.Sent()[2].Email.(*notifications.IdentityChangedEmail)
+	a.True(ok)
+
+	history, err := s.service.ListHistory(context.Background(), s.session, identity.IdentityID)
+	a.Nil(err)
+	a.Len(history, 1)
+	a.Contains(history[0].Changes, client.FieldChange{Field: "email", Before: &identity.Email, After: &updated.Email})
+
+	// codes can only be used once
+	resp = s.Request("POST", "/identities/"+identity.IdentityID+"/email/confirm", client.ConfirmEmailChange{Code: code}, nil)
+	a.Equal(404, resp.StatusCode)
+}
+
+func Test_EmailChange_CodeNotStored(t *testing.T) {
+	a, s, f := Setup(t)
+
+	identity := RegisterIdentity(s, f)
+
+	change, err := s.service.RequestEmailChange(context.Background(), s.session, identity.IdentityID, client.ChangeEmail{Email: "new@example.com"})
+	a.Nil(err)
+
+	// anyone with the link can confirm the change so its filled back in as its sent
+	payload := ""
+	a.Nil(s.db.QueryRow("SELECT payload FROM outbox WHERE recipient = ?", "new@example.com").Scan(&payload))
+	a.Contains(payload, change.EmailChangeID)
+	a.NotContains(payload, "confirm_code")
+
+	code := ConfirmCode(s, "new@example.com")
+	a.NotEmpty(code)
+
+	updated, err := s.service.ConfirmEmailChange(context.Background(), s.session, identity.IdentityID, client.ConfirmEmailChange{Code: code})
+	a.Nil(err)
+	a.Equal("new@example.com", updated.Email)
+}
+
+func Test_EmailChange_Expired(t *testing.T) {
+	a, s, f := Setup(t)
+
+	identity := RegisterIdentity(s, f)
+
+	_, err := s.service.RequestEmailChange(context.Background(), s.session, identity.IdentityID, client.ChangeEmail{Email: "new@example.com"})
+	a.Nil(err)
+
+	s.time.Add(2 * time.Hour)
+
+	resp := s.Request("POST", "/identities/"+identity.IdentityID+"/email/confirm", client.ConfirmEmailChange{Code: ConfirmCode(s, "new@example.com")}, nil)
+	a.Equal(410, resp.StatusCode)
+}
+
+func Test_EmailChange_Superseded(t *testing.T) {
+	a, s, f := Setup(t)
+
+	identity := RegisterIdentity(s, f)
+
+	_, err := s.service.RequestEmailChange(context.Background(), s.session, identity.IdentityID, client.ChangeEmail{Email: "first@example.com"})
+	a.Nil(err)
+	first := ConfirmCode(s, "first@example.com")
+
+	_, err = s.service.RequestEmailChange(context.Background(), s.session, identity.IdentityID, client.ChangeEmail{Email: "second@example.com"})
+	a.Nil(err)
+
+	_, err = s.service.ConfirmEmailChange(context.Background(), s.session, identity.IdentityID, client.ConfirmEmailChange{Code: first})
+	a.Equal(ErrEmailChangeNotFound, err)
+
+	updated, err := s.service.ConfirmEmailChange(context.Background(), s.session, identity.IdentityID, client.ConfirmEmailChange{Code: ConfirmCode(s, "second@example.com")})
+	a.Nil(err)
+	a.Equal("second@example.com", updated.Email)
+}
+
+func Test_EmailChange_Invalid(t *testing.T) {
+	a, s, f := Setup(t)
+
+	identity := RegisterIdentity(s, f)
+
+	resp := s.Request("POST", "/identities/"+identity.IdentityID+"/email", client.ChangeEmail{Email: "not an email"}, nil)
+	a.Equal(400, resp.StatusCode)
+
+	resp = s.Request("POST", "/identities/"+identity.IdentityID+"/email", client.ChangeEmail{Email: strings.ToUpper(identity.Email)}, nil)
+	a.Equal(400, resp.StatusCode)
+
+	a.Len(s.Sent(), 0)
+}
+
+// SentTo - The emails sent to the address. Emails queued together can go out in any order.
+func SentTo(s Scope, to string) []notifications.SentEmail {
+	sent := []notifications.SentEmail{}
+	for _, email := range s.Sent() {
+		if email.To == to {
+			sent = append(sent, email)
+		}
+	}
+	return sent
+}
+
+// ConfirmCode pulls the code out of the link in the confirmation email that was sent to the new address
+func ConfirmCode(s Scope, to string) string {
+	email := SentTo(s, to)[0].Email.(*notifications.EmailChangeConfirmEmail)
+
+	link, err := url.Parse(email.ConfirmURL)
+	if err != nil {
+		panic(err)
+	}
+
+	if !strings.Contains(link.Path, s.session.TenantID.String()) {
+		panic("confirm link is missing the tenant")
+	}
+
+	return link.Query().Get("confirm_code")
+}
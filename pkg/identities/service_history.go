@@ -0,0 +1,43 @@
+package identities
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+	"github.com/moov-io/identity/pkg/client"
+	"github.com/moov-io/identity/pkg/notifications"
+	tmw "github.com/moov-io/tumbler/pkg/middleware"
+)
+
+// ListHistory - Lists every update made to the identity with the fields it changed, oldest first.
+func (s *service) ListHistory(ctx context.Context, claims tmw.TumblerClaims, identityID string) ([]client.IdentityChange, error) {
+	identity, err := s.GetIdentity(ctx, claims, identityID)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.repository.listHistory(ctx, identity.TenantID, identity.IdentityID)
+}
+
+func (s *service) newIdentityChange(claims tmw.TumblerClaims, identity client.Identity, changes []client.FieldChange) client.IdentityChange {
+	return client.IdentityChange{
+		IdentityChangeID: uuid.New().String(),
+		TenantID:         identity.TenantID,
+		IdentityID:       identity.IdentityID,
+		Changes:          changes,
+		ChangedBy:        claims.Subject,
+		ChangedOn:        s.time.Now(),
+	}
+}
+
+// changeNotices - Lets the identity know about security relevant changes.
+// Goes to the email on file before the update so a changed email still reaches the owner.
+func (s *service) changeNotices(to string, identity client.Identity, changes []client.FieldChange) []Notice {
+	security := SecurityChanges(changes)
+	if len(security) == 0 {
+		return nil
+	}
+
+	email := notifications.NewIdentityChangedEmail(identity, security, s.time.Now())
+	return []Notice{{To: to, Email: &email}}
+}
@@ -0,0 +1,187 @@
+package identities_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/moov-io/identity/pkg/client"
+	. "github.com/moov-io/identity/pkg/identities"
+	"github.com/moov-io/identity/pkg/notifications"
+	"github.com/moov-io/identity/pkg/outbox"
+)
+
+func Test_History(t *testing.T) {
+	a, s, f := Setup(t)
+
+	identity := RegisterIdentity(s, f)
+
+	s.time.Add(time.Millisecond)
+	patched, err := s.service.PatchIdentity(context.Background(), s.session, identity.IdentityID, identity.Version, []byte(`{"firstName":"Jane","nickName":"JJ"}`))
+	a.Nil(err)
+
+	s.time.Add(time.Millisecond)
+	phone, err := s.service.AddPhone(context.Background(), s.session, identity.IdentityID, client.UpdatePhone{Number: "555-555-5555", Type: "mobile"})
+	a.Nil(err)
+
+	s.time.Add(time.Millisecond)
+	a.Nil(s.service.RemovePhone(context.Background(), s.session, identity.IdentityID, phone.PhoneID))
+
+	history, err := s.service.ListHistory(context.Background(), s.session, identity.IdentityID)
+	a.Nil(err)
+	a.Len(history, 3)
+
+	a.Equal(s.session.Subject, history[0].ChangedBy)
+	a.Equal(patched.LastUpdatedOn, history[0].ChangedOn)
+	a.Contains(history[0].Changes, client.FieldChange{Field: "firstName", Before: &identity.FirstName, After: &patched.FirstName})
+	a.Contains(history[0].Changes, client.FieldChange{Field: "nickName", Before: identity.NickName, After: patched.NickName})
+	a.Len(history[0].Changes, 2)
+
+	number := "555-555-5555"
+	a.Contains(history[1].Changes, client.FieldChange{Field: "phones." + phone.PhoneID + ".number", After: &number})
+	a.Contains(history[2].Changes, client.FieldChange{Field: "phones." + phone.PhoneID + ".number", Before: &number})
+
+	// only the name change is worth an email
+	a.Len(s.Sent(), 1)
+	a.Equal(identity.Email, s.Sent()[0].To)
+
+	email, ok := s.Sent()[0].Email.(*notifications.IdentityChangedEmail)
+	a.True(ok)
+	a.Equal([]client.FieldChange{{Field: "firstName", Before: &identity.FirstName, After: &patched.FirstName}}, email.Changes)
+}
+
+func Test_History_NothingChanged(t *testing.T) {
+	a, s, f := Setup(t)
+
+	identity := RegisterIdentity(s, f)
+
+	s.time.Add(time.Millisecond)
+	_, err := s.service.PatchIdentity(context.Background(), s.session, identity.IdentityID, identity.Version, []byte(`{}`))
+	a.Nil(err)
+
+	history, err := s.service.ListHistory(context.Background(), s.session, identity.IdentityID)
+	a.Nil(err)
+	a.Len(history, 0)
+	a.Len(s.Sent(), 0)
+}
+
+// The emails go out after the change is saved, so not being able to send them doesn't fail the change.
+func Test_History_DeliveryFails(t *testing.T) {
+	a, s, f := Setup(t)
+
+	identity := RegisterIdentity(s, f)
+
+	resp := s.Request("POST", "/identities/"+identity.IdentityID+"/email", client.ChangeEmail{Email: "new@example.com"}, nil)
+	a.Equal(202, resp.StatusCode)
+	code := ConfirmCode(s, "new@example.com")
+	sent := len(s.sent.Sent())
+
+	s.sent.err = errors.New("smtp is down")
+
+	s.time.Add(time.Millisecond)
+	resp = s.Request("PATCH", "/identities/"+identity.IdentityID, map[string]interface{}{"firstName": "Jane"}, map[string]string{
+		"Content-Type": "application/merge-patch+json",
+		"If-Match":     ETag(identity),
+	})
+	a.Equal(200, resp.StatusCode)
+
+	s.time.Add(time.Millisecond)
+	resp = s.Request("POST", "/identities/"+identity.IdentityID+"/phones", client.UpdatePhone{Number: "555-555-5555", Type: "mobile"}, nil)
+	a.Equal(201, resp.StatusCode)
+
+	s.time.Add(time.Millisecond)
+	resp = s.Request("POST", "/identities/"+identity.IdentityID+"/addresses", client.UpdateAddress{
+		Type:       "primary",
+		Address1:   "123 Main St",
+		City:       "Des Moines",
+		PostalCode: "50309",
+		State:      "IA",
+		Country:    "US",
+	}, nil)
+	a.Equal(201, resp.StatusCode)
+
+	s.time.Add(time.Millisecond)
+	resp = s.Request("POST", "/identities/"+identity.IdentityID+"/email/confirm", client.ConfirmEmailChange{Code: code}, nil)
+	a.Equal(200, resp.StatusCode)
+
+	found, err := s.service.GetIdentity(context.Background(), s.session, identity.IdentityID)
+	a.Nil(err)
+	a.Equal("Jane", found.FirstName)
+	a.Equal("new@example.com", found.Email)
+	a.Len(found.Phones, len(identity.Phones)+1)
+	a.Len(found.Addresses, len(identity.Addresses)+1)
+
+	history, err := s.service.ListHistory(context.Background(), s.session, identity.IdentityID)
+	a.Nil(err)
+	a.Len(history, 4)
+
+	// the name and email changes wait in the outbox until they can be sent
+	a.Len(s.Sent(), sent)
+
+	pending, err := s.outbox.ListMessages(context.Background(), outbox.StatusPending)
+	a.Nil(err)
+	a.Len(pending, 2)
+}
+
+func Test_HistoryAPI(t *testing.T) {
+	a, s, f := Setup(t)
+
+	identity := RegisterIdentity(s, f)
+
+	s.time.Add(time.Millisecond)
+	_, err := s.service.PatchIdentity(context.Background(), s.session, identity.IdentityID, identity.Version, []byte(`{"lastName":"Smith"}`))
+	a.Nil(err)
+
+	resp := s.Request("GET", "/identities/"+identity.IdentityID+"/history", nil, nil)
+	a.Equal(200, resp.StatusCode)
+
+	history := []client.IdentityChange{}
+	a.Nil(json.NewDecoder(resp.Body).Decode(&history))
+	a.Len(history, 1)
+	a.Equal("lastName", history[0].Changes[0].Field)
+
+	resp = s.Request("GET", "/identities/"+uuid.New().String()+"/history", nil, nil)
+	a.Equal(404, resp.StatusCode)
+}
+
+func Test_DiffIdentity(t *testing.T) {
+	a, _, _ := Setup(t)
+
+	apt := "Apt 1"
+	before := client.Identity{
+		FirstName: "John",
+		LastName:  "Doe",
+		Email:     "john@example.com",
+		Phones: []client.Phone{
+			{PhoneID: "p1", Number: "555-555-5555", Type: "mobile"},
+		},
+		Addresses: []client.Address{
+			{AddressID: "a1", Type: "primary", Address1: "123 Main St", City: "Des Moines", State: "IA", PostalCode: "50309", Country: "US"},
+		},
+	}
+
+	after := before
+	after.Email = "jdoe@example.com"
+	after.Phones = []client.Phone{
+		{PhoneID: "p1", Number: "555-555-5555", Type: "mobile", Validated: true},
+	}
+	after.Addresses = []client.Address{
+		{AddressID: "a1", Type: "primary", Address1: "123 Main St", Address2: &apt, City: "Des Moines", State: "IA", PostalCode: "50309", Country: "US"},
+	}
+
+	a.Len(DiffIdentity(before, before), 0)
+
+	changes := DiffIdentity(before, after)
+	a.Len(changes, 3)
+	a.Equal("email", changes[0].Field)
+	a.Equal("phones.p1.validated", changes[1].Field)
+	a.Equal("false", *changes[1].Before)
+	a.Equal("true", *changes[1].After)
+	a.Equal("addresses.a1.address2", changes[2].Field)
+	a.Nil(changes[2].Before)
+
+	a.Equal(changes[:1], SecurityChanges(changes))
+}
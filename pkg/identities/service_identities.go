@@ -1,11 +1,14 @@
 package identities
 
 import (
-	"errors"
+	"context"
+	"text/template"
+	"time"
 
 	"github.com/google/uuid"
 	"github.com/moov-io/identity/pkg/api"
 	"github.com/moov-io/identity/pkg/client"
+	"github.com/moov-io/identity/pkg/notifications"
 	"github.com/moov-io/identity/pkg/stime"
 	tmw "github.com/moov-io/tumbler/pkg/middleware"
 )
@@ -13,46 +16,68 @@ import (
 // Service - Service that implents the logic for the IdentitiesApiServicer
 // This service should implement the business logic for every endpoint for the IdentitiesApi API.
 type Service interface {
-	DisableIdentity(claims tmw.TumblerClaims, identityID string) error
-	GetIdentity(claims tmw.TumblerClaims, identityID string) (*client.Identity, error)
-	ListIdentities(claims tmw.TumblerClaims) ([]client.Identity, error)
-	UpdateIdentity(claims tmw.TumblerClaims, identityID string, update client.UpdateIdentity) (*client.Identity, error)
-
-	Register(register client.Register, invite *client.Invite) (*client.Identity, error)
-	GetIdentityByID(identityID string) (*client.Identity, error)
-
-	UpdateInsecure(identity *client.Identity) (*client.Identity, error)
+	DisableIdentity(ctx context.Context, claims tmw.TumblerClaims, identityID string) error
+	GetIdentity(ctx context.Context, claims tmw.TumblerClaims, identityID string) (*client.Identity, error)
+	ListIdentities(ctx context.Context, claims tmw.TumblerClaims, attributes map[string]string) ([]client.Identity, error)
+	ListIdentitiesByStatus(ctx context.Context, claims tmw.TumblerClaims, status string) ([]client.Identity, error)
+	ImportIdentities(ctx context.Context, claims tmw.TumblerClaims, imports []client.ImportIdentity) ([]client.Identity, error)
+	ExportIdentities(ctx context.Context, claims tmw.TumblerClaims, each func(client.Identity) error) error
+	SearchIdentities(ctx context.Context, claims tmw.TumblerClaims, query string, limit int) ([]client.Identity, error)
+	UpdateIdentity(ctx context.Context, claims tmw.TumblerClaims, identityID string, version int64, update client.UpdateIdentity) (*client.Identity, error)
+	PatchIdentity(ctx context.Context, claims tmw.TumblerClaims, identityID string, version int64, patch []byte) (*client.Identity, error)
+	AddPhone(ctx context.Context, claims tmw.TumblerClaims, identityID string, phone client.UpdatePhone) (*client.Phone, error)
+	UpdatePhone(ctx context.Context, claims tmw.TumblerClaims, identityID string, phoneID string, phone client.UpdatePhone) (*client.Phone, error)
+	RemovePhone(ctx context.Context, claims tmw.TumblerClaims, identityID string, phoneID string) error
+	AddAddress(ctx context.Context, claims tmw.TumblerClaims, identityID string, address client.UpdateAddress) (*client.Address, error)
+	UpdateAddress(ctx context.Context, claims tmw.TumblerClaims, identityID string, addressID string, address client.UpdateAddress) (*client.Address, error)
+	RemoveAddress(ctx context.Context, claims tmw.TumblerClaims, identityID string, addressID string) error
+	MergeIdentities(ctx context.Context, claims tmw.TumblerClaims, survivorID string, merge client.MergeIdentities) (*client.MergeReport, error)
+	ChangeStatus(ctx context.Context, claims tmw.TumblerClaims, identityID string, status string, change client.ChangeStatus, notify Notify) (*client.Identity, error)
+	ListStatusHistory(ctx context.Context, claims tmw.TumblerClaims, identityID string) ([]client.StatusChange, error)
+	ListHistory(ctx context.Context, claims tmw.TumblerClaims, identityID string) ([]client.IdentityChange, error)
+	RequestEmailChange(ctx context.Context, claims tmw.TumblerClaims, identityID string, change client.ChangeEmail) (*client.EmailChange, error)
+	ConfirmEmailChange(ctx context.Context, claims tmw.TumblerClaims, identityID string, confirm client.ConfirmEmailChange) (*client.Identity, error)
+	FillEmail(ctx context.Context, tenantID string, email notifications.EmailTemplate) error
+	ListAttributeDefinitions(ctx context.Context, claims tmw.TumblerClaims) ([]client.AttributeDefinition, error)
+	SaveAttributeDefinition(ctx context.Context, claims tmw.TumblerClaims, definition client.AttributeDefinition) (*client.AttributeDefinition, error)
+	DeleteAttributeDefinition(ctx context.Context, claims tmw.TumblerClaims, name string) error
+
+	Register(ctx context.Context, register client.Register, invite *client.Invite, status string, notify Notify) (*client.Identity, error)
+	GetIdentityByID(ctx context.Context, identityID string) (*client.Identity, error)
+
+	UpdateInsecure(ctx context.Context, identity *client.Identity) (*client.Identity, error)
 }
 
 type service struct {
-	time       stime.TimeService
-	repository Repository
+	emailChangeURL        *template.Template
+	emailChangeExpiration time.Duration
+	time                  stime.TimeService
+	repository            Repository
 }
 
 // NewIdentitiesService creates a default service
-func NewIdentitiesService(time stime.TimeService, repository Repository) Service {
-	return &service{
-		time:       time,
-		repository: repository,
+func NewIdentitiesService(config Config, time stime.TimeService, repository Repository) (Service, error) {
+	emailChangeURL, err := template.New("email-change").Parse(config.EmailChangeURL)
+	if err != nil {
+		return nil, err
 	}
+
+	return &service{
+		emailChangeURL:        emailChangeURL,
+		emailChangeExpiration: config.EmailChangeExpiration,
+		time:                  time,
+		repository:            repository,
+	}, nil
 }
 
 // DisableIdentity - Disable an identity. Its left around for historical reporting
-func (s *service) DisableIdentity(claims tmw.TumblerClaims, identityID string) error {
-	identity, err := s.GetIdentity(claims, identityID)
+func (s *service) DisableIdentity(ctx context.Context, claims tmw.TumblerClaims, identityID string) error {
+	identity, err := s.GetIdentity(ctx, claims, identityID)
 	if err != nil {
 		return err
 	}
 
-	now := s.time.Now()
-	callerIdentityID := claims.Subject
-
-	identity.DisabledOn = &now
-	identity.DisabledBy = &callerIdentityID
-	identity.LastUpdatedOn = s.time.Now()
-
-	_, nil := s.repository.update(*identity)
-	if err != nil {
+	if _, err := s.transition(ctx, claims, identity, StatusDisabled, "identity disabled", nil); err != nil {
 		return err
 	}
 
@@ -61,43 +86,71 @@ func (s *service) DisableIdentity(claims tmw.TumblerClaims, identityID string) e
 }
 
 // GetIdentity - List identities and associates userId
-func (s *service) GetIdentity(claims tmw.TumblerClaims, identityID string) (*client.Identity, error) {
-	i, e := s.GetIdentityByID(identityID)
+func (s *service) GetIdentity(ctx context.Context, claims tmw.TumblerClaims, identityID string) (*client.Identity, error) {
+	i, e := s.GetIdentityByID(ctx, identityID)
 	if e != nil {
 		return nil, e
 	}
 
 	if i.TenantID != claims.TenantID.String() {
-		return nil, errors.New("TenantID of session user doesn't match retrieved identity")
+		return nil, ErrIdentityNotFound
 	}
 
 	return i, nil
 }
 
-// ListIdentities - List identities and associates userId
-func (s *service) ListIdentities(claims tmw.TumblerClaims) ([]client.Identity, error) {
-	identities, err := s.repository.list(api.TenantID(claims.TenantID))
+// ListIdentities - List identities and associates userId. Only identities with all of the attribute values passed in are returned.
+func (s *service) ListIdentities(ctx context.Context, claims tmw.TumblerClaims, attributes map[string]string) ([]client.Identity, error) {
+	filter, err := s.attributeFilter(ctx, claims.TenantID.String(), attributes)
+	if err != nil {
+		return nil, err
+	}
+
+	identities, err := s.repository.list(ctx, api.TenantID(claims.TenantID), filter)
 	return identities, err
 }
 
-// UpdateIdentity - Update a specific Identity
-func (s *service) UpdateIdentity(claims tmw.TumblerClaims, identityID string, update client.UpdateIdentity) (*client.Identity, error) {
+// ListIdentitiesByStatus - Lists the identities of the tenant that are in the status passed in.
+func (s *service) ListIdentitiesByStatus(ctx context.Context, claims tmw.TumblerClaims, status string) ([]client.Identity, error) {
+	return s.repository.listByStatus(ctx, api.TenantID(claims.TenantID), status)
+}
+
+// UpdateIdentity - Update a specific Identity. The version is the one the caller last saw, if its been changed since the update is rejected.
+func (s *service) UpdateIdentity(ctx context.Context, claims tmw.TumblerClaims, identityID string, version int64, update client.UpdateIdentity) (*client.Identity, error) {
 	if err := update.Validate(); err != nil {
 		return nil, err
 	}
 
-	identity, err := s.GetIdentity(claims, identityID)
+	identity, err := s.GetIdentity(ctx, claims, identityID)
 	if err != nil {
 		return nil, err
 	}
 
+	// Checked again in the update transaction in case it changes between here and there.
+	if identity.Version != version {
+		return nil, ErrVersionConflict
+	}
+
+	return s.applyUpdate(ctx, claims, identity, update)
+}
+
+// applyUpdate - Writes the validated update over the identity and saves it along with what changed.
+func (s *service) applyUpdate(ctx context.Context, claims tmw.TumblerClaims, identity *client.Identity, update client.UpdateIdentity) (*client.Identity, error) {
+	attributes, err := s.checkAttributes(ctx, identity.TenantID, update.Attributes)
+	if err != nil {
+		return nil, err
+	}
+
+	before := *identity
+
 	identity.FirstName = update.FirstName
 	identity.MiddleName = update.MiddleName
 	identity.LastName = update.LastName
 	identity.NickName = update.NickName
 	identity.Suffix = update.Suffix
 	identity.BirthDate = update.BirthDate
-	identity.Status = update.Status
+	identity.Locale = update.Locale
+	identity.Attributes = attributes
 	identity.LastUpdatedOn = s.time.Now()
 
 	identity.Phones = []client.Phone{}
@@ -143,22 +196,66 @@ func (s *service) UpdateIdentity(claims tmw.TumblerClaims, identityID string, up
 		)
 	}
 
-	updated, err := s.repository.update(*identity)
-	if err != nil {
-		return nil, err
+	changes := DiffIdentity(before, *identity)
+	if len(changes) == 0 {
+		return s.repository.update(ctx, *identity)
 	}
 
-	// @TODO record update and email identity that changes were made.
-
-	return updated, err
+	return s.repository.updateWithHistory(ctx, *identity, s.newIdentityChange(claims, *identity, changes), s.changeNotices(before.Email, *identity, changes))
 }
 
 // Register - Takes an invite and the registration information and creates the new identity from it.
-func (s *service) Register(register client.Register, invite *client.Invite) (*client.Identity, error) {
+func (s *service) Register(ctx context.Context, register client.Register, invite *client.Invite, status string, notify Notify) (*client.Identity, error) {
 	if err := register.Validate(); err != nil {
 		return nil, err
 	}
 
+	identity := s.newIdentity(register)
+
+	if invite != nil {
+		identity.TenantID = invite.TenantID
+		identity.InviteID = &invite.InviteID
+
+		// They've already been reading emails in the language they were invited in.
+		if identity.Locale == nil {
+			identity.Locale = invite.Locale
+		}
+	}
+
+	// Waiting on approval starts the identity's status history so the approval shows up after it.
+	var change *client.StatusChange
+	switch status {
+	case StatusActive:
+	case StatusPending:
+		change = &client.StatusChange{
+			StatusChangeID: uuid.New().String(),
+			TenantID:       identity.TenantID,
+			IdentityID:     identity.IdentityID,
+			FromStatus:     "",
+			ToStatus:       status,
+			Reason:         "registered",
+			ChangedBy:      identity.IdentityID,
+			ChangedOn:      s.time.Now(),
+		}
+		identity.Status = status
+	default:
+		return nil, ErrInvalidStatusTransition
+	}
+
+	saved, err := s.repository.register(ctx, identity, change, notify.notices(identity))
+	if err != nil {
+		return nil, err
+	}
+
+	// @TODO record user was registered
+
+	// @TODO send email notification to get registered email verified
+
+	return saved, nil
+}
+
+// newIdentity - Builds the active identity along with its phones and addresses out of the registration.
+func (s *service) newIdentity(register client.Register) client.Identity {
 	identityID := uuid.New().String()
 
 	phones := []client.Phone{}
@@ -198,7 +295,7 @@ func (s *service) Register(register client.Register, invite *client.Invite) (*cl
 		NickName:      register.NickName,
 		Suffix:        register.Suffix,
 		BirthDate:     register.BirthDate,
-		Status:        "none",
+		Status:        StatusActive,
 		Email:         register.Email,
 		EmailVerified: false,
 		Phones:        phones,
@@ -207,28 +304,15 @@ func (s *service) Register(register client.Register, invite *client.Invite) (*cl
 		LastLogin:     client.LastLogin{},
 		LastUpdatedOn: s.time.Now(),
 		ImageUrl:      register.ImageUrl,
+		Locale:        register.Locale,
 	}
 
-	if invite != nil {
-		identity.TenantID = invite.TenantID
-		identity.InviteID = &invite.InviteID
-	}
-
-	saved, err := s.repository.add(identity)
-	if err != nil {
-		return nil, err
-	}
-
-	// @TODO record user was registered
-
-	// @TODO send email notification to get registered email verified
-
-	return saved, nil
+	return identity
 }
 
 // GetIdentityByID - Returns the Identity specified by the ID. Used after a login session to get identity information
-func (s *service) GetIdentityByID(identityID string) (*client.Identity, error) {
-	i, e := s.repository.get(identityID)
+func (s *service) GetIdentityByID(ctx context.Context, identityID string) (*client.Identity, error) {
+	i, e := s.repository.get(ctx, identityID)
 	if e != nil {
 		return nil, e
 	}
@@ -237,8 +321,8 @@ func (s *service) GetIdentityByID(identityID string) (*client.Identity, error) {
 }
 
 // UpdateInsecure - Updates an Identity with new values
-func (s *service) UpdateInsecure(identity *client.Identity) (*client.Identity, error) {
-	u, e := s.repository.update(*identity)
+func (s *service) UpdateInsecure(ctx context.Context, identity *client.Identity) (*client.Identity, error) {
+	u, e := s.repository.update(ctx, *identity)
 	if e != nil {
 		return nil, e
 	}
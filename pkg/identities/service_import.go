@@ -0,0 +1,75 @@
+package identities
+
+import (
+	"context"
+
+	"github.com/moov-io/identity/pkg/client"
+	tmw "github.com/moov-io/tumbler/pkg/middleware"
+)
+
+const exportPageSize = 100
+
+// ImportIdentities - Creates identities in the tenant for people brought over in bulk. They are saved together,
+// if any of them can't be none of them are.
+func (s *service) ImportIdentities(ctx context.Context, claims tmw.TumblerClaims, imports []client.ImportIdentity) ([]client.Identity, error) {
+	identities := []client.Identity{}
+	for _, imp := range imports {
+		if err := imp.Validate(); err != nil {
+			return nil, err
+		}
+
+		register := client.Register{
+			TenantID:   claims.TenantID.String(),
+			FirstName:  imp.FirstName,
+			MiddleName: imp.MiddleName,
+			LastName:   imp.LastName,
+			NickName:   imp.NickName,
+			Suffix:     imp.Suffix,
+			BirthDate:  imp.BirthDate,
+			Email:      imp.Email,
+		}
+
+		for _, p := range imp.Phones {
+			register.Phones = append(register.Phones, client.RegisterPhone{Number: p.Number, Type: p.Type})
+		}
+
+		for _, a := range imp.Addresses {
+			register.Addresses = append(register.Addresses, client.RegisterAddress{
+				Type:       a.Type,
+				Address1:   a.Address1,
+				Address2:   a.Address2,
+				City:       a.City,
+				State:      a.State,
+				PostalCode: a.PostalCode,
+				Country:    a.Country,
+			})
+		}
+
+		identities = append(identities, s.newIdentity(register))
+	}
+
+	return s.repository.addBatch(ctx, identities)
+}
+
+// ExportIdentities - Hands every identity of the tenant with its phones and addresses to each in order of their IDs.
+// They are read a page at a time so the tenant is never loaded all at once. Stops at the first error each returns.
+func (s *service) ExportIdentities(ctx context.Context, claims tmw.TumblerClaims, each func(client.Identity) error) error {
+	after := ""
+	for {
+		page, err := s.repository.listPage(ctx, claims.TenantID.String(), after, exportPageSize)
+		if err != nil {
+			return err
+		}
+
+		for _, identity := range page {
+			if err := each(identity); err != nil {
+				return err
+			}
+		}
+
+		if len(page) < exportPageSize {
+			return nil
+		}
+		after = page[len(page)-1].IdentityID
+	}
+}
@@ -0,0 +1,52 @@
+package identities_test
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	validation "github.com/go-ozzo/ozzo-validation/v4"
+	"github.com/moov-io/identity/pkg/client"
+)
+
+func Test_ImportIdentities(t *testing.T) {
+	a, s, _ := Setup(t)
+
+	imports := []client.ImportIdentity{}
+	for i := 0; i < 105; i++ {
+		imports = append(imports, client.ImportIdentity{
+			Email:     fmt.Sprintf("person%d@moov.io", i),
+			FirstName: "Ada",
+			LastName:  "Lovelace",
+			Phones:    []client.UpdatePhone{{Number: "555-123-4567", Type: "mobile"}},
+		})
+	}
+
+	created, err := s.service.ImportIdentities(context.Background(), s.session, imports)
+	a.Nil(err)
+	a.Len(created, 105)
+	a.Equal(s.session.TenantID.String(), created[0].TenantID)
+	a.Equal(int64(1), created[0].Version)
+
+	// Exported across pages in order of their IDs
+	exported := []client.Identity{}
+	err = s.service.ExportIdentities(context.Background(), s.session, func(identity client.Identity) error {
+		exported = append(exported, identity)
+		return nil
+	})
+	a.Nil(err)
+	a.Len(exported, 105)
+	for i := 1; i < len(exported); i++ {
+		a.Less(exported[i-1].IdentityID, exported[i].IdentityID)
+	}
+	a.Len(exported[0].Phones, 1)
+
+	// Nothing is saved when one of them is invalid
+	imports[1].Phones[0].Number = "12"
+	_, err = s.service.ImportIdentities(context.Background(), s.session, imports)
+	a.IsType(validation.Errors{}, err)
+
+	found, err := s.service.ListIdentities(context.Background(), s.session, nil)
+	a.Nil(err)
+	a.Len(found, 105)
+}
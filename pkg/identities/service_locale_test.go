@@ -0,0 +1,65 @@
+package identities_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/moov-io/identity/pkg/client"
+	. "github.com/moov-io/identity/pkg/identities"
+)
+
+func Test_Register_LocaleFromInvite(t *testing.T) {
+	a, s, f := Setup(t)
+
+	locale := "fr-CA"
+	invite := s.RandomInvite()
+	invite.Locale = &locale
+
+	register := client.Register{}
+	f.Fuzz(&register)
+
+	identity, err := s.service.Register(context.Background(), register, &invite, StatusActive, nil)
+	a.Nil(err)
+	a.Equal(&locale, identity.Locale)
+
+	// What they picked when registering wins over the invite
+	chosen := "en"
+	register = client.Register{}
+	f.Fuzz(&register)
+	register.Locale = &chosen
+
+	identity, err = s.service.Register(context.Background(), register, &invite, StatusActive, nil)
+	a.Nil(err)
+	a.Equal(&chosen, identity.Locale)
+
+	found, err := s.service.GetIdentity(context.Background(), s.session, identity.IdentityID)
+	a.Nil(err)
+	a.Equal(&chosen, found.Locale)
+}
+
+func Test_UpdateAPI_Locale(t *testing.T) {
+	a, s, f := Setup(t)
+
+	identity := RegisterIdentity(s, f)
+
+	locale := "fr"
+	update := client.UpdateIdentity{}
+	f.Fuzz(&update)
+	update.Locale = &locale
+
+	updated, resp, err := s.api.IdentitiesApi.UpdateIdentity(context.Background(), identity.IdentityID, ETag(identity), update)
+	a.Nil(err)
+	a.Equal(200, resp.StatusCode)
+	a.Equal(&locale, updated.Locale)
+
+	history, err := s.service.ListHistory(context.Background(), s.session, identity.IdentityID)
+	a.Nil(err)
+	a.Len(history, 1)
+	a.Contains(history[0].Changes, client.FieldChange{Field: "locale", After: &locale})
+
+	invalid := "not a locale"
+	update.Locale = &invalid
+
+	_, resp, _ = s.api.IdentitiesApi.UpdateIdentity(context.Background(), identity.IdentityID, ETag(updated), update)
+	a.Equal(400, resp.StatusCode)
+}
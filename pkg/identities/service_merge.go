@@ -0,0 +1,66 @@
+package identities
+
+import (
+	"context"
+
+	validation "github.com/go-ozzo/ozzo-validation/v4"
+	"github.com/go-ozzo/ozzo-validation/v4/is"
+	"github.com/moov-io/identity/pkg/client"
+	tmw "github.com/moov-io/tumbler/pkg/middleware"
+)
+
+// MergeIdentities - Moves the credentials, phones, addresses and login history of a duplicate identity over to the survivor
+// and disables the duplicate pointing it at the survivor.
+func (s *service) MergeIdentities(ctx context.Context, claims tmw.TumblerClaims, survivorID string, merge client.MergeIdentities) (*client.MergeReport, error) {
+	if err := validation.ValidateStruct(&merge,
+		validation.Field(&merge.DuplicateID, validation.Required, is.UUID),
+	); err != nil {
+		return nil, err
+	}
+
+	if survivorID == merge.DuplicateID {
+		return nil, ErrMergeSameIdentity
+	}
+
+	survivor, err := s.GetIdentity(ctx, claims, survivorID)
+	if err != nil {
+		return nil, err
+	}
+
+	duplicate, err := s.GetIdentity(ctx, claims, merge.DuplicateID)
+	if err != nil {
+		return nil, err
+	}
+
+	if survivor.MergedInto != nil || duplicate.MergedInto != nil {
+		return nil, ErrIdentityAlreadyMerged
+	}
+
+	if survivor.DisabledOn != nil {
+		return nil, ErrMergeIntoDisabled
+	}
+
+	// The duplicate ends up disabled, record the change unless it already was.
+	var change *client.StatusChange
+	if duplicate.Status != StatusDisabled {
+		if !CanTransition(duplicate.Status, StatusDisabled) {
+			return nil, ErrInvalidStatusTransition
+		}
+
+		c := s.newStatusChange(claims, *duplicate, StatusDisabled, "merged into "+survivor.IdentityID)
+		change = &c
+	}
+
+	now := s.time.Now()
+	mergedBy := claims.Subject
+
+	survivor.LastUpdatedOn = now
+
+	duplicate.Status = StatusDisabled
+	duplicate.DisabledOn = &now
+	duplicate.DisabledBy = &mergedBy
+	duplicate.MergedInto = &survivor.IdentityID
+	duplicate.LastUpdatedOn = now
+
+	return s.repository.merge(ctx, *survivor, *duplicate, change, merge.DryRun)
+}
@@ -0,0 +1,113 @@
+package identities_test
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/moov-io/identity/pkg/client"
+	"github.com/moov-io/identity/pkg/credentials"
+	. "github.com/moov-io/identity/pkg/identities"
+)
+
+func Test_Merge(t *testing.T) {
+	a, s, f := Setup(t)
+
+	survivor := RegisterIdentity(s, f)
+	duplicate := RegisterIdentity(s, f)
+
+	creds := credentials.NewCredentialsService(s.time, credentials.NewCredentialRepository(s.db))
+	cred, err := creds.Register(context.Background(), duplicate.IdentityID, uuid.New().String(), duplicate.TenantID)
+	a.Nil(err)
+	a.Nil(creds.Record(context.Background(), cred.CredentialID, cred.TenantID, "nonce1", "1.2.3.4"))
+	a.Nil(creds.Record(context.Background(), cred.CredentialID, cred.TenantID, "nonce2", "1.2.3.4"))
+
+	s.time.Add(time.Millisecond)
+
+	report, err := s.service.MergeIdentities(context.Background(), s.session, survivor.IdentityID, client.MergeIdentities{DuplicateID: duplicate.IdentityID})
+	a.Nil(err)
+
+	a.False(report.DryRun)
+	a.Equal(survivor.IdentityID, report.SurvivorID)
+	a.Equal(duplicate.IdentityID, report.DuplicateID)
+	a.Equal([]string{cred.CredentialID}, report.Credentials)
+	a.Len(report.Phones, len(duplicate.Phones))
+	a.Len(report.Addresses, len(duplicate.Addresses))
+	a.Equal(int64(2), report.Logins)
+	a.Equal(s.session.Subject, report.MergedBy)
+
+	merged, err := s.service.GetIdentity(context.Background(), s.session, survivor.IdentityID)
+	a.Nil(err)
+	a.Len(merged.Phones, len(survivor.Phones)+len(duplicate.Phones))
+	a.Len(merged.Addresses, len(survivor.Addresses)+len(duplicate.Addresses))
+
+	moved, err := creds.ListCredentials(context.Background(), s.session, survivor.IdentityID)
+	a.Nil(err)
+	a.Len(moved, 1)
+
+	disabled, err := s.service.GetIdentity(context.Background(), s.session, duplicate.IdentityID)
+	a.Nil(err)
+	a.Equal(survivor.IdentityID, *disabled.MergedInto)
+	a.Equal(s.time.Now(), *disabled.DisabledOn)
+	a.Equal(s.session.Subject, *disabled.DisabledBy)
+	a.Len(disabled.Phones, 0)
+	a.Len(disabled.Addresses, 0)
+
+	// Can't merge it again
+	_, err = s.service.MergeIdentities(context.Background(), s.session, survivor.IdentityID, client.MergeIdentities{DuplicateID: duplicate.IdentityID})
+	a.Equal(ErrIdentityAlreadyMerged, err)
+}
+
+func Test_Merge_DryRun(t *testing.T) {
+	a, s, f := Setup(t)
+
+	survivor := RegisterIdentity(s, f)
+	duplicate := RegisterIdentity(s, f)
+
+	report, err := s.service.MergeIdentities(context.Background(), s.session, survivor.IdentityID, client.MergeIdentities{
+		DuplicateID: duplicate.IdentityID,
+		DryRun:      true,
+	})
+	a.Nil(err)
+	a.True(report.DryRun)
+	a.Len(report.Phones, len(duplicate.Phones))
+	a.Len(report.Addresses, len(duplicate.Addresses))
+
+	unchanged, err := s.service.GetIdentity(context.Background(), s.session, duplicate.IdentityID)
+	a.Nil(err)
+	a.Equal(duplicate, *unchanged)
+}
+
+func Test_Merge_SameIdentity(t *testing.T) {
+	a, s, f := Setup(t)
+
+	survivor := RegisterIdentity(s, f)
+
+	_, err := s.service.MergeIdentities(context.Background(), s.session, survivor.IdentityID, client.MergeIdentities{DuplicateID: survivor.IdentityID})
+	a.Equal(ErrMergeSameIdentity, err)
+}
+
+func Test_MergeAPI(t *testing.T) {
+	a, s, f := Setup(t)
+
+	survivor := RegisterIdentity(s, f)
+	duplicate := RegisterIdentity(s, f)
+
+	resp := s.Request("POST", "/identities/"+survivor.IdentityID+"/merge", client.MergeIdentities{DuplicateID: duplicate.IdentityID}, nil)
+	a.Equal(200, resp.StatusCode)
+
+	report := client.MergeReport{}
+	a.Nil(json.NewDecoder(resp.Body).Decode(&report))
+	a.Equal(duplicate.IdentityID, report.DuplicateID)
+
+	resp = s.Request("POST", "/identities/"+survivor.IdentityID+"/merge", client.MergeIdentities{DuplicateID: duplicate.IdentityID}, nil)
+	a.Equal(409, resp.StatusCode)
+
+	resp = s.Request("POST", "/identities/"+survivor.IdentityID+"/merge", client.MergeIdentities{DuplicateID: "notauuid"}, nil)
+	a.Equal(400, resp.StatusCode)
+
+	resp = s.Request("POST", "/identities/"+survivor.IdentityID+"/merge", client.MergeIdentities{DuplicateID: uuid.New().String()}, nil)
+	a.Equal(404, resp.StatusCode)
+}
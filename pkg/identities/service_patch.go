@@ -0,0 +1,282 @@
+package identities
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+
+	"github.com/google/uuid"
+	"github.com/moov-io/identity/pkg/api"
+	"github.com/moov-io/identity/pkg/client"
+	tmw "github.com/moov-io/tumbler/pkg/middleware"
+)
+
+// PatchIdentity - Applies a JSON merge patch to the updatable fields of an identity.
+// The patched result goes through the same validation as a full update.
+func (s *service) PatchIdentity(ctx context.Context, claims tmw.TumblerClaims, identityID string, version int64, patch []byte) (*client.Identity, error) {
+	identity, err := s.GetIdentity(ctx, claims, identityID)
+	if err != nil {
+		return nil, err
+	}
+
+	if identity.Version != version {
+		return nil, ErrVersionConflict
+	}
+
+	current, err := json.Marshal(toUpdateIdentity(*identity))
+	if err != nil {
+		return nil, err
+	}
+
+	patched, err := api.MergePatch(current, patch)
+	if err != nil {
+		return nil, ErrInvalidPatch
+	}
+
+	// Anything outside of what UpdateIdentity accepts can't be patched.
+	update := client.UpdateIdentity{}
+	decoder := json.NewDecoder(bytes.NewReader(patched))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&update); err != nil {
+		return nil, ErrInvalidPatch
+	}
+
+	if err := update.Validate(); err != nil {
+		return nil, err
+	}
+
+	return s.applyUpdate(ctx, claims, identity, update)
+}
+
+// AddPhone - Adds a single phone number to an identity leaving the rest alone.
+func (s *service) AddPhone(ctx context.Context, claims tmw.TumblerClaims, identityID string, phone client.UpdatePhone) (*client.Phone, error) {
+	if err := phone.Validate(); err != nil {
+		return nil, err
+	}
+
+	identity, err := s.GetIdentity(ctx, claims, identityID)
+	if err != nil {
+		return nil, err
+	}
+
+	phone.PhoneID = uuid.New().String()
+
+	update := toUpdateIdentity(*identity)
+	update.Phones = append(update.Phones, phone)
+
+	updated, err := s.validateAndApply(ctx, claims, identity, update)
+	if err != nil {
+		return nil, err
+	}
+
+	return findPhone(updated, phone.PhoneID)
+}
+
+// UpdatePhone - Replaces a single phone number of an identity.
+func (s *service) UpdatePhone(ctx context.Context, claims tmw.TumblerClaims, identityID string, phoneID string, phone client.UpdatePhone) (*client.Phone, error) {
+	if err := phone.Validate(); err != nil {
+		return nil, err
+	}
+
+	identity, err := s.GetIdentity(ctx, claims, identityID)
+	if err != nil {
+		return nil, err
+	}
+
+	update := toUpdateIdentity(*identity)
+
+	found := false
+	for i := range update.Phones {
+		if update.Phones[i].PhoneID == phoneID {
+			phone.PhoneID = phoneID
+			update.Phones[i] = phone
+			found = true
+		}
+	}
+
+	if !found {
+		return nil, ErrPhoneNotFound
+	}
+
+	updated, err := s.validateAndApply(ctx, claims, identity, update)
+	if err != nil {
+		return nil, err
+	}
+
+	return findPhone(updated, phoneID)
+}
+
+// RemovePhone - Removes a single phone number from an identity.
+func (s *service) RemovePhone(ctx context.Context, claims tmw.TumblerClaims, identityID string, phoneID string) error {
+	identity, err := s.GetIdentity(ctx, claims, identityID)
+	if err != nil {
+		return err
+	}
+
+	update := toUpdateIdentity(*identity)
+
+	phones := []client.UpdatePhone{}
+	for _, p := range update.Phones {
+		if p.PhoneID != phoneID {
+			phones = append(phones, p)
+		}
+	}
+
+	if len(phones) == len(update.Phones) {
+		return ErrPhoneNotFound
+	}
+
+	update.Phones = phones
+
+	_, err = s.validateAndApply(ctx, claims, identity, update)
+	return err
+}
+
+// AddAddress - Adds a single address to an identity leaving the rest alone.
+func (s *service) AddAddress(ctx context.Context, claims tmw.TumblerClaims, identityID string, address client.UpdateAddress) (*client.Address, error) {
+	if err := address.Validate(); err != nil {
+		return nil, err
+	}
+
+	identity, err := s.GetIdentity(ctx, claims, identityID)
+	if err != nil {
+		return nil, err
+	}
+
+	address.AddressID = uuid.New().String()
+
+	update := toUpdateIdentity(*identity)
+	update.Addresses = append(update.Addresses, address)
+
+	updated, err := s.validateAndApply(ctx, claims, identity, update)
+	if err != nil {
+		return nil, err
+	}
+
+	return findAddress(updated, address.AddressID)
+}
+
+// UpdateAddress - Replaces a single address of an identity.
+func (s *service) UpdateAddress(ctx context.Context, claims tmw.TumblerClaims, identityID string, addressID string, address client.UpdateAddress) (*client.Address, error) {
+	if err := address.Validate(); err != nil {
+		return nil, err
+	}
+
+	identity, err := s.GetIdentity(ctx, claims, identityID)
+	if err != nil {
+		return nil, err
+	}
+
+	update := toUpdateIdentity(*identity)
+
+	found := false
+	for i := range update.Addresses {
+		if update.Addresses[i].AddressID == addressID {
+			address.AddressID = addressID
+			update.Addresses[i] = address
+			found = true
+		}
+	}
+
+	if !found {
+		return nil, ErrAddressNotFound
+	}
+
+	updated, err := s.validateAndApply(ctx, claims, identity, update)
+	if err != nil {
+		return nil, err
+	}
+
+	return findAddress(updated, addressID)
+}
+
+// RemoveAddress - Removes a single address from an identity.
+func (s *service) RemoveAddress(ctx context.Context, claims tmw.TumblerClaims, identityID string, addressID string) error {
+	identity, err := s.GetIdentity(ctx, claims, identityID)
+	if err != nil {
+		return err
+	}
+
+	update := toUpdateIdentity(*identity)
+
+	addresses := []client.UpdateAddress{}
+	for _, a := range update.Addresses {
+		if a.AddressID != addressID {
+			addresses = append(addresses, a)
+		}
+	}
+
+	if len(addresses) == len(update.Addresses) {
+		return ErrAddressNotFound
+	}
+
+	update.Addresses = addresses
+
+	_, err = s.validateAndApply(ctx, claims, identity, update)
+	return err
+}
+
+// validateAndApply - Sub-resource changes still have to leave the identity valid as a whole.
+// They go against the version just read so a concurrent change is still caught by the update.
+func (s *service) validateAndApply(ctx context.Context, claims tmw.TumblerClaims, identity *client.Identity, update client.UpdateIdentity) (*client.Identity, error) {
+	if err := update.Validate(); err != nil {
+		return nil, err
+	}
+
+	return s.applyUpdate(ctx, claims, identity, update)
+}
+
+// toUpdateIdentity - The updatable view of an identity
+func toUpdateIdentity(identity client.Identity) client.UpdateIdentity {
+	update := client.UpdateIdentity{
+		FirstName:  identity.FirstName,
+		MiddleName: identity.MiddleName,
+		LastName:   identity.LastName,
+		NickName:   identity.NickName,
+		Suffix:     identity.Suffix,
+		BirthDate:  identity.BirthDate,
+		Locale:     identity.Locale,
+		Attributes: identity.Attributes,
+		Phones:     []client.UpdatePhone{},
+		Addresses:  []client.UpdateAddress{},
+	}
+
+	for _, p := range identity.Phones {
+		update.Phones = append(update.Phones, client.UpdatePhone{
+			PhoneID:   p.PhoneID,
+			Number:    p.Number,
+			Validated: p.Validated,
+			Type:      p.Type,
+		})
+	}
+
+	for _, a := range identity.Addresses {
+		update.Addresses = append(update.Addresses, client.UpdateAddress{
+			AddressID:  a.AddressID,
+			Type:       a.Type,
+			Address1:   a.Address1,
+			Address2:   a.Address2,
+			City:       a.City,
+			PostalCode: a.PostalCode,
+			State:      a.State,
+			Country:    a.Country,
+			Validated:  a.Validated,
+		})
+	}
+
+	return update
+}
+
+func findPhone(identity *client.Identity, phoneID string) (*client.Phone, error) {
+	if p := phoneByID(identity.Phones, phoneID); p != nil {
+		return p, nil
+	}
+	return nil, ErrPhoneNotFound
+}
+
+func findAddress(identity *client.Identity, addressID string) (*client.Address, error) {
+	if a := addressByID(identity.Addresses, addressID); a != nil {
+		return a, nil
+	}
+	return nil, ErrAddressNotFound
+}
@@ -0,0 +1,172 @@
+package identities_test
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/moov-io/identity/pkg/client"
+	. "github.com/moov-io/identity/pkg/identities"
+)
+
+var mergePatchJSON = map[string]string{"Content-Type": "application/merge-patch+json"}
+
+func Test_PatchAPI(t *testing.T) {
+	a, s, f := Setup(t)
+
+	identity := RegisterIdentity(s, f)
+	s.time.Add(time.Millisecond)
+
+	headers := map[string]string{
+		"Content-Type": "application/merge-patch+json",
+		"If-Match":     ETag(identity),
+	}
+
+	resp := s.Request("PATCH", "/identities/"+identity.IdentityID, map[string]interface{}{
+		"nickName": "Johnny",
+		"suffix":   nil,
+	}, headers)
+	a.Equal(200, resp.StatusCode)
+
+	patched := client.Identity{}
+	a.Nil(json.NewDecoder(resp.Body).Decode(&patched))
+	a.Equal(ETag(patched), resp.Header.Get("ETag"))
+
+	// only what was in the patch changes
+	a.Equal("Johnny", *patched.NickName)
+	a.Nil(patched.Suffix)
+	a.Equal(identity.FirstName, patched.FirstName)
+	a.Equal(identity.LastName, patched.LastName)
+	a.Equal(identity.BirthDate, patched.BirthDate)
+	a.ElementsMatch(identity.Phones, patched.Phones)
+	a.ElementsMatch(identity.Addresses, patched.Addresses)
+	a.Equal(s.time.Now(), patched.LastUpdatedOn)
+
+	// stale version
+	resp = s.Request("PATCH", "/identities/"+identity.IdentityID, map[string]interface{}{"nickName": "John"}, headers)
+	a.Equal(412, resp.StatusCode)
+}
+
+func Test_PatchAPI_Invalid(t *testing.T) {
+	a, s, f := Setup(t)
+
+	identity := RegisterIdentity(s, f)
+	path := "/identities/" + identity.IdentityID
+
+	headers := map[string]string{
+		"Content-Type": "application/merge-patch+json",
+		"If-Match":     ETag(identity),
+	}
+
+	// same validation as a full update
+	resp := s.Request("PATCH", path, map[string]interface{}{"firstName": nil}, headers)
+	a.Equal(400, resp.StatusCode)
+
+	// not something that can be updated
+	resp = s.Request("PATCH", path, map[string]interface{}{"email": "someone@example.com"}, headers)
+	a.Equal(400, resp.StatusCode)
+
+	resp = s.Request("PATCH", path, map[string]interface{}{"nickName": "Johnny"}, mergePatchJSON)
+	a.Equal(428, resp.StatusCode)
+
+	resp = s.Request("PATCH", path, map[string]interface{}{"nickName": "Johnny"}, map[string]string{
+		"Content-Type": "text/plain",
+		"If-Match":     ETag(identity),
+	})
+	a.Equal(415, resp.StatusCode)
+}
+
+func Test_PhonesAPI(t *testing.T) {
+	a, s, f := Setup(t)
+
+	identity := RegisterIdentity(s, f)
+	path := "/identities/" + identity.IdentityID + "/phones"
+
+	s.time.Add(time.Millisecond)
+	resp := s.Request("POST", path, client.UpdatePhone{Number: "555-555-5555", Type: "mobile"}, nil)
+	a.Equal(201, resp.StatusCode)
+
+	added := client.Phone{}
+	a.Nil(json.NewDecoder(resp.Body).Decode(&added))
+	a.Equal("555-555-5555", added.Number)
+	a.NotEmpty(added.PhoneID)
+
+	found, err := s.service.GetIdentity(context.Background(), s.session, identity.IdentityID)
+	a.Nil(err)
+	a.Len(found.Phones, len(identity.Phones)+1)
+	a.Equal(identity.Version+1, found.Version)
+
+	s.time.Add(time.Millisecond)
+	resp = s.Request("PUT", path+"/"+added.PhoneID, client.UpdatePhone{Number: "555-555-1234", Type: "work"}, nil)
+	a.Equal(200, resp.StatusCode)
+
+	updated := client.Phone{}
+	a.Nil(json.NewDecoder(resp.Body).Decode(&updated))
+	a.Equal(added.PhoneID, updated.PhoneID)
+	a.Equal("555-555-1234", updated.Number)
+	a.Equal("work", updated.Type)
+
+	resp = s.Request("PUT", path+"/"+added.PhoneID, client.UpdatePhone{Number: "nope", Type: "work"}, nil)
+	a.Equal(400, resp.StatusCode)
+
+	s.time.Add(time.Millisecond)
+	resp = s.Request("DELETE", path+"/"+added.PhoneID, nil, nil)
+	a.Equal(204, resp.StatusCode)
+
+	found, err = s.service.GetIdentity(context.Background(), s.session, identity.IdentityID)
+	a.Nil(err)
+	a.ElementsMatch(identity.Phones, found.Phones)
+
+	resp = s.Request("DELETE", path+"/"+added.PhoneID, nil, nil)
+	a.Equal(404, resp.StatusCode)
+}
+
+func Test_AddressesAPI(t *testing.T) {
+	a, s, f := Setup(t)
+
+	identity := RegisterIdentity(s, f)
+	path := "/identities/" + identity.IdentityID + "/addresses"
+
+	address := client.UpdateAddress{
+		Type:       "primary",
+		Address1:   "123 Main St",
+		City:       "Des Moines",
+		PostalCode: "50309",
+		State:      "IA",
+		Country:    "US",
+	}
+
+	s.time.Add(time.Millisecond)
+	resp := s.Request("POST", path, address, nil)
+	a.Equal(201, resp.StatusCode)
+
+	added := client.Address{}
+	a.Nil(json.NewDecoder(resp.Body).Decode(&added))
+	a.Equal("123 Main St", added.Address1)
+	a.NotEmpty(added.AddressID)
+
+	address.City = "Ames"
+	s.time.Add(time.Millisecond)
+	resp = s.Request("PUT", path+"/"+added.AddressID, address, nil)
+	a.Equal(200, resp.StatusCode)
+
+	updated := client.Address{}
+	a.Nil(json.NewDecoder(resp.Body).Decode(&updated))
+	a.Equal(added.AddressID, updated.AddressID)
+	a.Equal("Ames", updated.City)
+
+	resp = s.Request("PUT", path+"/"+added.AddressID, client.UpdateAddress{}, nil)
+	a.Equal(400, resp.StatusCode)
+
+	s.time.Add(time.Millisecond)
+	resp = s.Request("DELETE", path+"/"+added.AddressID, nil, nil)
+	a.Equal(204, resp.StatusCode)
+
+	found, err := s.service.GetIdentity(context.Background(), s.session, identity.IdentityID)
+	a.Nil(err)
+	a.ElementsMatch(identity.Addresses, found.Addresses)
+
+	_, err = s.service.UpdateAddress(context.Background(), s.session, identity.IdentityID, added.AddressID, address)
+	a.Equal(ErrAddressNotFound, err)
+}
@@ -0,0 +1,51 @@
+package identities
+
+import (
+	"context"
+	"errors"
+	"strings"
+
+	validation "github.com/go-ozzo/ozzo-validation/v4"
+	"github.com/moov-io/identity/pkg/client"
+	"github.com/moov-io/identity/pkg/database"
+	tmw "github.com/moov-io/tumbler/pkg/middleware"
+)
+
+const (
+	defaultSearchLimit = 25
+	maxSearchLimit     = 100
+)
+
+// SearchIdentities - Finds the identities of the tenant with a name, nickname, email, phone number or postal code
+// starting with each word of the query, ignoring case. The most relevant come first.
+func (s *service) SearchIdentities(ctx context.Context, claims tmw.TumblerClaims, query string, limit int) ([]client.Identity, error) {
+	if limit == 0 {
+		limit = defaultSearchLimit
+	}
+
+	errs := validation.Errors{}
+	if len(database.SearchTerms(query)) == 0 {
+		errs["q"] = errors.New("must contain a letter or number")
+	}
+	if limit < 1 || limit > maxSearchLimit {
+		errs["limit"] = validation.NewError("validation_out_of_range", "must be between 1 and 100")
+	}
+	if len(errs) > 0 {
+		return nil, errs
+	}
+
+	return s.repository.search(ctx, claims.TenantID.String(), searchQuery(query), limit)
+}
+
+// searchQuery - Phone numbers are indexed by their digits so a query that looks like one is searched for the same way.
+func searchQuery(query string) string {
+	if strings.Trim(query, "0123456789+-(). ") != "" {
+		return query
+	}
+
+	if digits := phoneDigits(query); len(digits) > 0 {
+		return digits
+	}
+
+	return query
+}
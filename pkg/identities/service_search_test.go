@@ -0,0 +1,139 @@
+package identities_test
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	fuzz "github.com/google/gofuzz"
+	"github.com/moov-io/identity/pkg/client"
+	. "github.com/moov-io/identity/pkg/identities"
+	tmwt "github.com/moov-io/tumbler/pkg/middleware/middlewaretest"
+)
+
+func Test_SearchAPI(t *testing.T) {
+	a, s, f := Setup(t)
+
+	ada := RegisterSearchable(s, f, "Ada", "Lovelace", "ada@moov.io", "+1 (555) 123-4567", "94105")
+	adam := RegisterSearchable(s, f, "Adam", "Smith", "asmith@moov.io", "555.987.6543", "10001-1234")
+	RegisterSearchable(s, f, "Grace", "Hopper", "grace@moov.io", "555 000 1111", "94105")
+
+	search := func(query string) []client.Identity {
+		resp := s.Request("GET", "/identities/search?"+query, nil, nil)
+		a.Equal(200, resp.StatusCode)
+
+		found := []client.Identity{}
+		a.Nil(json.NewDecoder(resp.Body).Decode(&found))
+		return found
+	}
+
+	ids := func(identities []client.Identity) []string {
+		found := []string{}
+		for _, i := range identities {
+			found = append(found, i.IdentityID)
+		}
+		return found
+	}
+
+	// whole word matches rank ahead of prefixes
+	found := search("q=ADA")
+	a.Equal([]string{ada.IdentityID, adam.IdentityID}, ids(found))
+	a.Len(found[0].Phones, 1)
+
+	a.Equal([]string{adam.IdentityID}, ids(search("q=ada+smi")))
+	a.Equal([]string{ada.IdentityID}, ids(search("q=lovelace%40moov.io")))
+	a.Len(search("q=94105"), 2)
+	a.Equal([]string{adam.IdentityID}, ids(search("q=10001")))
+	a.Len(search("q=ada&limit=1"), 1)
+	a.Empty(search("q=nobody"))
+
+	// phone numbers match no matter how they are punctuated
+	a.Equal([]string{ada.IdentityID}, ids(search("q=1-555-123-4567")))
+	a.Equal([]string{adam.IdentityID}, ids(search("q=(555)+987-6543")))
+
+	// updates are reindexed
+	s.time.Add(time.Millisecond)
+	_, err := s.service.PatchIdentity(context.Background(), s.session, adam.IdentityID, adam.Version, []byte(`{"lastName":"Jones"}`))
+	a.Nil(err)
+	a.Empty(search("q=smith"))
+	a.Equal([]string{adam.IdentityID}, ids(search("q=jones")))
+
+	// other tenants can't be found
+	other, err := s.service.SearchIdentities(context.Background(), tmwt.NewRandomClaims(), "ada", 0)
+	a.Nil(err)
+	a.Empty(other)
+
+	resp := s.Request("GET", "/identities/search?q=++", nil, nil)
+	a.Equal(400, resp.StatusCode)
+
+	resp = s.Request("GET", "/identities/search?q=ada&limit=1000", nil, nil)
+	a.Equal(400, resp.StatusCode)
+
+	resp = s.Request("GET", "/identities/search?q=ada&limit=ten", nil, nil)
+	a.Equal(400, resp.StatusCode)
+}
+
+func Test_Search_MergedAway(t *testing.T) {
+	a, s, f := Setup(t)
+
+	survivor := RegisterSearchable(s, f, "Ada", "Lovelace", "ada@moov.io", "555-123-4567", "94105")
+	duplicate := RegisterSearchable(s, f, "Ada", "King", "ada.king@moov.io", "555-765-4321", "10001")
+
+	s.time.Add(time.Millisecond)
+	_, err := s.service.MergeIdentities(context.Background(), s.session, survivor.IdentityID, client.MergeIdentities{DuplicateID: duplicate.IdentityID})
+	a.Nil(err)
+
+	found, err := s.service.SearchIdentities(context.Background(), s.session, "ada", 0)
+	a.Nil(err)
+	a.Len(found, 1)
+	a.Equal(survivor.IdentityID, found[0].IdentityID)
+
+	// the survivor took over the phone of the duplicate
+	found, err = s.service.SearchIdentities(context.Background(), s.session, "555-765-4321", 0)
+	a.Nil(err)
+	a.Len(found, 1)
+	a.Equal(survivor.IdentityID, found[0].IdentityID)
+}
+
+func Test_BuildSearchIndex(t *testing.T) {
+	a, s, f := Setup(t)
+
+	ada := RegisterSearchable(s, f, "Ada", "Lovelace", "ada@moov.io", "555-123-4567", "94105")
+
+	// Like a database from before search existed
+	_, err := s.db.Exec("DELETE FROM identity_search")
+	a.Nil(err)
+
+	found, err := s.service.SearchIdentities(context.Background(), s.session, "ada", 0)
+	a.Nil(err)
+	a.Empty(found)
+
+	a.Nil(BuildSearchIndex(context.Background(), s.repository))
+
+	found, err = s.service.SearchIdentities(context.Background(), s.session, "ada", 0)
+	a.Nil(err)
+	a.Len(found, 1)
+	a.Equal(ada.IdentityID, found[0].IdentityID)
+}
+
+func RegisterSearchable(s Scope, f *fuzz.Fuzzer, first, last, email, phone, postalCode string) client.Identity {
+	register := client.Register{}
+	f.Fuzz(&register)
+
+	register.FirstName = first
+	register.MiddleName = ""
+	register.LastName = last
+	register.NickName = nil
+	register.Email = email
+	register.Phones = []client.RegisterPhone{{Number: phone, Type: "mobile"}}
+	register.Addresses = []client.RegisterAddress{{Type: "primary", Address1: "1 Main St", City: "Anytown", State: "CA", PostalCode: postalCode, Country: "US"}}
+
+	invite := s.RandomInvite()
+	identity, err := s.service.Register(context.Background(), register, &invite, StatusActive, nil)
+	if err != nil {
+		panic(err)
+	}
+
+	return *identity
+}
@@ -0,0 +1,82 @@
+package identities
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+	"github.com/moov-io/identity/pkg/client"
+	tmw "github.com/moov-io/tumbler/pkg/middleware"
+)
+
+// ChangeStatus - Moves an identity to another status if its allowed from its current one and records why.
+// The emails from notify are only sent if the status changes.
+func (s *service) ChangeStatus(ctx context.Context, claims tmw.TumblerClaims, identityID string, status string, change client.ChangeStatus, notify Notify) (*client.Identity, error) {
+	if err := change.Validate(); err != nil {
+		return nil, err
+	}
+
+	identity, err := s.GetIdentity(ctx, claims, identityID)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.transition(ctx, claims, identity, status, change.Reason, notify)
+}
+
+// ListStatusHistory - Lists every status the identity moved through, oldest first.
+func (s *service) ListStatusHistory(ctx context.Context, claims tmw.TumblerClaims, identityID string) ([]client.StatusChange, error) {
+	identity, err := s.GetIdentity(ctx, claims, identityID)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.repository.listStatusHistory(ctx, identity.TenantID, identity.IdentityID)
+}
+
+// transition - Moving to the status the identity is already in is a no-op so repeating a request like a disable is safe.
+func (s *service) transition(ctx context.Context, claims tmw.TumblerClaims, identity *client.Identity, status string, reason string, notify Notify) (*client.Identity, error) {
+	if identity.Status == status {
+		return identity, nil
+	}
+
+	if !CanTransition(identity.Status, status) {
+		return nil, ErrInvalidStatusTransition
+	}
+
+	now := s.time.Now()
+	callerIdentityID := claims.Subject
+
+	switch status {
+	case StatusActive:
+		// Merged identities only exist to point at the survivor.
+		if identity.MergedInto != nil {
+			return nil, ErrIdentityAlreadyMerged
+		}
+
+		identity.DisabledOn = nil
+		identity.DisabledBy = nil
+	case StatusDisabled, StatusDeleted:
+		if identity.DisabledOn == nil {
+			identity.DisabledOn = &now
+			identity.DisabledBy = &callerIdentityID
+		}
+	}
+
+	identity.LastUpdatedOn = now
+
+	change := s.newStatusChange(claims, *identity, status, reason)
+	return s.repository.changeStatus(ctx, *identity, change, notify.notices(*identity))
+}
+
+func (s *service) newStatusChange(claims tmw.TumblerClaims, identity client.Identity, status string, reason string) client.StatusChange {
+	return client.StatusChange{
+		StatusChangeID: uuid.New().String(),
+		TenantID:       identity.TenantID,
+		IdentityID:     identity.IdentityID,
+		FromStatus:     identity.Status,
+		ToStatus:       status,
+		Reason:         reason,
+		ChangedBy:      claims.Subject,
+		ChangedOn:      s.time.Now(),
+	}
+}
@@ -0,0 +1,170 @@
+package identities_test
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/moov-io/identity/pkg/client"
+	. "github.com/moov-io/identity/pkg/identities"
+)
+
+func Test_CanTransition(t *testing.T) {
+	a, _, _ := Setup(t)
+
+	a.True(CanTransition(StatusPending, StatusActive))
+	a.True(CanTransition(StatusActive, StatusSuspended))
+	a.True(CanTransition(StatusLocked, StatusActive))
+	a.True(CanTransition(StatusDisabled, StatusDeleted))
+
+	a.False(CanTransition(StatusActive, StatusActive))
+	a.False(CanTransition(StatusActive, StatusPending))
+	a.False(CanTransition(StatusSuspended, StatusLocked))
+	a.False(CanTransition(StatusDeleted, StatusActive))
+	a.False(CanTransition("none", StatusActive))
+}
+
+func Test_ChangeStatus(t *testing.T) {
+	a, s, f := Setup(t)
+
+	identity := RegisterIdentity(s, f)
+	a.Equal(StatusActive, identity.Status)
+
+	s.time.Add(time.Millisecond)
+
+	suspended, err := s.service.ChangeStatus(context.Background(), s.session, identity.IdentityID, StatusSuspended, client.ChangeStatus{Reason: "unpaid invoices"}, nil)
+	a.Nil(err)
+	a.Equal(StatusSuspended, suspended.Status)
+	a.Equal(s.time.Now(), suspended.LastUpdatedOn)
+	a.Nil(suspended.DisabledOn)
+
+	// suspended can't be locked without being reactivated first
+	_, err = s.service.ChangeStatus(context.Background(), s.session, identity.IdentityID, StatusLocked, client.ChangeStatus{Reason: "too many attempts"}, nil)
+	a.Equal(ErrInvalidStatusTransition, err)
+
+	s.time.Add(time.Millisecond)
+	disabled, err := s.service.ChangeStatus(context.Background(), s.session, identity.IdentityID, StatusDisabled, client.ChangeStatus{Reason: "left the company"}, nil)
+	a.Nil(err)
+	a.Equal(StatusDisabled, disabled.Status)
+	a.Equal(s.time.Now(), *disabled.DisabledOn)
+	a.Equal(s.session.Subject, *disabled.DisabledBy)
+
+	// Already disabled so nothing changes or gets recorded
+	s.time.Add(time.Millisecond)
+	again, err := s.service.ChangeStatus(context.Background(), s.session, identity.IdentityID, StatusDisabled, client.ChangeStatus{Reason: "left the company"}, nil)
+	a.Nil(err)
+	a.Equal(disabled.Version, again.Version)
+	a.Equal(*disabled.DisabledOn, *again.DisabledOn)
+
+	a.Nil(s.service.DisableIdentity(context.Background(), s.session, identity.IdentityID))
+
+	s.time.Add(time.Millisecond)
+	activated, err := s.service.ChangeStatus(context.Background(), s.session, identity.IdentityID, StatusActive, client.ChangeStatus{Reason: "came back"}, nil)
+	a.Nil(err)
+	a.Equal(StatusActive, activated.Status)
+	a.Nil(activated.DisabledOn)
+	a.Nil(activated.DisabledBy)
+
+	history, err := s.service.ListStatusHistory(context.Background(), s.session, identity.IdentityID)
+	a.Nil(err)
+	a.Len(history, 3)
+
+	a.Equal(StatusActive, history[0].FromStatus)
+	a.Equal(StatusSuspended, history[0].ToStatus)
+	a.Equal("unpaid invoices", history[0].Reason)
+	a.Equal(s.session.Subject, history[0].ChangedBy)
+	a.Equal(StatusSuspended, history[1].FromStatus)
+	a.Equal(StatusDisabled, history[1].ToStatus)
+	a.Equal(StatusDisabled, history[2].FromStatus)
+	a.Equal(StatusActive, history[2].ToStatus)
+}
+
+func Test_ChangeStatus_ReasonRequired(t *testing.T) {
+	a, s, f := Setup(t)
+
+	identity := RegisterIdentity(s, f)
+
+	_, err := s.service.ChangeStatus(context.Background(), s.session, identity.IdentityID, StatusLocked, client.ChangeStatus{}, nil)
+	a.NotNil(err)
+}
+
+func Test_ChangeStatus_Merged(t *testing.T) {
+	a, s, f := Setup(t)
+
+	survivor := RegisterIdentity(s, f)
+	duplicate := RegisterIdentity(s, f)
+
+	_, err := s.service.MergeIdentities(context.Background(), s.session, survivor.IdentityID, client.MergeIdentities{DuplicateID: duplicate.IdentityID})
+	a.Nil(err)
+
+	history, err := s.service.ListStatusHistory(context.Background(), s.session, duplicate.IdentityID)
+	a.Nil(err)
+	a.Len(history, 1)
+	a.Equal(StatusDisabled, history[0].ToStatus)
+
+	_, err = s.service.ChangeStatus(context.Background(), s.session, duplicate.IdentityID, StatusActive, client.ChangeStatus{Reason: "oops"}, nil)
+	a.Equal(ErrIdentityAlreadyMerged, err)
+}
+
+func Test_ChangeStatusAPI(t *testing.T) {
+	a, s, f := Setup(t)
+
+	identity := RegisterIdentity(s, f)
+	path := "/identities/" + identity.IdentityID
+
+	resp := s.Request("POST", path+"/lock", client.ChangeStatus{Reason: "too many attempts"}, nil)
+	a.Equal(200, resp.StatusCode)
+
+	locked := client.Identity{}
+	a.Nil(json.NewDecoder(resp.Body).Decode(&locked))
+	a.Equal(StatusLocked, locked.Status)
+
+	resp = s.Request("POST", path+"/suspend", client.ChangeStatus{Reason: "unpaid invoices"}, nil)
+	a.Equal(409, resp.StatusCode)
+
+	resp = s.Request("POST", path+"/activate", client.ChangeStatus{}, nil)
+	a.Equal(400, resp.StatusCode)
+
+	s.time.Add(time.Millisecond)
+	resp = s.Request("POST", path+"/activate", client.ChangeStatus{Reason: "verified by phone"}, nil)
+	a.Equal(200, resp.StatusCode)
+
+	s.time.Add(time.Millisecond)
+	resp = s.Request("POST", path+"/delete", client.ChangeStatus{Reason: "requested by user"}, nil)
+	a.Equal(200, resp.StatusCode)
+
+	resp = s.Request("GET", path+"/status-history", nil, nil)
+	a.Equal(200, resp.StatusCode)
+
+	history := []client.StatusChange{}
+	a.Nil(json.NewDecoder(resp.Body).Decode(&history))
+	a.Len(history, 3)
+	a.Equal(StatusDeleted, history[2].ToStatus)
+}
+
+func Test_Register_Pending(t *testing.T) {
+	a, s, f := Setup(t)
+
+	invite := s.RandomInvite()
+	register := client.Register{}
+	f.Fuzz(&register)
+
+	identity, err := s.service.Register(context.Background(), register, &invite, StatusPending, nil)
+	a.Nil(err)
+	a.Equal(StatusPending, identity.Status)
+
+	found, err := s.service.GetIdentity(context.Background(), s.session, identity.IdentityID)
+	a.Nil(err)
+	a.Equal(StatusPending, found.Status)
+
+	history, err := s.service.ListStatusHistory(context.Background(), s.session, identity.IdentityID)
+	a.Nil(err)
+	a.Len(history, 1)
+	a.Equal("", history[0].FromStatus)
+	a.Equal(StatusPending, history[0].ToStatus)
+
+	// Only new or held for approval
+	_, err = s.service.Register(context.Background(), register, &invite, StatusSuspended, nil)
+	a.Equal(ErrInvalidStatusTransition, err)
+}
@@ -58,6 +58,11 @@ func RandNullable(c fuzz.Continue, value string) *string {
 func NewFuzzer() *fuzz.Fuzzer {
 
 	return fuzz.New().NumElements(0, 5).Funcs(
+		// Attributes have to match the definitions of the tenant so random ones are left off.
+		func(e *map[string]interface{}, c fuzz.Continue) {
+			*e = nil
+		},
+
 		func(e *client.RegisterAddress, c fuzz.Continue) {
 			e.Type = "primary"
 			e.Address1 = RandAddress(c)
@@ -135,7 +140,6 @@ func NewFuzzer() *fuzz.Fuzzer {
 			c.Fuzz(e.NickName)
 			c.Fuzz(e.Suffix)
 			e.BirthDate = RandNullable(c, RandBirthDate(c))
-			e.Status = RandStringN(c, 10)
 
 			e.Phones = make([]client.UpdatePhone, c.Intn(3)+1)
 			for i := range e.Phones {
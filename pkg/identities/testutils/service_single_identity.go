@@ -1,10 +1,12 @@
 package identitiestestutils
 
 import (
+	"context"
 	"errors"
 
 	"github.com/moov-io/identity/pkg/client"
 	"github.com/moov-io/identity/pkg/identities"
+	"github.com/moov-io/identity/pkg/notifications"
 	tmw "github.com/moov-io/tumbler/pkg/middleware"
 )
 
@@ -24,33 +26,120 @@ type singleService struct {
 	identity client.Identity
 }
 
-func (s *singleService) UpdateInsecure(identity *client.Identity) (*client.Identity, error) {
+func (s *singleService) UpdateInsecure(ctx context.Context, identity *client.Identity) (*client.Identity, error) {
 	panic(ErrNotImplemented)
 }
 
-func (s *singleService) DisableIdentity(claims tmw.TumblerClaims, identityID string) error {
+func (s *singleService) DisableIdentity(ctx context.Context, claims tmw.TumblerClaims, identityID string) error {
 	panic(ErrNotImplemented)
 }
 
-func (s *singleService) GetIdentity(claims tmw.TumblerClaims, identityID string) (*client.Identity, error) {
+func (s *singleService) GetIdentity(ctx context.Context, claims tmw.TumblerClaims, identityID string) (*client.Identity, error) {
 	shallowCopy := s.identity
 	shallowCopy.IdentityID = identityID
 	return &shallowCopy, nil
 }
 
-func (s *singleService) ListIdentities(claims tmw.TumblerClaims) ([]client.Identity, error) {
+func (s *singleService) ListIdentities(ctx context.Context, claims tmw.TumblerClaims, attributes map[string]string) ([]client.Identity, error) {
 	return []client.Identity{s.identity}, nil
 }
 
-func (s *singleService) UpdateIdentity(claims tmw.TumblerClaims, identityID string, update client.UpdateIdentity) (*client.Identity, error) {
+func (s *singleService) ListIdentitiesByStatus(ctx context.Context, claims tmw.TumblerClaims, status string) ([]client.Identity, error) {
+	if s.identity.Status != status {
+		return []client.Identity{}, nil
+	}
+	return []client.Identity{s.identity}, nil
+}
+
+func (s *singleService) SearchIdentities(ctx context.Context, claims tmw.TumblerClaims, query string, limit int) ([]client.Identity, error) {
+	return []client.Identity{s.identity}, nil
+}
+
+func (s *singleService) ImportIdentities(ctx context.Context, claims tmw.TumblerClaims, imports []client.ImportIdentity) ([]client.Identity, error) {
+	return nil, nil
+}
+
+func (s *singleService) ExportIdentities(ctx context.Context, claims tmw.TumblerClaims, each func(client.Identity) error) error {
+	return each(s.identity)
+}
+
+func (s *singleService) UpdateIdentity(ctx context.Context, claims tmw.TumblerClaims, identityID string, version int64, update client.UpdateIdentity) (*client.Identity, error) {
+	panic(ErrNotImplemented)
+}
+
+func (s *singleService) PatchIdentity(ctx context.Context, claims tmw.TumblerClaims, identityID string, version int64, patch []byte) (*client.Identity, error) {
+	panic(ErrNotImplemented)
+}
+
+func (s *singleService) AddPhone(ctx context.Context, claims tmw.TumblerClaims, identityID string, phone client.UpdatePhone) (*client.Phone, error) {
+	panic(ErrNotImplemented)
+}
+
+func (s *singleService) UpdatePhone(ctx context.Context, claims tmw.TumblerClaims, identityID string, phoneID string, phone client.UpdatePhone) (*client.Phone, error) {
+	panic(ErrNotImplemented)
+}
+
+func (s *singleService) RemovePhone(ctx context.Context, claims tmw.TumblerClaims, identityID string, phoneID string) error {
+	panic(ErrNotImplemented)
+}
+
+func (s *singleService) AddAddress(ctx context.Context, claims tmw.TumblerClaims, identityID string, address client.UpdateAddress) (*client.Address, error) {
+	panic(ErrNotImplemented)
+}
+
+func (s *singleService) UpdateAddress(ctx context.Context, claims tmw.TumblerClaims, identityID string, addressID string, address client.UpdateAddress) (*client.Address, error) {
+	panic(ErrNotImplemented)
+}
+
+func (s *singleService) RemoveAddress(ctx context.Context, claims tmw.TumblerClaims, identityID string, addressID string) error {
+	panic(ErrNotImplemented)
+}
+
+func (s *singleService) MergeIdentities(ctx context.Context, claims tmw.TumblerClaims, survivorID string, merge client.MergeIdentities) (*client.MergeReport, error) {
+	panic(ErrNotImplemented)
+}
+
+func (s *singleService) ChangeStatus(ctx context.Context, claims tmw.TumblerClaims, identityID string, status string, change client.ChangeStatus, notify identities.Notify) (*client.Identity, error) {
+	panic(ErrNotImplemented)
+}
+
+func (s *singleService) ListStatusHistory(ctx context.Context, claims tmw.TumblerClaims, identityID string) ([]client.StatusChange, error) {
+	panic(ErrNotImplemented)
+}
+
+func (s *singleService) ListHistory(ctx context.Context, claims tmw.TumblerClaims, identityID string) ([]client.IdentityChange, error) {
+	panic(ErrNotImplemented)
+}
+
+func (s *singleService) RequestEmailChange(ctx context.Context, claims tmw.TumblerClaims, identityID string, change client.ChangeEmail) (*client.EmailChange, error) {
+	panic(ErrNotImplemented)
+}
+
+func (s *singleService) ConfirmEmailChange(ctx context.Context, claims tmw.TumblerClaims, identityID string, confirm client.ConfirmEmailChange) (*client.Identity, error) {
+	panic(ErrNotImplemented)
+}
+
+func (s *singleService) FillEmail(ctx context.Context, tenantID string, email notifications.EmailTemplate) error {
+	panic(ErrNotImplemented)
+}
+
+func (s *singleService) ListAttributeDefinitions(ctx context.Context, claims tmw.TumblerClaims) ([]client.AttributeDefinition, error) {
+	panic(ErrNotImplemented)
+}
+
+func (s *singleService) SaveAttributeDefinition(ctx context.Context, claims tmw.TumblerClaims, definition client.AttributeDefinition) (*client.AttributeDefinition, error) {
+	panic(ErrNotImplemented)
+}
+
+func (s *singleService) DeleteAttributeDefinition(ctx context.Context, claims tmw.TumblerClaims, name string) error {
 	panic(ErrNotImplemented)
 }
 
-func (s *singleService) Register(register client.Register, invite *client.Invite) (*client.Identity, error) {
+func (s *singleService) Register(ctx context.Context, register client.Register, invite *client.Invite, status string, notify identities.Notify) (*client.Identity, error) {
 	panic(ErrNotImplemented)
 }
 
-func (s *singleService) GetIdentityByID(identityID string) (*client.Identity, error) {
+func (s *singleService) GetIdentityByID(ctx context.Context, identityID string) (*client.Identity, error) {
 	shallowCopy := s.identity
 	shallowCopy.IdentityID = identityID
 	return &shallowCopy, nil
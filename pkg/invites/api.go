@@ -1,7 +1,6 @@
 package invites
 
 import (
-	"encoding/json"
 	"net/http"
 	"strings"
 
@@ -47,6 +46,12 @@ func (c *Controller) Routes() api.Routes {
 			Pattern:     "/invites",
 			HandlerFunc: c.SendInvite,
 		},
+		{
+			Name:        "SendInviteBatch",
+			Method:      strings.ToUpper("Post"),
+			Pattern:     "/invites/batch",
+			HandlerFunc: c.SendInviteBatch,
+		},
 	}
 }
 
@@ -55,9 +60,9 @@ func (c *Controller) DeleteInvite(w http.ResponseWriter, r *http.Request) {
 	tmw.WithClaimsFromRequest(w, r, func(claims tmw.TumblerClaims) {
 		params := mux.Vars(r)
 		inviteID := params["inviteID"]
-		err := c.service.DisableInvite(claims, inviteID)
+		err := c.service.DisableInvite(r.Context(), claims, inviteID)
 		if err != nil {
-			w.WriteHeader(500)
+			api.EncodeError(w, err)
 			return
 		}
 
@@ -68,9 +73,9 @@ func (c *Controller) DeleteInvite(w http.ResponseWriter, r *http.Request) {
 // ListInvites - List outstanding invites
 func (c *Controller) ListInvites(w http.ResponseWriter, r *http.Request) {
 	tmw.WithClaimsFromRequest(w, r, func(claims tmw.TumblerClaims) {
-		result, err := c.service.ListInvites(claims)
+		result, err := c.service.ListInvites(r.Context(), claims)
 		if err != nil {
-			w.WriteHeader(500)
+			api.EncodeError(w, c.logger.LogError("unable to list invites", err))
 			return
 		}
 
@@ -82,18 +87,37 @@ func (c *Controller) ListInvites(w http.ResponseWriter, r *http.Request) {
 func (c *Controller) SendInvite(w http.ResponseWriter, r *http.Request) {
 	tmw.WithClaimsFromRequest(w, r, func(claims tmw.TumblerClaims) {
 		invite := &client.SendInvite{}
-		if err := json.NewDecoder(r.Body).Decode(&invite); err != nil {
-			w.WriteHeader(500)
+		if err := api.DecodeJSONRequest(r, &invite); err != nil {
+			api.EncodeError(w, err)
 			return
 		}
 
-		result, _, err := c.service.SendInvite(claims, *invite)
+		result, _, err := c.service.SendInvite(r.Context(), claims, *invite)
 		if err != nil {
-			c.logger.Error().LogError("unable to send invite", err)
-			w.WriteHeader(500)
+			api.EncodeError(w, c.logger.LogError("unable to send invite", err))
 			return
 		}
 
 		api.EncodeJSONResponse(result, nil, w)
 	})
 }
+
+// SendInviteBatch - Send email invites to many new users in a background job
+func (c *Controller) SendInviteBatch(w http.ResponseWriter, r *http.Request) {
+	tmw.WithClaimsFromRequest(w, r, func(claims tmw.TumblerClaims) {
+		batch := client.SendInviteBatch{}
+		if err := api.DecodeJSONRequest(r, &batch); err != nil {
+			api.EncodeError(w, err)
+			return
+		}
+
+		result, err := c.service.SendInviteBatch(r.Context(), claims, batch)
+		if err != nil {
+			api.EncodeError(w, c.logger.LogError("unable to send invite batch", err))
+			return
+		}
+
+		status := http.StatusAccepted
+		api.EncodeJSONResponse(result, &status, w)
+	})
+}
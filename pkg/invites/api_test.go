@@ -2,6 +2,9 @@ package invites
 
 import (
 	"context"
+	"encoding/json"
+	"net/http/httptest"
+	"strings"
 	"testing"
 
 	"github.com/moov-io/identity/pkg/client"
@@ -64,6 +67,22 @@ func TestAPIDeactivate(t *testing.T) {
 	a.Equal(sent.InviteID, deactivated.InviteID)
 }
 
+func TestAPIInviteMalformed(t *testing.T) {
+	a := assert.New(t)
+	s := NewScope(t)
+
+	w := httptest.NewRecorder()
+	s.routes.ServeHTTP(w, httptest.NewRequest("POST", "/invites", strings.NewReader("{not json")))
+
+	a.Equal(400, w.Code)
+	a.Equal("application/problem+json", w.Header().Get("Content-Type"))
+
+	problem := client.Problem{}
+	a.Nil(json.NewDecoder(w.Body).Decode(&problem))
+	a.Equal(int32(400), problem.Status)
+	a.NotEmpty(problem.Detail)
+}
+
 func sendInvite(a *assert.Assertions, s Scope, email string) client.Invite {
 	invite, _, err := s.api.InvitesApi.SendInvite(context.Background(), client.SendInvite{Email: email})
 	a.Nil(err)
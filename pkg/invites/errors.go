@@ -1,9 +1,12 @@
 package invites
 
-import "errors"
+import "github.com/moov-io/identity/pkg/apperr"
 
 // ErrInviteCodeExpired is issued when the invite code has Expired.
-var ErrInviteCodeExpired = errors.New("invite token is expired")
+var ErrInviteCodeExpired = apperr.Expired("invite token is expired")
 
 // ErrInviteCodeDisabled is issued when the invite was disabled by another person
-var ErrInviteCodeDisabled = errors.New("invite was disabled")
+var ErrInviteCodeDisabled = apperr.Forbidden("invite was disabled")
+
+// ErrInviteExists is issued when an invite is saved with the ID or code of one that already exists.
+var ErrInviteExists = apperr.Conflict("invite already exists")
@@ -4,9 +4,15 @@ import (
 	"time"
 )
 
+// JobKindInvites is the kind of the jobs sending batches of invites
+const JobKindInvites = "invites"
+
 // Config holds the configuration for the Invites package
 type Config struct {
 	Expiration time.Duration
 	SendToHost string
 	SendToPath string
+
+	// How many invites of a batch are sent at the same time
+	BatchConcurrency int
 }
@@ -1,32 +1,40 @@
 package invites
 
 import (
+	"context"
 	"database/sql"
 	"fmt"
 
 	api "github.com/moov-io/identity/pkg/api"
 	"github.com/moov-io/identity/pkg/client"
+	"github.com/moov-io/identity/pkg/database"
+	"github.com/moov-io/identity/pkg/notifications"
+	"github.com/moov-io/identity/pkg/outbox"
 )
 
 // Repository allows for interacting with the invites data store.
 type Repository interface {
-	list(tenantID api.TenantID) ([]client.Invite, error)
-	get(tenantID api.TenantID, inviteID string) (*client.Invite, error)
-	getByCode(code string) (*client.Invite, error)
-	add(invite client.Invite, secretCode string) (*client.Invite, error)
-	update(updated client.Invite) error
+	list(ctx context.Context, tenantID api.TenantID) ([]client.Invite, error)
+	get(ctx context.Context, tenantID api.TenantID, inviteID string) (*client.Invite, error)
+	getByCode(ctx context.Context, code string) (*client.Invite, error)
+	getCode(ctx context.Context, tenantID string, inviteID string) (string, error)
+	add(ctx context.Context, invite client.Invite, secretCode string, email notifications.EmailTemplate) (*client.Invite, error)
+	update(ctx context.Context, updated client.Invite) error
 }
 
-// NewInvitesRepository instantiates a new InvitesRepository
-func NewInvitesRepository(db *sql.DB) Repository {
-	return &sqlInvitesRepo{db: db}
+// NewInvitesRepository instantiates a new InvitesRepository. The outbox has to be on the same DB
+// since the invite emails are added to it in the same transaction as the invite.
+func NewInvitesRepository(db *sql.DB, outbox outbox.Outbox) Repository {
+	return &sqlInvitesRepo{db: db, dialect: database.DialectOf(db), outbox: outbox}
 }
 
 type sqlInvitesRepo struct {
-	db *sql.DB
+	db      *sql.DB
+	dialect database.Dialect
+	outbox  outbox.Outbox
 }
 
-func (r *sqlInvitesRepo) list(tenantID api.TenantID) ([]client.Invite, error) {
+func (r *sqlInvitesRepo) list(ctx context.Context, tenantID api.TenantID) ([]client.Invite, error) {
 	qry := fmt.Sprintf(`
 		SELECT %s 
 		FROM invites 
@@ -35,10 +43,10 @@ func (r *sqlInvitesRepo) list(tenantID api.TenantID) ([]client.Invite, error) {
 		ORDER BY invites.invited_on DESC
 	`, inviteSelect)
 
-	return r.queryScan(qry, tenantID.String())
+	return r.queryScan(ctx, qry, tenantID.String())
 }
 
-func (r *sqlInvitesRepo) get(tenantID api.TenantID, inviteID string) (*client.Invite, error) {
+func (r *sqlInvitesRepo) get(ctx context.Context, tenantID api.TenantID, inviteID string) (*client.Invite, error) {
 	qry := fmt.Sprintf(`
 		SELECT %s
 		FROM invites
@@ -46,7 +54,7 @@ func (r *sqlInvitesRepo) get(tenantID api.TenantID, inviteID string) (*client.In
 		LIMIT 1
 	`, inviteSelect)
 
-	res, err := r.queryScan(qry, tenantID.String(), inviteID)
+	res, err := r.queryScan(ctx, qry, tenantID.String(), inviteID)
 	if err != nil {
 		return nil, err
 	}
@@ -58,7 +66,7 @@ func (r *sqlInvitesRepo) get(tenantID api.TenantID, inviteID string) (*client.In
 	return &res[0], nil
 }
 
-func (r *sqlInvitesRepo) getByCode(code string) (*client.Invite, error) {
+func (r *sqlInvitesRepo) getByCode(ctx context.Context, code string) (*client.Invite, error) {
 	qry := fmt.Sprintf(`
 		SELECT %s
 		FROM invites
@@ -66,7 +74,7 @@ func (r *sqlInvitesRepo) getByCode(code string) (*client.Invite, error) {
 		LIMIT 1
 	`, inviteSelect)
 
-	res, err := r.queryScan(qry, code)
+	res, err := r.queryScan(ctx, qry, code)
 	if err != nil {
 		return nil, err
 	}
@@ -78,7 +86,27 @@ func (r *sqlInvitesRepo) getByCode(code string) (*client.Invite, error) {
 	return &res[0], nil
 }
 
-func (r *sqlInvitesRepo) add(invite client.Invite, secretCode string) (*client.Invite, error) {
+func (r *sqlInvitesRepo) getCode(ctx context.Context, tenantID string, inviteID string) (string, error) {
+	qry := `
+		SELECT secret_code
+		FROM invites
+		WHERE tenant_id = ? AND invite_id = ?
+		LIMIT 1
+	`
+
+	code := ""
+	err := r.db.QueryRowContext(ctx, r.dialect.Rebind(qry), tenantID, inviteID).Scan(&code)
+	return code, err
+}
+
+// add - Saves the invite and queues up the email for it together so one isn't sent without the other.
+func (r *sqlInvitesRepo) add(ctx context.Context, invite client.Invite, secretCode string, email notifications.EmailTemplate) (*client.Invite, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
 	qry := `
 		INSERT INTO invites(
 			invite_id,
@@ -88,10 +116,11 @@ func (r *sqlInvitesRepo) add(invite client.Invite, secretCode string) (*client.I
 			invited_on,
 			redeemed_on,
 			expires_on,
-			secret_code
-		) VALUES (?,?,?,?,?,?,?,?)`
+			secret_code,
+			locale
+		) VALUES (?,?,?,?,?,?,?,?,?)`
 
-	_, err := r.db.Exec(qry,
+	_, err = tx.ExecContext(ctx, r.dialect.Rebind(qry),
 		invite.InviteID,
 		invite.TenantID,
 		invite.Email,
@@ -99,16 +128,28 @@ func (r *sqlInvitesRepo) add(invite client.Invite, secretCode string) (*client.I
 		invite.InvitedOn,
 		invite.RedeemedOn,
 		invite.ExpiresOn,
-		secretCode)
+		secretCode,
+		invite.Locale)
 
+	if database.UniqueViolation(err) {
+		return nil, ErrInviteExists
+	}
 	if err != nil {
 		return nil, err
 	}
 
+	if err := r.outbox.Email(ctx, tx, invite.TenantID, invite.Email, email); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+
 	return &invite, nil
 }
 
-func (r *sqlInvitesRepo) update(updated client.Invite) error {
+func (r *sqlInvitesRepo) update(ctx context.Context, updated client.Invite) error {
 	qry := `
 		UPDATE invites 
 		SET 
@@ -120,7 +161,7 @@ func (r *sqlInvitesRepo) update(updated client.Invite) error {
 			invite_id = ?
 	`
 
-	res, err := r.db.Exec(qry,
+	res, err := r.db.ExecContext(ctx, r.dialect.Rebind(qry),
 		updated.RedeemedOn,
 		updated.DisabledBy,
 		updated.DisabledOn,
@@ -151,11 +192,12 @@ var inviteSelect = `
 	invites.redeemed_on,
 	invites.expires_on,
 	invites.disabled_on,
-	invites.disabled_by
+	invites.disabled_by,
+	invites.locale
 `
 
-func (r *sqlInvitesRepo) queryScan(query string, args ...interface{}) ([]client.Invite, error) {
-	rows, err := r.db.Query(query, args...)
+func (r *sqlInvitesRepo) queryScan(ctx context.Context, query string, args ...interface{}) ([]client.Invite, error) {
+	rows, err := r.db.QueryContext(ctx, r.dialect.Rebind(query), args...)
 	if err != nil {
 		return nil, err
 	}
@@ -174,6 +216,7 @@ func (r *sqlInvitesRepo) queryScan(query string, args ...interface{}) ([]client.
 			&item.ExpiresOn,
 			&item.DisabledOn,
 			&item.DisabledBy,
+			&item.Locale,
 		); err != nil {
 			return nil, err
 		}
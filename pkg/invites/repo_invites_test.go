@@ -9,11 +9,14 @@ import (
 	"github.com/google/go-cmp/cmp"
 	"github.com/google/uuid"
 	_ "github.com/mattn/go-sqlite3"
-	"github.com/moov-io/base/docker"
+	authn "github.com/moov-io/authn/pkg/client"
 	"github.com/moov-io/identity/pkg/api"
 	"github.com/moov-io/identity/pkg/client"
 	"github.com/moov-io/identity/pkg/database"
 	log "github.com/moov-io/identity/pkg/logging"
+	"github.com/moov-io/identity/pkg/notifications"
+	"github.com/moov-io/identity/pkg/outbox"
+	"github.com/moov-io/identity/pkg/stime"
 )
 
 func TestGetById(t *testing.T) {
@@ -22,7 +25,7 @@ func TestGetById(t *testing.T) {
 
 		tenantID := api.TenantID(uuid.MustParse(invite.TenantID))
 
-		found, err := repository.get(tenantID, invite.InviteID)
+		found, err := repository.get(context.Background(), tenantID, invite.InviteID)
 		if err != nil {
 			t.Error(err)
 		}
@@ -32,7 +35,7 @@ func TestGetById(t *testing.T) {
 		}
 
 		badTenantID := api.TenantID(uuid.New())
-		_, err = repository.get(badTenantID, invite.InviteID)
+		_, err = repository.get(context.Background(), badTenantID, invite.InviteID)
 		if err != sql.ErrNoRows {
 			t.Error(err)
 		}
@@ -43,7 +46,7 @@ func TestGetByCode(t *testing.T) {
 	ForEachDatabase(t, func(t *testing.T, repository Repository) {
 		invite, code := AddTestingInvite(t, repository)
 
-		found, err := repository.getByCode(code)
+		found, err := repository.getByCode(context.Background(), code)
 		if err != nil {
 			t.Error(err)
 		}
@@ -52,7 +55,7 @@ func TestGetByCode(t *testing.T) {
 			t.Error("found by code doesn't match invite", found, invite)
 		}
 
-		_, err = repository.getByCode("doesnotexist")
+		_, err = repository.getByCode(context.Background(), "doesnotexist")
 		if err != sql.ErrNoRows {
 			t.Error(err)
 		}
@@ -69,7 +72,7 @@ func TestList(t *testing.T) {
 		AddTestingInvite(t, repository)
 		AddTestingInvite(t, repository)
 
-		found, err := repository.list(tenantID1)
+		found, err := repository.list(context.Background(), tenantID1)
 		if err != nil {
 			t.Error(err)
 		}
@@ -83,7 +86,7 @@ func TestList(t *testing.T) {
 		}
 
 		badTenantID := api.TenantID(uuid.New())
-		found, err = repository.list(badTenantID)
+		found, err = repository.list(context.Background(), badTenantID)
 		if err != nil {
 			t.Error(err)
 		}
@@ -106,12 +109,12 @@ func TestUpdate(t *testing.T) {
 		updated.DisabledBy = &disabledBy
 		updated.DisabledOn = &redeemedOn
 
-		err := repository.update(updated)
+		err := repository.update(context.Background(), updated)
 		if err != nil {
 			t.Error(err)
 		}
 
-		found, err := repository.get(tenantID, updated.InviteID)
+		found, err := repository.get(context.Background(), tenantID, updated.InviteID)
 		if err != nil {
 			t.Error(err)
 		}
@@ -123,48 +126,30 @@ func TestUpdate(t *testing.T) {
 		badUpdate := updated
 		badUpdate.TenantID = uuid.New().String()
 
-		err = repository.update(badUpdate)
+		err = repository.update(context.Background(), badUpdate)
 		if err != sql.ErrNoRows {
 			t.Error(err)
 		}
 	})
 }
 
-func ForEachDatabase(t *testing.T, run func(t *testing.T, repository Repository)) {
-	cases := map[string]database.DatabaseConfig{
-		"sqlite": database.InMemorySqliteConfig,
-	}
-
-	for k, tc := range cases {
-		t.Run(k, func(t *testing.T) {
-			if !docker.Enabled() && tc != database.InMemorySqliteConfig {
-				t.SkipNow()
-			}
+func TestAdd_DuplicateCode(t *testing.T) {
+	ForEachDatabase(t, func(t *testing.T, repository Repository) {
+		_, code := AddTestingInvite(t, repository)
 
-			db := LoadDatabase(t, tc)
-			repo := NewInvitesRepository(db)
-			run(t, repo)
-		})
-	}
+		email := notifications.NewInviteEmail("https://local.moov.io/accept", client.Identity{}, authn.Tenant{}, nil)
+		_, err := repository.add(context.Background(), RandomInvite(), code, &email)
+		if err != ErrInviteExists {
+			t.Error("expected ErrInviteExists", err)
+		}
+	})
 }
 
-func LoadDatabase(t *testing.T, config database.DatabaseConfig) *sql.DB {
-	l := log.NewNopLogger()
-	db, err := database.New(context.Background(), l, config)
-	if err != nil {
-		panic(err)
-	}
-
-	t.Cleanup(func() {
-		db.Close()
+func ForEachDatabase(t *testing.T, run func(t *testing.T, repository Repository)) {
+	database.ForEachDatabase(t, func(t *testing.T, db *sql.DB) {
+		repo := NewInvitesRepository(db, NewTestOutbox(t, db))
+		run(t, repo)
 	})
-
-	err = database.RunMigrations(l, db, config)
-	if err != nil {
-		panic(err)
-	}
-
-	return db
 }
 
 func NewInMemoryInvitesRepository(t *testing.T) Repository {
@@ -174,11 +159,25 @@ func NewInMemoryInvitesRepository(t *testing.T) Repository {
 		t.Error(err)
 	}
 
-	repo := NewInvitesRepository(db)
+	repo := NewInvitesRepository(db, NewTestOutbox(t, db))
 
 	return repo
 }
 
+// NewTestOutbox - Outbox on the database passed in that delivers to a mock.
+func NewTestOutbox(t *testing.T, db *sql.DB) outbox.Service {
+	templates, err := notifications.NewTemplateRepository(log.NewNopLogger())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	notifications := notifications.NewMockNotificationsService(notifications.MockConfig{
+		From: "noreply@moov.io",
+	}, templates)
+
+	return outbox.NewOutboxService(outbox.Config{}, log.NewNopLogger(), stime.NewStaticTimeService(), outbox.NewOutboxRepository(db), notifications)
+}
+
 func AddTestingInvite(t *testing.T, repository Repository) (client.Invite, string) {
 	i := RandomInvite()
 	code, err := generateInviteCode()
@@ -186,7 +185,8 @@ func AddTestingInvite(t *testing.T, repository Repository) (client.Invite, strin
 		t.Error(err)
 	}
 
-	added, err := repository.add(i, *code)
+	email := notifications.NewInviteEmail("https://local.moov.io/accept", client.Identity{}, authn.Tenant{}, nil)
+	added, err := repository.add(context.Background(), i, *code, &email)
 	if err != nil {
 		t.Error(err)
 	}
@@ -9,9 +9,12 @@ import (
 	authntestutils "github.com/moov-io/identity/pkg/authn/testutils"
 	client "github.com/moov-io/identity/pkg/client"
 	clienttest "github.com/moov-io/identity/pkg/client_test"
+	"github.com/moov-io/identity/pkg/database"
 	identitiestestutils "github.com/moov-io/identity/pkg/identities/testutils"
+	"github.com/moov-io/identity/pkg/jobs"
 	"github.com/moov-io/identity/pkg/logging"
 	"github.com/moov-io/identity/pkg/notifications"
+	"github.com/moov-io/identity/pkg/outbox"
 	"github.com/moov-io/identity/pkg/stime"
 	tmw "github.com/moov-io/tumbler/pkg/middleware"
 	tmwt "github.com/moov-io/tumbler/pkg/middleware/middlewaretest"
@@ -21,9 +24,11 @@ type Scope struct {
 	session       tmw.TumblerClaims
 	config        Config
 	time          stime.StaticTimeService
-	notifications notifications.NotificationsService
+	notifications notifications.MockNotificationsService
+	outbox        outbox.Service
 	repository    Repository
 	service       InvitesService
+	jobs          jobs.Service
 	routes        *mux.Router
 	api           *client.APIClient
 }
@@ -39,20 +44,38 @@ func NewScope(t *testing.T) Scope {
 	}
 
 	times := stime.NewStaticTimeService()
-	repository := NewInMemoryInvitesRepository(t)
 
+	db, close, err := database.NewAndMigrate(database.InMemorySqliteConfig, nil, nil)
+	t.Cleanup(close)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	templates, err := notifications.NewTemplateRepository(logging)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	inviteTemplate := (&notifications.InviteEmail{}).TemplateName()
 	notifications := notifications.NewMockNotificationsService(notifications.MockConfig{
 		From: "noreply@moov.io",
-	})
+	}, templates)
+
+	outbox := outbox.NewOutboxService(outbox.Config{}, logging, times, outbox.NewOutboxRepository(db), notifications)
+	repository := NewInvitesRepository(db, outbox)
 
 	authnClient := authntestutils.NewMockAuthnClient()
 	singleIdentity := identitiestestutils.NewSingleService(nil)
 
-	service, err := NewInvitesService(invitesConfig, times, repository, notifications, authnClient, singleIdentity)
+	jobs := NewInMemoryJobsService(t, times)
+
+	service, err := NewInvitesService(invitesConfig, times, repository, authnClient, singleIdentity, jobs)
 	if err != nil {
 		t.Error(err)
 	}
 
+	outbox.Fill(inviteTemplate, service.FillEmail)
+
 	controller := NewInvitesController(logging, service)
 
 	routes := mux.NewRouter()
@@ -68,9 +91,25 @@ func NewScope(t *testing.T) Scope {
 		config:        invitesConfig,
 		time:          times,
 		notifications: notifications,
+		outbox:        outbox,
 		repository:    repository,
 		service:       service,
+		jobs:          jobs,
 		routes:        routes,
 		api:           testAPI,
 	}
 }
+
+// NewInMemoryJobsService - Jobs tracked in their own database for the scopes that don't need to share one.
+func NewInMemoryJobsService(t *testing.T, times stime.TimeService) jobs.Service {
+	db, close, err := database.NewAndMigrate(database.InMemorySqliteConfig, nil, nil)
+	t.Cleanup(close)
+	if err != nil {
+		t.Error(err)
+	}
+
+	service := jobs.NewJobsService(logging.NewNopLogger(), times, jobs.NewJobsRepository(db))
+	t.Cleanup(service.Wait)
+
+	return service
+}
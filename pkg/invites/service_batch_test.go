@@ -0,0 +1,111 @@
+package invites
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/moov-io/identity/pkg/client"
+	"github.com/moov-io/identity/pkg/jobs"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_SendInviteBatch(t *testing.T) {
+	a := require.New(t)
+	s := NewScope(t)
+
+	job, err := s.service.SendInviteBatch(context.Background(), s.session, client.SendInviteBatch{
+		Emails: []string{"one@moov.io", "two@moov.io", "ONE@moov.io", " three@moov.io "},
+	})
+	a.Nil(err)
+	a.Equal(JobKindInvites, job.Kind)
+	a.Equal(int64(3), job.Total)
+
+	s.jobs.Wait()
+
+	job, err = s.jobs.GetJob(context.Background(), s.session, job.JobID)
+	a.Nil(err)
+	a.Equal(jobs.JobCompleted, job.Status)
+	a.Equal(int64(3), job.Succeeded)
+	a.Equal("three@moov.io", job.Items[2].Key)
+
+	invites, err := s.service.ListInvites(context.Background(), s.session)
+	a.Nil(err)
+	a.Len(invites, 3)
+
+	sent := map[string]string{}
+	for _, i := range invites {
+		sent[i.InviteID] = i.Email
+	}
+	for _, item := range job.Items {
+		a.Equal(jobs.ItemSucceeded, item.Status)
+		a.Equal(item.Key, sent[*item.ResultID])
+	}
+}
+
+func Test_SendInviteBatch_Invalid(t *testing.T) {
+	a := require.New(t)
+	s := NewScope(t)
+
+	_, err := s.service.SendInviteBatch(context.Background(), s.session, client.SendInviteBatch{})
+	a.Error(err)
+
+	_, err = s.service.SendInviteBatch(context.Background(), s.session, client.SendInviteBatch{Emails: []string{"one@moov.io", "not an email"}})
+	a.Error(err)
+
+	emails := make([]string, 1001)
+	for i := range emails {
+		emails[i] = "many@moov.io"
+	}
+	_, err = s.service.SendInviteBatch(context.Background(), s.session, client.SendInviteBatch{Emails: emails})
+	a.Error(err)
+}
+
+func Test_SendInvites(t *testing.T) {
+	a := require.New(t)
+	s := NewScope(t)
+
+	sent := map[string]string{}
+	err := s.service.SendInvites(context.Background(), s.session, []string{"one@moov.io", "two@moov.io"}, func(email string, invite *client.Invite, err error) {
+		a.Nil(err)
+		sent[invite.InviteID] = email
+	})
+	a.Nil(err)
+	a.Len(sent, 2)
+
+	invites, err := s.service.ListInvites(context.Background(), s.session)
+	a.Nil(err)
+	a.Len(invites, 2)
+	for _, i := range invites {
+		a.Equal(i.Email, sent[i.InviteID])
+	}
+}
+
+func Test_SendInviteBatchAPI(t *testing.T) {
+	a := require.New(t)
+	s := NewScope(t)
+
+	send := func(batch interface{}) *httptest.ResponseRecorder {
+		body, _ := json.Marshal(batch)
+		rec := httptest.NewRecorder()
+		s.routes.ServeHTTP(rec, httptest.NewRequest("POST", "/invites/batch", bytes.NewReader(body)))
+		return rec
+	}
+
+	resp := send(client.SendInviteBatch{Emails: []string{"one@moov.io", "two@moov.io"}})
+	a.Equal(202, resp.Code)
+
+	job := client.Job{}
+	a.Nil(json.NewDecoder(resp.Body).Decode(&job))
+	a.Equal(int64(2), job.Total)
+	a.Len(job.Items, 2)
+
+	resp = send(client.SendInviteBatch{Emails: []string{"bad"}})
+	a.Equal(400, resp.Code)
+	a.Contains(resp.Body.String(), "emails")
+
+	resp = send("not a batch")
+	a.Equal(400, resp.Code)
+}
@@ -1,6 +1,7 @@
 package invites
 
 import (
+	"context"
 	"crypto/rand"
 	"encoding/base64"
 	"html/template"
@@ -9,9 +10,11 @@ import (
 	"time"
 
 	"github.com/google/uuid"
+	authn "github.com/moov-io/authn/pkg/client"
 	authnclient "github.com/moov-io/identity/pkg/authn/client"
 	"github.com/moov-io/identity/pkg/client"
 	"github.com/moov-io/identity/pkg/identities"
+	"github.com/moov-io/identity/pkg/jobs"
 	"github.com/moov-io/identity/pkg/notifications"
 	"github.com/moov-io/identity/pkg/stime"
 
@@ -21,59 +24,148 @@ import (
 
 // InvitesApiServicer defines the api actions for the InvitesApi service
 type InvitesService interface {
-	DisableInvite(tmw.TumblerClaims, string) error
-	ListInvites(tmw.TumblerClaims) ([]client.Invite, error)
-	SendInvite(tmw.TumblerClaims, client.SendInvite) (*client.Invite, string, error)
-	Redeem(code string) (*client.Invite, error)
+	DisableInvite(context.Context, tmw.TumblerClaims, string) error
+	ListInvites(context.Context, tmw.TumblerClaims) ([]client.Invite, error)
+	SendInvite(context.Context, tmw.TumblerClaims, client.SendInvite) (*client.Invite, string, error)
+	SendInviteBatch(context.Context, tmw.TumblerClaims, client.SendInviteBatch) (*client.Job, error)
+	SendInvites(ctx context.Context, claims tmw.TumblerClaims, emails []string, each func(email string, invite *client.Invite, err error)) error
+	Redeem(ctx context.Context, code string) (*client.Invite, error)
+	FillEmail(ctx context.Context, tenantID string, email notifications.EmailTemplate) error
 }
 
 type invitesService struct {
-	sendToURL     *template.Template
-	expiration    time.Duration
-	time          stime.TimeService
-	repository    Repository
-	notifications notifications.NotificationsService
-	authnClient   authnclient.AuthnClient
-	identity      identities.Service
+	sendToURL        *template.Template
+	expiration       time.Duration
+	batchConcurrency int
+	time             stime.TimeService
+	repository       Repository
+	authnClient      authnclient.AuthnClient
+	identity         identities.Service
+	jobs             jobs.Service
 }
 
 // NewInvitesService instantiates a new invitesService for interacting with Invites from outside of the package.
-func NewInvitesService(config Config, time stime.TimeService, repository Repository, notifications notifications.NotificationsService, authnClient authnclient.AuthnClient, identity identities.Service) (InvitesService, error) {
+func NewInvitesService(config Config, time stime.TimeService, repository Repository, authnClient authnclient.AuthnClient, identity identities.Service, jobs jobs.Service) (InvitesService, error) {
 
 	urlTemplate, err := template.New("send").Parse(config.SendToHost + config.SendToPath)
 	if err != nil {
 		return nil, err
 	}
 
+	batchConcurrency := config.BatchConcurrency
+	if batchConcurrency < 1 {
+		batchConcurrency = 1
+	}
+
 	return &invitesService{
-		sendToURL:     urlTemplate,
-		expiration:    config.Expiration,
-		time:          time,
-		repository:    repository,
-		notifications: notifications,
-		authnClient:   authnClient,
-		identity:      identity,
+		sendToURL:        urlTemplate,
+		expiration:       config.Expiration,
+		batchConcurrency: batchConcurrency,
+		time:             time,
+		repository:       repository,
+		authnClient:      authnClient,
+		identity:         identity,
+		jobs:             jobs,
 	}, nil
 }
 
 // ListInvites - List outstanding invites
-func (s *invitesService) ListInvites(claims tmw.TumblerClaims) ([]client.Invite, error) {
-	invites, err := s.repository.list(api.TenantID(claims.TenantID))
+func (s *invitesService) ListInvites(ctx context.Context, claims tmw.TumblerClaims) ([]client.Invite, error) {
+	invites, err := s.repository.list(ctx, api.TenantID(claims.TenantID))
 	return invites, err
 }
 
 // SendInvite - Send an email invite to a new user
-func (s *invitesService) SendInvite(claims tmw.TumblerClaims, send client.SendInvite) (*client.Invite, string, error) {
+func (s *invitesService) SendInvite(ctx context.Context, claims tmw.TumblerClaims, send client.SendInvite) (*client.Invite, string, error) {
+	if err := send.Validate(); err != nil {
+		return nil, "", err
+	}
+
+	tenant, inviter, err := s.sender(ctx, claims)
+	if err != nil {
+		return nil, "", err
+	}
+
+	return s.send(ctx, claims, send.Email, send.Locale, *tenant, *inviter)
+}
+
+// SendInviteBatch - Sends an invite to each of the emails in a background job. The tenant and the inviter are only
+// looked up once for all of them. Repeats of the same email only get one invite.
+func (s *invitesService) SendInviteBatch(ctx context.Context, claims tmw.TumblerClaims, batch client.SendInviteBatch) (*client.Job, error) {
+	for i := range batch.Emails {
+		batch.Emails[i] = strings.TrimSpace(batch.Emails[i])
+	}
+
+	if err := batch.Validate(); err != nil {
+		return nil, err
+	}
+
+	emails := []string{}
+	seen := map[string]bool{}
+	for _, email := range batch.Emails {
+		if !seen[strings.ToLower(email)] {
+			seen[strings.ToLower(email)] = true
+			emails = append(emails, email)
+		}
+	}
+
+	tenant, inviter, err := s.sender(ctx, claims)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.jobs.Start(ctx, claims, JobKindInvites, emails, s.batchConcurrency, func(ctx context.Context, email string) (string, error) {
+		invite, _, err := s.send(ctx, claims, email, batch.Locale, *tenant, *inviter)
+		if err != nil {
+			return "", err
+		}
+		return invite.InviteID, nil
+	})
+}
+
+// SendInvites - Sends an invite to each of the emails right away with the tenant and the inviter only looked up once.
+// How each one went is handed to each, the error is only for not being able to find who's sending them.
+func (s *invitesService) SendInvites(ctx context.Context, claims tmw.TumblerClaims, emails []string, each func(email string, invite *client.Invite, err error)) error {
+	tenant, inviter, err := s.sender(ctx, claims)
+	if err != nil {
+		return err
+	}
+
+	for _, email := range emails {
+		invite, _, err := s.send(ctx, claims, email, nil, *tenant, *inviter)
+		each(email, invite, err)
+	}
+
+	return nil
+}
+
+// sender - The tenant the invites are into and who is sending them.
+func (s *invitesService) sender(ctx context.Context, claims tmw.TumblerClaims) (*authn.Tenant, *client.Identity, error) {
+	tenant, err := s.authnClient.GetTenant(claims, claims.TenantID.String())
+	if err != nil {
+		return nil, nil, err
+	}
+
+	inviter, err := s.identity.GetIdentity(ctx, claims, claims.Subject)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return tenant, inviter, nil
+}
+
+func (s *invitesService) send(ctx context.Context, claims tmw.TumblerClaims, email string, locale *string, tenant authn.Tenant, inviter client.Identity) (*client.Invite, string, error) {
 	invite := client.Invite{
 		InviteID:   uuid.New().String(),
 		TenantID:   claims.TenantID.String(),
-		Email:      send.Email,
+		Email:      email,
 		InvitedBy:  claims.Subject,
 		InvitedOn:  s.time.Now(),
 		RedeemedOn: nil,
 		ExpiresOn:  s.time.Now().Add(s.expiration),
 		DisabledBy: nil,
 		DisabledOn: nil,
+		Locale:     locale,
 	}
 
 	code, err1 := generateInviteCode()
@@ -86,24 +178,11 @@ func (s *invitesService) SendInvite(claims tmw.TumblerClaims, send client.SendIn
 		return nil, "", err
 	}
 
-	tenant, err := s.authnClient.GetTenant(claims, claims.TenantID.String())
-	if err != nil {
-		return nil, "", err
-	}
-
-	inviter, err := s.identity.GetIdentity(claims, claims.Subject)
-	if err != nil {
-		return nil, "", err
-	}
-
-	notification := notifications.NewInviteEmail(redeemURL.String(), *inviter, *tenant)
-
-	if err := s.notifications.SendEmail(invite.Email, &notification); err != nil {
-		return nil, "", err
-	}
+	notification := notifications.NewInviteEmail(redeemURL.String(), inviter, tenant, locale)
+	notification.InviteID = invite.InviteID
 
-	// add to DB
-	created, err2 := s.repository.add(invite, *code)
+	// add to DB, the email goes out from the outbox once its committed
+	created, err2 := s.repository.add(ctx, invite, *code, &notification)
 	if err2 != nil {
 		return nil, "", err2
 	}
@@ -112,8 +191,8 @@ func (s *invitesService) SendInvite(claims tmw.TumblerClaims, send client.SendIn
 }
 
 // DeleteInvite - Delete an invite that was sent and invalidate the token.
-func (s *invitesService) DisableInvite(claims tmw.TumblerClaims, inviteID string) error {
-	invite, err := s.repository.get(api.TenantID(claims.TenantID), inviteID)
+func (s *invitesService) DisableInvite(ctx context.Context, claims tmw.TumblerClaims, inviteID string) error {
+	invite, err := s.repository.get(ctx, api.TenantID(claims.TenantID), inviteID)
 	if err != nil {
 		return err
 	}
@@ -123,11 +202,11 @@ func (s *invitesService) DisableInvite(claims tmw.TumblerClaims, inviteID string
 	invite.DisabledBy = &disabledBy
 	invite.DisabledOn = &disabledOn
 
-	return s.repository.update(*invite)
+	return s.repository.update(ctx, *invite)
 }
 
-func (s *invitesService) Redeem(code string) (*client.Invite, error) {
-	invite, err := s.repository.getByCode(strings.TrimSpace(code))
+func (s *invitesService) Redeem(ctx context.Context, code string) (*client.Invite, error) {
+	invite, err := s.repository.getByCode(ctx, strings.TrimSpace(code))
 	if err != nil {
 		return nil, err
 	}
@@ -143,13 +222,35 @@ func (s *invitesService) Redeem(code string) (*client.Invite, error) {
 	redeemedOn := s.time.Now()
 	invite.RedeemedOn = &redeemedOn
 
-	if err := s.repository.update(*invite); err != nil {
+	if err := s.repository.update(ctx, *invite); err != nil {
 		return nil, err
 	}
 
 	return invite, nil
 }
 
+// FillEmail - Puts the link to accept the invite back into its email as its sent from the outbox.
+// The link isn't stored with the email since anyone with it can redeem the invite.
+func (s *invitesService) FillEmail(ctx context.Context, tenantID string, email notifications.EmailTemplate) error {
+	inviteEmail, ok := email.(*notifications.InviteEmail)
+	if !ok {
+		return nil
+	}
+
+	code, err := s.repository.getCode(ctx, tenantID, inviteEmail.InviteID)
+	if err != nil {
+		return err
+	}
+
+	redeemURL, err := generateRedeemURL(*s.sendToURL, client.Invite{TenantID: tenantID}, &code)
+	if err != nil {
+		return err
+	}
+
+	inviteEmail.AcceptInvitationURL = redeemURL.String()
+	return nil
+}
+
 // Generate a large random crypto string to work as the invitation token
 func generateInviteCode() (*string, error) {
 	b := make([]byte, 32)
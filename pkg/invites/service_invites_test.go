@@ -1,19 +1,24 @@
 package invites
 
 import (
+	"context"
 	"database/sql"
 	"testing"
 	"time"
 
+	validation "github.com/go-ozzo/ozzo-validation/v4"
 	"github.com/google/go-cmp/cmp"
 	_ "github.com/mattn/go-sqlite3"
+	authn "github.com/moov-io/authn/pkg/client"
 	authntestutils "github.com/moov-io/identity/pkg/authn/testutils"
 	"github.com/moov-io/identity/pkg/client"
 	identitiestestutils "github.com/moov-io/identity/pkg/identities/testutils"
 	"github.com/moov-io/identity/pkg/notifications"
+	"github.com/moov-io/identity/pkg/outbox"
 	"github.com/moov-io/identity/pkg/stime"
 	tmw "github.com/moov-io/tumbler/pkg/middleware"
 	tmwt "github.com/moov-io/tumbler/pkg/middleware/middlewaretest"
+	"github.com/stretchr/testify/require"
 )
 
 type InvitesServiceScope struct {
@@ -27,12 +32,12 @@ func TestSendInvite(t *testing.T) {
 
 	sendInvite := client.SendInvite{Email: "testuser@moov.io"}
 
-	invite, code, err := s.service.SendInvite(s.session, sendInvite)
+	invite, code, err := s.service.SendInvite(context.Background(), s.session, sendInvite)
 	if err != nil {
 		t.Error(err)
 	}
 
-	invites, err := s.service.ListInvites(s.session)
+	invites, err := s.service.ListInvites(context.Background(), s.session)
 	if err != nil {
 		t.Error(err)
 	}
@@ -45,7 +50,7 @@ func TestSendInvite(t *testing.T) {
 		t.Errorf("Invite doesn't exist in list %s", cmp.Diff(*invite, invites[0]))
 	}
 
-	redeemed, err := s.service.Redeem(code)
+	redeemed, err := s.service.Redeem(context.Background(), code)
 	if err != nil {
 		t.Error(err)
 	}
@@ -59,12 +64,12 @@ func Test_RedeemExpired(t *testing.T) {
 
 	sendInvite := client.SendInvite{Email: "testuser@moov.io"}
 
-	_, _, err := s.service.SendInvite(s.session, sendInvite)
+	_, _, err := s.service.SendInvite(context.Background(), s.session, sendInvite)
 	if err != nil {
 		t.Error(err)
 	}
 
-	_, err = s.service.Redeem("doesnotexist")
+	_, err = s.service.Redeem(context.Background(), "doesnotexist")
 	if err != sql.ErrNoRows {
 		t.Error("A token that does not exist didn't fail with No Rows")
 	}
@@ -75,17 +80,17 @@ func TestDisableInvite(t *testing.T) {
 
 	sendInvite := client.SendInvite{Email: "testuser@moov.io"}
 
-	invite, code, err := s.service.SendInvite(s.session, sendInvite)
+	invite, code, err := s.service.SendInvite(context.Background(), s.session, sendInvite)
 	if err != nil {
 		t.Error(err)
 	}
 
-	err = s.service.DisableInvite(s.session, invite.InviteID)
+	err = s.service.DisableInvite(context.Background(), s.session, invite.InviteID)
 	if err != nil {
 		t.Error(err)
 	}
 
-	_, err = s.service.Redeem(code)
+	_, err = s.service.Redeem(context.Background(), code)
 	if err != ErrInviteCodeDisabled {
 		t.Error("Disabled token didn't redeem will disabled failure")
 	}
@@ -95,14 +100,14 @@ func TestExpiredInvite(t *testing.T) {
 
 	sendInvite := client.SendInvite{Email: "testuser@moov.io"}
 
-	invite, code, err := s.service.SendInvite(s.session, sendInvite)
+	invite, code, err := s.service.SendInvite(context.Background(), s.session, sendInvite)
 	if err != nil {
 		t.Error(err)
 	}
 
 	s.time.Change(invite.ExpiresOn.Add(time.Millisecond))
 
-	_, err = s.service.Redeem(code)
+	_, err = s.service.Redeem(context.Background(), code)
 	if err != ErrInviteCodeExpired {
 		t.Error("Expired token didn't redeem with expired failure")
 	}
@@ -120,15 +125,11 @@ func NewInvitesScope(t *testing.T) InvitesServiceScope {
 	}
 
 	times := stime.NewStaticTimeService()
-	notification := notifications.NewMockNotificationsService(notifications.MockConfig{
-		From: "noreply@moov.io",
-	})
-
 	authnClient := authntestutils.NewMockAuthnClient()
 
 	identity := identitiestestutils.NewSingleService(nil)
 
-	service, err := NewInvitesService(config, times, repository, notification, authnClient, identity)
+	service, err := NewInvitesService(config, times, repository, authnClient, identity, NewInMemoryJobsService(t, times))
 	if err != nil {
 		panic(err)
 	}
@@ -141,3 +142,65 @@ func NewInvitesScope(t *testing.T) InvitesServiceScope {
 
 	return scope
 }
+
+func Test_SendInvite_Outbox(t *testing.T) {
+	a := require.New(t)
+	s := NewScope(t)
+
+	invite, code, err := s.service.SendInvite(context.Background(), s.session, client.SendInvite{Email: "testuser@moov.io"})
+	a.Nil(err)
+
+	pending, err := s.outbox.ListMessages(context.Background(), outbox.StatusPending)
+	a.Nil(err)
+	a.Len(pending, 1)
+	a.Equal(invite.Email, pending[0].Recipient)
+	a.Equal(invite.TenantID, pending[0].TenantID)
+
+	// A second invite with the same ID fails to save so its email never makes it to the outbox.
+	email := notifications.NewInviteEmail("https://local.moov.io/accept", client.Identity{}, authn.Tenant{}, nil)
+	_, err = s.repository.add(context.Background(), *invite, "another-code", &email)
+	a.Equal(ErrInviteExists, err)
+
+	sent, err := s.outbox.Dispatch(context.Background())
+	a.Nil(err)
+	a.Equal(1, sent)
+
+	delivered, err := s.outbox.ListMessages(context.Background(), outbox.StatusSent)
+	a.Nil(err)
+	a.Len(delivered, 1)
+	a.Equal(pending[0].MessageID, delivered[0].MessageID)
+
+	emails := s.notifications.Sent()
+	a.Len(emails, 1)
+	a.Equal("testuser@moov.io", emails[0].To)
+	a.Contains(emails[0].Text, "invite_code="+code)
+	a.Contains(emails[0].HTML, "invite_code="+code)
+}
+
+func Test_SendInvite_Locale(t *testing.T) {
+	a := require.New(t)
+	s := NewScope(t)
+
+	locale := "fr-CA"
+	invite, _, err := s.service.SendInvite(context.Background(), s.session, client.SendInvite{Email: "testuser@moov.io", Locale: &locale})
+	a.Nil(err)
+	a.Equal(&locale, invite.Locale)
+
+	invites, err := s.service.ListInvites(context.Background(), s.session)
+	a.Nil(err)
+	a.Len(invites, 1)
+	a.Equal(&locale, invites[0].Locale)
+
+	_, err = s.outbox.Dispatch(context.Background())
+	a.Nil(err)
+
+	// There aren't any fr-CA templates so its sent with the fr ones.
+	emails := s.notifications.Sent()
+	a.Len(emails, 1)
+	a.Contains(emails[0].Subject, "Invitation")
+	a.Contains(emails[0].Text, "Bienvenue")
+
+	invalid := "not a locale"
+	_, _, err = s.service.SendInvite(context.Background(), s.session, client.SendInvite{Email: "testuser@moov.io", Locale: &invalid})
+	a.IsType(validation.Errors{}, err)
+}
@@ -0,0 +1,53 @@
+package jobs
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/gorilla/mux"
+	api "github.com/moov-io/identity/pkg/api"
+	"github.com/moov-io/identity/pkg/logging"
+	tmw "github.com/moov-io/tumbler/pkg/middleware"
+)
+
+// A Controller binds http requests to an api service and writes the service results to the http response
+type controller struct {
+	logger  logging.Logger
+	service Service
+}
+
+// NewJobsController creates a default api controller
+func NewJobsController(logger logging.Logger, s Service) api.Router {
+	return &controller{
+		logger:  logger,
+		service: s,
+	}
+}
+
+// Routes returns all of the api route for the JobsController
+func (c *controller) Routes() api.Routes {
+	return api.Routes{
+		{
+			Name:        "GetJob",
+			Method:      strings.ToUpper("Get"),
+			Pattern:     "/jobs/{jobID}",
+			HandlerFunc: c.GetJob,
+		},
+	}
+}
+
+// GetJob - Status of a background job and each of its items
+func (c *controller) GetJob(w http.ResponseWriter, r *http.Request) {
+	tmw.WithClaimsFromRequest(w, r, func(claims tmw.TumblerClaims) {
+		params := mux.Vars(r)
+		jobID := params["jobID"]
+
+		result, err := c.service.GetJob(r.Context(), claims, jobID)
+		if err != nil {
+			api.EncodeError(w, c.logger.LogError("unable to get job", err))
+			return
+		}
+
+		api.EncodeJSONResponse(result, nil, w)
+	})
+}
@@ -0,0 +1,6 @@
+package jobs
+
+import "github.com/moov-io/identity/pkg/apperr"
+
+// ErrJobNotFound is issued when the job doesn't exist or belongs to another tenant.
+var ErrJobNotFound = apperr.NotFound("job not found")
@@ -0,0 +1,202 @@
+package jobs
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/moov-io/identity/pkg/client"
+	"github.com/moov-io/identity/pkg/database"
+)
+
+// Repository - Used for tracking jobs and their items on the data store
+type Repository interface {
+	add(ctx context.Context, job client.Job) (*client.Job, error)
+	get(ctx context.Context, tenantID string, jobID string) (*client.Job, error)
+	start(ctx context.Context, jobID string, startedOn time.Time) error
+	completeItem(ctx context.Context, jobID string, seq int, item client.JobItem) error
+	complete(ctx context.Context, jobID string, completedOn time.Time) error
+}
+
+// NewJobsRepository - Builds a new repository tied to the DB passed in.
+func NewJobsRepository(db *sql.DB) Repository {
+	return &sqlJobsRepo{db: db, dialect: database.DialectOf(db)}
+}
+
+type sqlJobsRepo struct {
+	db      *sql.DB
+	dialect database.Dialect
+}
+
+// add - Saves the job along with all of its items, which are numbered by their place in the list.
+func (r *sqlJobsRepo) add(ctx context.Context, job client.Job) (*client.Job, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	_, err = tx.ExecContext(ctx, r.dialect.Rebind(`
+		INSERT INTO job (
+			job_id,
+			tenant_id,
+			kind,
+			status,
+			created_by,
+			created_on,
+			started_on,
+			completed_on
+		) VALUES (?, ?, ?, ?, ?, ?, ?, ?)
+	`),
+		job.JobID,
+		job.TenantID,
+		job.Kind,
+		job.Status,
+		job.CreatedBy,
+		job.CreatedOn,
+		job.StartedOn,
+		job.CompletedOn)
+	if err != nil {
+		return nil, err
+	}
+
+	for seq, item := range job.Items {
+		_, err := tx.ExecContext(ctx, r.dialect.Rebind(`
+			INSERT INTO job_item (
+				job_id,
+				seq,
+				item_key,
+				status,
+				result_id,
+				error,
+				completed_on
+			) VALUES (?, ?, ?, ?, ?, ?, ?)
+		`),
+			job.JobID,
+			seq,
+			item.Key,
+			item.Status,
+			item.ResultID,
+			item.Error,
+			item.CompletedOn)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	return &job, nil
+}
+
+// get - Loads the job with its items and tallies up how they went.
+func (r *sqlJobsRepo) get(ctx context.Context, tenantID string, jobID string) (*client.Job, error) {
+	job := client.Job{}
+	err := r.db.QueryRowContext(ctx, r.dialect.Rebind(`
+		SELECT
+			job_id,
+			tenant_id,
+			kind,
+			status,
+			created_by,
+			created_on,
+			started_on,
+			completed_on
+		FROM job
+		WHERE tenant_id = ? AND job_id = ?
+	`), tenantID, jobID).Scan(
+		&job.JobID,
+		&job.TenantID,
+		&job.Kind,
+		&job.Status,
+		&job.CreatedBy,
+		&job.CreatedOn,
+		&job.StartedOn,
+		&job.CompletedOn,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := r.db.QueryContext(ctx, r.dialect.Rebind(`
+		SELECT
+			item_key,
+			status,
+			result_id,
+			error,
+			completed_on
+		FROM job_item
+		WHERE job_id = ?
+		ORDER BY seq
+	`), jobID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	job.Items = []client.JobItem{}
+	for rows.Next() {
+		item := client.JobItem{}
+		if err := rows.Scan(
+			&item.Key,
+			&item.Status,
+			&item.ResultID,
+			&item.Error,
+			&item.CompletedOn,
+		); err != nil {
+			return nil, err
+		}
+
+		job.Total++
+		switch item.Status {
+		case ItemSucceeded:
+			job.Succeeded++
+		case ItemFailed:
+			job.Failed++
+		}
+
+		job.Items = append(job.Items, item)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return &job, nil
+}
+
+func (r *sqlJobsRepo) start(ctx context.Context, jobID string, startedOn time.Time) error {
+	return r.exec(ctx, `UPDATE job SET status = ?, started_on = ? WHERE job_id = ?`, JobRunning, startedOn, jobID)
+}
+
+func (r *sqlJobsRepo) completeItem(ctx context.Context, jobID string, seq int, item client.JobItem) error {
+	return r.exec(ctx, `
+		UPDATE job_item
+		SET status = ?, result_id = ?, error = ?, completed_on = ?
+		WHERE job_id = ? AND seq = ?
+	`, item.Status, item.ResultID, item.Error, item.CompletedOn, jobID, seq)
+}
+
+func (r *sqlJobsRepo) complete(ctx context.Context, jobID string, completedOn time.Time) error {
+	return r.exec(ctx, `UPDATE job SET status = ?, completed_on = ? WHERE job_id = ?`, JobCompleted, completedOn, jobID)
+}
+
+func (r *sqlJobsRepo) exec(ctx context.Context, qry string, args ...interface{}) error {
+	res, err := r.db.ExecContext(ctx, r.dialect.Rebind(qry), args...)
+	if err != nil {
+		return err
+	}
+
+	cnt, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if cnt != 1 {
+		return fmt.Errorf("expected 1 row to be updated but was %d", cnt)
+	}
+
+	return nil
+}
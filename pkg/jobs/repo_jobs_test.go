@@ -0,0 +1,68 @@
+package jobs
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/moov-io/identity/pkg/client"
+	"github.com/moov-io/identity/pkg/database"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_Repository_Lifecycle(t *testing.T) {
+	ForEachDatabase(t, func(t *testing.T, a *require.Assertions, repository Repository) {
+		now := time.Now().UTC().Round(time.Second)
+		job := client.Job{
+			JobID:     uuid.New().String(),
+			TenantID:  uuid.New().String(),
+			Kind:      "testing",
+			Status:    JobPending,
+			CreatedBy: uuid.New().String(),
+			CreatedOn: now,
+			Items: []client.JobItem{
+				{Key: "first", Status: ItemPending},
+				{Key: "second", Status: ItemPending},
+			},
+		}
+
+		_, err := repository.add(context.Background(), job)
+		a.NoError(err)
+
+		_, err = repository.get(context.Background(), uuid.New().String(), job.JobID)
+		a.Equal(sql.ErrNoRows, err)
+
+		a.NoError(repository.start(context.Background(), job.JobID, now))
+
+		resultID, failure := uuid.New().String(), "it broke"
+		a.NoError(repository.completeItem(context.Background(), job.JobID, 0, client.JobItem{Key: "first", Status: ItemSucceeded, ResultID: &resultID, CompletedOn: &now}))
+		a.NoError(repository.completeItem(context.Background(), job.JobID, 1, client.JobItem{Key: "second", Status: ItemFailed, Error: &failure, CompletedOn: &now}))
+		a.Error(repository.completeItem(context.Background(), job.JobID, 2, client.JobItem{Key: "third", Status: ItemSucceeded}))
+
+		a.NoError(repository.complete(context.Background(), job.JobID, now))
+
+		found, err := repository.get(context.Background(), job.TenantID, job.JobID)
+		a.NoError(err)
+		a.Equal(JobCompleted, found.Status)
+		a.NotNil(found.StartedOn)
+		a.NotNil(found.CompletedOn)
+		a.Equal(int64(2), found.Total)
+		a.Equal(int64(1), found.Succeeded)
+		a.Equal(int64(1), found.Failed)
+
+		// Kept in the order they were added
+		a.Len(found.Items, 2)
+		a.Equal("first", found.Items[0].Key)
+		a.Equal(&resultID, found.Items[0].ResultID)
+		a.Equal("second", found.Items[1].Key)
+		a.Equal(&failure, found.Items[1].Error)
+	})
+}
+
+func ForEachDatabase(t *testing.T, run func(t *testing.T, a *require.Assertions, repository Repository)) {
+	database.ForEachDatabase(t, func(t *testing.T, db *sql.DB) {
+		run(t, require.New(t), NewJobsRepository(db))
+	})
+}
@@ -0,0 +1,57 @@
+package jobs
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gorilla/mux"
+	api "github.com/moov-io/identity/pkg/api"
+	"github.com/moov-io/identity/pkg/database"
+	"github.com/moov-io/identity/pkg/logging"
+	"github.com/moov-io/identity/pkg/stime"
+	tmw "github.com/moov-io/tumbler/pkg/middleware"
+	tmwt "github.com/moov-io/tumbler/pkg/middleware/middlewaretest"
+	"github.com/stretchr/testify/require"
+)
+
+type Scope struct {
+	session tmw.TumblerClaims
+	time    stime.StaticTimeService
+	service Service
+	routes  *mux.Router
+}
+
+func Setup(t *testing.T) (*require.Assertions, Scope) {
+	logging := logging.NewDefaultLogger()
+	session := tmwt.NewRandomClaims()
+	times := stime.NewStaticTimeService()
+
+	db, close, err := database.NewAndMigrate(database.InMemorySqliteConfig, nil, nil)
+	t.Cleanup(close)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	service := NewJobsService(logging, times, NewJobsRepository(db))
+	t.Cleanup(service.Wait)
+
+	routes := mux.NewRouter()
+	api.AppendRouters(logging, routes, NewJobsController(logging, service))
+
+	testMiddleware := tmwt.NewTestMiddleware(times, session)
+	routes.Use(testMiddleware.Handler)
+
+	return require.New(t), Scope{
+		session: session,
+		time:    times,
+		service: service,
+		routes:  routes,
+	}
+}
+
+func (s *Scope) Request(method string, path string) *http.Response {
+	rec := httptest.NewRecorder()
+	s.routes.ServeHTTP(rec, httptest.NewRequest(method, path, nil))
+	return rec.Result()
+}
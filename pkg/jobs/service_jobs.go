@@ -0,0 +1,164 @@
+package jobs
+
+import (
+	"context"
+	"database/sql"
+	"sync"
+
+	"github.com/google/uuid"
+	"github.com/moov-io/identity/pkg/client"
+	"github.com/moov-io/identity/pkg/logging"
+	"github.com/moov-io/identity/pkg/stime"
+	tmw "github.com/moov-io/tumbler/pkg/middleware"
+)
+
+// Statuses of a job
+const (
+	JobPending   = "pending"
+	JobRunning   = "running"
+	JobCompleted = "completed"
+)
+
+// Statuses of each item of a job
+const (
+	ItemPending   = "pending"
+	ItemSucceeded = "succeeded"
+	ItemFailed    = "failed"
+)
+
+// Longest error kept for an item
+const maxErrorLength = 255
+
+// Work - Does the work for one item of a job and returns the ID of what it created.
+// Jobs outlive the request that started them so the context isn't the request's.
+type Work func(ctx context.Context, key string) (string, error)
+
+// Service - Runs work in the background and keeps track of how each item of it went.
+type Service interface {
+	GetJob(ctx context.Context, claims tmw.TumblerClaims, jobID string) (*client.Job, error)
+	Start(ctx context.Context, claims tmw.TumblerClaims, kind string, keys []string, concurrency int, work Work) (*client.Job, error)
+	Wait()
+}
+
+type service struct {
+	logger     logging.Logger
+	time       stime.TimeService
+	repository Repository
+	running    sync.WaitGroup
+}
+
+// NewJobsService - Creates a default service
+func NewJobsService(logger logging.Logger, time stime.TimeService, repository Repository) Service {
+	return &service{
+		logger:     logger,
+		time:       time,
+		repository: repository,
+	}
+}
+
+// GetJob - Returns the job of the tenant along with how far along it is.
+func (s *service) GetJob(ctx context.Context, claims tmw.TumblerClaims, jobID string) (*client.Job, error) {
+	job, err := s.repository.get(ctx, claims.TenantID.String(), jobID)
+	if err == sql.ErrNoRows {
+		return nil, ErrJobNotFound
+	}
+	return job, err
+}
+
+// Start - Records the job with an item for each key and works through them in the background with up to
+// concurrency of them at a time. The job is returned as soon as its recorded.
+func (s *service) Start(ctx context.Context, claims tmw.TumblerClaims, kind string, keys []string, concurrency int, work Work) (*client.Job, error) {
+	job := client.Job{
+		JobID:     uuid.New().String(),
+		TenantID:  claims.TenantID.String(),
+		Kind:      kind,
+		Status:    JobPending,
+		Total:     int64(len(keys)),
+		CreatedBy: claims.Subject,
+		CreatedOn: s.time.Now(),
+		Items:     []client.JobItem{},
+	}
+
+	for _, key := range keys {
+		job.Items = append(job.Items, client.JobItem{Key: key, Status: ItemPending})
+	}
+
+	created, err := s.repository.add(ctx, job)
+	if err != nil {
+		return nil, err
+	}
+
+	if concurrency < 1 {
+		concurrency = 1
+	}
+
+	s.running.Add(1)
+	go func() {
+		defer s.running.Done()
+		s.run(*created, concurrency, work)
+	}()
+
+	return created, nil
+}
+
+// Wait - Blocks until every job started has finished, like when shutting down.
+func (s *service) Wait() {
+	s.running.Wait()
+}
+
+func (s *service) run(job client.Job, concurrency int, work Work) {
+	ctx := context.Background()
+	logger := s.logger.WithKeyValue("job_id", job.JobID)
+
+	if err := s.repository.start(ctx, job.JobID, s.time.Now()); err != nil {
+		logger.LogError("unable to start job", err)
+	}
+
+	seqs := make(chan int)
+	workers := sync.WaitGroup{}
+	for i := 0; i < concurrency; i++ {
+		workers.Add(1)
+		go func() {
+			defer workers.Done()
+			for seq := range seqs {
+				item := s.work(ctx, job.Items[seq].Key, work)
+				if err := s.repository.completeItem(ctx, job.JobID, seq, item); err != nil {
+					logger.LogError("unable to record job item", err)
+				}
+			}
+		}()
+	}
+
+	for seq := range job.Items {
+		seqs <- seq
+	}
+	close(seqs)
+	workers.Wait()
+
+	if err := s.repository.complete(ctx, job.JobID, s.time.Now()); err != nil {
+		logger.LogError("unable to complete job", err)
+	}
+}
+
+func (s *service) work(ctx context.Context, key string, work Work) client.JobItem {
+	item := client.JobItem{Key: key}
+
+	resultID, err := work(ctx, key)
+
+	completedOn := s.time.Now()
+	item.CompletedOn = &completedOn
+
+	if err != nil {
+		msg := err.Error()
+		if len(msg) > maxErrorLength {
+			msg = msg[:maxErrorLength]
+		}
+		item.Status = ItemFailed
+		item.Error = &msg
+		return item
+	}
+
+	item.Status = ItemSucceeded
+	item.ResultID = &resultID
+	return item
+}
@@ -0,0 +1,110 @@
+package jobs
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/moov-io/identity/pkg/client"
+	tmwt "github.com/moov-io/tumbler/pkg/middleware/middlewaretest"
+)
+
+func Test_Job(t *testing.T) {
+	a, s := Setup(t)
+
+	keys := []string{"a", "b", "fail", "c", "d", "e"}
+
+	running := int32(0)
+	most := int32(0)
+	work := func(ctx context.Context, key string) (string, error) {
+		now := atomic.AddInt32(&running, 1)
+		defer atomic.AddInt32(&running, -1)
+		for {
+			m := atomic.LoadInt32(&most)
+			if now <= m || atomic.CompareAndSwapInt32(&most, m, now) {
+				break
+			}
+		}
+		time.Sleep(10 * time.Millisecond)
+
+		if key == "fail" {
+			return "", errors.New(strings.Repeat("x", 300))
+		}
+		return "id-" + key, nil
+	}
+
+	job, err := s.service.Start(context.Background(), s.session, "test", keys, 2, work)
+	a.Nil(err)
+	a.Equal(int64(6), job.Total)
+	a.Equal(s.session.Subject, job.CreatedBy)
+
+	s.service.Wait()
+
+	job, err = s.service.GetJob(context.Background(), s.session, job.JobID)
+	a.Nil(err)
+	a.Equal(JobCompleted, job.Status)
+	a.NotNil(job.StartedOn)
+	a.NotNil(job.CompletedOn)
+	a.Equal(int64(6), job.Total)
+	a.Equal(int64(5), job.Succeeded)
+	a.Equal(int64(1), job.Failed)
+	a.LessOrEqual(most, int32(2))
+
+	a.Equal("a", job.Items[0].Key)
+	a.Equal("id-a", *job.Items[0].ResultID)
+
+	a.Equal(ItemFailed, job.Items[2].Status)
+	a.Nil(job.Items[2].ResultID)
+	a.Len(*job.Items[2].Error, maxErrorLength)
+
+	_, err = s.service.GetJob(context.Background(), tmwt.NewRandomClaims(), job.JobID)
+	a.Equal(ErrJobNotFound, err)
+}
+
+func Test_Job_OutlivesRequest(t *testing.T) {
+	a, s := Setup(t)
+
+	ctx, cancel := context.WithCancel(context.Background())
+
+	started := make(chan struct{})
+	job, err := s.service.Start(ctx, s.session, "test", []string{"a"}, 1, func(ctx context.Context, key string) (string, error) {
+		<-started
+		return key, ctx.Err()
+	})
+	a.Nil(err)
+
+	// The request finishing shouldn't stop the work it started
+	cancel()
+	close(started)
+	s.service.Wait()
+
+	job, err = s.service.GetJob(context.Background(), s.session, job.JobID)
+	a.Nil(err)
+	a.Equal(int64(1), job.Succeeded)
+}
+
+func Test_JobAPI(t *testing.T) {
+	a, s := Setup(t)
+
+	job, err := s.service.Start(context.Background(), s.session, "test", []string{"a"}, 1, func(ctx context.Context, key string) (string, error) {
+		return key, nil
+	})
+	a.Nil(err)
+	s.service.Wait()
+
+	resp := s.Request("GET", "/jobs/"+job.JobID)
+	a.Equal(200, resp.StatusCode)
+
+	found := client.Job{}
+	a.Nil(json.NewDecoder(resp.Body).Decode(&found))
+	a.Equal(job.JobID, found.JobID)
+	a.Equal(JobCompleted, found.Status)
+	a.Len(found.Items, 1)
+
+	resp = s.Request("GET", "/jobs/doesnotexist")
+	a.Equal(404, resp.StatusCode)
+}
@@ -0,0 +1,108 @@
+package notifications
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/gorilla/mux"
+	api "github.com/moov-io/identity/pkg/api"
+	log "github.com/moov-io/identity/pkg/logging"
+)
+
+// A Controller binds http requests to an api service and writes the service results to the http response.
+// The mailbox shows the emails of every tenant so it only belongs on the admin server, and only for local development.
+type mailboxController struct {
+	logger  log.Logger
+	mailbox MockNotificationsService
+}
+
+// NewMailboxController creates a default api controller for browsing what the mock captured
+func NewMailboxController(logger log.Logger, mailbox MockNotificationsService) api.Router {
+	return &mailboxController{
+		logger:  logger,
+		mailbox: mailbox,
+	}
+}
+
+// Routes returns all of the api route for the MailboxController
+func (c *mailboxController) Routes() api.Routes {
+	return api.Routes{
+		{
+			Name:        "ListMailbox",
+			Method:      strings.ToUpper("Get"),
+			Pattern:     "/mailbox",
+			HandlerFunc: c.ListMailbox,
+		},
+		{
+			Name:        "ClearMailbox",
+			Method:      strings.ToUpper("Delete"),
+			Pattern:     "/mailbox",
+			HandlerFunc: c.ClearMailbox,
+		},
+		{
+			Name:        "GetMailboxEmail",
+			Method:      strings.ToUpper("Get"),
+			Pattern:     "/mailbox/{emailID}",
+			HandlerFunc: c.GetMailboxEmail,
+		},
+		{
+			Name:        "GetMailboxEmailHTML",
+			Method:      strings.ToUpper("Get"),
+			Pattern:     "/mailbox/{emailID}/html",
+			HandlerFunc: c.GetMailboxEmailHTML,
+		},
+	}
+}
+
+// ListMailbox - Captured emails, newest first
+func (c *mailboxController) ListMailbox(w http.ResponseWriter, r *http.Request) {
+	sent := c.mailbox.Sent()
+
+	newest := make([]SentEmail, 0, len(sent))
+	for i := len(sent) - 1; i >= 0; i-- {
+		newest = append(newest, sent[i])
+	}
+
+	api.EncodeJSONResponse(newest, nil, w)
+}
+
+// ClearMailbox - Forgets all the captured emails
+func (c *mailboxController) ClearMailbox(w http.ResponseWriter, r *http.Request) {
+	c.mailbox.Clear()
+	w.WriteHeader(204)
+}
+
+// GetMailboxEmail - One captured email
+func (c *mailboxController) GetMailboxEmail(w http.ResponseWriter, r *http.Request) {
+	email := c.find(mux.Vars(r)["emailID"])
+	if email == nil {
+		api.EncodeError(w, ErrEmailNotCaptured)
+		return
+	}
+
+	api.EncodeJSONResponse(email, nil, w)
+}
+
+// GetMailboxEmailHTML - The html of a captured email so it can be viewed in a browser
+func (c *mailboxController) GetMailboxEmailHTML(w http.ResponseWriter, r *http.Request) {
+	email := c.find(mux.Vars(r)["emailID"])
+	if email == nil {
+		api.EncodeError(w, ErrEmailNotCaptured)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/html; charset=UTF-8")
+	w.WriteHeader(200)
+	if _, err := w.Write([]byte(email.HTML)); err != nil {
+		c.logger.LogError("unable to write mailbox email", err)
+	}
+}
+
+func (c *mailboxController) find(emailID string) *SentEmail {
+	for _, email := range c.mailbox.Sent() {
+		if email.EmailID == emailID {
+			return &email
+		}
+	}
+	return nil
+}
@@ -0,0 +1,143 @@
+package notifications
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/gorilla/mux"
+	api "github.com/moov-io/identity/pkg/api"
+	"github.com/moov-io/identity/pkg/apperr"
+	"github.com/moov-io/identity/pkg/client"
+	log "github.com/moov-io/identity/pkg/logging"
+	tmw "github.com/moov-io/tumbler/pkg/middleware"
+)
+
+// A Controller binds http requests to an api service and writes the service results to the http response
+type templatesController struct {
+	logger  log.Logger
+	service TemplatesService
+}
+
+// NewTemplatesController creates a default api controller for the tenant's email templates
+func NewTemplatesController(logger log.Logger, service TemplatesService) api.Router {
+	return &templatesController{
+		logger:  logger,
+		service: service,
+	}
+}
+
+// Routes returns all of the api route for the TemplatesController
+func (c *templatesController) Routes() api.Routes {
+	return api.Routes{
+		{
+			Name:        "ListTemplateOverrides",
+			Method:      strings.ToUpper("Get"),
+			Pattern:     "/templates",
+			HandlerFunc: c.ListTemplateOverrides,
+		},
+		{
+			Name:        "GetTemplateOverride",
+			Method:      strings.ToUpper("Get"),
+			Pattern:     "/templates/{templateName}",
+			HandlerFunc: c.GetTemplateOverride,
+		},
+		{
+			Name:        "UpdateTemplateOverride",
+			Method:      strings.ToUpper("Put"),
+			Pattern:     "/templates/{templateName}",
+			HandlerFunc: c.UpdateTemplateOverride,
+		},
+		{
+			Name:        "DeleteTemplateOverride",
+			Method:      strings.ToUpper("Delete"),
+			Pattern:     "/templates/{templateName}",
+			HandlerFunc: c.DeleteTemplateOverride,
+		},
+		{
+			Name:        "PreviewTemplate",
+			Method:      strings.ToUpper("Post"),
+			Pattern:     "/templates/{templateName}/preview",
+			HandlerFunc: c.PreviewTemplate,
+		},
+	}
+}
+
+// ListTemplateOverrides - Every email the tenant has changed
+func (c *templatesController) ListTemplateOverrides(w http.ResponseWriter, r *http.Request) {
+	tmw.WithClaimsFromRequest(w, r, func(claims tmw.TumblerClaims) {
+		result, err := c.service.ListTemplateOverrides(r.Context(), claims)
+		if err != nil {
+			api.EncodeError(w, c.logger.LogError("unable to list template overrides", err))
+			return
+		}
+
+		api.EncodeJSONResponse(result, nil, w)
+	})
+}
+
+// GetTemplateOverride - How the tenant changed one of the emails
+func (c *templatesController) GetTemplateOverride(w http.ResponseWriter, r *http.Request) {
+	tmw.WithClaimsFromRequest(w, r, func(claims tmw.TumblerClaims) {
+		result, err := c.service.GetTemplateOverride(r.Context(), claims, mux.Vars(r)["templateName"])
+		if err != nil {
+			api.EncodeError(w, c.logger.LogError("unable to get template override", err))
+			return
+		}
+
+		api.EncodeJSONResponse(result, nil, w)
+	})
+}
+
+// UpdateTemplateOverride - Replaces the changes the tenant made to one of the emails
+func (c *templatesController) UpdateTemplateOverride(w http.ResponseWriter, r *http.Request) {
+	tmw.WithClaimsFromRequest(w, r, func(claims tmw.TumblerClaims) {
+		update := client.UpdateEmailTemplateOverride{}
+		if err := api.DecodeJSONRequest(r, &update); err != nil {
+			api.EncodeError(w, err)
+			return
+		}
+
+		result, err := c.service.SaveTemplateOverride(r.Context(), claims, mux.Vars(r)["templateName"], update)
+		if err != nil {
+			api.EncodeError(w, c.logger.LogError("unable to save template override", err))
+			return
+		}
+
+		api.EncodeJSONResponse(result, nil, w)
+	})
+}
+
+// DeleteTemplateOverride - Goes back to sending the built-in email
+func (c *templatesController) DeleteTemplateOverride(w http.ResponseWriter, r *http.Request) {
+	tmw.WithClaimsFromRequest(w, r, func(claims tmw.TumblerClaims) {
+		err := c.service.DeleteTemplateOverride(r.Context(), claims, mux.Vars(r)["templateName"])
+		if err != nil {
+			api.EncodeError(w, c.logger.LogError("unable to delete template override", err))
+			return
+		}
+
+		w.WriteHeader(204)
+	})
+}
+
+// PreviewTemplate - Renders the email with example values. Without a body it shows what the tenant saved.
+func (c *templatesController) PreviewTemplate(w http.ResponseWriter, r *http.Request) {
+	tmw.WithClaimsFromRequest(w, r, func(claims tmw.TumblerClaims) {
+		var update *client.UpdateEmailTemplateOverride
+		if err := json.NewDecoder(r.Body).Decode(&update); err != nil && !errors.Is(err, io.EOF) {
+			api.EncodeError(w, apperr.Validation("request body isn't valid json: "+err.Error(), nil))
+			return
+		}
+
+		result, err := c.service.PreviewTemplate(r.Context(), claims, mux.Vars(r)["templateName"], update)
+		if err != nil {
+			api.EncodeError(w, c.logger.LogError("unable to preview template", err))
+			return
+		}
+
+		api.EncodeJSONResponse(result, nil, w)
+	})
+}
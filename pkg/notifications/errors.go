@@ -0,0 +1,12 @@
+package notifications
+
+import "github.com/moov-io/identity/pkg/apperr"
+
+// ErrUnknownTemplate is issued when there isn't an email with the template name.
+var ErrUnknownTemplate = apperr.NotFound("unknown email template")
+
+// ErrTemplateOverrideNotFound is issued when the tenant hasn't changed the template.
+var ErrTemplateOverrideNotFound = apperr.NotFound("email template override not found")
+
+// ErrEmailNotCaptured is issued when the mock hasn't captured an email with the ID.
+var ErrEmailNotCaptured = apperr.NotFound("email not found in the mailbox")
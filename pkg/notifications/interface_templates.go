@@ -6,5 +6,29 @@ type Template interface {
 
 type EmailTemplate interface {
 	EmailSubject() string
+	EmailTenantID() string
+	EmailLocale() string
 	Template
+
+	brand(branding Branding)
+}
+
+// NewEmailTemplate - Blank email of the template name given so a stored copy of it can be decoded back into it.
+func NewEmailTemplate(name string) (EmailTemplate, error) {
+	emails := []EmailTemplate{
+		&ApprovalRequestEmail{},
+		&EmailChangeConfirmEmail{},
+		&EmailChangeNoticeEmail{},
+		&IdentityChangedEmail{},
+		&InviteEmail{},
+		&WelcomeEmail{},
+	}
+
+	for _, email := range emails {
+		if email.TemplateName() == name {
+			return email, nil
+		}
+	}
+
+	return nil, ErrUnknownTemplate
 }
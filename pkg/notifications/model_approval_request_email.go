@@ -0,0 +1,36 @@
+package notifications
+
+import (
+	"github.com/moov-io/identity/pkg/client"
+)
+
+type ApprovalRequestEmail struct {
+	Subject   string
+	ReviewURL string
+	Identity  client.Identity
+
+	Branded
+	Localized
+}
+
+// NewApprovalRequestEmail - Goes to the approvers so its left in the default language instead of the one of the identity registering.
+func NewApprovalRequestEmail(url string, identity client.Identity) ApprovalRequestEmail {
+	return ApprovalRequestEmail{
+		Subject:   "Registration waiting on approval",
+		ReviewURL: url,
+		Identity:  identity,
+		Branded:   Branded{Branding: DefaultBranding()},
+	}
+}
+
+func (i *ApprovalRequestEmail) TemplateName() string {
+	return "approval-request.template"
+}
+
+func (i *ApprovalRequestEmail) EmailSubject() string {
+	return i.Subject
+}
+
+func (i *ApprovalRequestEmail) EmailTenantID() string {
+	return i.Identity.TenantID
+}
@@ -0,0 +1,32 @@
+package notifications
+
+// Branding - How a tenant's emails look and who they appear to come from.
+type Branding struct {
+	// Shown as the name of who the email is from, left out when empty.
+	SenderName      string
+	LogoURL         string
+	DarkLogoURL     string
+	PrimaryColor    string
+	BackgroundColor string
+}
+
+// DefaultBranding - What the built-in templates look like for tenants that haven't changed them.
+func DefaultBranding() Branding {
+	return Branding{
+		SenderName:      "",
+		LogoURL:         "https://moov.io/images/email/logo-black.png",
+		DarkLogoURL:     "https://moov.io/images/email/logo-white.png",
+		PrimaryColor:    "#0D80F2",
+		BackgroundColor: "#F5F5F4",
+	}
+}
+
+// Branded - Embedded into each email so its templates can use the branding of the tenant its sent for.
+// Its worked out again when the email is rendered so its never stored with it.
+type Branded struct {
+	Branding Branding `json:"-"`
+}
+
+func (b *Branded) brand(branding Branding) {
+	b.Branding = branding
+}
@@ -1,7 +1,16 @@
 package notifications
 
+import "time"
+
+// NotificationsConfig - Picks the backend emails are sent with. Failover takes precedence, then the first of the
+// others thats set.
 type NotificationsConfig struct {
+	// Backends tried in order until one of them sends the email, each one set up like the config here.
+	Failover []NotificationsConfig
+
 	SMTP *SMTPConfig
+	HTTP *HTTPConfig
+	File *FileConfig
 	Mock *MockConfig
 }
 
@@ -13,8 +22,62 @@ type SMTPConfig struct {
 	From        string
 	SSL         bool
 	InsecureSSL bool
+
+	// How many connections are kept open to the server and sending at once. Defaults to 1.
+	MaxConnections int
+	// How long a connection is kept open waiting on the next email before its closed. Defaults to 30s.
+	KeepAlive time.Duration
+
+	DKIM *DKIMConfig
+
+	// Extra headers of the emails of a template, keyed by its name like invite or welcome.
+	Headers map[string]EmailHeaders
+}
+
+// DKIMConfig - Signs the emails so receivers can check they came from the domain. The public key of the private key
+// needs to be published in DNS under {Selector}._domainkey.{Domain}.
+type DKIMConfig struct {
+	Domain   string
+	Selector string
+	// PEM encoded RSA private key, or the path to a file with it in PrivateKeyFile.
+	PrivateKey     string
+	PrivateKeyFile string
+	// Headers covered by the signature. Defaults to the ones that identify the email.
+	Headers []string
+}
+
+// EmailHeaders - Headers set on every email of a template.
+type EmailHeaders struct {
+	// Where replies go instead of the address the email is from.
+	ReplyTo string
+	// Value of the List-Unsubscribe header, like <mailto:unsubscribe@moov.io> or <https://moov.io/unsubscribe>.
+	ListUnsubscribe string
+	// Domain of the Message-ID, defaults to the domain the email is from.
+	MessageIDDomain string
+}
+
+// HTTPConfig - Posts the rendered email as JSON to an email provider's API or any webhook.
+type HTTPConfig struct {
+	URL  string
+	From string
+	// Sent along with each request, like the Authorization the provider needs.
+	Headers map[string]string
+	Timeout time.Duration
+}
+
+// FileConfig - Writes each email as an .eml file into the directory instead of sending it, for local development.
+type FileConfig struct {
+	Directory string
+	From      string
 }
 
 type MockConfig struct {
 	From string
 }
+
+// TemplatesConfig - Where the email templates come from. Left empty its the ones built into the binary.
+type TemplatesConfig struct {
+	Directory string
+	// Loads the templates again whenever a file in the directory changes.
+	Watch bool
+}
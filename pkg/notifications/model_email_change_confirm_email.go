@@ -0,0 +1,42 @@
+package notifications
+
+import (
+	"github.com/moov-io/identity/pkg/client"
+)
+
+type EmailChangeConfirmEmail struct {
+	Subject       string
+	EmailChangeID string
+
+	// Has the secret code of the change in it so its not kept when the email is stored, its filled back in from the EmailChangeID.
+	ConfirmURL string `json:"-"`
+
+	NewEmail string
+	Identity client.Identity
+
+	Branded
+	Localized
+}
+
+func NewEmailChangeConfirmEmail(url string, identity client.Identity, newEmail string) EmailChangeConfirmEmail {
+	return EmailChangeConfirmEmail{
+		Subject:    "Confirm your new email for Moov.io",
+		ConfirmURL: url,
+		NewEmail:   newEmail,
+		Identity:   identity,
+		Branded:    Branded{Branding: DefaultBranding()},
+		Localized:  Localized{Locale: localeOf(identity.Locale)},
+	}
+}
+
+func (i *EmailChangeConfirmEmail) TemplateName() string {
+	return "email-change-confirm.template"
+}
+
+func (i *EmailChangeConfirmEmail) EmailSubject() string {
+	return i.Subject
+}
+
+func (i *EmailChangeConfirmEmail) EmailTenantID() string {
+	return i.Identity.TenantID
+}
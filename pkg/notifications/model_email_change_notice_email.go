@@ -0,0 +1,36 @@
+package notifications
+
+import (
+	"github.com/moov-io/identity/pkg/client"
+)
+
+type EmailChangeNoticeEmail struct {
+	Subject  string
+	NewEmail string
+	Identity client.Identity
+
+	Branded
+	Localized
+}
+
+func NewEmailChangeNoticeEmail(identity client.Identity, newEmail string) EmailChangeNoticeEmail {
+	return EmailChangeNoticeEmail{
+		Subject:   "Your Moov.io email is being changed",
+		NewEmail:  newEmail,
+		Identity:  identity,
+		Branded:   Branded{Branding: DefaultBranding()},
+		Localized: Localized{Locale: localeOf(identity.Locale)},
+	}
+}
+
+func (i *EmailChangeNoticeEmail) TemplateName() string {
+	return "email-change-notice.template"
+}
+
+func (i *EmailChangeNoticeEmail) EmailSubject() string {
+	return i.Subject
+}
+
+func (i *EmailChangeNoticeEmail) EmailTenantID() string {
+	return i.Identity.TenantID
+}
@@ -0,0 +1,40 @@
+package notifications
+
+import (
+	"time"
+
+	"github.com/moov-io/identity/pkg/client"
+)
+
+type IdentityChangedEmail struct {
+	Subject   string
+	Identity  client.Identity
+	Changes   []client.FieldChange
+	ChangedOn time.Time
+
+	Branded
+	Localized
+}
+
+func NewIdentityChangedEmail(identity client.Identity, changes []client.FieldChange, changedOn time.Time) IdentityChangedEmail {
+	return IdentityChangedEmail{
+		Subject:   "Your Moov.io account was changed",
+		Identity:  identity,
+		Changes:   changes,
+		ChangedOn: changedOn,
+		Branded:   Branded{Branding: DefaultBranding()},
+		Localized: Localized{Locale: localeOf(identity.Locale)},
+	}
+}
+
+func (i *IdentityChangedEmail) TemplateName() string {
+	return "identity-changed.template"
+}
+
+func (i *IdentityChangedEmail) EmailSubject() string {
+	return i.Subject
+}
+
+func (i *IdentityChangedEmail) EmailTenantID() string {
+	return i.Identity.TenantID
+}
@@ -6,18 +6,28 @@ import (
 )
 
 type InviteEmail struct {
-	Subject             string
-	AcceptInvitationURL string
-	Inviter             client.Identity
-	Tenant              authn.Tenant
+	Subject  string
+	InviteID string
+
+	// Has the secret code of the invite in it so its not kept when the email is stored, its filled back in from the InviteID.
+	AcceptInvitationURL string `json:"-"`
+
+	Inviter client.Identity
+	Tenant  authn.Tenant
+
+	Branded
+	Localized
 }
 
-func NewInviteEmail(url string, inviter client.Identity, tenant authn.Tenant) InviteEmail {
+// NewInviteEmail - The locale is of who is being invited, the inviter could be using any language.
+func NewInviteEmail(url string, inviter client.Identity, tenant authn.Tenant, locale *string) InviteEmail {
 	return InviteEmail{
 		Subject:             "Invite for Moov.io!",
 		AcceptInvitationURL: url,
 		Inviter:             inviter,
 		Tenant:              tenant,
+		Branded:             Branded{Branding: DefaultBranding()},
+		Localized:           Localized{Locale: localeOf(locale)},
 	}
 }
 
@@ -28,3 +38,7 @@ func (i *InviteEmail) TemplateName() string {
 func (i *InviteEmail) EmailSubject() string {
 	return i.Subject
 }
+
+func (i *InviteEmail) EmailTenantID() string {
+	return i.Tenant.TenantID
+}
@@ -0,0 +1,65 @@
+package notifications
+
+import "strings"
+
+// Localized - Embedded into each email so its templates and subject are picked in the language of who its sent to.
+// Empty is the default language of the built-in templates.
+type Localized struct {
+	Locale string `json:",omitempty"`
+}
+
+func (l *Localized) EmailLocale() string {
+	return l.Locale
+}
+
+func localeOf(locale *string) string {
+	if locale == nil {
+		return ""
+	}
+	return *locale
+}
+
+// localeChain - Locales to look for a template in, from the most specific down to the default.
+// fr-CA falls back to fr and then to the default templates.
+func localeChain(values Template) []string {
+	email, ok := values.(EmailTemplate)
+	if !ok {
+		return []string{""}
+	}
+
+	chain := []string{}
+	parts := strings.Split(canonicalLocale(email.EmailLocale()), "-")
+	for i := len(parts); i > 0; i-- {
+		if locale := strings.Join(parts[:i], "-"); locale != "" {
+			chain = append(chain, locale)
+		}
+	}
+
+	return append(chain, "")
+}
+
+// canonicalLocale - Spells language tags the way the template files are named, like fr-CA for fr_ca.
+func canonicalLocale(locale string) string {
+	parts := strings.FieldsFunc(locale, func(r rune) bool { return r == '-' || r == '_' })
+	for i, part := range parts {
+		switch {
+		case i == 0:
+			parts[i] = strings.ToLower(part)
+		case len(part) == 2:
+			parts[i] = strings.ToUpper(part)
+		case len(part) == 4:
+			parts[i] = strings.ToUpper(part[:1]) + strings.ToLower(part[1:])
+		default:
+			parts[i] = strings.ToLower(part)
+		}
+	}
+	return strings.Join(parts, "-")
+}
+
+// localizedName - Name of the template in the locale, like invite.template.fr
+func localizedName(values Template, locale string) string {
+	if locale == "" {
+		return values.TemplateName()
+	}
+	return values.TemplateName() + "." + locale
+}
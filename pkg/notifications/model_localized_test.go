@@ -0,0 +1,78 @@
+package notifications
+
+import (
+	"testing"
+
+	authnlib "github.com/moov-io/authn/pkg/client"
+	"github.com/moov-io/identity/pkg/client"
+)
+
+func Test_LocaleChain(t *testing.T) {
+	a, _ := Setup(t)
+
+	invite := func(locale string) Template {
+		e := NewInviteEmail("https://localhost/accept", client.Identity{}, authnlib.Tenant{}, &locale)
+		return &e
+	}
+
+	a.Equal([]string{""}, localeChain(invite("")))
+	a.Equal([]string{"fr", ""}, localeChain(invite("FR")))
+	a.Equal([]string{"fr-CA", "fr", ""}, localeChain(invite("fr_ca")))
+	a.Equal([]string{"zh-Hant-TW", "zh-Hant", "zh", ""}, localeChain(invite("zh-hant-tw")))
+	a.Equal([]string{""}, localeChain(&missingTemplateEmail{}))
+}
+
+func Test_Templates_Localized(t *testing.T) {
+	a, s := Setup(t)
+
+	inviter := client.Identity{Email: "john@moov.io"}
+	tenant := authnlib.Tenant{Name: "Acme"}
+
+	french := "fr-CA"
+	invite := NewInviteEmail("https://localhost/accept", inviter, tenant, &french)
+	invite.brand(DefaultBranding())
+
+	subject, err := s.templates.Subject(&invite)
+	a.Nil(err)
+	a.Equal("Invitation à rejoindre Acme sur Moov.io", subject)
+
+	text, err := s.templates.Text(&invite)
+	a.Nil(err)
+	a.Contains(text, "Bienvenue sur Moov.io")
+
+	html, err := s.templates.HTML(&invite)
+	a.Nil(err)
+	a.Contains(html, "vous invite à rejoindre")
+
+	// Without templates in the language it falls back to the default ones.
+	german := "de"
+	invite = NewInviteEmail("https://localhost/accept", inviter, tenant, &german)
+	invite.brand(DefaultBranding())
+
+	subject, err = s.templates.Subject(&invite)
+	a.Nil(err)
+	a.Equal(invite.Subject, subject)
+
+	html, err = s.templates.HTML(&invite)
+	a.Nil(err)
+	a.Contains(html, "has invited you to join")
+
+	// Identities carry their locale into the emails sent to them.
+	welcome := NewWelcomeEmail("https://localhost/login", client.Identity{FirstName: "Jean", Locale: &french})
+	welcome.brand(DefaultBranding())
+
+	text, err = s.templates.Text(&welcome)
+	a.Nil(err)
+	a.Contains(text, "Bonjour Jean")
+
+	// Emails without a translation of their own stay in the default language.
+	changed := NewEmailChangeNoticeEmail(client.Identity{FirstName: "Jean", Locale: &french}, "jean@example.com")
+	changed.brand(DefaultBranding())
+
+	subject, err = s.templates.Subject(&changed)
+	a.Nil(err)
+	a.Equal(changed.Subject, subject)
+
+	_, err = s.templates.Text(&changed)
+	a.Nil(err)
+}
@@ -0,0 +1,48 @@
+package notifications
+
+import (
+	"time"
+
+	authn "github.com/moov-io/authn/pkg/client"
+	"github.com/moov-io/identity/pkg/client"
+)
+
+// sampleEmail - An email of the template filled out with example values for previewing and checking overrides against.
+func sampleEmail(template string, tenantID string) (EmailTemplate, error) {
+	identity := client.Identity{
+		IdentityID: "00000000-0000-0000-0000-000000000000",
+		TenantID:   tenantID,
+		FirstName:  "Jane",
+		LastName:   "Doe",
+		Email:      "jane.doe@example.com",
+	}
+
+	url := "https://example.com/"
+	before, after := "Jane", "Janet"
+
+	var email EmailTemplate
+	switch template {
+	case templateKey(&InviteEmail{}):
+		e := NewInviteEmail(url, identity, authn.Tenant{TenantID: tenantID, Name: "Example"}, nil)
+		email = &e
+	case templateKey(&WelcomeEmail{}):
+		e := NewWelcomeEmail(url, identity)
+		email = &e
+	case templateKey(&ApprovalRequestEmail{}):
+		e := NewApprovalRequestEmail(url, identity)
+		email = &e
+	case templateKey(&EmailChangeConfirmEmail{}):
+		e := NewEmailChangeConfirmEmail(url, identity, "janet.doe@example.com")
+		email = &e
+	case templateKey(&EmailChangeNoticeEmail{}):
+		e := NewEmailChangeNoticeEmail(identity, "janet.doe@example.com")
+		email = &e
+	case templateKey(&IdentityChangedEmail{}):
+		e := NewIdentityChangedEmail(identity, []client.FieldChange{{Field: "firstName", Before: &before, After: &after}}, time.Now())
+		email = &e
+	default:
+		return nil, ErrUnknownTemplate
+	}
+
+	return email, nil
+}
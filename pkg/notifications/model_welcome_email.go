@@ -0,0 +1,36 @@
+package notifications
+
+import (
+	"github.com/moov-io/identity/pkg/client"
+)
+
+type WelcomeEmail struct {
+	Subject  string
+	LoginURL string
+	Identity client.Identity
+
+	Branded
+	Localized
+}
+
+func NewWelcomeEmail(url string, identity client.Identity) WelcomeEmail {
+	return WelcomeEmail{
+		Subject:   "Welcome to Moov.io!",
+		LoginURL:  url,
+		Identity:  identity,
+		Branded:   Branded{Branding: DefaultBranding()},
+		Localized: Localized{Locale: localeOf(identity.Locale)},
+	}
+}
+
+func (i *WelcomeEmail) TemplateName() string {
+	return "welcome.template"
+}
+
+func (i *WelcomeEmail) EmailSubject() string {
+	return i.Subject
+}
+
+func (i *WelcomeEmail) EmailTenantID() string {
+	return i.Identity.TenantID
+}
@@ -0,0 +1,178 @@
+package notifications
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/moov-io/identity/pkg/client"
+	"github.com/moov-io/identity/pkg/database"
+)
+
+// TemplateOverridesRepository - Used for keeping the changes tenants made to their emails on the data store
+type TemplateOverridesRepository interface {
+	list(ctx context.Context, tenantID string) ([]client.EmailTemplateOverride, error)
+	get(ctx context.Context, tenantID string, template string) (*client.EmailTemplateOverride, error)
+	save(ctx context.Context, override client.EmailTemplateOverride) error
+	delete(ctx context.Context, tenantID string, template string) error
+}
+
+// NewTemplateOverridesRepository - Builds a new repository tied to the DB passed in.
+func NewTemplateOverridesRepository(db *sql.DB) TemplateOverridesRepository {
+	return &sqlTemplateOverridesRepo{db: db, dialect: database.DialectOf(db)}
+}
+
+type sqlTemplateOverridesRepo struct {
+	db      *sql.DB
+	dialect database.Dialect
+}
+
+func (r *sqlTemplateOverridesRepo) list(ctx context.Context, tenantID string) ([]client.EmailTemplateOverride, error) {
+	qry := fmt.Sprintf(`
+		SELECT %s
+		FROM email_template_override
+		WHERE tenant_id = ?
+		ORDER BY template
+	`, overrideSelect)
+
+	return r.queryScan(ctx, qry, tenantID)
+}
+
+func (r *sqlTemplateOverridesRepo) get(ctx context.Context, tenantID string, template string) (*client.EmailTemplateOverride, error) {
+	qry := fmt.Sprintf(`
+		SELECT %s
+		FROM email_template_override
+		WHERE tenant_id = ? AND template = ?
+		LIMIT 1
+	`, overrideSelect)
+
+	res, err := r.queryScan(ctx, qry, tenantID, template)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(res) != 1 {
+		return nil, sql.ErrNoRows
+	}
+
+	return &res[0], nil
+}
+
+// save - Replaces the override of the template, or adds it when the tenant didn't have one yet.
+func (r *sqlTemplateOverridesRepo) save(ctx context.Context, override client.EmailTemplateOverride) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, r.dialect.Rebind(`
+		DELETE FROM email_template_override
+		WHERE tenant_id = ? AND template = ?
+	`), override.TenantID, override.Template); err != nil {
+		return err
+	}
+
+	_, err = tx.ExecContext(ctx, r.dialect.Rebind(`
+		INSERT INTO email_template_override (
+			tenant_id,
+			template,
+			sender_name,
+			subject,
+			logo_url,
+			primary_color,
+			background_color,
+			html_body,
+			text_body,
+			updated_by,
+			updated_on
+		) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`),
+		override.TenantID,
+		override.Template,
+		override.SenderName,
+		override.Subject,
+		override.LogoURL,
+		override.PrimaryColor,
+		override.BackgroundColor,
+		override.Html,
+		override.Text,
+		override.UpdatedBy,
+		override.UpdatedOn)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func (r *sqlTemplateOverridesRepo) delete(ctx context.Context, tenantID string, template string) error {
+	res, err := r.db.ExecContext(ctx, r.dialect.Rebind(`
+		DELETE FROM email_template_override
+		WHERE tenant_id = ? AND template = ?
+	`), tenantID, template)
+	if err != nil {
+		return err
+	}
+
+	cnt, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if cnt == 0 {
+		return sql.ErrNoRows
+	}
+
+	return nil
+}
+
+// Matches the order pulled in by the rows.Scan below in queryScan
+var overrideSelect = `
+	email_template_override.tenant_id,
+	email_template_override.template,
+	email_template_override.sender_name,
+	email_template_override.subject,
+	email_template_override.logo_url,
+	email_template_override.primary_color,
+	email_template_override.background_color,
+	email_template_override.html_body,
+	email_template_override.text_body,
+	email_template_override.updated_by,
+	email_template_override.updated_on
+`
+
+func (r *sqlTemplateOverridesRepo) queryScan(ctx context.Context, query string, args ...interface{}) ([]client.EmailTemplateOverride, error) {
+	rows, err := r.db.QueryContext(ctx, r.dialect.Rebind(query), args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	items := []client.EmailTemplateOverride{}
+	for rows.Next() {
+		item := client.EmailTemplateOverride{}
+		if err := rows.Scan(
+			&item.TenantID,
+			&item.Template,
+			&item.SenderName,
+			&item.Subject,
+			&item.LogoURL,
+			&item.PrimaryColor,
+			&item.BackgroundColor,
+			&item.Html,
+			&item.Text,
+			&item.UpdatedBy,
+			&item.UpdatedOn,
+		); err != nil {
+			return nil, err
+		}
+
+		items = append(items, item)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return items, nil
+}
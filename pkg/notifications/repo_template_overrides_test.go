@@ -0,0 +1,65 @@
+package notifications
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/moov-io/identity/pkg/client"
+	"github.com/moov-io/identity/pkg/database"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_Repository_TemplateOverrides(t *testing.T) {
+	ForEachDatabase(t, func(t *testing.T, a *require.Assertions, repository TemplateOverridesRepository) {
+		tenantID := uuid.New().String()
+		override := client.EmailTemplateOverride{
+			TenantID:     tenantID,
+			Template:     "invite.template",
+			Subject:      strPtr("Join {{.Tenant.Name}}"),
+			PrimaryColor: strPtr("#0D80F2"),
+			UpdatedBy:    uuid.New().String(),
+			UpdatedOn:    time.Now().UTC().Round(time.Second),
+		}
+
+		a.NoError(repository.save(context.Background(), override))
+
+		// Saving again replaces it.
+		override.Subject = strPtr("Welcome to {{.Tenant.Name}}")
+		override.PrimaryColor = nil
+		a.NoError(repository.save(context.Background(), override))
+
+		found, err := repository.get(context.Background(), tenantID, "invite.template")
+		a.NoError(err)
+		a.Equal("Welcome to {{.Tenant.Name}}", *found.Subject)
+		a.Nil(found.PrimaryColor)
+
+		_, err = repository.get(context.Background(), uuid.New().String(), "invite.template")
+		a.Equal(sql.ErrNoRows, err)
+
+		welcome := override
+		welcome.Template = "welcome.template"
+		a.NoError(repository.save(context.Background(), welcome))
+
+		list, err := repository.list(context.Background(), tenantID)
+		a.NoError(err)
+		a.Len(list, 2)
+		a.Equal("invite.template", list[0].Template)
+		a.Equal("welcome.template", list[1].Template)
+
+		a.NoError(repository.delete(context.Background(), tenantID, "invite.template"))
+		a.Equal(sql.ErrNoRows, repository.delete(context.Background(), tenantID, "invite.template"))
+
+		list, err = repository.list(context.Background(), tenantID)
+		a.NoError(err)
+		a.Len(list, 1)
+	})
+}
+
+func ForEachDatabase(t *testing.T, run func(t *testing.T, a *require.Assertions, repository TemplateOverridesRepository)) {
+	database.ForEachDatabase(t, func(t *testing.T, db *sql.DB) {
+		run(t, require.New(t), NewTemplateOverridesRepository(db))
+	})
+}
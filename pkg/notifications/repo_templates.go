@@ -1,12 +1,17 @@
 package notifications
 
 import (
+	"context"
+	"encoding/json"
 	"errors"
+	"fmt"
 	html "html/template"
+	"io"
 	"io/ioutil"
 	"os"
 	"path/filepath"
 	"strings"
+	"sync/atomic"
 	text "text/template"
 
 	"github.com/markbates/pkger"
@@ -16,20 +21,92 @@ import (
 type TemplateRepository interface {
 	Text(values Template) (string, error)
 	HTML(values Template) (string, error)
+	Subject(email EmailTemplate) (string, error)
+	Branding(email EmailTemplate) (Branding, error)
+
+	// ForEmail - The templates to render all the parts of the email with, so anything they depend on is only looked up once.
+	ForEmail(ctx context.Context, email EmailTemplate) (TemplateRepository, error)
 }
 
 type templateRepository struct {
-	textTemplates text.Template
-	htmlTemplates html.Template
+	logger log.Logger
+	source templateSource
+
+	// Holds a *templateSet, swapped out in one go when the templates are loaded again
+	templates atomic.Value
+}
+
+// templateSet - Everything parsed out of the template files at one point in time.
+type templateSet struct {
+	textTemplates *text.Template
+	htmlTemplates *html.Template
+
+	// Subjects and other short texts keyed by locale, then by name like invite.subject
+	messages map[string]map[string]*text.Template
+}
+
+// templateSource - Where the template files are read from, the ones built into the binary or a directory.
+type templateSource struct {
+	walk func(fn filepath.WalkFunc) error
+	open func(path string) (io.ReadCloser, error)
 }
 
 func NewTemplateRepository(logger log.Logger) (TemplateRepository, error) {
+	return newTemplateRepository(logger, templateSource{
+		walk: func(fn filepath.WalkFunc) error {
+			return pkger.Walk("/configs/notifications/", fn)
+		},
+		open: func(path string) (io.ReadCloser, error) {
+			return pkger.Open(path)
+		},
+	})
+}
+
+func newTemplateRepository(logger log.Logger, source templateSource) (*templateRepository, error) {
+	t := &templateRepository{
+		logger: logger,
+		source: source,
+	}
+
+	if err := t.reload(); err != nil {
+		return nil, err
+	}
+
+	return t, nil
+}
+
+// reload - Parses all the templates again and only swaps them in if every one of them loads.
+func (t *templateRepository) reload() error {
+	t.logger.Info().Log("Loading templates")
+
+	set, err := loadTemplates(t.logger, t.source)
+	if err != nil {
+		return t.logger.Error().LogError("Unable to load templates", err)
+	}
+
+	t.templates.Store(set)
+
+	t.logger.Info().Log("Loaded templates")
+	return nil
+}
+
+func (t *templateRepository) set() *templateSet {
+	return t.templates.Load().(*templateSet)
+}
+
+func loadTemplates(logger log.Logger, source templateSource) (*templateSet, error) {
 	ht := html.New("notifications")
 	tt := text.New("notifications")
+	messages := map[string]map[string]*text.Template{}
 
-	logger.Info().Log("Loading templates")
+	err := source.walk(func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
 
-	err := pkger.Walk("/configs/notifications/", func(path string, info os.FileInfo, err error) error {
+		if info.IsDir() {
+			return nil
+		}
 
 		ext := strings.ToLower(filepath.Ext(info.Name()))
 
@@ -41,58 +118,155 @@ func NewTemplateRepository(logger log.Logger) (TemplateRepository, error) {
 
 		switch ext {
 		case ".txt":
-			f, err := pkger.Open(path)
-			if err != nil {
-				return err
-			}
-
-			content, err := ioutil.ReadAll(f)
+			content, err := readTemplate(source, path)
 			if err != nil {
 				return err
 			}
 
-			_, err = tt.New(info.Name()).Parse(string(content))
+			_, err = tt.New(info.Name()).Parse(content)
 			if err != nil {
 				return err
 			}
 
 			logCtx.Log("Loaded template - " + info.Name())
 		case ".html":
-			f, err := pkger.Open(path)
+			content, err := readTemplate(source, path)
 			if err != nil {
 				return err
 			}
 
-			content, err := ioutil.ReadAll(f)
+			_, err = ht.New(info.Name()).Parse(content)
 			if err != nil {
 				return err
 			}
 
-			_, err = ht.New(info.Name()).Parse(string(content))
+			logCtx.Log("Loaded template - " + info.Name())
+		case ".json":
+			content, err := readTemplate(source, path)
 			if err != nil {
 				return err
 			}
 
-			logCtx.Log("Loaded template - " + info.Name())
+			catalog := map[string]string{}
+			if err := json.Unmarshal([]byte(content), &catalog); err != nil {
+				return fmt.Errorf("%s: %w", info.Name(), err)
+			}
+
+			// messages.json is the default language, messages.fr.json is french
+			locale := canonicalLocale(strings.TrimPrefix(strings.TrimPrefix(strings.TrimSuffix(info.Name(), ext), "messages"), "."))
+
+			if messages[locale] == nil {
+				messages[locale] = map[string]*text.Template{}
+			}
+
+			for key, message := range catalog {
+				parsed, err := text.New(key).Parse(message)
+				if err != nil {
+					return err
+				}
+				messages[locale][key] = parsed
+			}
+
+			logCtx.Log("Loaded messages - " + info.Name())
 		}
 
 		return nil
 	})
-
 	if err != nil {
 		return nil, err
 	}
 
-	logger.Info().Log("Loaded templates")
+	set := &templateSet{
+		textTemplates: tt,
+		htmlTemplates: ht,
+		messages:      messages,
+	}
+
+	if err := set.validate(); err != nil {
+		return nil, err
+	}
 
-	return &templateRepository{
-		textTemplates: *tt,
-		htmlTemplates: *ht,
-	}, nil
+	return set, nil
+}
+
+func readTemplate(source templateSource, path string) (string, error) {
+	f, err := source.open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	content, err := ioutil.ReadAll(f)
+	if err != nil {
+		return "", err
+	}
+
+	return string(content), nil
+}
+
+// validate - Runs every template against an example of the email its for so a misspelled field fails the load
+// instead of the email being sent. Templates that aren't for one of the emails are left alone.
+func (s *templateSet) validate() error {
+	for _, template := range s.textTemplates.Templates() {
+		email, ok := exampleFor(template.Name())
+		if !ok {
+			continue
+		}
+
+		if err := template.Execute(ioutil.Discard, email); err != nil {
+			return err
+		}
+	}
+
+	for _, template := range s.htmlTemplates.Templates() {
+		email, ok := exampleFor(template.Name())
+		if !ok {
+			continue
+		}
+
+		if err := template.Execute(ioutil.Discard, email); err != nil {
+			return err
+		}
+	}
+
+	for locale, messages := range s.messages {
+		for key, message := range messages {
+			email, ok := exampleFor(key)
+			if !ok {
+				continue
+			}
+
+			if err := message.Execute(ioutil.Discard, email); err != nil {
+				return fmt.Errorf("messages %s: %w", locale, err)
+			}
+		}
+	}
+
+	return nil
+}
+
+// exampleFor - Example of the email a template or message is for going by its name, like invite.template.fr.html or invite.subject
+func exampleFor(name string) (EmailTemplate, bool) {
+	key := strings.SplitN(name, ".", 2)[0]
+
+	email, err := sampleEmail(key, "")
+	if err != nil {
+		return nil, false
+	}
+
+	email.brand(DefaultBranding())
+	return email, true
 }
 
 func (t *templateRepository) Text(values Template) (string, error) {
-	template := t.textTemplates.Lookup(values.TemplateName() + ".txt")
+	templates := t.set().textTemplates
+
+	var template *text.Template
+	for _, locale := range localeChain(values) {
+		if template = templates.Lookup(localizedName(values, locale) + ".txt"); template != nil {
+			break
+		}
+	}
 	if template == nil {
 		return "", errors.New("Unable to find template: " + values.TemplateName())
 	}
@@ -108,7 +282,14 @@ func (t *templateRepository) Text(values Template) (string, error) {
 }
 
 func (t *templateRepository) HTML(values Template) (string, error) {
-	template := t.htmlTemplates.Lookup(values.TemplateName() + ".html")
+	templates := t.set().htmlTemplates
+
+	var template *html.Template
+	for _, locale := range localeChain(values) {
+		if template = templates.Lookup(localizedName(values, locale) + ".html"); template != nil {
+			break
+		}
+	}
 	if template == nil {
 		return "", errors.New("Unable to find template: " + values.TemplateName())
 	}
@@ -122,3 +303,33 @@ func (t *templateRepository) HTML(values Template) (string, error) {
 
 	return generated.String(), nil
 }
+
+// Subject - Translated from the message catalog, otherwise its the one the email was created with.
+func (t *templateRepository) Subject(email EmailTemplate) (string, error) {
+	messages := t.set().messages
+	key := templateKey(email) + ".subject"
+
+	for _, locale := range localeChain(email) {
+		message, ok := messages[locale][key]
+		if !ok {
+			continue
+		}
+
+		generated := strings.Builder{}
+		if err := message.Execute(&generated, email); err != nil {
+			return "", err
+		}
+
+		return generated.String(), nil
+	}
+
+	return email.EmailSubject(), nil
+}
+
+func (t *templateRepository) Branding(email EmailTemplate) (Branding, error) {
+	return DefaultBranding(), nil
+}
+
+func (t *templateRepository) ForEmail(ctx context.Context, email EmailTemplate) (TemplateRepository, error) {
+	return t, nil
+}
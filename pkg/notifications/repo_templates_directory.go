@@ -0,0 +1,116 @@
+package notifications
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+	log "github.com/moov-io/identity/pkg/logging"
+)
+
+// Editors tend to write a file in a few steps, so changes are given a moment to settle before loading them.
+const reloadDelay = 100 * time.Millisecond
+
+// NewDirectoryTemplateRepository - Loads the templates from a directory instead of the ones built into the binary.
+// When watching, every change to the directory loads them again. If any of them don't load the ones from before are kept.
+func NewDirectoryTemplateRepository(logger log.Logger, config TemplatesConfig) (TemplateRepository, func(), error) {
+	directory := config.Directory
+
+	repository, err := newTemplateRepository(logger, templateSource{
+		walk: func(fn filepath.WalkFunc) error {
+			return filepath.Walk(directory, fn)
+		},
+		open: func(path string) (io.ReadCloser, error) {
+			return os.Open(path)
+		},
+	})
+	if err != nil {
+		return nil, func() {}, err
+	}
+
+	if !config.Watch {
+		return repository, func() {}, nil
+	}
+
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return nil, func() {}, err
+	}
+
+	// Templates are loaded out of the subdirectories too, but a watcher only sees the directory its given.
+	if err := watchDirectories(watcher, directory); err != nil {
+		watcher.Close()
+		return nil, func() {}, err
+	}
+
+	done := make(chan struct{})
+	go repository.watch(watcher, done)
+
+	once := sync.Once{}
+	stop := func() {
+		once.Do(func() {
+			watcher.Close()
+			<-done
+		})
+	}
+
+	return repository, stop, nil
+}
+
+func (t *templateRepository) watch(watcher *fsnotify.Watcher, done chan struct{}) {
+	defer close(done)
+
+	timer := time.NewTimer(reloadDelay)
+	timer.Stop()
+	defer timer.Stop()
+
+	for {
+		select {
+		case event, ok := <-watcher.Events:
+			if !ok {
+				return
+			}
+
+			t.logger.Info().WithKeyValue("file", event.Name).Log("Template changed")
+
+			if event.Op&fsnotify.Create == fsnotify.Create {
+				if info, err := os.Stat(event.Name); err == nil && info.IsDir() {
+					if err := watchDirectories(watcher, event.Name); err != nil {
+						t.logger.Error().LogError("Unable to watch templates", err)
+					}
+				}
+			}
+
+			timer.Reset(reloadDelay)
+
+		case err, ok := <-watcher.Errors:
+			if !ok {
+				return
+			}
+
+			t.logger.Error().LogError("Unable to watch templates", err)
+
+		case <-timer.C:
+			// Errors are logged and the templates from before are kept.
+			t.reload()
+		}
+	}
+}
+
+// watchDirectories - Adds the directory and every directory under it to the watcher.
+func watchDirectories(watcher *fsnotify.Watcher, directory string) error {
+	return filepath.Walk(directory, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+
+		if !info.IsDir() {
+			return nil
+		}
+
+		return watcher.Add(path)
+	})
+}
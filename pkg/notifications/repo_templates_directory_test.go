@@ -0,0 +1,147 @@
+package notifications
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	authnlib "github.com/moov-io/authn/pkg/client"
+	"github.com/moov-io/identity/pkg/client"
+	"github.com/stretchr/testify/require"
+)
+
+// copyTemplates - Copies the built-in templates into a directory of the test to change them in.
+func copyTemplates(t *testing.T) string {
+	a := require.New(t)
+
+	directory, err := ioutil.TempDir("", "templates")
+	a.Nil(err)
+	t.Cleanup(func() { os.RemoveAll(directory) })
+
+	files, err := filepath.Glob("../../configs/notifications/*")
+	a.Nil(err)
+	a.NotEmpty(files)
+
+	for _, file := range files {
+		content, err := ioutil.ReadFile(file)
+		a.Nil(err)
+		a.Nil(ioutil.WriteFile(filepath.Join(directory, filepath.Base(file)), content, 0644))
+	}
+
+	return directory
+}
+
+func Test_DirectoryTemplates(t *testing.T) {
+	a, s := Setup(t)
+
+	directory := copyTemplates(t)
+	a.Nil(ioutil.WriteFile(filepath.Join(directory, "invite.template.txt"), []byte("Join {{.Tenant.Name}}"), 0644))
+
+	templates, close, err := NewDirectoryTemplateRepository(s.logger, TemplatesConfig{Directory: directory})
+	a.Nil(err)
+	defer close()
+
+	invite := NewInviteEmail("https://localhost/accept", client.Identity{}, authnlib.Tenant{Name: "Acme"}, nil)
+
+	text, err := templates.Text(&invite)
+	a.Nil(err)
+	a.Equal("Join Acme", text)
+
+	html, err := templates.HTML(&invite)
+	a.Nil(err)
+	a.Contains(html, "has invited you to join")
+}
+
+func Test_DirectoryTemplates_Validated(t *testing.T) {
+	a, s := Setup(t)
+
+	directory := copyTemplates(t)
+	a.Nil(ioutil.WriteFile(filepath.Join(directory, "invite.template.fr.html"), []byte("<p>{{.Inviter.FirstNme}}</p>"), 0644))
+
+	_, _, err := NewDirectoryTemplateRepository(s.logger, TemplatesConfig{Directory: directory})
+	a.NotNil(err)
+	a.Contains(err.Error(), "FirstNme")
+
+	// Subjects in the message catalog are held to the same fields
+	directory = copyTemplates(t)
+	a.Nil(ioutil.WriteFile(filepath.Join(directory, "messages.de.json"), []byte(`{"welcome.subject": "Willkommen {{.Identity.Frist}}"}`), 0644))
+
+	_, _, err = NewDirectoryTemplateRepository(s.logger, TemplatesConfig{Directory: directory})
+	a.NotNil(err)
+	a.Contains(err.Error(), "Frist")
+}
+
+func Test_DirectoryTemplates_Watch(t *testing.T) {
+	a, s := Setup(t)
+
+	directory := copyTemplates(t)
+
+	templates, close, err := NewDirectoryTemplateRepository(s.logger, TemplatesConfig{Directory: directory, Watch: true})
+	a.Nil(err)
+	defer close()
+
+	invite := NewInviteEmail("https://localhost/accept", client.Identity{FirstName: "John"}, authnlib.Tenant{Name: "Acme"}, nil)
+	path := filepath.Join(directory, "invite.template.txt")
+
+	text := func() string {
+		generated, err := templates.Text(&invite)
+		a.Nil(err)
+		return generated
+	}
+
+	a.Contains(text(), "Welcome to Moov.io!")
+
+	a.Nil(ioutil.WriteFile(path, []byte("{{.Inviter.FirstName}} invited you to {{.Tenant.Name}}"), 0644))
+	a.Eventually(func() bool { return text() == "John invited you to Acme" }, 5*time.Second, 10*time.Millisecond)
+
+	// Broken templates are logged and the ones that were working are kept
+	a.Nil(ioutil.WriteFile(path, []byte("{{.Inviter.FirstNme}}"), 0644))
+	time.Sleep(5 * reloadDelay)
+	a.Equal("John invited you to Acme", text())
+
+	a.Nil(ioutil.WriteFile(path, []byte("{{.Inviter.FirstName"), 0644))
+	time.Sleep(5 * reloadDelay)
+	a.Equal("John invited you to Acme", text())
+
+	a.Nil(ioutil.WriteFile(path, []byte("Hi from {{.Tenant.Name}}"), 0644))
+	a.Eventually(func() bool { return text() == "Hi from Acme" }, 5*time.Second, 10*time.Millisecond)
+}
+
+func Test_DirectoryTemplates_WatchSubdirectories(t *testing.T) {
+	a, s := Setup(t)
+
+	directory := copyTemplates(t)
+	a.Nil(os.Mkdir(filepath.Join(directory, "invite"), 0755))
+
+	path := filepath.Join(directory, "invite", "invite.template.txt")
+	a.Nil(os.Rename(filepath.Join(directory, "invite.template.txt"), path))
+
+	templates, close, err := NewDirectoryTemplateRepository(s.logger, TemplatesConfig{Directory: directory, Watch: true})
+	a.Nil(err)
+	defer close()
+
+	invite := NewInviteEmail("https://localhost/accept", client.Identity{FirstName: "John"}, authnlib.Tenant{Name: "Acme"}, nil)
+
+	text := func() string {
+		generated, err := templates.Text(&invite)
+		a.Nil(err)
+		return generated
+	}
+
+	a.Contains(text(), "Welcome to Moov.io!")
+
+	a.Nil(ioutil.WriteFile(path, []byte("{{.Inviter.FirstName}} invited you to {{.Tenant.Name}}"), 0644))
+	a.Eventually(func() bool { return text() == "John invited you to Acme" }, 5*time.Second, 10*time.Millisecond)
+
+	// Directories made after it started are watched too
+	later := filepath.Join(directory, "later")
+	a.Nil(os.Mkdir(later, 0755))
+	time.Sleep(5 * reloadDelay)
+
+	a.Nil(os.Rename(path, filepath.Join(later, "invite.template.txt")))
+	time.Sleep(5 * reloadDelay)
+	a.Nil(ioutil.WriteFile(filepath.Join(later, "invite.template.txt"), []byte("Hi from {{.Tenant.Name}}"), 0644))
+	a.Eventually(func() bool { return text() == "Hi from Acme" }, 5*time.Second, 10*time.Millisecond)
+}
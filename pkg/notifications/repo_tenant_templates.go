@@ -0,0 +1,132 @@
+package notifications
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	html "html/template"
+	"strings"
+	text "text/template"
+
+	"github.com/moov-io/identity/pkg/client"
+)
+
+// NewTenantTemplateRepository - Renders emails with the changes their tenant made, falling back to the built-in templates for anything it left alone.
+// The changes are found with ForEmail, rendering with it directly only uses the built-in templates.
+func NewTenantTemplateRepository(builtin TemplateRepository, overrides TemplateOverridesRepository) TemplateRepository {
+	return &tenantTemplateRepository{
+		TemplateRepository: builtin,
+		overrides:          overrides,
+	}
+}
+
+type tenantTemplateRepository struct {
+	TemplateRepository
+	overrides TemplateOverridesRepository
+}
+
+func (t *tenantTemplateRepository) ForEmail(ctx context.Context, email EmailTemplate) (TemplateRepository, error) {
+	override, err := t.overrides.get(ctx, email.EmailTenantID(), templateKey(email))
+	if errors.Is(err, sql.ErrNoRows) {
+		return t.TemplateRepository, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	return newOverriddenTemplates(t.TemplateRepository, override), nil
+}
+
+// overriddenTemplates - The changes of one tenant to one template laid over the built-in one.
+type overriddenTemplates struct {
+	builtin  TemplateRepository
+	override *client.EmailTemplateOverride
+}
+
+func newOverriddenTemplates(builtin TemplateRepository, override *client.EmailTemplateOverride) TemplateRepository {
+	return &overriddenTemplates{
+		builtin:  builtin,
+		override: override,
+	}
+}
+
+func (o *overriddenTemplates) Text(values Template) (string, error) {
+	if o.override.Text == nil {
+		return o.builtin.Text(values)
+	}
+	return executeText(o.override.Template+".txt", *o.override.Text, values)
+}
+
+func (o *overriddenTemplates) HTML(values Template) (string, error) {
+	if o.override.Html == nil {
+		return o.builtin.HTML(values)
+	}
+
+	template, err := html.New(o.override.Template + ".html").Parse(*o.override.Html)
+	if err != nil {
+		return "", err
+	}
+
+	generated := strings.Builder{}
+	if err := template.Execute(&generated, values); err != nil {
+		return "", err
+	}
+
+	return generated.String(), nil
+}
+
+func (o *overriddenTemplates) Subject(email EmailTemplate) (string, error) {
+	if o.override.Subject == nil {
+		return o.builtin.Subject(email)
+	}
+	return executeText(o.override.Template+".subject", *o.override.Subject, email)
+}
+
+func (o *overriddenTemplates) Branding(email EmailTemplate) (Branding, error) {
+	branding, err := o.builtin.Branding(email)
+	if err != nil {
+		return Branding{}, err
+	}
+
+	if o.override.SenderName != nil {
+		branding.SenderName = *o.override.SenderName
+	}
+
+	// A tenant only has the one logo so its used on the dark parts of the email as well.
+	if o.override.LogoURL != nil {
+		branding.LogoURL = *o.override.LogoURL
+		branding.DarkLogoURL = *o.override.LogoURL
+	}
+
+	if o.override.PrimaryColor != nil {
+		branding.PrimaryColor = *o.override.PrimaryColor
+	}
+
+	if o.override.BackgroundColor != nil {
+		branding.BackgroundColor = *o.override.BackgroundColor
+	}
+
+	return branding, nil
+}
+
+func (o *overriddenTemplates) ForEmail(ctx context.Context, email EmailTemplate) (TemplateRepository, error) {
+	return o, nil
+}
+
+func executeText(name string, content string, values interface{}) (string, error) {
+	template, err := text.New(name).Parse(content)
+	if err != nil {
+		return "", err
+	}
+
+	generated := strings.Builder{}
+	if err := template.Execute(&generated, values); err != nil {
+		return "", err
+	}
+
+	return generated.String(), nil
+}
+
+// templateKey - Name tenants know the template by, like invite instead of invite.template
+func templateKey(values Template) string {
+	return strings.TrimSuffix(values.TemplateName(), ".template")
+}
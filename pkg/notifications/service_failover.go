@@ -0,0 +1,53 @@
+package notifications
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strings"
+
+	log "github.com/moov-io/identity/pkg/logging"
+)
+
+type failoverService struct {
+	logger   log.Logger
+	services []NotificationsService
+}
+
+// NewFailoverNotificationsService - Tries each of the services in order until one of them sends the email.
+func NewFailoverNotificationsService(logger log.Logger, services ...NotificationsService) NotificationsService {
+	return &failoverService{
+		logger:   logger,
+		services: services,
+	}
+}
+
+func (s *failoverService) SendEmail(ctx context.Context, to string, email EmailTemplate) error {
+	failures := []string{}
+	for i, service := range s.services {
+		err := service.SendEmail(ctx, to, email)
+		if err == nil {
+			return nil
+		}
+
+		s.logger.WithMap(map[string]string{
+			"email_to":       to,
+			"email_template": email.TemplateName(),
+			"backend":        fmt.Sprintf("%d", i),
+		}).Error().LogError("Email backend failed, trying the next one", err)
+
+		failures = append(failures, err.Error())
+	}
+
+	return fmt.Errorf("every email backend failed: %s", strings.Join(failures, "; "))
+}
+
+// Close - Closes the backends that hold onto connections.
+func (s *failoverService) Close() error {
+	for _, service := range s.services {
+		if closer, ok := service.(io.Closer); ok {
+			closer.Close()
+		}
+	}
+	return nil
+}
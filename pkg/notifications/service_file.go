@@ -0,0 +1,66 @@
+package notifications
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/google/uuid"
+	log "github.com/moov-io/identity/pkg/logging"
+)
+
+type fileService struct {
+	logger    log.Logger
+	config    FileConfig
+	templates TemplateRepository
+}
+
+// NewFileNotificationsService - Makes sure the directory exists up front so a bad path shows up at startup.
+func NewFileNotificationsService(logger log.Logger, config FileConfig, templates TemplateRepository) (NotificationsService, error) {
+	if err := os.MkdirAll(config.Directory, 0755); err != nil {
+		return nil, err
+	}
+
+	return &fileService{
+		logger:    logger,
+		config:    config,
+		templates: templates,
+	}, nil
+}
+
+// SendEmail - Writes the email out as an RFC 5322 message, named so they list in the order they were sent.
+func (s *fileService) SendEmail(ctx context.Context, to string, email EmailTemplate) error {
+	logCtx := s.logger.WithMap(map[string]string{
+		"email_to":       to,
+		"email_from":     s.config.From,
+		"email_subject":  email.EmailSubject(),
+		"email_template": email.TemplateName(),
+	})
+
+	rendered, err := renderEmail(ctx, s.templates, s.config.From, to, email)
+	if err != nil {
+		return logCtx.Error().LogError("Unable to render email", err)
+	}
+
+	name := fmt.Sprintf("%s-%s.eml", time.Now().UTC().Format("20060102T150405.000000000"), uuid.New().String())
+	path := filepath.Join(s.config.Directory, name)
+
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0644)
+	if err != nil {
+		return logCtx.Error().LogError("Unable to create email file", err)
+	}
+
+	if _, err := rendered.message().WriteTo(f); err != nil {
+		f.Close()
+		return logCtx.Error().LogError("Unable to write email file", err)
+	}
+
+	if err := f.Close(); err != nil {
+		return logCtx.Error().LogError("Unable to write email file", err)
+	}
+
+	logCtx.Info().Log(fmt.Sprintf("Wrote email to: %s", path))
+	return nil
+}
@@ -0,0 +1,100 @@
+package notifications
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"time"
+
+	log "github.com/moov-io/identity/pkg/logging"
+)
+
+// HTTPEmail - What gets posted to the provider for each email.
+type HTTPEmail struct {
+	From     string   `json:"from"`
+	FromName string   `json:"fromName,omitempty"`
+	To       []string `json:"to"`
+	Subject  string   `json:"subject"`
+	Template string   `json:"template"`
+	Text     string   `json:"text,omitempty"`
+	HTML     string   `json:"html,omitempty"`
+}
+
+type httpService struct {
+	logger    log.Logger
+	client    *http.Client
+	config    HTTPConfig
+	templates TemplateRepository
+}
+
+func NewHTTPNotificationsService(logger log.Logger, config HTTPConfig, templates TemplateRepository) NotificationsService {
+	timeout := config.Timeout
+	if timeout <= 0 {
+		timeout = 10 * time.Second
+	}
+
+	return &httpService{
+		logger:    logger,
+		client:    &http.Client{Timeout: timeout},
+		config:    config,
+		templates: templates,
+	}
+}
+
+func (s *httpService) SendEmail(ctx context.Context, to string, email EmailTemplate) error {
+	// Never log the message because of security concerns.
+	logCtx := s.logger.WithMap(map[string]string{
+		"email_to":       to,
+		"email_from":     s.config.From,
+		"email_subject":  email.EmailSubject(),
+		"email_template": email.TemplateName(),
+	})
+
+	rendered, err := renderEmail(ctx, s.templates, s.config.From, to, email)
+	if err != nil {
+		return logCtx.Error().LogError("Unable to render email", err)
+	}
+
+	body, err := json.Marshal(HTTPEmail{
+		From:     rendered.From,
+		FromName: rendered.SenderName,
+		To:       []string{rendered.To},
+		Subject:  rendered.Subject,
+		Template: rendered.Template,
+		Text:     rendered.Text,
+		HTML:     rendered.HTML,
+	})
+	if err != nil {
+		return logCtx.Error().LogError("Unable to encode email", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.config.URL, bytes.NewReader(body))
+	if err != nil {
+		return logCtx.Error().LogError("Unable to create email request", err)
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+	for k, v := range s.config.Headers {
+		req.Header.Set(k, v)
+	}
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return logCtx.Error().LogError("Failed to send email", err)
+	}
+	defer resp.Body.Close()
+
+	// Drain it so the connection can be reused.
+	_, _ = io.Copy(ioutil.Discard, resp.Body)
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return logCtx.Error().LogError("Failed to send email", fmt.Errorf("email provider responded with %s", resp.Status))
+	}
+
+	logCtx.Info().Log(fmt.Sprintf("Successfully sent email to: %s", to))
+	return nil
+}
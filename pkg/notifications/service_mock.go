@@ -1,17 +1,94 @@
 package notifications
 
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Most emails the mock holds onto, the oldest are dropped past it so a long running dev server doesn't grow forever.
+const maxMockEmails = 1000
+
+// SentEmail - An email the mock captured along with what its templates rendered.
+type SentEmail struct {
+	EmailID  string        `json:"emailID"`
+	From     string        `json:"from"`
+	To       string        `json:"to"`
+	Subject  string        `json:"subject"`
+	Template string        `json:"template"`
+	Text     string        `json:"text"`
+	HTML     string        `json:"html"`
+	SentOn   time.Time     `json:"sentOn"`
+	Email    EmailTemplate `json:"-"`
+}
+
+// MockNotificationsService - Captures emails instead of sending them so they can be looked at by tests or in the mailbox.
+type MockNotificationsService interface {
+	NotificationsService
+	Sent() []SentEmail
+	Clear()
+}
+
 type mockService struct {
-	config MockConfig
-	sent   []EmailTemplate
+	config    MockConfig
+	templates TemplateRepository
+
+	lock sync.RWMutex
+	sent []SentEmail
 }
 
-func NewMockNotificationsService(config MockConfig) NotificationsService {
+func NewMockNotificationsService(config MockConfig, templates TemplateRepository) MockNotificationsService {
 	return &mockService{
-		config: config,
+		config:    config,
+		templates: templates,
 	}
 }
 
-func (s *mockService) SendEmail(to string, email EmailTemplate) error {
-	s.sent = append(s.sent, email)
+// SendEmail - Renders the email just like a real backend would so template errors come out here too.
+func (s *mockService) SendEmail(ctx context.Context, to string, email EmailTemplate) error {
+	rendered, err := renderEmail(ctx, s.templates, s.config.From, to, email)
+	if err != nil {
+		return err
+	}
+
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	s.sent = append(s.sent, SentEmail{
+		EmailID:  uuid.New().String(),
+		From:     rendered.fromAddress(),
+		To:       rendered.To,
+		Subject:  rendered.Subject,
+		Template: rendered.Template,
+		Text:     rendered.Text,
+		HTML:     rendered.HTML,
+		SentOn:   time.Now(),
+		Email:    email,
+	})
+
+	if len(s.sent) > maxMockEmails {
+		s.sent = s.sent[len(s.sent)-maxMockEmails:]
+	}
+
 	return nil
 }
+
+// Sent - Copy of the captured emails, oldest first.
+func (s *mockService) Sent() []SentEmail {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+
+	sent := make([]SentEmail, len(s.sent))
+	copy(sent, s.sent)
+	return sent
+}
+
+// Clear - Forgets all the captured emails.
+func (s *mockService) Clear() {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	s.sent = nil
+}
@@ -1,21 +1,138 @@
 package notifications
 
 import (
+	"context"
 	"errors"
+	"fmt"
+	"net/mail"
+	"strings"
 
+	"github.com/google/uuid"
 	log "github.com/moov-io/identity/pkg/logging"
+	"gopkg.in/gomail.v2"
 )
 
 type NotificationsService interface {
-	SendEmail(to string, email EmailTemplate) error
+	SendEmail(ctx context.Context, to string, email EmailTemplate) error
 }
 
 func NewNotificationsService(logger log.Logger, config NotificationsConfig, templates TemplateRepository) (NotificationsService, error) {
-	if config.SMTP != nil {
-		return NewSmtpNotificationsService(logger, *config.SMTP, templates), nil
+	if len(config.Failover) > 0 {
+		services := []NotificationsService{}
+		for _, c := range config.Failover {
+			service, err := NewNotificationsService(logger, c, templates)
+			if err != nil {
+				return nil, err
+			}
+			services = append(services, service)
+		}
+		return NewFailoverNotificationsService(logger, services...), nil
+	} else if config.SMTP != nil {
+		return NewSmtpNotificationsService(logger, *config.SMTP, templates)
+	} else if config.HTTP != nil {
+		return NewHTTPNotificationsService(logger, *config.HTTP, templates), nil
+	} else if config.File != nil {
+		return NewFileNotificationsService(logger, *config.File, templates)
 	} else if config.Mock != nil {
-		return NewMockNotificationsService(*config.Mock), nil
+		return NewMockNotificationsService(*config.Mock, templates), nil
 	}
 
 	return nil, errors.New("no notifications method specified check config")
 }
+
+// renderedEmail - An email filled out by its templates, ready to be handed off to whatever is delivering it.
+type renderedEmail struct {
+	From       string
+	SenderName string
+	To         string
+	Subject    string
+	Template   string
+	Text       string
+	HTML       string
+}
+
+// renderEmail - Runs the email through its templates so every backend sends the same thing.
+func renderEmail(ctx context.Context, templates TemplateRepository, from string, to string, email EmailTemplate) (*renderedEmail, error) {
+	templates, err := templates.ForEmail(ctx, email)
+	if err != nil {
+		return nil, fmt.Errorf("unable to find templates: %w", err)
+	}
+
+	branding, err := templates.Branding(email)
+	if err != nil {
+		return nil, fmt.Errorf("unable to find branding: %w", err)
+	}
+	email.brand(branding)
+
+	subject, err := templates.Subject(email)
+	if err != nil {
+		return nil, fmt.Errorf("unable to generate subject: %w", err)
+	}
+
+	txt, err := templates.Text(email)
+	if err != nil {
+		return nil, fmt.Errorf("unable to generate text template: %w", err)
+	}
+
+	html, err := templates.HTML(email)
+	if err != nil {
+		return nil, fmt.Errorf("unable to generate html template: %w", err)
+	}
+
+	return &renderedEmail{
+		From:       from,
+		SenderName: branding.SenderName,
+		To:         to,
+		Subject:    subject,
+		Template:   email.TemplateName(),
+		Text:       txt,
+		HTML:       html,
+	}, nil
+}
+
+// message - The rendered email as a MIME message with the html as an alternative to the text.
+func (r *renderedEmail) message() *gomail.Message {
+	m := gomail.NewMessage()
+	if r.SenderName != "" {
+		m.SetAddressHeader("From", r.From, r.SenderName)
+	} else {
+		m.SetHeader("From", r.From)
+	}
+	m.SetHeader("To", r.To)
+	m.SetHeader("Subject", r.Subject)
+	m.SetHeader("Message-ID", newMessageID(domainOf(r.From)))
+
+	if r.Text != "" {
+		m.SetBody("text/plain", r.Text)
+	}
+
+	if r.HTML != "" {
+		if r.Text != "" {
+			m.AddAlternative("text/html", r.HTML)
+		} else {
+			m.SetBody("text/html", r.HTML)
+		}
+	}
+
+	return m
+}
+
+// fromAddress - Who the email is from along with the name of the sender if the tenant gave one.
+func (r *renderedEmail) fromAddress() string {
+	if r.SenderName == "" {
+		return r.From
+	}
+	return (&mail.Address{Name: r.SenderName, Address: r.From}).String()
+}
+
+func newMessageID(domain string) string {
+	return fmt.Sprintf("<%s@%s>", uuid.New().String(), domain)
+}
+
+func domainOf(address string) string {
+	address = strings.TrimSuffix(address, ">")
+	if i := strings.LastIndex(address, "@"); i >= 0 {
+		return address[i+1:]
+	}
+	return "localhost"
+}
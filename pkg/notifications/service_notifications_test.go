@@ -1,11 +1,24 @@
 package notifications
 
 import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"mime"
+	"net/http"
+	"net/http/httptest"
+	"net/mail"
+	"os"
+	"path/filepath"
+	"sync"
 	"testing"
+	"time"
 
 	"github.com/google/uuid"
+	"github.com/gorilla/mux"
 	authnlib "github.com/moov-io/authn/pkg/client"
 	"github.com/moov-io/base/docker"
+	api "github.com/moov-io/identity/pkg/api"
 	authnclient "github.com/moov-io/identity/pkg/authn/client"
 	authntestutils "github.com/moov-io/identity/pkg/authn/testutils"
 	"github.com/moov-io/identity/pkg/client"
@@ -49,9 +62,9 @@ func Test_SMTP_SendInvite(t *testing.T) {
 		Website:  "Website",
 	}
 
-	invite := NewInviteEmail("https://localhost/accept", inviter, tenant)
+	invite := NewInviteEmail("https://localhost/accept", inviter, tenant, nil)
 
-	err = service.SendEmail("test@moovtest.io", &invite)
+	err = service.SendEmail(context.Background(), "test@moovtest.io", &invite)
 	a.Nil(err, "Check that `docker-compose up` is running before running tests. Can't talk to mailslurper.")
 }
 
@@ -79,16 +92,137 @@ func Test_Mock_SendInvite(t *testing.T) {
 		Website:  "Website",
 	}
 
-	invite := NewInviteEmail("https://localhost/accept", inviter, tenant)
+	invite := NewInviteEmail("https://localhost/accept", inviter, tenant, nil)
 
-	err = service.SendEmail("test@moovtest.io", &invite)
+	err = service.SendEmail(context.Background(), "test@moovtest.io", &invite)
 	a.Nil(err, "Check that `docker-compose up` is running before running tests. Can't talk to mailslurper.")
 
-	mock, ok := service.(*mockService)
+	mock, ok := service.(MockNotificationsService)
 	a.True(ok)
 
-	a.Contains(mock.sent, &invite)
+	sent := mock.Sent()
+	a.Len(sent, 1)
+	a.Equal(&invite, sent[0].Email)
+	a.Equal("noreply@moovtest.io", sent[0].From)
+	a.Equal("test@moovtest.io", sent[0].To)
+	a.Equal(invite.Subject, sent[0].Subject)
+	a.Contains(sent[0].Text, "https://localhost/accept")
+	a.Contains(sent[0].HTML, "https://localhost/accept")
 
+	mock.Clear()
+	a.Empty(mock.Sent())
+}
+
+type missingTemplateEmail struct {
+	Branded
+	Localized
+}
+
+func (e *missingTemplateEmail) TemplateName() string  { return "missing.template" }
+func (e *missingTemplateEmail) EmailSubject() string  { return "Missing" }
+func (e *missingTemplateEmail) EmailTenantID() string { return "" }
+
+func Test_Mock_TemplateError(t *testing.T) {
+	a, s := Setup(t)
+
+	mock := NewMockNotificationsService(MockConfig{From: "noreply@moovtest.io"}, s.templates)
+
+	err := mock.SendEmail(context.Background(), "test@moovtest.io", &missingTemplateEmail{})
+	a.NotNil(err)
+	a.Empty(mock.Sent())
+}
+
+func Test_Mock_Concurrent(t *testing.T) {
+	a, s := Setup(t)
+
+	mock := NewMockNotificationsService(MockConfig{From: "noreply@moovtest.io"}, s.templates)
+	welcome := NewWelcomeEmail("https://localhost/login", client.Identity{})
+
+	wg := sync.WaitGroup{}
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			a.Nil(mock.SendEmail(context.Background(), "test@moovtest.io", &welcome))
+			_ = mock.Sent()
+		}()
+	}
+	wg.Wait()
+
+	a.Len(mock.Sent(), 10)
+}
+
+func Test_MailboxAPI(t *testing.T) {
+	a, s := Setup(t)
+
+	mock := NewMockNotificationsService(MockConfig{From: "noreply@moovtest.io"}, s.templates)
+
+	routes := mux.NewRouter()
+	api.AppendRouters(s.logger, routes, NewMailboxController(s.logger, mock))
+
+	request := func(method string, path string) *http.Response {
+		rec := httptest.NewRecorder()
+		routes.ServeHTTP(rec, httptest.NewRequest(method, path, nil))
+		return rec.Result()
+	}
+
+	welcome := NewWelcomeEmail("https://localhost/login", client.Identity{FirstName: "John"})
+	request1 := NewApprovalRequestEmail("https://localhost/pending", client.Identity{FirstName: "Jane"})
+	a.Nil(mock.SendEmail(context.Background(), "john@moovtest.io", &welcome))
+	a.Nil(mock.SendEmail(context.Background(), "admin@moovtest.io", &request1))
+
+	res := request("GET", "/mailbox")
+	a.Equal(http.StatusOK, res.StatusCode)
+
+	listed := []SentEmail{}
+	a.Nil(json.NewDecoder(res.Body).Decode(&listed))
+	a.Len(listed, 2)
+	a.Equal("admin@moovtest.io", listed[0].To)
+	a.Equal("john@moovtest.io", listed[1].To)
+
+	res = request("GET", "/mailbox/"+listed[1].EmailID)
+	a.Equal(http.StatusOK, res.StatusCode)
+
+	found := SentEmail{}
+	a.Nil(json.NewDecoder(res.Body).Decode(&found))
+	a.Equal(welcome.Subject, found.Subject)
+
+	res = request("GET", "/mailbox/"+listed[1].EmailID+"/html")
+	a.Equal(http.StatusOK, res.StatusCode)
+	a.Contains(res.Header.Get("Content-Type"), "text/html")
+	body, err := ioutil.ReadAll(res.Body)
+	a.Nil(err)
+	a.Contains(string(body), "https://localhost/login")
+
+	res = request("GET", "/mailbox/missing")
+	a.Equal(http.StatusNotFound, res.StatusCode)
+
+	res = request("DELETE", "/mailbox")
+	a.Equal(http.StatusNoContent, res.StatusCode)
+	a.Empty(mock.Sent())
+}
+
+func Test_Templates_Registration(t *testing.T) {
+	a, s := Setup(t)
+
+	identity := client.Identity{
+		FirstName: "John",
+		LastName:  "Doe",
+		Email:     "john@example.com",
+	}
+
+	request := NewApprovalRequestEmail("https://localhost/pending", identity)
+	welcome := NewWelcomeEmail("https://localhost/login", identity)
+
+	for _, email := range []EmailTemplate{&request, &welcome} {
+		text, err := s.templates.Text(email)
+		a.Nil(err)
+		a.Contains(text, "John")
+
+		html, err := s.templates.HTML(email)
+		a.Nil(err)
+		a.Contains(html, "https://localhost/")
+	}
 }
 
 type Scope struct {
@@ -114,3 +248,200 @@ func Setup(t *testing.T) (*assert.Assertions, Scope) {
 		claims:    middlewaretest.NewRandomClaims(),
 	}
 }
+
+func Test_Templates_IdentityChanged(t *testing.T) {
+	a, s := Setup(t)
+
+	identity := client.Identity{
+		FirstName: "Jane",
+		LastName:  "Doe",
+		Email:     "jane@example.com",
+	}
+
+	before := "John"
+	changes := []client.FieldChange{
+		{Field: "firstName", Before: &before, After: &identity.FirstName},
+		{Field: "suffix", Before: nil, After: nil},
+	}
+
+	email := NewIdentityChangedEmail(identity, changes, time.Date(2020, time.June, 1, 12, 0, 0, 0, time.UTC))
+
+	text, err := s.templates.Text(&email)
+	a.Nil(err)
+	a.Contains(text, "firstName: John -> Jane")
+	a.Contains(text, "suffix: (empty) -> (empty)")
+	a.Contains(text, "June 1, 2020")
+
+	html, err := s.templates.HTML(&email)
+	a.Nil(err)
+	a.Contains(html, "<strong>firstName</strong>")
+	a.Contains(html, "<em>empty</em>")
+}
+
+func Test_Templates_EmailChange(t *testing.T) {
+	a, s := Setup(t)
+
+	identity := client.Identity{
+		FirstName: "John",
+		LastName:  "Doe",
+		Email:     "john@example.com",
+	}
+
+	confirm := NewEmailChangeConfirmEmail("https://localhost/confirm?confirm_code=abc", identity, "jdoe@example.com")
+	notice := NewEmailChangeNoticeEmail(identity, "jdoe@example.com")
+
+	for _, email := range []EmailTemplate{&confirm, &notice} {
+		text, err := s.templates.Text(email)
+		a.Nil(err)
+		a.Contains(text, "jdoe@example.com")
+
+		html, err := s.templates.HTML(email)
+		a.Nil(err)
+		a.Contains(html, "jdoe@example.com")
+	}
+
+	html, err := s.templates.HTML(&confirm)
+	a.Nil(err)
+	a.Contains(html, "https://localhost/confirm?confirm_code=abc")
+}
+
+func Test_NewEmailTemplate(t *testing.T) {
+	a := assert.New(t)
+
+	invite := NewInviteEmail("https://localhost/accept", client.Identity{FirstName: "John"}, authnlib.Tenant{Name: "Tenant"}, nil)
+
+	email, err := NewEmailTemplate(invite.TemplateName())
+	a.Nil(err)
+	a.IsType(&InviteEmail{}, email)
+
+	_, err = NewEmailTemplate("missing.template")
+	a.Equal(ErrUnknownTemplate, err)
+}
+
+func Test_HTTP_SendEmail(t *testing.T) {
+	a, s := Setup(t)
+
+	received := []HTTPEmail{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		a.Equal("Bearer secret", r.Header.Get("Authorization"))
+		a.Equal("application/json", r.Header.Get("Content-Type"))
+
+		email := HTTPEmail{}
+		a.Nil(json.NewDecoder(r.Body).Decode(&email))
+		received = append(received, email)
+
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	t.Cleanup(server.Close)
+
+	config := NotificationsConfig{
+		HTTP: &HTTPConfig{
+			URL:     server.URL,
+			From:    "noreply@moovtest.io",
+			Headers: map[string]string{"authorization": "Bearer secret"},
+		},
+	}
+
+	service, err := NewNotificationsService(s.logger, config, s.templates)
+	a.Nil(err)
+
+	welcome := NewWelcomeEmail("https://localhost/login", client.Identity{FirstName: "John"})
+	a.Nil(service.SendEmail(context.Background(), "john@moovtest.io", &welcome))
+
+	a.Len(received, 1)
+	a.Equal("noreply@moovtest.io", received[0].From)
+	a.Equal([]string{"john@moovtest.io"}, received[0].To)
+	a.Equal(welcome.Subject, received[0].Subject)
+	a.Equal(welcome.TemplateName(), received[0].Template)
+	a.Contains(received[0].Text, "https://localhost/login")
+	a.Contains(received[0].HTML, "https://localhost/login")
+}
+
+func Test_HTTP_SendEmail_Rejected(t *testing.T) {
+	a, s := Setup(t)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	t.Cleanup(server.Close)
+
+	service := NewHTTPNotificationsService(s.logger, HTTPConfig{URL: server.URL}, s.templates)
+
+	welcome := NewWelcomeEmail("https://localhost/login", client.Identity{})
+	err := service.SendEmail(context.Background(), "john@moovtest.io", &welcome)
+	a.NotNil(err)
+	a.Contains(err.Error(), "503")
+}
+
+func Test_File_SendEmail(t *testing.T) {
+	a, s := Setup(t)
+
+	dir := filepath.Join(t.TempDir(), "outbox")
+	config := NotificationsConfig{
+		File: &FileConfig{
+			Directory: dir,
+			From:      "noreply@moovtest.io",
+		},
+	}
+
+	service, err := NewNotificationsService(s.logger, config, s.templates)
+	a.Nil(err)
+
+	welcome := NewWelcomeEmail("https://localhost/login", client.Identity{FirstName: "John"})
+	a.Nil(service.SendEmail(context.Background(), "john@moovtest.io", &welcome))
+
+	files, err := filepath.Glob(filepath.Join(dir, "*.eml"))
+	a.Nil(err)
+	a.Len(files, 1)
+
+	f, err := os.Open(files[0])
+	a.Nil(err)
+	defer f.Close()
+
+	msg, err := mail.ReadMessage(f)
+	a.Nil(err)
+	a.Equal("noreply@moovtest.io", msg.Header.Get("From"))
+	a.Equal("john@moovtest.io", msg.Header.Get("To"))
+	a.Equal(welcome.Subject, msg.Header.Get("Subject"))
+	a.Contains(msg.Header.Get("Message-ID"), "@moovtest.io>")
+
+	_, err = msg.Header.Date()
+	a.Nil(err)
+
+	// Both the text and html are in there as alternatives of each other.
+	mediaType, _, err := mime.ParseMediaType(msg.Header.Get("Content-Type"))
+	a.Nil(err)
+	a.Equal("multipart/alternative", mediaType)
+}
+
+func Test_Failover_SendEmail(t *testing.T) {
+	a, s := Setup(t)
+
+	down := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	t.Cleanup(down.Close)
+
+	config := NotificationsConfig{
+		Failover: []NotificationsConfig{
+			{HTTP: &HTTPConfig{URL: down.URL}},
+			{Mock: &MockConfig{From: "noreply@moovtest.io"}},
+		},
+	}
+
+	service, err := NewNotificationsService(s.logger, config, s.templates)
+	a.Nil(err)
+
+	welcome := NewWelcomeEmail("https://localhost/login", client.Identity{})
+	a.Nil(service.SendEmail(context.Background(), "john@moovtest.io", &welcome))
+
+	mock, ok := service.(*failoverService).services[1].(MockNotificationsService)
+	a.True(ok)
+	a.Len(mock.Sent(), 1)
+
+	// Nothing left to fall back on.
+	service = NewFailoverNotificationsService(s.logger, service.(*failoverService).services[0])
+	err = service.SendEmail(context.Background(), "john@moovtest.io", &welcome)
+	a.NotNil(err)
+	a.Contains(err.Error(), "every email backend failed")
+}
@@ -1,8 +1,11 @@
 package notifications
 
 import (
+	"bytes"
+	"context"
 	"crypto/tls"
 	"fmt"
+	"io"
 
 	log "github.com/moov-io/identity/pkg/logging"
 	"gopkg.in/gomail.v2"
@@ -10,28 +13,41 @@ import (
 
 type smtpService struct {
 	logger    log.Logger
-	dailer    gomail.Dialer
+	pool      *smtpPool
+	signer    *dkimSigner
 	config    SMTPConfig
 	templates TemplateRepository
 }
 
-func NewSmtpNotificationsService(logger log.Logger, config SMTPConfig, templates TemplateRepository) NotificationsService {
-	d := *gomail.NewDialer(config.Host, config.Port, config.User, config.Pass)
+// NewSmtpNotificationsService - Sends the emails over a pool of connections kept open to the SMTP server, signing them
+// with DKIM when its configured.
+func NewSmtpNotificationsService(logger log.Logger, config SMTPConfig, templates TemplateRepository) (NotificationsService, error) {
+	d := gomail.NewDialer(config.Host, config.Port, config.User, config.Pass)
 	d.SSL = config.SSL
 	d.TLSConfig = &tls.Config{
 		ServerName:         config.Host,
 		InsecureSkipVerify: config.InsecureSSL,
 	}
 
+	var signer *dkimSigner
+	if config.DKIM != nil {
+		s, err := newDKIMSigner(*config.DKIM)
+		if err != nil {
+			return nil, err
+		}
+		signer = s
+	}
+
 	return &smtpService{
 		logger:    logger,
-		dailer:    d,
+		pool:      newSMTPPool(d, config.MaxConnections, config.KeepAlive),
+		signer:    signer,
 		config:    config,
 		templates: templates,
-	}
+	}, nil
 }
 
-func (s *smtpService) SendEmail(to string, email EmailTemplate) error {
+func (s *smtpService) SendEmail(ctx context.Context, to string, email EmailTemplate) error {
 	// Never log the message because of security concerns.
 	logCtx := s.logger.WithMap(map[string]string{
 		"email_to":       to,
@@ -40,33 +56,66 @@ func (s *smtpService) SendEmail(to string, email EmailTemplate) error {
 		"email_template": email.TemplateName(),
 	})
 
-	m := gomail.NewMessage()
-	m.SetHeader("From", s.config.From)
-	m.SetHeader("To", to)
-	m.SetHeader("Subject", email.EmailSubject())
-
-	txt, err := s.templates.Text(email)
+	rendered, err := renderEmail(ctx, s.templates, s.config.From, to, email)
 	if err != nil {
-		return logCtx.Error().LogError("Unable to generate text template", err)
+		return logCtx.Error().LogError("Unable to render email", err)
 	}
 
-	if txt != "" {
-		m.SetBody("text/plain", txt)
-	}
+	m := rendered.message()
+	s.setHeaders(m, templateKey(email))
 
-	html, err := s.templates.HTML(email)
-	if err != nil {
-		return logCtx.Error().LogError("Unable to generate html template", err)
-	}
+	var msg io.WriterTo = m
+	if s.signer != nil {
+		buf := bytes.Buffer{}
+		if _, err := m.WriteTo(&buf); err != nil {
+			return logCtx.Error().LogError("Unable to write email", err)
+		}
+
+		signed, err := s.signer.sign(buf.Bytes())
+		if err != nil {
+			return logCtx.Error().LogError("Unable to sign email", err)
+		}
 
-	if html != "" {
-		m.SetBody("text/html", html)
+		msg = rawMessage(signed)
 	}
 
-	if err := s.dailer.DialAndSend(m); err != nil {
+	if err := s.pool.send(s.config.From, []string{to}, msg); err != nil {
 		return logCtx.Error().LogError("Failed to send email", err)
 	}
 
 	logCtx.Info().Log(fmt.Sprintf("Successfully sent email to: %s", to))
 	return nil
 }
+
+// setHeaders - Adds the headers configured for the template the email was rendered from.
+func (s *smtpService) setHeaders(m *gomail.Message, template string) {
+	headers, ok := s.config.Headers[template]
+	if !ok {
+		return
+	}
+
+	if headers.ReplyTo != "" {
+		m.SetHeader("Reply-To", headers.ReplyTo)
+	}
+
+	if headers.ListUnsubscribe != "" {
+		m.SetHeader("List-Unsubscribe", headers.ListUnsubscribe)
+	}
+
+	if headers.MessageIDDomain != "" {
+		m.SetHeader("Message-ID", newMessageID(headers.MessageIDDomain))
+	}
+}
+
+// Close - Hangs up the connections to the SMTP server.
+func (s *smtpService) Close() error {
+	return s.pool.Close()
+}
+
+// rawMessage - An email already written out, it can be sent again if the first connection tried fails.
+type rawMessage []byte
+
+func (m rawMessage) WriteTo(w io.Writer) (int64, error) {
+	n, err := w.Write(m)
+	return int64(n), err
+}
@@ -0,0 +1,92 @@
+package notifications
+
+import (
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io/ioutil"
+
+	"github.com/toorop/go-dkim"
+)
+
+// The headers signed when none are configured, the ones a receiver would show or use to thread the email.
+var defaultDKIMHeaders = []string{
+	"from", "to", "subject", "date", "message-id", "reply-to", "list-unsubscribe", "mime-version", "content-type",
+}
+
+type dkimSigner struct {
+	options dkim.SigOptions
+}
+
+func newDKIMSigner(config DKIMConfig) (*dkimSigner, error) {
+	key := []byte(config.PrivateKey)
+	if config.PrivateKeyFile != "" {
+		k, err := ioutil.ReadFile(config.PrivateKeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("unable to read dkim private key: %w", err)
+		}
+		key = k
+	}
+
+	if err := checkDKIMKey(key); err != nil {
+		return nil, err
+	}
+
+	if config.Domain == "" || config.Selector == "" {
+		return nil, errors.New("dkim requires a domain and selector")
+	}
+
+	headers := config.Headers
+	if len(headers) == 0 {
+		headers = defaultDKIMHeaders
+	}
+
+	options := dkim.NewSigOptions()
+	options.Domain = config.Domain
+	options.Selector = config.Selector
+	options.PrivateKey = key
+	options.Canonicalization = "relaxed/relaxed"
+	options.Headers = headers
+
+	return &dkimSigner{options: options}, nil
+}
+
+// checkDKIMKey - Catches a bad key when starting up instead of on the first email.
+func checkDKIMKey(key []byte) error {
+	block, _ := pem.Decode(key)
+	if block == nil {
+		return errors.New("dkim private key isn't PEM encoded")
+	}
+
+	if _, err := x509.ParsePKCS1PrivateKey(block.Bytes); err == nil {
+		return nil
+	}
+
+	parsed, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return fmt.Errorf("unable to parse dkim private key: %w", err)
+	}
+
+	if _, ok := parsed.(*rsa.PrivateKey); !ok {
+		return errors.New("dkim private key must be an RSA key")
+	}
+
+	return nil
+}
+
+// sign - The email with a DKIM-Signature header added to the top of it.
+func (s *dkimSigner) sign(email []byte) ([]byte, error) {
+	options := s.options
+
+	// Signing lowercases the headers in place so each email gets its own copy.
+	options.Headers = append([]string{}, s.options.Headers...)
+
+	signed := append([]byte{}, email...)
+	if err := dkim.Sign(&signed, options); err != nil {
+		return nil, err
+	}
+
+	return signed, nil
+}
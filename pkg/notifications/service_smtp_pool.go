@@ -0,0 +1,166 @@
+package notifications
+
+import (
+	"errors"
+	"io"
+	"net"
+	"net/textproto"
+	"sync"
+	"time"
+
+	"gopkg.in/gomail.v2"
+)
+
+// smtpPool - Keeps connections to the SMTP server open between emails instead of dialing one for each of them.
+// At most size emails are sent at once, each over its own connection.
+type smtpPool struct {
+	dialer    *gomail.Dialer
+	keepAlive time.Duration
+	slots     chan struct{}
+
+	lock   sync.Mutex
+	idle   []*smtpConn
+	closed bool
+}
+
+type smtpConn struct {
+	sender gomail.SendCloser
+	expire *time.Timer
+}
+
+func newSMTPPool(dialer *gomail.Dialer, size int, keepAlive time.Duration) *smtpPool {
+	if size < 1 {
+		size = 1
+	}
+
+	if keepAlive <= 0 {
+		keepAlive = 30 * time.Second
+	}
+
+	return &smtpPool{
+		dialer:    dialer,
+		keepAlive: keepAlive,
+		slots:     make(chan struct{}, size),
+	}
+}
+
+func (p *smtpPool) send(from string, to []string, msg io.WriterTo) error {
+	p.slots <- struct{}{}
+	defer func() { <-p.slots }()
+
+	conn := p.take()
+	if conn != nil {
+		// The server could have hung up on a connection thats been sitting around, so its tried again on a new one.
+		// Only if the message wasn't started though, otherwise the server could have it already.
+		tracked := &trackedMessage{WriterTo: msg}
+		err := conn.sender.Send(from, to, tracked)
+		if err == nil {
+			p.put(conn)
+			return nil
+		}
+		conn.sender.Close()
+
+		if tracked.started || !retryable(err) {
+			return err
+		}
+	}
+
+	sender, err := p.dialer.Dial()
+	if err != nil {
+		return err
+	}
+
+	conn = &smtpConn{sender: sender}
+	if err := conn.sender.Send(from, to, msg); err != nil {
+		conn.sender.Close()
+		return err
+	}
+
+	p.put(conn)
+	return nil
+}
+
+// trackedMessage - Notes when the message starts being written to the server, which is only after its accepted
+// who its from and to.
+type trackedMessage struct {
+	io.WriterTo
+	started bool
+}
+
+func (m *trackedMessage) WriteTo(w io.Writer) (int64, error) {
+	m.started = true
+	return m.WriterTo.WriteTo(w)
+}
+
+// retryable - Errors from the connection itself or ones the server says are temporary.
+func retryable(err error) bool {
+	if err == io.EOF || err == io.ErrUnexpectedEOF {
+		return true
+	}
+
+	var netErr net.Error
+	if errors.As(err, &netErr) {
+		return true
+	}
+
+	var protoErr *textproto.Error
+	return errors.As(err, &protoErr) && protoErr.Code >= 400 && protoErr.Code < 500
+}
+
+// take - The most recently used idle connection, if there is one
+func (p *smtpPool) take() *smtpConn {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	if len(p.idle) == 0 {
+		return nil
+	}
+
+	conn := p.idle[len(p.idle)-1]
+	p.idle = p.idle[:len(p.idle)-1]
+	conn.expire.Stop()
+
+	return conn
+}
+
+func (p *smtpPool) put(conn *smtpConn) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	if p.closed {
+		conn.sender.Close()
+		return
+	}
+
+	conn.expire = time.AfterFunc(p.keepAlive, func() { p.expire(conn) })
+	p.idle = append(p.idle, conn)
+}
+
+// expire - Closes the connection if its still idle, it could have been taken just as its time ran out.
+func (p *smtpPool) expire(conn *smtpConn) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	for i, c := range p.idle {
+		if c == conn {
+			p.idle = append(p.idle[:i], p.idle[i+1:]...)
+			conn.sender.Close()
+			return
+		}
+	}
+}
+
+// Close - Hangs up the idle connections, ones still sending are closed once they're done.
+func (p *smtpPool) Close() error {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	p.closed = true
+	for _, conn := range p.idle {
+		conn.expire.Stop()
+		conn.sender.Close()
+	}
+	p.idle = nil
+
+	return nil
+}
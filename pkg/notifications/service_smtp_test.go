@@ -0,0 +1,423 @@
+package notifications
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"io"
+	"io/ioutil"
+	stdlog "log"
+	"net"
+	"net/mail"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/emersion/go-smtp"
+	"github.com/google/uuid"
+	authnlib "github.com/moov-io/authn/pkg/client"
+	"github.com/moov-io/identity/pkg/client"
+	"github.com/toorop/go-dkim"
+)
+
+func Test_SMTP_ReusesConnection(t *testing.T) {
+	a, s := Setup(t)
+	server := NewSMTPFixture(t)
+
+	service, err := NewSmtpNotificationsService(s.logger, server.Config(), s.templates)
+	a.Nil(err)
+	defer service.(*smtpService).Close()
+
+	for i := 0; i < 3; i++ {
+		invite := newTestInvite()
+		a.Nil(service.SendEmail(context.Background(), "test@moovtest.io", &invite))
+	}
+
+	a.Len(server.Messages(), 3)
+	a.Equal(1, server.Connections())
+}
+
+func Test_SMTP_MaxConnections(t *testing.T) {
+	a, s := Setup(t)
+	server := NewSMTPFixture(t)
+	server.delay = 20 * time.Millisecond
+
+	config := server.Config()
+	config.MaxConnections = 2
+
+	service, err := NewSmtpNotificationsService(s.logger, config, s.templates)
+	a.Nil(err)
+	defer service.(*smtpService).Close()
+
+	wg := sync.WaitGroup{}
+	for i := 0; i < 6; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			invite := newTestInvite()
+			a.Nil(service.SendEmail(context.Background(), "test@moovtest.io", &invite))
+		}()
+	}
+	wg.Wait()
+
+	a.Len(server.Messages(), 6)
+	a.LessOrEqual(server.Connections(), 2)
+	a.LessOrEqual(server.maxSending, 2)
+}
+
+func Test_SMTP_KeepAliveExpires(t *testing.T) {
+	a, s := Setup(t)
+	server := NewSMTPFixture(t)
+
+	config := server.Config()
+	config.KeepAlive = 50 * time.Millisecond
+
+	service, err := NewSmtpNotificationsService(s.logger, config, s.templates)
+	a.Nil(err)
+	defer service.(*smtpService).Close()
+
+	invite := newTestInvite()
+	a.Nil(service.SendEmail(context.Background(), "test@moovtest.io", &invite))
+
+	// The connection should be hung up once its been idle long enough
+	a.Eventually(func() bool { return server.Closed() == 1 }, time.Second, 10*time.Millisecond)
+
+	invite = newTestInvite()
+	a.Nil(service.SendEmail(context.Background(), "test@moovtest.io", &invite))
+
+	a.Len(server.Messages(), 2)
+	a.Equal(2, server.Connections())
+}
+
+func Test_SMTP_RedialsDroppedConnection(t *testing.T) {
+	a, s := Setup(t)
+	server := NewSMTPFixture(t)
+
+	service, err := NewSmtpNotificationsService(s.logger, server.Config(), s.templates)
+	a.Nil(err)
+	defer service.(*smtpService).Close()
+
+	invite := newTestInvite()
+	a.Nil(service.SendEmail(context.Background(), "test@moovtest.io", &invite))
+
+	// Server hangs up on the idle connection without the pool knowing
+	server.server.ForEachConn(func(c *smtp.Conn) { c.Close() })
+
+	invite = newTestInvite()
+	a.Nil(service.SendEmail(context.Background(), "test@moovtest.io", &invite))
+
+	a.Len(server.Messages(), 2)
+	a.Equal(2, server.Connections())
+}
+
+func Test_SMTP_RetriesBeforeData(t *testing.T) {
+	a, s := Setup(t)
+	server := NewSMTPFixture(t)
+
+	service, err := NewSmtpNotificationsService(s.logger, server.Config(), s.templates)
+	a.Nil(err)
+	defer service.(*smtpService).Close()
+
+	invite := newTestInvite()
+	a.Nil(service.SendEmail(context.Background(), "test@moovtest.io", &invite))
+
+	server.lock.Lock()
+	server.rejectMail = 1
+	server.lock.Unlock()
+
+	invite = newTestInvite()
+	a.Nil(service.SendEmail(context.Background(), "test@moovtest.io", &invite))
+
+	a.Len(server.Messages(), 2)
+	a.Equal(2, server.Connections())
+}
+
+func Test_SMTP_NoRetryAfterData(t *testing.T) {
+	a, s := Setup(t)
+	server := NewSMTPFixture(t)
+
+	service, err := NewSmtpNotificationsService(s.logger, server.Config(), s.templates)
+	a.Nil(err)
+	defer service.(*smtpService).Close()
+
+	invite := newTestInvite()
+	a.Nil(service.SendEmail(context.Background(), "test@moovtest.io", &invite))
+
+	server.lock.Lock()
+	server.rejectData = 1
+	server.lock.Unlock()
+
+	// The server could have the email so its not sent again.
+	invite = newTestInvite()
+	a.NotNil(service.SendEmail(context.Background(), "test@moovtest.io", &invite))
+
+	a.Len(server.Messages(), 2)
+	a.Equal(1, server.Connections())
+}
+
+func Test_SMTP_TemplateHeaders(t *testing.T) {
+	a, s := Setup(t)
+	server := NewSMTPFixture(t)
+
+	config := server.Config()
+	config.Headers = map[string]EmailHeaders{
+		"invite": {
+			ReplyTo:         "support@moovtest.io",
+			ListUnsubscribe: "<mailto:unsubscribe@moovtest.io>",
+			MessageIDDomain: "mail.moovtest.io",
+		},
+	}
+
+	service, err := NewSmtpNotificationsService(s.logger, config, s.templates)
+	a.Nil(err)
+	defer service.(*smtpService).Close()
+
+	invite := newTestInvite()
+	a.Nil(service.SendEmail(context.Background(), "test@moovtest.io", &invite))
+
+	welcome := NewWelcomeEmail("https://localhost/login", client.Identity{FirstName: "John", LastName: "Doe"})
+	a.Nil(service.SendEmail(context.Background(), "test@moovtest.io", &welcome))
+
+	messages := server.Messages()
+	a.Len(messages, 2)
+
+	msg, err := mail.ReadMessage(bytes.NewReader(messages[0].data))
+	a.Nil(err)
+	a.Equal("noreply@moovtest.io", messages[0].from)
+	a.Equal([]string{"test@moovtest.io"}, messages[0].to)
+	a.Equal("support@moovtest.io", msg.Header.Get("Reply-To"))
+	a.Equal("<mailto:unsubscribe@moovtest.io>", msg.Header.Get("List-Unsubscribe"))
+	a.True(strings.HasSuffix(msg.Header.Get("Message-ID"), "@mail.moovtest.io>"))
+
+	// Headers are only set on the emails of the template they're configured for
+	msg, err = mail.ReadMessage(bytes.NewReader(messages[1].data))
+	a.Nil(err)
+	a.Empty(msg.Header.Get("Reply-To"))
+	a.Empty(msg.Header.Get("List-Unsubscribe"))
+	a.True(strings.HasSuffix(msg.Header.Get("Message-ID"), "@moovtest.io>"))
+}
+
+func Test_SMTP_DKIM(t *testing.T) {
+	a, s := Setup(t)
+	server := NewSMTPFixture(t)
+
+	key, err := rsa.GenerateKey(rand.Reader, 1024)
+	a.Nil(err)
+
+	config := server.Config()
+	config.DKIM = &DKIMConfig{
+		Domain:     "moovtest.io",
+		Selector:   "identity",
+		PrivateKey: string(pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(key)})),
+	}
+
+	service, err := NewSmtpNotificationsService(s.logger, config, s.templates)
+	a.Nil(err)
+	defer service.(*smtpService).Close()
+
+	invite := newTestInvite()
+	a.Nil(service.SendEmail(context.Background(), "test@moovtest.io", &invite))
+	a.Nil(service.SendEmail(context.Background(), "test@moovtest.io", &invite))
+
+	public, err := x509.MarshalPKIXPublicKey(&key.PublicKey)
+	a.Nil(err)
+
+	lookup := dkim.DNSOptLookupTXT(func(name string) ([]string, error) {
+		a.Equal("identity._domainkey.moovtest.io", name)
+		return []string{"v=DKIM1; k=rsa; p=" + base64.StdEncoding.EncodeToString(public)}, nil
+	})
+
+	for _, message := range server.Messages() {
+		a.Contains(string(message.data), "DKIM-Signature:")
+
+		status, err := dkim.Verify(&message.data, lookup)
+		a.Nil(err)
+		a.Equal(dkim.SUCCESS, status)
+	}
+}
+
+func Test_SMTP_DKIM_BadKey(t *testing.T) {
+	a, s := Setup(t)
+
+	_, err := NewSmtpNotificationsService(s.logger, SMTPConfig{
+		Host: "localhost",
+		From: "noreply@moovtest.io",
+		DKIM: &DKIMConfig{
+			Domain:     "moovtest.io",
+			Selector:   "identity",
+			PrivateKey: "not a key",
+		},
+	}, s.templates)
+	a.NotNil(err)
+}
+
+func newTestTenant() authnlib.Tenant {
+	return authnlib.Tenant{
+		TenantID: uuid.New().String(),
+		Name:     "Tenant",
+		Alias:    "tenant",
+		Website:  "Website",
+	}
+}
+
+func newTestInvite() InviteEmail {
+	return NewInviteEmail("https://localhost/accept", client.Identity{FirstName: "John", LastName: "Doe"}, newTestTenant(), nil)
+}
+
+// SMTPFixture - An SMTP server running in the test that records the emails sent to it.
+type SMTPFixture struct {
+	server *smtp.Server
+	addr   *net.TCPAddr
+
+	// How long each email takes to be received
+	delay time.Duration
+
+	// How many of the next emails are turned away before or after their data is received
+	rejectMail int
+	rejectData int
+
+	lock        sync.Mutex
+	messages    []smtpMessage
+	connections int
+	closed      int
+	sending     int
+	maxSending  int
+}
+
+type smtpMessage struct {
+	from string
+	to   []string
+	data []byte
+}
+
+func NewSMTPFixture(t *testing.T) *SMTPFixture {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	f := &SMTPFixture{addr: l.Addr().(*net.TCPAddr)}
+
+	f.server = smtp.NewServer(f)
+	f.server.Domain = "localhost"
+	f.server.ErrorLog = stdlog.New(ioutil.Discard, "", 0)
+
+	go f.server.Serve(l)
+	t.Cleanup(func() { f.server.Close() })
+
+	return f
+}
+
+func (f *SMTPFixture) Config() SMTPConfig {
+	return SMTPConfig{
+		Host: f.addr.IP.String(),
+		Port: f.addr.Port,
+		From: "noreply@moovtest.io",
+	}
+}
+
+func (f *SMTPFixture) Messages() []smtpMessage {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	return append([]smtpMessage{}, f.messages...)
+}
+
+func (f *SMTPFixture) Connections() int {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	return f.connections
+}
+
+func (f *SMTPFixture) Closed() int {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	return f.closed
+}
+
+func (f *SMTPFixture) Login(state *smtp.ConnectionState, username, password string) (smtp.Session, error) {
+	return nil, smtp.ErrAuthUnsupported
+}
+
+// AnonymousLogin - Called once for each connection when its first email is sent.
+func (f *SMTPFixture) AnonymousLogin(state *smtp.ConnectionState) (smtp.Session, error) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	f.connections++
+	return &smtpFixtureSession{fixture: f}, nil
+}
+
+type smtpFixtureSession struct {
+	fixture *SMTPFixture
+	message smtpMessage
+}
+
+func (s *smtpFixtureSession) Reset() {
+	s.message = smtpMessage{}
+}
+
+func (s *smtpFixtureSession) Logout() error {
+	s.fixture.lock.Lock()
+	defer s.fixture.lock.Unlock()
+
+	s.fixture.closed++
+	return nil
+}
+
+func (s *smtpFixtureSession) Mail(from string, opts smtp.MailOptions) error {
+	f := s.fixture
+
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	if f.rejectMail > 0 {
+		f.rejectMail--
+		return &smtp.SMTPError{Code: 421, Message: "Service not available"}
+	}
+
+	s.message.from = from
+	return nil
+}
+
+func (s *smtpFixtureSession) Rcpt(to string) error {
+	s.message.to = append(s.message.to, to)
+	return nil
+}
+
+func (s *smtpFixtureSession) Data(r io.Reader) error {
+	f := s.fixture
+
+	f.lock.Lock()
+	f.sending++
+	if f.sending > f.maxSending {
+		f.maxSending = f.sending
+	}
+	f.lock.Unlock()
+
+	data, err := ioutil.ReadAll(r)
+	time.Sleep(f.delay)
+
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	f.sending--
+	if err != nil {
+		return err
+	}
+
+	s.message.data = data
+	f.messages = append(f.messages, s.message)
+
+	// Its been received, the client just isn't told it was.
+	if f.rejectData > 0 {
+		f.rejectData--
+		return &smtp.SMTPError{Code: 451, Message: "Local error in processing"}
+	}
+
+	return nil
+}
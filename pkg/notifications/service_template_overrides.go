@@ -0,0 +1,178 @@
+package notifications
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	validation "github.com/go-ozzo/ozzo-validation/v4"
+	"github.com/moov-io/identity/pkg/client"
+	"github.com/moov-io/identity/pkg/stime"
+	tmw "github.com/moov-io/tumbler/pkg/middleware"
+)
+
+// TemplatesService - Lets tenants change how the emails sent on their behalf look.
+type TemplatesService interface {
+	ListTemplateOverrides(ctx context.Context, claims tmw.TumblerClaims) ([]client.EmailTemplateOverride, error)
+	GetTemplateOverride(ctx context.Context, claims tmw.TumblerClaims, template string) (*client.EmailTemplateOverride, error)
+	SaveTemplateOverride(ctx context.Context, claims tmw.TumblerClaims, template string, update client.UpdateEmailTemplateOverride) (*client.EmailTemplateOverride, error)
+	DeleteTemplateOverride(ctx context.Context, claims tmw.TumblerClaims, template string) error
+	PreviewTemplate(ctx context.Context, claims tmw.TumblerClaims, template string, update *client.UpdateEmailTemplateOverride) (*client.EmailTemplatePreview, error)
+}
+
+type templatesService struct {
+	time       stime.TimeService
+	builtin    TemplateRepository
+	repository TemplateOverridesRepository
+}
+
+// NewTemplatesService - Builds the service with the built-in templates that overrides fall back to.
+func NewTemplatesService(time stime.TimeService, builtin TemplateRepository, repository TemplateOverridesRepository) TemplatesService {
+	return &templatesService{
+		time:       time,
+		builtin:    builtin,
+		repository: repository,
+	}
+}
+
+func (s *templatesService) ListTemplateOverrides(ctx context.Context, claims tmw.TumblerClaims) ([]client.EmailTemplateOverride, error) {
+	return s.repository.list(ctx, claims.TenantID.String())
+}
+
+func (s *templatesService) GetTemplateOverride(ctx context.Context, claims tmw.TumblerClaims, template string) (*client.EmailTemplateOverride, error) {
+	if _, err := sampleEmail(template, claims.TenantID.String()); err != nil {
+		return nil, err
+	}
+
+	override, err := s.repository.get(ctx, claims.TenantID.String(), template)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrTemplateOverrideNotFound
+	}
+
+	return override, err
+}
+
+// SaveTemplateOverride - Replaces the changes to the template after making sure it renders.
+func (s *templatesService) SaveTemplateOverride(ctx context.Context, claims tmw.TumblerClaims, template string, update client.UpdateEmailTemplateOverride) (*client.EmailTemplateOverride, error) {
+	override, err := s.override(claims, template, update)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := s.render(override); err != nil {
+		return nil, err
+	}
+
+	if err := s.repository.save(ctx, *override); err != nil {
+		return nil, err
+	}
+
+	return override, nil
+}
+
+func (s *templatesService) DeleteTemplateOverride(ctx context.Context, claims tmw.TumblerClaims, template string) error {
+	if _, err := sampleEmail(template, claims.TenantID.String()); err != nil {
+		return err
+	}
+
+	err := s.repository.delete(ctx, claims.TenantID.String(), template)
+	if errors.Is(err, sql.ErrNoRows) {
+		return ErrTemplateOverrideNotFound
+	}
+
+	return err
+}
+
+// PreviewTemplate - Renders the template with example values. Without an update its rendered with what the tenant already saved.
+func (s *templatesService) PreviewTemplate(ctx context.Context, claims tmw.TumblerClaims, template string, update *client.UpdateEmailTemplateOverride) (*client.EmailTemplatePreview, error) {
+	var override *client.EmailTemplateOverride
+	if update != nil {
+		o, err := s.override(claims, template, *update)
+		if err != nil {
+			return nil, err
+		}
+		override = o
+	} else {
+		o, err := s.GetTemplateOverride(ctx, claims, template)
+		if errors.Is(err, ErrTemplateOverrideNotFound) {
+			o = &client.EmailTemplateOverride{
+				TenantID: claims.TenantID.String(),
+				Template: template,
+			}
+		} else if err != nil {
+			return nil, err
+		}
+		override = o
+	}
+
+	return s.render(override)
+}
+
+func (s *templatesService) override(claims tmw.TumblerClaims, template string, update client.UpdateEmailTemplateOverride) (*client.EmailTemplateOverride, error) {
+	if _, err := sampleEmail(template, claims.TenantID.String()); err != nil {
+		return nil, err
+	}
+
+	if err := update.Validate(); err != nil {
+		return nil, err
+	}
+
+	return &client.EmailTemplateOverride{
+		TenantID:        claims.TenantID.String(),
+		Template:        template,
+		SenderName:      update.SenderName,
+		Subject:         update.Subject,
+		LogoURL:         update.LogoURL,
+		PrimaryColor:    update.PrimaryColor,
+		BackgroundColor: update.BackgroundColor,
+		Html:            update.Html,
+		Text:            update.Text,
+		UpdatedBy:       claims.Subject,
+		UpdatedOn:       s.time.Now(),
+	}, nil
+}
+
+// render - Fills out the override with example values, any errors in its templates come back as validation errors of their field.
+func (s *templatesService) render(override *client.EmailTemplateOverride) (*client.EmailTemplatePreview, error) {
+	email, err := sampleEmail(override.Template, override.TenantID)
+	if err != nil {
+		return nil, err
+	}
+
+	templates := newOverriddenTemplates(s.builtin, override)
+
+	branding, err := templates.Branding(email)
+	if err != nil {
+		return nil, err
+	}
+	email.brand(branding)
+
+	errs := validation.Errors{}
+
+	subject, err := templates.Subject(email)
+	if err != nil {
+		errs["subject"] = err
+	}
+
+	html, err := templates.HTML(email)
+	if err != nil {
+		errs["html"] = err
+	}
+
+	text, err := templates.Text(email)
+	if err != nil {
+		errs["text"] = err
+	}
+
+	if len(errs) > 0 {
+		return nil, errs
+	}
+
+	return &client.EmailTemplatePreview{
+		Template:   override.Template,
+		SenderName: branding.SenderName,
+		Subject:    subject,
+		Html:       html,
+		Text:       text,
+	}, nil
+}
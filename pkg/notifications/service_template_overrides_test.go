@@ -0,0 +1,288 @@
+package notifications
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/mail"
+	"testing"
+
+	validation "github.com/go-ozzo/ozzo-validation/v4"
+	"github.com/gorilla/mux"
+	authnlib "github.com/moov-io/authn/pkg/client"
+	api "github.com/moov-io/identity/pkg/api"
+	"github.com/moov-io/identity/pkg/client"
+	"github.com/moov-io/identity/pkg/database"
+	"github.com/moov-io/identity/pkg/stime"
+	"github.com/moov-io/tumbler/pkg/middleware/middlewaretest"
+	"github.com/stretchr/testify/assert"
+)
+
+func SetupOverrides(t *testing.T) (*assert.Assertions, Scope, TemplateOverridesRepository, TemplatesService) {
+	a, s := Setup(t)
+
+	db, close, err := database.NewAndMigrate(database.InMemorySqliteConfig, nil, nil)
+	t.Cleanup(close)
+	a.Nil(err)
+
+	repository := NewTemplateOverridesRepository(db)
+	service := NewTemplatesService(stime.NewStaticTimeService(), s.templates, repository)
+
+	return a, s, repository, service
+}
+
+func strPtr(s string) *string {
+	return &s
+}
+
+func Test_TemplateOverrides_Repository(t *testing.T) {
+	a, s, repository, _ := SetupOverrides(t)
+
+	tenantID := s.claims.TenantID.String()
+
+	override := client.EmailTemplateOverride{
+		TenantID:     tenantID,
+		Template:     "invite",
+		Subject:      strPtr("Join {{.Tenant.Name}}"),
+		PrimaryColor: strPtr("#112233"),
+		UpdatedBy:    s.claims.Subject,
+	}
+	a.Nil(repository.save(context.Background(), override))
+
+	// Saving again replaces what was there
+	override.Subject = strPtr("Welcome to {{.Tenant.Name}}")
+	a.Nil(repository.save(context.Background(), override))
+
+	found, err := repository.get(context.Background(), tenantID, "invite")
+	a.Nil(err)
+	a.Equal("Welcome to {{.Tenant.Name}}", *found.Subject)
+	a.Equal("#112233", *found.PrimaryColor)
+	a.Nil(found.Html)
+
+	list, err := repository.list(context.Background(), tenantID)
+	a.Nil(err)
+	a.Len(list, 1)
+
+	list, err = repository.list(context.Background(), "other")
+	a.Nil(err)
+	a.Len(list, 0)
+
+	a.Nil(repository.delete(context.Background(), tenantID, "invite"))
+	a.NotNil(repository.delete(context.Background(), tenantID, "invite"))
+
+	_, err = repository.get(context.Background(), tenantID, "invite")
+	a.NotNil(err)
+}
+
+func Test_TenantTemplates_FallBack(t *testing.T) {
+	a, s, repository, service := SetupOverrides(t)
+
+	_, err := service.SaveTemplateOverride(context.Background(), s.claims, "invite", client.UpdateEmailTemplateOverride{
+		SenderName:   strPtr("Acme Support"),
+		Subject:      strPtr("{{.Inviter.FirstName}} invited you to {{.Tenant.Name}}"),
+		LogoURL:      strPtr("https://acme.example/logo.png"),
+		PrimaryColor: strPtr("#112233"),
+		Text:         strPtr("Accept at {{.AcceptInvitationURL}}"),
+	})
+	a.Nil(err)
+
+	mock := NewMockNotificationsService(MockConfig{From: "noreply@moovtest.io"}, NewTenantTemplateRepository(s.templates, repository))
+
+	inviter := client.Identity{FirstName: "John"}
+
+	invite := NewInviteEmail("https://localhost/accept", inviter, authnlib.Tenant{TenantID: s.claims.TenantID.String(), Name: "Acme"}, nil)
+	a.Nil(mock.SendEmail(context.Background(), "invited@moovtest.io", &invite))
+
+	other := NewInviteEmail("https://localhost/accept", inviter, authnlib.Tenant{TenantID: "other", Name: "Other"}, nil)
+	a.Nil(mock.SendEmail(context.Background(), "invited@moovtest.io", &other))
+
+	sent := mock.Sent()
+	a.Len(sent, 2)
+
+	from, err := mail.ParseAddress(sent[0].From)
+	a.Nil(err)
+	a.Equal("Acme Support", from.Name)
+	a.Equal("John invited you to Acme", sent[0].Subject)
+	a.Equal("Accept at https://localhost/accept", sent[0].Text)
+	a.Contains(sent[0].HTML, "https://acme.example/logo.png")
+	a.Contains(sent[0].HTML, "#112233")
+	a.NotContains(sent[0].HTML, DefaultBranding().PrimaryColor)
+
+	// Tenants without an override get the built-in templates
+	a.Equal("noreply@moovtest.io", sent[1].From)
+	a.Equal("Invite for Moov.io!", sent[1].Subject)
+	a.Contains(sent[1].HTML, DefaultBranding().LogoURL)
+	a.Contains(sent[1].HTML, DefaultBranding().PrimaryColor)
+}
+
+// countingOverrides - Counts the lookups of overrides and the contexts they're made with.
+type countingOverrides struct {
+	TemplateOverridesRepository
+	contexts []context.Context
+}
+
+func (c *countingOverrides) get(ctx context.Context, tenantID string, template string) (*client.EmailTemplateOverride, error) {
+	c.contexts = append(c.contexts, ctx)
+	return c.TemplateOverridesRepository.get(ctx, tenantID, template)
+}
+
+func Test_TenantTemplates_LookedUpOnce(t *testing.T) {
+	a, s, repository, _ := SetupOverrides(t)
+
+	counting := &countingOverrides{TemplateOverridesRepository: repository}
+	mock := NewMockNotificationsService(MockConfig{From: "noreply@moovtest.io"}, NewTenantTemplateRepository(s.templates, counting))
+
+	type key struct{}
+	ctx := context.WithValue(context.Background(), key{}, "sending")
+
+	invite := NewInviteEmail("https://localhost/accept", client.Identity{}, authnlib.Tenant{TenantID: s.claims.TenantID.String(), Name: "Acme"}, nil)
+	a.Nil(mock.SendEmail(ctx, "invited@moovtest.io", &invite))
+
+	a.Len(counting.contexts, 1)
+	a.Equal("sending", counting.contexts[0].Value(key{}))
+
+	// Nothing goes out once the context its sent under is done
+	cancelled, cancel := context.WithCancel(context.Background())
+	cancel()
+	a.NotNil(mock.SendEmail(cancelled, "invited@moovtest.io", &invite))
+}
+
+func Test_TemplateOverrides_Service(t *testing.T) {
+	a, s, _, service := SetupOverrides(t)
+
+	_, err := service.GetTemplateOverride(context.Background(), s.claims, "invite")
+	a.Equal(ErrTemplateOverrideNotFound, err)
+
+	_, err = service.GetTemplateOverride(context.Background(), s.claims, "unknown")
+	a.Equal(ErrUnknownTemplate, err)
+
+	saved, err := service.SaveTemplateOverride(context.Background(), s.claims, "welcome", client.UpdateEmailTemplateOverride{
+		Subject: strPtr("Welcome {{.Identity.FirstName}}"),
+	})
+	a.Nil(err)
+	a.Equal(s.claims.Subject, saved.UpdatedBy)
+
+	found, err := service.GetTemplateOverride(context.Background(), s.claims, "welcome")
+	a.Nil(err)
+	a.Equal(saved.Subject, found.Subject)
+
+	list, err := service.ListTemplateOverrides(context.Background(), s.claims)
+	a.Nil(err)
+	a.Len(list, 1)
+
+	a.Nil(service.DeleteTemplateOverride(context.Background(), s.claims, "welcome"))
+	a.Equal(ErrTemplateOverrideNotFound, service.DeleteTemplateOverride(context.Background(), s.claims, "welcome"))
+}
+
+func Test_TemplateOverrides_Invalid(t *testing.T) {
+	a, s, _, service := SetupOverrides(t)
+
+	_, err := service.SaveTemplateOverride(context.Background(), s.claims, "invite", client.UpdateEmailTemplateOverride{
+		SenderName:   strPtr("Acme\r\nBcc: everyone@example.com"),
+		PrimaryColor: strPtr("blue"),
+	})
+	errs, ok := err.(validation.Errors)
+	a.True(ok)
+	a.Contains(errs, "senderName")
+	a.Contains(errs, "primaryColor")
+
+	// Templates are checked against the fields the email actually has
+	_, err = service.SaveTemplateOverride(context.Background(), s.claims, "invite", client.UpdateEmailTemplateOverride{
+		Subject: strPtr("{{.Missing}}"),
+		Html:    strPtr("<p>{{.Inviter.FirstName}</p>"),
+	})
+	errs, ok = err.(validation.Errors)
+	a.True(ok)
+	a.Contains(errs, "subject")
+	a.Contains(errs, "html")
+	a.NotContains(errs, "text")
+
+	list, err := service.ListTemplateOverrides(context.Background(), s.claims)
+	a.Nil(err)
+	a.Len(list, 0)
+}
+
+func Test_TemplateOverrides_PreviewAPI(t *testing.T) {
+	a, s, _, service := SetupOverrides(t)
+
+	routes := mux.NewRouter()
+	api.AppendRouters(s.logger, routes, NewTemplatesController(s.logger, service))
+	routes.Use(middlewaretest.NewTestMiddleware(stime.NewStaticTimeService(), s.claims).Handler)
+
+	request := func(method string, path string, body interface{}) *http.Response {
+		buf := &bytes.Buffer{}
+		if body != nil {
+			a.Nil(json.NewEncoder(buf).Encode(body))
+		}
+
+		rec := httptest.NewRecorder()
+		routes.ServeHTTP(rec, httptest.NewRequest(method, path, buf))
+		return rec.Result()
+	}
+
+	// Without a body and nothing saved its the built-in email
+	res := request("POST", "/templates/invite/preview", nil)
+	a.Equal(200, res.StatusCode)
+
+	preview := client.EmailTemplatePreview{}
+	a.Nil(json.NewDecoder(res.Body).Decode(&preview))
+	a.Equal("Invite for Moov.io!", preview.Subject)
+	a.Contains(preview.Html, DefaultBranding().LogoURL)
+
+	res = request("POST", "/templates/invite/preview", client.UpdateEmailTemplateOverride{
+		SenderName: strPtr("Acme"),
+		Subject:    strPtr("Join {{.Tenant.Name}}"),
+	})
+	a.Equal(200, res.StatusCode)
+	a.Nil(json.NewDecoder(res.Body).Decode(&preview))
+	a.Equal("Acme", preview.SenderName)
+	a.Equal("Join Example", preview.Subject)
+
+	// Previews aren't saved
+	res = request("GET", "/templates/invite", nil)
+	a.Equal(404, res.StatusCode)
+
+	res = request("PUT", "/templates/invite", client.UpdateEmailTemplateOverride{Subject: strPtr("{{.Missing}}")})
+	a.Equal(400, res.StatusCode)
+
+	res = request("PUT", "/templates/invite", client.UpdateEmailTemplateOverride{Subject: strPtr("Join us")})
+	a.Equal(200, res.StatusCode)
+
+	res = request("POST", "/templates/invite/preview", nil)
+	a.Equal(200, res.StatusCode)
+	a.Nil(json.NewDecoder(res.Body).Decode(&preview))
+	a.Equal("Join us", preview.Subject)
+
+	res = request("GET", "/templates", nil)
+	a.Equal(200, res.StatusCode)
+
+	res = request("POST", "/templates/unknown/preview", nil)
+	a.Equal(404, res.StatusCode)
+
+	res = request("DELETE", "/templates/invite", nil)
+	a.Equal(204, res.StatusCode)
+}
+
+func Test_RenderedEmail_SenderName(t *testing.T) {
+	a, s := Setup(t)
+
+	welcome := NewWelcomeEmail("https://localhost/login", client.Identity{FirstName: "John"})
+	rendered, err := renderEmail(context.Background(), s.templates, "noreply@moovtest.io", "john@moovtest.io", &welcome)
+	a.Nil(err)
+
+	rendered.SenderName = "Acme Support"
+
+	buf := &bytes.Buffer{}
+	_, err = rendered.message().WriteTo(buf)
+	a.Nil(err)
+
+	msg, err := mail.ReadMessage(buf)
+	a.Nil(err)
+
+	from, err := mail.ParseAddress(msg.Header.Get("From"))
+	a.Nil(err)
+	a.Equal("Acme Support", from.Name)
+	a.Equal("noreply@moovtest.io", from.Address)
+}
@@ -0,0 +1,82 @@
+package outbox
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/gorilla/mux"
+	api "github.com/moov-io/identity/pkg/api"
+	"github.com/moov-io/identity/pkg/logging"
+)
+
+// A Controller binds http requests to an api service and writes the service results to the http response.
+// These routes span every tenant so they only belong on the admin server.
+type controller struct {
+	logger  logging.Logger
+	service Service
+}
+
+// NewOutboxAdminController creates a default api controller
+func NewOutboxAdminController(logger logging.Logger, s Service) api.Router {
+	return &controller{
+		logger:  logger,
+		service: s,
+	}
+}
+
+// Routes returns all of the api route for the OutboxAdminController
+func (c *controller) Routes() api.Routes {
+	return api.Routes{
+		{
+			Name:        "ListOutboxMessages",
+			Method:      strings.ToUpper("Get"),
+			Pattern:     "/outbox",
+			HandlerFunc: c.ListOutboxMessages,
+		},
+		{
+			Name:        "GetOutboxMessage",
+			Method:      strings.ToUpper("Get"),
+			Pattern:     "/outbox/{messageID}",
+			HandlerFunc: c.GetOutboxMessage,
+		},
+		{
+			Name:        "RedriveOutboxMessage",
+			Method:      strings.ToUpper("Post"),
+			Pattern:     "/outbox/{messageID}/redrive",
+			HandlerFunc: c.RedriveOutboxMessage,
+		},
+	}
+}
+
+// ListOutboxMessages - Newest messages of the outbox, optionally only those with a status
+func (c *controller) ListOutboxMessages(w http.ResponseWriter, r *http.Request) {
+	result, err := c.service.ListMessages(r.Context(), r.URL.Query().Get("status"))
+	if err != nil {
+		api.EncodeError(w, c.logger.LogError("unable to list outbox messages", err))
+		return
+	}
+
+	api.EncodeJSONResponse(result, nil, w)
+}
+
+// GetOutboxMessage - Where delivery of a message is at
+func (c *controller) GetOutboxMessage(w http.ResponseWriter, r *http.Request) {
+	result, err := c.service.GetMessage(r.Context(), mux.Vars(r)["messageID"])
+	if err != nil {
+		api.EncodeError(w, c.logger.LogError("unable to get outbox message", err))
+		return
+	}
+
+	api.EncodeJSONResponse(result, nil, w)
+}
+
+// RedriveOutboxMessage - Puts a message that hasn't been sent back in line for delivery
+func (c *controller) RedriveOutboxMessage(w http.ResponseWriter, r *http.Request) {
+	result, err := c.service.Redrive(r.Context(), mux.Vars(r)["messageID"])
+	if err != nil {
+		api.EncodeError(w, c.logger.LogError("unable to redrive outbox message", err))
+		return
+	}
+
+	api.EncodeJSONResponse(result, nil, w)
+}
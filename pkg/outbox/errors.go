@@ -0,0 +1,12 @@
+package outbox
+
+import "github.com/moov-io/identity/pkg/apperr"
+
+// ErrMessageNotFound is issued when there isn't a message in the outbox with the ID.
+var ErrMessageNotFound = apperr.NotFound("outbox message not found")
+
+// ErrMessageAlreadySent is issued when trying to redrive a message that was already delivered.
+var ErrMessageAlreadySent = apperr.Conflict("outbox message was already sent")
+
+// ErrUnknownStatus is issued when listing messages by a status that doesn't exist.
+var ErrUnknownStatus = apperr.Validation("unknown outbox message status", nil)
@@ -0,0 +1,40 @@
+package outbox
+
+import "time"
+
+// Config - How the dispatcher delivers what's in the outbox. Anything left out falls back to a default.
+type Config struct {
+	// How often to look for messages that are due.
+	PollInterval time.Duration
+	// Most messages delivered on each poll.
+	BatchSize int
+	// Attempts before a message is given up on and moved to dead.
+	MaxAttempts int
+	// Wait after the first failure, doubled after each one that follows up to MaxBackoff.
+	InitialBackoff time.Duration
+	MaxBackoff     time.Duration
+	// How long a message is held by the dispatcher delivering it before another one can pick it up.
+	Lease time.Duration
+}
+
+func (c Config) withDefaults() Config {
+	if c.PollInterval <= 0 {
+		c.PollInterval = 5 * time.Second
+	}
+	if c.BatchSize <= 0 {
+		c.BatchSize = 25
+	}
+	if c.MaxAttempts <= 0 {
+		c.MaxAttempts = 8
+	}
+	if c.InitialBackoff <= 0 {
+		c.InitialBackoff = 30 * time.Second
+	}
+	if c.MaxBackoff <= 0 {
+		c.MaxBackoff = time.Hour
+	}
+	if c.Lease <= 0 {
+		c.Lease = 5 * time.Minute
+	}
+	return c
+}
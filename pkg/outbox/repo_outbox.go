@@ -0,0 +1,248 @@
+package outbox
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/moov-io/identity/pkg/client"
+	"github.com/moov-io/identity/pkg/database"
+)
+
+// Repository - Used for keeping the messages of the outbox on the data store
+type Repository interface {
+	add(ctx context.Context, tx *sql.Tx, msg message) error
+	list(ctx context.Context, status string, limit int) ([]client.OutboxMessage, error)
+	get(ctx context.Context, messageID string) (*client.OutboxMessage, error)
+	listDue(ctx context.Context, now time.Time, limit int) ([]message, error)
+	claim(ctx context.Context, messageID string, attempts int64, leaseUntil time.Time) (bool, error)
+	update(ctx context.Context, msg client.OutboxMessage) error
+}
+
+// NewOutboxRepository - Builds a new repository tied to the DB passed in.
+func NewOutboxRepository(db *sql.DB) Repository {
+	return &sqlOutboxRepo{db: db, dialect: database.DialectOf(db)}
+}
+
+type sqlOutboxRepo struct {
+	db      *sql.DB
+	dialect database.Dialect
+}
+
+// message - A message of the outbox along with the payload its email is rebuilt from.
+type message struct {
+	client.OutboxMessage
+	payload string
+}
+
+// add - Saves the message as part of the transaction of the change that caused it.
+func (r *sqlOutboxRepo) add(ctx context.Context, tx *sql.Tx, msg message) error {
+	_, err := tx.ExecContext(ctx, r.dialect.Rebind(`
+		INSERT INTO outbox (
+			message_id,
+			tenant_id,
+			recipient,
+			template,
+			subject,
+			payload,
+			status,
+			attempts,
+			last_error,
+			created_on,
+			next_attempt_on,
+			sent_on
+		) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`),
+		msg.MessageID,
+		msg.TenantID,
+		msg.Recipient,
+		msg.Template,
+		msg.Subject,
+		msg.payload,
+		msg.Status,
+		msg.Attempts,
+		msg.LastError,
+		msg.CreatedOn,
+		msg.NextAttemptOn.UTC(),
+		msg.SentOn)
+
+	return err
+}
+
+// list - Newest messages first, only those with the status when its not empty.
+func (r *sqlOutboxRepo) list(ctx context.Context, status string, limit int) ([]client.OutboxMessage, error) {
+	qry := fmt.Sprintf(`
+		SELECT %s
+		FROM outbox
+		WHERE ? = '' OR status = ?
+		ORDER BY created_on DESC
+		LIMIT ?
+	`, outboxSelect)
+
+	return r.queryScan(ctx, qry, status, status, limit)
+}
+
+func (r *sqlOutboxRepo) get(ctx context.Context, messageID string) (*client.OutboxMessage, error) {
+	qry := fmt.Sprintf(`
+		SELECT %s
+		FROM outbox
+		WHERE message_id = ?
+		LIMIT 1
+	`, outboxSelect)
+
+	res, err := r.queryScan(ctx, qry, messageID)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(res) != 1 {
+		return nil, sql.ErrNoRows
+	}
+
+	return &res[0], nil
+}
+
+// listDue - Pending messages whose next attempt has come, oldest first.
+// Times are kept in UTC so they compare correctly on SQLite where they're stored as text.
+func (r *sqlOutboxRepo) listDue(ctx context.Context, now time.Time, limit int) ([]message, error) {
+	qry := fmt.Sprintf(`
+		SELECT %s, payload
+		FROM outbox
+		WHERE status = ? AND next_attempt_on <= ?
+		ORDER BY next_attempt_on
+		LIMIT ?
+	`, outboxSelect)
+
+	rows, err := r.db.QueryContext(ctx, r.dialect.Rebind(qry), StatusPending, now.UTC(), limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	items := []message{}
+	for rows.Next() {
+		item := message{}
+		if err := rows.Scan(append(scanOutboxMessage(&item.OutboxMessage), &item.payload)...); err != nil {
+			return nil, err
+		}
+
+		items = append(items, item)
+	}
+
+	return items, rows.Err()
+}
+
+// claim - Takes the message for one delivery attempt so no other dispatcher tries it at the same time.
+// The attempts act as a version so only one of them can win, and the message is pushed back till the lease
+// runs out in case the dispatcher dies before recording how it went.
+func (r *sqlOutboxRepo) claim(ctx context.Context, messageID string, attempts int64, leaseUntil time.Time) (bool, error) {
+	res, err := r.db.ExecContext(ctx, r.dialect.Rebind(`
+		UPDATE outbox
+		SET
+			attempts = attempts + 1,
+			next_attempt_on = ?
+		WHERE
+			message_id = ? AND
+			status = ? AND
+			attempts = ?
+	`), leaseUntil.UTC(), messageID, StatusPending, attempts)
+	if err != nil {
+		return false, err
+	}
+
+	cnt, err := res.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+
+	return cnt == 1, nil
+}
+
+func (r *sqlOutboxRepo) update(ctx context.Context, msg client.OutboxMessage) error {
+	res, err := r.db.ExecContext(ctx, r.dialect.Rebind(`
+		UPDATE outbox
+		SET
+			status = ?,
+			attempts = ?,
+			last_error = ?,
+			next_attempt_on = ?,
+			sent_on = ?
+		WHERE
+			message_id = ?
+	`),
+		msg.Status,
+		msg.Attempts,
+		msg.LastError,
+		msg.NextAttemptOn.UTC(),
+		msg.SentOn,
+		msg.MessageID)
+	if err != nil {
+		return err
+	}
+
+	cnt, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if cnt == 0 {
+		return sql.ErrNoRows
+	}
+
+	return nil
+}
+
+// Matches the order pulled in by scanOutboxMessage, the payload is left out on purpose.
+var outboxSelect = `
+	outbox.message_id,
+	outbox.tenant_id,
+	outbox.recipient,
+	outbox.template,
+	outbox.subject,
+	outbox.status,
+	outbox.attempts,
+	outbox.last_error,
+	outbox.created_on,
+	outbox.next_attempt_on,
+	outbox.sent_on
+`
+
+func scanOutboxMessage(item *client.OutboxMessage) []interface{} {
+	return []interface{}{
+		&item.MessageID,
+		&item.TenantID,
+		&item.Recipient,
+		&item.Template,
+		&item.Subject,
+		&item.Status,
+		&item.Attempts,
+		&item.LastError,
+		&item.CreatedOn,
+		&item.NextAttemptOn,
+		&item.SentOn,
+	}
+}
+
+func (r *sqlOutboxRepo) queryScan(ctx context.Context, query string, args ...interface{}) ([]client.OutboxMessage, error) {
+	rows, err := r.db.QueryContext(ctx, r.dialect.Rebind(query), args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	items := []client.OutboxMessage{}
+	for rows.Next() {
+		item := client.OutboxMessage{}
+		if err := rows.Scan(scanOutboxMessage(&item)...); err != nil {
+			return nil, err
+		}
+
+		items = append(items, item)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return items, nil
+}
@@ -0,0 +1,96 @@
+package outbox
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/moov-io/identity/pkg/client"
+	"github.com/moov-io/identity/pkg/database"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_Repository_ClaimAndUpdate(t *testing.T) {
+	ForEachDatabase(t, func(t *testing.T, a *require.Assertions, db *sql.DB, repository Repository) {
+		now := time.Now().UTC().Round(time.Second)
+
+		due := addTestingMessage(a, db, repository, now)
+		later := addTestingMessage(a, db, repository, now.Add(time.Hour))
+
+		found, err := repository.listDue(context.Background(), now, 10)
+		a.NoError(err)
+		a.Len(found, 1)
+		a.Equal(due.MessageID, found[0].MessageID)
+		a.Equal(due.payload, found[0].payload)
+
+		// Only one dispatcher gets it.
+		claimed, err := repository.claim(context.Background(), due.MessageID, 0, now.Add(time.Minute))
+		a.NoError(err)
+		a.True(claimed)
+
+		claimed, err = repository.claim(context.Background(), due.MessageID, 0, now.Add(time.Minute))
+		a.NoError(err)
+		a.False(claimed)
+
+		sentOn := now
+		due.Status = StatusSent
+		due.Attempts = 1
+		due.SentOn = &sentOn
+		a.NoError(repository.update(context.Background(), due.OutboxMessage))
+
+		sent, err := repository.list(context.Background(), StatusSent, 10)
+		a.NoError(err)
+		a.Len(sent, 1)
+		a.Equal(due.MessageID, sent[0].MessageID)
+		a.Equal(int64(1), sent[0].Attempts)
+		a.NotNil(sent[0].SentOn)
+
+		all, err := repository.list(context.Background(), "", 10)
+		a.NoError(err)
+		a.Len(all, 2)
+
+		pending, err := repository.get(context.Background(), later.MessageID)
+		a.NoError(err)
+		a.Equal(StatusPending, pending.Status)
+
+		_, err = repository.get(context.Background(), uuid.New().String())
+		a.Equal(sql.ErrNoRows, err)
+
+		missing := later.OutboxMessage
+		missing.MessageID = uuid.New().String()
+		a.Equal(sql.ErrNoRows, repository.update(context.Background(), missing))
+	})
+}
+
+func ForEachDatabase(t *testing.T, run func(t *testing.T, a *require.Assertions, db *sql.DB, repository Repository)) {
+	database.ForEachDatabase(t, func(t *testing.T, db *sql.DB) {
+		run(t, require.New(t), db, NewOutboxRepository(db))
+	})
+}
+
+func addTestingMessage(a *require.Assertions, db *sql.DB, repository Repository, nextAttemptOn time.Time) message {
+	msg := message{
+		OutboxMessage: client.OutboxMessage{
+			MessageID:     uuid.New().String(),
+			TenantID:      uuid.New().String(),
+			Recipient:     "someone@moov.io",
+			Template:      "welcome.template",
+			Subject:       "Welcome",
+			Status:        StatusPending,
+			CreatedOn:     nextAttemptOn,
+			NextAttemptOn: nextAttemptOn,
+		},
+		payload: `{"Subject":"Welcome"}`,
+	}
+
+	tx, err := db.Begin()
+	a.NoError(err)
+	defer tx.Rollback()
+
+	a.NoError(repository.add(context.Background(), tx, msg))
+	a.NoError(tx.Commit())
+
+	return msg
+}
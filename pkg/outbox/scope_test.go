@@ -0,0 +1,86 @@
+package outbox
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gorilla/mux"
+	api "github.com/moov-io/identity/pkg/api"
+	"github.com/moov-io/identity/pkg/database"
+	"github.com/moov-io/identity/pkg/logging"
+	"github.com/moov-io/identity/pkg/notifications"
+	"github.com/moov-io/identity/pkg/stime"
+	"github.com/stretchr/testify/require"
+)
+
+type Scope struct {
+	db            *sql.DB
+	time          stime.StaticTimeService
+	notifications *flakyNotifications
+	repository    Repository
+	service       Service
+	routes        *mux.Router
+}
+
+func Setup(t *testing.T, config Config) (*require.Assertions, Scope) {
+	a := require.New(t)
+	logging := logging.NewNopLogger()
+	times := stime.NewStaticTimeService()
+
+	db, close, err := database.NewAndMigrate(database.InMemorySqliteConfig, nil, nil)
+	t.Cleanup(close)
+	a.Nil(err)
+
+	notifications := &flakyNotifications{}
+	repository := NewOutboxRepository(db)
+	service := NewOutboxService(config, logging, times, repository, notifications)
+	t.Cleanup(service.Stop)
+
+	routes := mux.NewRouter()
+	api.AppendRouters(logging, routes, NewOutboxAdminController(logging, service))
+
+	return a, Scope{
+		db:            db,
+		time:          times,
+		notifications: notifications,
+		repository:    repository,
+		service:       service,
+		routes:        routes,
+	}
+}
+
+// Enqueue - Adds the email to the outbox in its own committed transaction.
+func (s *Scope) Enqueue(a *require.Assertions, to string, email notifications.EmailTemplate) {
+	tx, err := s.db.Begin()
+	a.Nil(err)
+	defer tx.Rollback()
+
+	a.Nil(s.service.Email(context.Background(), tx, "tenant", to, email))
+	a.Nil(tx.Commit())
+}
+
+func (s *Scope) Request(method string, path string) *http.Response {
+	rec := httptest.NewRecorder()
+	s.routes.ServeHTTP(rec, httptest.NewRequest(method, path, nil))
+	return rec.Result()
+}
+
+// flakyNotifications - Fails the number of sends its told to before letting them through.
+type flakyNotifications struct {
+	failures int
+	sent     []notifications.EmailTemplate
+}
+
+func (n *flakyNotifications) SendEmail(ctx context.Context, to string, email notifications.EmailTemplate) error {
+	if n.failures > 0 {
+		n.failures--
+		return errors.New("smtp unavailable")
+	}
+
+	n.sent = append(n.sent, email)
+	return nil
+}
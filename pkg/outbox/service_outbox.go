@@ -0,0 +1,287 @@
+package outbox
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/moov-io/identity/pkg/client"
+	"github.com/moov-io/identity/pkg/logging"
+	"github.com/moov-io/identity/pkg/notifications"
+	"github.com/moov-io/identity/pkg/stime"
+)
+
+// Statuses of a message in the outbox
+const (
+	StatusPending = "pending"
+	StatusSent    = "sent"
+	StatusDead    = "dead"
+)
+
+// Most messages returned when listing them
+const maxListed = 100
+
+// Longest error kept for a message
+const maxErrorLength = 255
+
+// Outbox - Records notifications as part of the transaction of the change that caused them.
+// Nothing is sent until the transaction commits and the dispatcher picks them up.
+type Outbox interface {
+	Email(ctx context.Context, tx *sql.Tx, tenantID string, to string, email notifications.EmailTemplate) error
+}
+
+// Filler - Puts back what was left out of an email when it was stored, like a link with a secret in it,
+// right before its sent.
+type Filler func(ctx context.Context, tenantID string, email notifications.EmailTemplate) error
+
+// Service - The outbox along with the dispatcher delivering what's in it and the admin actions on its messages.
+type Service interface {
+	Outbox
+	Fill(template string, filler Filler)
+	ListMessages(ctx context.Context, status string) ([]client.OutboxMessage, error)
+	GetMessage(ctx context.Context, messageID string) (*client.OutboxMessage, error)
+	Redrive(ctx context.Context, messageID string) (*client.OutboxMessage, error)
+	Dispatch(ctx context.Context) (int, error)
+	Start()
+	Stop()
+}
+
+type service struct {
+	config        Config
+	logger        logging.Logger
+	time          stime.TimeService
+	repository    Repository
+	notifications notifications.NotificationsService
+	fillers       map[string]Filler
+
+	stop    chan struct{}
+	stopped sync.WaitGroup
+}
+
+// NewOutboxService - Creates a default service
+func NewOutboxService(config Config, logger logging.Logger, time stime.TimeService, repository Repository, notifications notifications.NotificationsService) Service {
+	return &service{
+		config:        config.withDefaults(),
+		logger:        logger,
+		time:          time,
+		repository:    repository,
+		notifications: notifications,
+		fillers:       map[string]Filler{},
+	}
+}
+
+// Fill - Has the emails of the template run through the filler before they're sent. Only meant to be called while
+// starting up before anything is dispatched.
+func (s *service) Fill(template string, filler Filler) {
+	s.fillers[template] = filler
+}
+
+// Email - Adds the email to the outbox inside of the transaction passed in. Its due right away.
+func (s *service) Email(ctx context.Context, tx *sql.Tx, tenantID string, to string, email notifications.EmailTemplate) error {
+	payload, err := json.Marshal(email)
+	if err != nil {
+		return err
+	}
+
+	msg := message{
+		OutboxMessage: client.OutboxMessage{
+			MessageID:     uuid.New().String(),
+			TenantID:      tenantID,
+			Recipient:     to,
+			Template:      email.TemplateName(),
+			Subject:       email.EmailSubject(),
+			Status:        StatusPending,
+			Attempts:      0,
+			CreatedOn:     s.time.Now(),
+			NextAttemptOn: s.time.Now(),
+		},
+		payload: string(payload),
+	}
+
+	return s.repository.add(ctx, tx, msg)
+}
+
+// ListMessages - Newest messages first, limited to a status when one is given.
+func (s *service) ListMessages(ctx context.Context, status string) ([]client.OutboxMessage, error) {
+	switch status {
+	case "", StatusPending, StatusSent, StatusDead:
+	default:
+		return nil, ErrUnknownStatus
+	}
+
+	return s.repository.list(ctx, status, maxListed)
+}
+
+func (s *service) GetMessage(ctx context.Context, messageID string) (*client.OutboxMessage, error) {
+	msg, err := s.repository.get(ctx, messageID)
+	if err == sql.ErrNoRows {
+		return nil, ErrMessageNotFound
+	}
+	return msg, err
+}
+
+// Redrive - Puts a message back to pending with a fresh set of attempts and makes it due right away.
+// Mostly for messages that went dead after whatever was stopping them from being sent has been fixed.
+func (s *service) Redrive(ctx context.Context, messageID string) (*client.OutboxMessage, error) {
+	msg, err := s.GetMessage(ctx, messageID)
+	if err != nil {
+		return nil, err
+	}
+
+	if msg.Status == StatusSent {
+		return nil, ErrMessageAlreadySent
+	}
+
+	msg.Status = StatusPending
+	msg.Attempts = 0
+	msg.NextAttemptOn = s.time.Now()
+
+	if err := s.repository.update(ctx, *msg); err != nil {
+		return nil, err
+	}
+
+	return msg, nil
+}
+
+// Dispatch - Tries to deliver one batch of the messages that are due and returns how many were sent.
+func (s *service) Dispatch(ctx context.Context) (int, error) {
+	due, err := s.repository.listDue(ctx, s.time.Now(), s.config.BatchSize)
+	if err != nil {
+		return 0, err
+	}
+
+	sent := 0
+	for _, msg := range due {
+		claimed, err := s.repository.claim(ctx, msg.MessageID, msg.Attempts, s.time.Now().Add(s.config.Lease))
+		if err != nil {
+			return sent, err
+		}
+
+		// Another dispatcher got to it first.
+		if !claimed {
+			continue
+		}
+
+		msg.Attempts++
+		delivered, err := s.deliver(ctx, msg)
+		if err != nil {
+			return sent, err
+		}
+		if delivered {
+			sent++
+		}
+	}
+
+	return sent, nil
+}
+
+// Start - Dispatches in the background every poll interval until stopped.
+func (s *service) Start() {
+	s.stop = make(chan struct{})
+	s.stopped.Add(1)
+
+	go func() {
+		defer s.stopped.Done()
+
+		// Not cancelled by Stop so a message that was sent still gets recorded as sent.
+		ctx := context.Background()
+
+		ticker := time.NewTicker(s.config.PollInterval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-s.stop:
+				return
+			case <-ticker.C:
+				if _, err := s.Dispatch(ctx); err != nil {
+					s.logger.LogError("unable to dispatch outbox", err)
+				}
+			}
+		}
+	}()
+}
+
+// Stop - Stops the background dispatching and waits on the delivery thats in progress.
+func (s *service) Stop() {
+	if s.stop == nil {
+		return
+	}
+
+	close(s.stop)
+	s.stopped.Wait()
+	s.stop = nil
+}
+
+// deliver - Sends the message and records how it went. A failure is scheduled to be retried after the backoff
+// unless it was the last attempt, then its moved to dead. The error is only for not being able to record it.
+func (s *service) deliver(ctx context.Context, msg message) (bool, error) {
+	logger := s.logger.WithMap(map[string]string{
+		"message_id":     msg.MessageID,
+		"email_template": msg.Template,
+	})
+
+	// Past the lease another dispatcher can claim it, so the send is given up on by then.
+	sendCtx, cancel := context.WithTimeout(ctx, s.config.Lease)
+	sendErr := s.send(sendCtx, msg)
+	cancel()
+	if sendErr == nil {
+		sentOn := s.time.Now()
+		msg.Status = StatusSent
+		msg.SentOn = &sentOn
+		msg.LastError = nil
+		return true, s.repository.update(ctx, msg.OutboxMessage)
+	}
+
+	errMsg := sendErr.Error()
+	if len(errMsg) > maxErrorLength {
+		errMsg = errMsg[:maxErrorLength]
+	}
+	msg.LastError = &errMsg
+
+	if msg.Attempts >= int64(s.config.MaxAttempts) {
+		msg.Status = StatusDead
+		logger.LogError("outbox message is dead after its last attempt", sendErr)
+	} else {
+		msg.NextAttemptOn = s.time.Now().Add(s.backoff(msg.Attempts))
+		logger.LogError("unable to send outbox message, will retry", sendErr)
+	}
+
+	return false, s.repository.update(ctx, msg.OutboxMessage)
+}
+
+func (s *service) send(ctx context.Context, msg message) error {
+	email, err := notifications.NewEmailTemplate(msg.Template)
+	if err != nil {
+		return err
+	}
+
+	if err := json.Unmarshal([]byte(msg.payload), email); err != nil {
+		return err
+	}
+
+	if filler, ok := s.fillers[msg.Template]; ok {
+		if err := filler(ctx, msg.TenantID, email); err != nil {
+			return err
+		}
+	}
+
+	return s.notifications.SendEmail(ctx, msg.Recipient, email)
+}
+
+// backoff - How long to wait after a number of failed attempts, doubling each time up to the max.
+func (s *service) backoff(attempts int64) time.Duration {
+	wait := s.config.InitialBackoff
+	for i := int64(1); i < attempts && wait < s.config.MaxBackoff; i++ {
+		wait *= 2
+	}
+
+	if wait > s.config.MaxBackoff {
+		wait = s.config.MaxBackoff
+	}
+
+	return wait
+}
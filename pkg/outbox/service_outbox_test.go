@@ -0,0 +1,216 @@
+package outbox
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	authn "github.com/moov-io/authn/pkg/client"
+	"github.com/moov-io/identity/pkg/client"
+	"github.com/moov-io/identity/pkg/notifications"
+)
+
+func Test_Email_Transaction(t *testing.T) {
+	a, s := Setup(t, Config{})
+
+	welcome := notifications.NewWelcomeEmail("https://local.moov.io/login", client.Identity{FirstName: "John"})
+
+	// Rolled back along with the change it was part of.
+	tx, err := s.db.Begin()
+	a.Nil(err)
+	a.Nil(s.service.Email(context.Background(), tx, "tenant", "rolled@moov.io", &welcome))
+	a.Nil(tx.Rollback())
+
+	s.Enqueue(a, "committed@moov.io", &welcome)
+
+	messages, err := s.service.ListMessages(context.Background(), "")
+	a.Nil(err)
+	a.Len(messages, 1)
+	a.Equal("committed@moov.io", messages[0].Recipient)
+	a.Equal(welcome.TemplateName(), messages[0].Template)
+	a.Equal(welcome.Subject, messages[0].Subject)
+	a.Equal(StatusPending, messages[0].Status)
+}
+
+func Test_Dispatch(t *testing.T) {
+	a, s := Setup(t, Config{})
+
+	invite := notifications.NewInviteEmail("https://local.moov.io/accept?invite_code=abc", client.Identity{FirstName: "John"}, authn.Tenant{Name: "Moov"}, nil)
+	invite.InviteID = "invite-id"
+	s.Enqueue(a, "invited@moov.io", &invite)
+
+	// The link with the secret code in it isn't stored.
+	payload := ""
+	a.Nil(s.db.QueryRow("SELECT payload FROM outbox").Scan(&payload))
+	a.Contains(payload, "invite-id")
+	a.NotContains(payload, "invite_code=abc")
+
+	s.service.Fill(invite.TemplateName(), func(ctx context.Context, tenantID string, email notifications.EmailTemplate) error {
+		a.Equal("tenant", tenantID)
+		filling := email.(*notifications.InviteEmail)
+		filling.AcceptInvitationURL = "https://local.moov.io/accept?invite_code=abc"
+		return nil
+	})
+
+	sent, err := s.service.Dispatch(context.Background())
+	a.Nil(err)
+	a.Equal(1, sent)
+
+	// The email is rebuilt from the outbox just like it was queued, except for the branding which is worked out again when its rendered.
+	a.Len(s.notifications.sent, 1)
+	invite.Branded = notifications.Branded{}
+	a.Equal(&invite, s.notifications.sent[0])
+
+	messages, err := s.service.ListMessages(context.Background(), StatusSent)
+	a.Nil(err)
+	a.Len(messages, 1)
+	a.Equal(int64(1), messages[0].Attempts)
+	a.NotNil(messages[0].SentOn)
+
+	// Nothing left to send.
+	sent, err = s.service.Dispatch(context.Background())
+	a.Nil(err)
+	a.Equal(0, sent)
+	a.Len(s.notifications.sent, 1)
+}
+
+func Test_Dispatch_BackoffAndDeadLetter(t *testing.T) {
+	a, s := Setup(t, Config{MaxAttempts: 3, InitialBackoff: time.Minute, MaxBackoff: 90 * time.Second})
+	s.notifications.failures = 100
+
+	welcome := notifications.NewWelcomeEmail("https://local.moov.io/login", client.Identity{})
+	s.Enqueue(a, "retry@moov.io", &welcome)
+
+	sent, err := s.service.Dispatch(context.Background())
+	a.Nil(err)
+	a.Equal(0, sent)
+
+	messages, err := s.service.ListMessages(context.Background(), StatusPending)
+	a.Nil(err)
+	a.Len(messages, 1)
+	msg := messages[0]
+	a.Equal(int64(1), msg.Attempts)
+	a.Equal("smtp unavailable", *msg.LastError)
+	a.True(msg.NextAttemptOn.Equal(s.time.Now().Add(time.Minute)))
+
+	// Not due yet so its left alone.
+	_, err = s.service.Dispatch(context.Background())
+	a.Nil(err)
+	found, err := s.service.GetMessage(context.Background(), msg.MessageID)
+	a.Nil(err)
+	a.Equal(int64(1), found.Attempts)
+
+	// Doubled, but held to the max backoff.
+	s.time.Add(time.Minute)
+	_, err = s.service.Dispatch(context.Background())
+	a.Nil(err)
+	found, err = s.service.GetMessage(context.Background(), msg.MessageID)
+	a.Nil(err)
+	a.Equal(int64(2), found.Attempts)
+	a.True(found.NextAttemptOn.Equal(s.time.Now().Add(90 * time.Second)))
+
+	// Last attempt moves it to dead.
+	s.time.Add(90 * time.Second)
+	_, err = s.service.Dispatch(context.Background())
+	a.Nil(err)
+	found, err = s.service.GetMessage(context.Background(), msg.MessageID)
+	a.Nil(err)
+	a.Equal(StatusDead, found.Status)
+	a.Equal(int64(3), found.Attempts)
+
+	s.time.Add(time.Hour)
+	_, err = s.service.Dispatch(context.Background())
+	a.Nil(err)
+	a.Len(s.notifications.sent, 0)
+
+	// Once whatever was wrong is fixed it can be sent again.
+	s.notifications.failures = 0
+	redriven, err := s.service.Redrive(context.Background(), msg.MessageID)
+	a.Nil(err)
+	a.Equal(StatusPending, redriven.Status)
+	a.Equal(int64(0), redriven.Attempts)
+
+	sent, err = s.service.Dispatch(context.Background())
+	a.Nil(err)
+	a.Equal(1, sent)
+
+	found, err = s.service.GetMessage(context.Background(), msg.MessageID)
+	a.Nil(err)
+	a.Equal(StatusSent, found.Status)
+	a.Nil(found.LastError)
+
+	_, err = s.service.Redrive(context.Background(), msg.MessageID)
+	a.Equal(ErrMessageAlreadySent, err)
+}
+
+func Test_Claim(t *testing.T) {
+	a, s := Setup(t, Config{})
+
+	welcome := notifications.NewWelcomeEmail("https://local.moov.io/login", client.Identity{})
+	s.Enqueue(a, "claimed@moov.io", &welcome)
+
+	due, err := s.repository.listDue(context.Background(), s.time.Now(), 10)
+	a.Nil(err)
+	a.Len(due, 1)
+
+	// Only one of two dispatchers that saw the message at the same time gets it.
+	claimed, err := s.repository.claim(context.Background(), due[0].MessageID, due[0].Attempts, s.time.Now().Add(time.Minute))
+	a.Nil(err)
+	a.True(claimed)
+
+	claimed, err = s.repository.claim(context.Background(), due[0].MessageID, due[0].Attempts, s.time.Now().Add(time.Minute))
+	a.Nil(err)
+	a.False(claimed)
+
+	// Held until the lease runs out.
+	due, err = s.repository.listDue(context.Background(), s.time.Now(), 10)
+	a.Nil(err)
+	a.Len(due, 0)
+
+	s.time.Add(time.Minute)
+	due, err = s.repository.listDue(context.Background(), s.time.Now(), 10)
+	a.Nil(err)
+	a.Len(due, 1)
+}
+
+func Test_OutboxAPI(t *testing.T) {
+	a, s := Setup(t, Config{MaxAttempts: 1})
+	s.notifications.failures = 1
+
+	welcome := notifications.NewWelcomeEmail("https://local.moov.io/login", client.Identity{})
+	s.Enqueue(a, "api@moov.io", &welcome)
+
+	_, err := s.service.Dispatch(context.Background())
+	a.Nil(err)
+
+	res := s.Request("GET", "/outbox?status=dead")
+	a.Equal(http.StatusOK, res.StatusCode)
+
+	dead := []client.OutboxMessage{}
+	a.Nil(json.NewDecoder(res.Body).Decode(&dead))
+	a.Len(dead, 1)
+
+	res = s.Request("GET", "/outbox/"+dead[0].MessageID)
+	a.Equal(http.StatusOK, res.StatusCode)
+
+	res = s.Request("POST", "/outbox/"+dead[0].MessageID+"/redrive")
+	a.Equal(http.StatusOK, res.StatusCode)
+
+	redriven := client.OutboxMessage{}
+	a.Nil(json.NewDecoder(res.Body).Decode(&redriven))
+	a.Equal(StatusPending, redriven.Status)
+
+	_, err = s.service.Dispatch(context.Background())
+	a.Nil(err)
+
+	res = s.Request("POST", "/outbox/"+dead[0].MessageID+"/redrive")
+	a.Equal(http.StatusConflict, res.StatusCode)
+
+	res = s.Request("GET", "/outbox/missing")
+	a.Equal(http.StatusNotFound, res.StatusCode)
+
+	res = s.Request("GET", "/outbox?status=lost")
+	a.Equal(http.StatusBadRequest, res.StatusCode)
+}
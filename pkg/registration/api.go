@@ -0,0 +1,139 @@
+package registration
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/gorilla/mux"
+	api "github.com/moov-io/identity/pkg/api"
+	"github.com/moov-io/identity/pkg/client"
+	"github.com/moov-io/identity/pkg/logging"
+	tmw "github.com/moov-io/tumbler/pkg/middleware"
+)
+
+// A Controller binds http requests to an api service and writes the service results to the http response
+type controller struct {
+	logger  logging.Logger
+	service Service
+}
+
+// NewRegistrationController creates a default api controller
+func NewRegistrationController(logger logging.Logger, s Service) api.Router {
+	return &controller{
+		logger:  logger,
+		service: s,
+	}
+}
+
+// Routes returns all of the api route for the RegistrationApiController
+func (c *controller) Routes() api.Routes {
+	return api.Routes{
+		{
+			Name:        "GetRegistrationPolicy",
+			Method:      strings.ToUpper("Get"),
+			Pattern:     "/registration/policy",
+			HandlerFunc: c.GetPolicy,
+		},
+		{
+			Name:        "UpdateRegistrationPolicy",
+			Method:      strings.ToUpper("Put"),
+			Pattern:     "/registration/policy",
+			HandlerFunc: c.UpdatePolicy,
+		},
+		{
+			Name:        "ListPendingRegistrations",
+			Method:      strings.ToUpper("Get"),
+			Pattern:     "/registration/pending",
+			HandlerFunc: c.ListPending,
+		},
+		{
+			Name:        "ApproveIdentity",
+			Method:      strings.ToUpper("Post"),
+			Pattern:     "/identities/{identityID}/approve",
+			HandlerFunc: c.Approve,
+		},
+		{
+			Name:        "RejectIdentity",
+			Method:      strings.ToUpper("Post"),
+			Pattern:     "/identities/{identityID}/reject",
+			HandlerFunc: c.Reject,
+		},
+	}
+}
+
+// GetPolicy - Returns how new users can register with the tenant
+func (c *controller) GetPolicy(w http.ResponseWriter, r *http.Request) {
+	tmw.WithClaimsFromRequest(w, r, func(claims tmw.TumblerClaims) {
+		result, err := c.service.GetPolicy(r.Context(), claims)
+		if err != nil {
+			api.EncodeError(w, c.logger.LogError("unable to get registration policy", err))
+			return
+		}
+
+		api.EncodeJSONResponse(result, nil, w)
+	})
+}
+
+// UpdatePolicy - Changes how new users can register with the tenant
+func (c *controller) UpdatePolicy(w http.ResponseWriter, r *http.Request) {
+	tmw.WithClaimsFromRequest(w, r, func(claims tmw.TumblerClaims) {
+		policy := client.RegistrationPolicy{}
+		if err := api.DecodeJSONRequest(r, &policy); err != nil {
+			api.EncodeError(w, err)
+			return
+		}
+
+		result, err := c.service.UpdatePolicy(r.Context(), claims, policy)
+		if err != nil {
+			api.EncodeError(w, c.logger.LogError("unable to update registration policy", err))
+			return
+		}
+
+		api.EncodeJSONResponse(result, nil, w)
+	})
+}
+
+// ListPending - Lists the registrations waiting on an admin to approve them
+func (c *controller) ListPending(w http.ResponseWriter, r *http.Request) {
+	tmw.WithClaimsFromRequest(w, r, func(claims tmw.TumblerClaims) {
+		result, err := c.service.ListPending(r.Context(), claims)
+		if err != nil {
+			api.EncodeError(w, c.logger.LogError("unable to list pending registrations", err))
+			return
+		}
+
+		api.EncodeJSONResponse(result, nil, w)
+	})
+}
+
+// Approve - Approves a pending registration and emails the identity a link to login
+func (c *controller) Approve(w http.ResponseWriter, r *http.Request) {
+	tmw.WithClaimsFromRequest(w, r, func(claims tmw.TumblerClaims) {
+		params := mux.Vars(r)
+		identityID := params["identityID"]
+
+		result, err := c.service.Approve(r.Context(), claims, identityID)
+		if err != nil {
+			api.EncodeError(w, c.logger.LogError("unable to approve registration", err))
+			return
+		}
+
+		api.EncodeJSONResponse(result, nil, w)
+	})
+}
+
+// Reject - Rejects a pending registration and disables the identity
+func (c *controller) Reject(w http.ResponseWriter, r *http.Request) {
+	tmw.WithClaimsFromRequest(w, r, func(claims tmw.TumblerClaims) {
+		params := mux.Vars(r)
+		identityID := params["identityID"]
+
+		result, err := c.service.Reject(r.Context(), claims, identityID)
+		if err != nil {
+			api.EncodeError(w, c.logger.LogError("unable to reject registration", err))
+			return
+		}
+
+		api.EncodeJSONResponse(result, nil, w)
+	})
+}
@@ -0,0 +1,73 @@
+package registration_test
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/moov-io/identity/pkg/client"
+)
+
+func Test_PolicyAPI(t *testing.T) {
+	a, s := Setup(t)
+
+	resp := s.Request("PUT", "/registration/policy", client.RegistrationPolicy{
+		Mode:           "domain",
+		AllowedDomains: []string{"example.com"},
+	})
+	a.Equal(200, resp.StatusCode)
+
+	resp = s.Request("GET", "/registration/policy", nil)
+	a.Equal(200, resp.StatusCode)
+
+	policy := client.RegistrationPolicy{}
+	a.Nil(json.NewDecoder(resp.Body).Decode(&policy))
+	a.Equal("domain", policy.Mode)
+	a.Equal([]string{"example.com"}, policy.AllowedDomains)
+}
+
+func Test_PolicyAPI_Invalid(t *testing.T) {
+	a, s := Setup(t)
+
+	resp := s.Request("PUT", "/registration/policy", client.RegistrationPolicy{Mode: "domain"})
+	a.Equal(400, resp.StatusCode)
+}
+
+func Test_ListPendingAPI_Empty(t *testing.T) {
+	a, s := Setup(t)
+
+	resp := s.Request("GET", "/registration/pending", nil)
+	a.Equal(200, resp.StatusCode)
+
+	pending := []client.Identity{}
+	a.Nil(json.NewDecoder(resp.Body).Decode(&pending))
+	a.Len(pending, 0)
+}
+
+func Test_ApproveAPI(t *testing.T) {
+	a, s := Setup(t)
+
+	pending := s.RegisterPending(a, "john@example.com")
+
+	resp := s.Request("POST", "/identities/"+pending.IdentityID+"/approve", nil)
+	a.Equal(200, resp.StatusCode)
+
+	approved := client.Identity{}
+	a.Nil(json.NewDecoder(resp.Body).Decode(&approved))
+	a.Equal(pending.IdentityID, approved.IdentityID)
+
+	resp = s.Request("POST", "/identities/"+pending.IdentityID+"/reject", nil)
+	a.Equal(409, resp.StatusCode)
+}
+
+func Test_RejectAPI(t *testing.T) {
+	a, s := Setup(t)
+
+	pending := s.RegisterPending(a, "john@example.com")
+
+	resp := s.Request("POST", "/identities/"+pending.IdentityID+"/reject", nil)
+	a.Equal(200, resp.StatusCode)
+
+	resp = s.Request("POST", "/identities/"+uuid.New().String()+"/reject", nil)
+	a.Equal(404, resp.StatusCode)
+}
@@ -0,0 +1,9 @@
+package registration
+
+import "github.com/moov-io/identity/pkg/apperr"
+
+// ErrRegistrationPendingApproval is issued when the identity was registered but has to be approved before it can login.
+var ErrRegistrationPendingApproval = apperr.Forbidden("registration is waiting on approval")
+
+// ErrIdentityNotPending is issued when approving or rejecting an identity that isn't waiting on approval.
+var ErrIdentityNotPending = apperr.Conflict("identity is not waiting on approval")
@@ -0,0 +1,10 @@
+package registration
+
+// Config holds the configuration for the Registration package
+type Config struct {
+	// URL sent to approved identities so they can login. {{.TenantID}} is replaced with the tenant of the identity.
+	LoginURL string
+
+	// URL sent to approvers to review the pending registrations. {{.TenantID}} is replaced with the tenant of the identity.
+	ReviewURL string
+}
@@ -0,0 +1,181 @@
+package registration
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	api "github.com/moov-io/identity/pkg/api"
+	"github.com/moov-io/identity/pkg/client"
+	"github.com/moov-io/identity/pkg/database"
+)
+
+// Repository - Used for interacting with the registration policies on the data store
+type Repository interface {
+	get(ctx context.Context, tenantID api.TenantID) (*client.RegistrationPolicy, error)
+	save(ctx context.Context, policy client.RegistrationPolicy) (*client.RegistrationPolicy, error)
+}
+
+// NewRegistrationRepository - Builds a new repository tied to the DB passed in.
+func NewRegistrationRepository(db *sql.DB) Repository {
+	return &sqlRegistrationRepo{db: db, dialect: database.DialectOf(db)}
+}
+
+type sqlRegistrationRepo struct {
+	db      *sql.DB
+	dialect database.Dialect
+}
+
+func (r *sqlRegistrationRepo) get(ctx context.Context, tenantID api.TenantID) (*client.RegistrationPolicy, error) {
+	qry := fmt.Sprintf(`
+		SELECT %s
+		FROM registration_policies
+		WHERE tenant_id = ?
+		LIMIT 1
+	`, policySelect)
+
+	policy := client.RegistrationPolicy{}
+	err := r.db.QueryRowContext(ctx, r.dialect.Rebind(qry), tenantID.String()).Scan(
+		&policy.TenantID,
+		&policy.Mode,
+		&policy.RequireApproval,
+		&policy.LastUpdatedOn,
+		&policy.LastUpdatedBy,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	policy.AllowedDomains, err = r.list(ctx, `
+		SELECT domain
+		FROM registration_policy_domains
+		WHERE tenant_id = ?
+		ORDER BY domain
+	`, tenantID.String())
+	if err != nil {
+		return nil, err
+	}
+
+	policy.ApproverEmails, err = r.list(ctx, `
+		SELECT email
+		FROM registration_policy_approvers
+		WHERE tenant_id = ?
+		ORDER BY email
+	`, tenantID.String())
+	if err != nil {
+		return nil, err
+	}
+
+	return &policy, nil
+}
+
+func (r *sqlRegistrationRepo) list(ctx context.Context, qry string, args ...interface{}) ([]string, error) {
+	rows, err := r.db.QueryContext(ctx, r.dialect.Rebind(qry), args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	values := []string{}
+	for rows.Next() {
+		value := ""
+		if err := rows.Scan(&value); err != nil {
+			return nil, err
+		}
+
+		values = append(values, value)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return values, nil
+}
+
+func (r *sqlRegistrationRepo) save(ctx context.Context, policy client.RegistrationPolicy) (*client.RegistrationPolicy, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	res, err := tx.ExecContext(ctx, r.dialect.Rebind(`
+		UPDATE registration_policies
+		SET
+			mode = ?,
+			require_approval = ?,
+			last_updated_on = ?,
+			last_updated_by = ?
+		WHERE tenant_id = ?
+	`),
+		policy.Mode,
+		policy.RequireApproval,
+		policy.LastUpdatedOn,
+		policy.LastUpdatedBy,
+
+		policy.TenantID)
+	if err != nil {
+		return nil, err
+	}
+
+	cnt, err := res.RowsAffected()
+	if err != nil {
+		return nil, err
+	}
+
+	if cnt == 0 {
+		_, err := tx.ExecContext(ctx, r.dialect.Rebind(`
+			INSERT INTO registration_policies (
+				tenant_id,
+				mode,
+				require_approval,
+				last_updated_on,
+				last_updated_by
+			) VALUES (?, ?, ?, ?, ?)
+		`),
+			policy.TenantID,
+			policy.Mode,
+			policy.RequireApproval,
+			policy.LastUpdatedOn,
+			policy.LastUpdatedBy)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	// domains and approvers are always replaced wholesale
+	if _, err := tx.ExecContext(ctx, r.dialect.Rebind(`DELETE FROM registration_policy_domains WHERE tenant_id = ?`), policy.TenantID); err != nil {
+		return nil, err
+	}
+
+	for _, domain := range policy.AllowedDomains {
+		if _, err := tx.ExecContext(ctx, r.dialect.Rebind(`INSERT INTO registration_policy_domains (tenant_id, domain) VALUES (?, ?)`), policy.TenantID, domain); err != nil {
+			return nil, err
+		}
+	}
+
+	if _, err := tx.ExecContext(ctx, r.dialect.Rebind(`DELETE FROM registration_policy_approvers WHERE tenant_id = ?`), policy.TenantID); err != nil {
+		return nil, err
+	}
+
+	for _, email := range policy.ApproverEmails {
+		if _, err := tx.ExecContext(ctx, r.dialect.Rebind(`INSERT INTO registration_policy_approvers (tenant_id, email) VALUES (?, ?)`), policy.TenantID, email); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	return &policy, nil
+}
+
+var policySelect = `
+	tenant_id,
+	mode,
+	require_approval,
+	last_updated_on,
+	last_updated_by
+`
@@ -0,0 +1,64 @@
+package registration
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	api "github.com/moov-io/identity/pkg/api"
+	"github.com/moov-io/identity/pkg/client"
+	"github.com/moov-io/identity/pkg/database"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_Repository_SavePolicy(t *testing.T) {
+	ForEachDatabase(t, func(t *testing.T, a *require.Assertions, repository Repository) {
+		tenantID := uuid.New()
+
+		_, err := repository.get(context.Background(), api.TenantID(tenantID))
+		a.Equal(sql.ErrNoRows, err)
+
+		policy := client.RegistrationPolicy{
+			TenantID:        tenantID.String(),
+			Mode:            "domain",
+			AllowedDomains:  []string{"moov.io", "example.com"},
+			RequireApproval: true,
+			ApproverEmails:  []string{"admin@moov.io"},
+			LastUpdatedOn:   time.Now().UTC().Round(time.Second),
+			LastUpdatedBy:   uuid.New().String(),
+		}
+
+		_, err = repository.save(context.Background(), policy)
+		a.NoError(err)
+
+		found, err := repository.get(context.Background(), api.TenantID(tenantID))
+		a.NoError(err)
+		a.Equal("domain", found.Mode)
+		a.True(found.RequireApproval)
+		a.Equal([]string{"example.com", "moov.io"}, found.AllowedDomains)
+		a.Equal([]string{"admin@moov.io"}, found.ApproverEmails)
+
+		// Saving again replaces it along with its domains and approvers.
+		policy.Mode = "signup"
+		policy.AllowedDomains = []string{}
+		policy.RequireApproval = false
+		policy.ApproverEmails = []string{"other@moov.io"}
+		_, err = repository.save(context.Background(), policy)
+		a.NoError(err)
+
+		found, err = repository.get(context.Background(), api.TenantID(tenantID))
+		a.NoError(err)
+		a.Equal("signup", found.Mode)
+		a.False(found.RequireApproval)
+		a.Empty(found.AllowedDomains)
+		a.Equal([]string{"other@moov.io"}, found.ApproverEmails)
+	})
+}
+
+func ForEachDatabase(t *testing.T, run func(t *testing.T, a *require.Assertions, repository Repository)) {
+	database.ForEachDatabase(t, func(t *testing.T, db *sql.DB) {
+		run(t, require.New(t), NewRegistrationRepository(db))
+	})
+}
@@ -0,0 +1,152 @@
+package registration_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+	"github.com/moov-io/identity/pkg/api"
+	"github.com/moov-io/identity/pkg/client"
+	"github.com/moov-io/identity/pkg/database"
+	"github.com/moov-io/identity/pkg/identities"
+	"github.com/moov-io/identity/pkg/logging"
+	"github.com/moov-io/identity/pkg/notifications"
+	"github.com/moov-io/identity/pkg/outbox"
+	. "github.com/moov-io/identity/pkg/registration"
+	"github.com/moov-io/identity/pkg/stime"
+	tmw "github.com/moov-io/tumbler/pkg/middleware"
+	tmwt "github.com/moov-io/tumbler/pkg/middleware/middlewaretest"
+	"github.com/stretchr/testify/require"
+)
+
+type Scope struct {
+	session    tmw.TumblerClaims
+	time       stime.StaticTimeService
+	identities identities.Service
+	outbox     outbox.Service
+	sent       *flakyNotifications
+	service    Service
+	routes     *mux.Router
+}
+
+func NewScope(t *testing.T) Scope {
+	logger := logging.NewDefaultLogger()
+	session := tmwt.NewRandomClaims()
+	times := stime.NewStaticTimeService()
+
+	db, close, err := database.NewAndMigrate(database.InMemorySqliteConfig, nil, nil)
+	t.Cleanup(close)
+	if err != nil {
+		t.Error(err)
+	}
+
+	templates, err := notifications.NewTemplateRepository(logger)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Records the emails so the tests can check who got notified and what they said
+	sent := &flakyNotifications{
+		MockNotificationsService: notifications.NewMockNotificationsService(notifications.MockConfig{From: "noreply@moov.io"}, templates),
+	}
+
+	outbox := outbox.NewOutboxService(outbox.Config{}, logger, times, outbox.NewOutboxRepository(db), sent)
+
+	identitiesService, err := identities.NewIdentitiesService(identities.Config{}, times, identities.NewIdentityRepository(db, outbox))
+	if err != nil {
+		t.Error(err)
+	}
+
+	config := Config{
+		LoginURL:  "https://local.moov.io/tenants/{{.TenantID}}/login",
+		ReviewURL: "https://local.moov.io/tenants/{{.TenantID}}/pending",
+	}
+
+	service, err := NewRegistrationService(config, times, NewRegistrationRepository(db), identitiesService)
+	if err != nil {
+		t.Error(err)
+	}
+
+	controller := NewRegistrationController(logger, service)
+
+	routes := mux.NewRouter()
+	api.AppendRouters(logger, routes, controller)
+
+	testMiddleware := tmwt.NewTestMiddleware(times, session)
+	routes.Use(testMiddleware.Handler)
+
+	return Scope{
+		session:    session,
+		time:       times,
+		identities: identitiesService,
+		outbox:     outbox,
+		sent:       sent,
+		service:    service,
+		routes:     routes,
+	}
+}
+
+func Setup(t *testing.T) (*require.Assertions, Scope) {
+	a := require.New(t)
+	s := NewScope(t)
+	return a, s
+}
+
+func (s *Scope) Request(method string, path string, body interface{}) *http.Response {
+	buf := &bytes.Buffer{}
+	if body != nil {
+		if err := json.NewEncoder(buf).Encode(body); err != nil {
+			panic(err)
+		}
+	}
+
+	req := httptest.NewRequest(method, "https://local.moov.io"+path, buf)
+	w := httptest.NewRecorder()
+	s.routes.ServeHTTP(w, req)
+
+	return w.Result()
+}
+
+// Sent - Delivers whatever is waiting in the outbox and returns every email sent so far.
+func (s *Scope) Sent() []notifications.SentEmail {
+	if _, err := s.outbox.Dispatch(context.Background()); err != nil {
+		panic(err)
+	}
+	return s.sent.Sent()
+}
+
+func (s *Scope) RegisterPending(a *require.Assertions, email string) *client.Identity {
+	return s.RegisterPendingNotify(a, email, nil)
+}
+
+// RegisterPendingNotify - Registers an identity waiting on approval along with the emails from notify.
+func (s *Scope) RegisterPendingNotify(a *require.Assertions, email string, notify identities.Notify) *client.Identity {
+	identity, err := s.identities.Register(context.Background(), client.Register{
+		CredentialID: uuid.New().String(),
+		TenantID:     s.session.TenantID.String(),
+		FirstName:    "John",
+		LastName:     "Doe",
+		Email:        email,
+	}, nil, identities.StatusPending, notify)
+	a.Nil(err)
+
+	return identity
+}
+
+// flakyNotifications - Records the emails like the mock does, unless err is set and then sending fails with it.
+type flakyNotifications struct {
+	notifications.MockNotificationsService
+	err error
+}
+
+func (n *flakyNotifications) SendEmail(ctx context.Context, to string, email notifications.EmailTemplate) error {
+	if n.err != nil {
+		return n.err
+	}
+	return n.MockNotificationsService.SendEmail(ctx, to, email)
+}
@@ -0,0 +1,240 @@
+package registration
+
+import (
+	"context"
+	"database/sql"
+	"strings"
+	"text/template"
+
+	"github.com/google/uuid"
+	api "github.com/moov-io/identity/pkg/api"
+	"github.com/moov-io/identity/pkg/client"
+	"github.com/moov-io/identity/pkg/identities"
+	"github.com/moov-io/identity/pkg/notifications"
+	"github.com/moov-io/identity/pkg/stime"
+	tmw "github.com/moov-io/tumbler/pkg/middleware"
+)
+
+// Service - Manages the tenant registration policies and decides how a registration is handled.
+type Service interface {
+	GetPolicy(ctx context.Context, claims tmw.TumblerClaims) (*client.RegistrationPolicy, error)
+	UpdatePolicy(ctx context.Context, claims tmw.TumblerClaims, policy client.RegistrationPolicy) (*client.RegistrationPolicy, error)
+	ListPending(ctx context.Context, claims tmw.TumblerClaims) ([]client.Identity, error)
+	Approve(ctx context.Context, claims tmw.TumblerClaims, identityID string) (*client.Identity, error)
+	Reject(ctx context.Context, claims tmw.TumblerClaims, identityID string) (*client.Identity, error)
+
+	Evaluate(ctx context.Context, register client.Register, isSignup bool, emailVerified bool) (*Decision, error)
+	NotifyApprovers(ctx context.Context, tenantID string) (identities.Notify, error)
+}
+
+// Decision - Outcome of evaluating a tenants policy against a registration
+type Decision struct {
+	// Registration has to redeem a valid invite code
+	RequireInvite bool
+
+	// Identity is created as pending and not logged in until an admin approves it
+	RequireApproval bool
+}
+
+type service struct {
+	loginURL   *template.Template
+	reviewURL  *template.Template
+	time       stime.TimeService
+	repository Repository
+	identities identities.Service
+}
+
+// NewRegistrationService creates a default service
+func NewRegistrationService(config Config, time stime.TimeService, repository Repository, identities identities.Service) (Service, error) {
+	loginURL, err := template.New("login").Parse(config.LoginURL)
+	if err != nil {
+		return nil, err
+	}
+
+	reviewURL, err := template.New("review").Parse(config.ReviewURL)
+	if err != nil {
+		return nil, err
+	}
+
+	return &service{
+		loginURL:   loginURL,
+		reviewURL:  reviewURL,
+		time:       time,
+		repository: repository,
+		identities: identities,
+	}, nil
+}
+
+// GetPolicy - Returns the registration policy of the tenant of the session. Tenants without one are invite only.
+func (s *service) GetPolicy(ctx context.Context, claims tmw.TumblerClaims) (*client.RegistrationPolicy, error) {
+	policy, err := s.repository.get(ctx, api.TenantID(claims.TenantID))
+	if err == sql.ErrNoRows {
+		return &client.RegistrationPolicy{
+			TenantID:       claims.TenantID.String(),
+			Mode:           "invite",
+			AllowedDomains: []string{},
+		}, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	return policy, nil
+}
+
+// UpdatePolicy - Replaces the registration policy of the tenant of the session.
+func (s *service) UpdatePolicy(ctx context.Context, claims tmw.TumblerClaims, policy client.RegistrationPolicy) (*client.RegistrationPolicy, error) {
+	if err := policy.Validate(); err != nil {
+		return nil, err
+	}
+
+	domains := []string{}
+	for _, d := range policy.AllowedDomains {
+		domains = append(domains, strings.ToLower(strings.TrimSpace(d)))
+	}
+
+	policy.TenantID = claims.TenantID.String()
+	policy.AllowedDomains = domains
+	policy.LastUpdatedOn = s.time.Now()
+	policy.LastUpdatedBy = claims.Subject
+
+	return s.repository.save(ctx, policy)
+}
+
+// ListPending - Lists the identities of the tenant waiting on approval.
+func (s *service) ListPending(ctx context.Context, claims tmw.TumblerClaims) ([]client.Identity, error) {
+	return s.identities.ListIdentitiesByStatus(ctx, claims, identities.StatusPending)
+}
+
+// Approve - Lets a pending identity login and sends them a welcome email with where to login.
+func (s *service) Approve(ctx context.Context, claims tmw.TumblerClaims, identityID string) (*client.Identity, error) {
+	identity, err := s.getPending(ctx, claims, identityID)
+	if err != nil {
+		return nil, err
+	}
+
+	loginURL, err := renderURL(s.loginURL, identity.TenantID)
+	if err != nil {
+		return nil, err
+	}
+
+	welcome := func(approved client.Identity) []identities.Notice {
+		email := notifications.NewWelcomeEmail(loginURL, approved)
+		return []identities.Notice{{To: approved.Email, Email: &email}}
+	}
+
+	return s.identities.ChangeStatus(ctx, claims, identity.IdentityID, identities.StatusActive, client.ChangeStatus{Reason: "registration approved"}, welcome)
+}
+
+// Reject - Turns down a pending identity and disables it so it can't be approved later.
+func (s *service) Reject(ctx context.Context, claims tmw.TumblerClaims, identityID string) (*client.Identity, error) {
+	identity, err := s.getPending(ctx, claims, identityID)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.identities.ChangeStatus(ctx, claims, identity.IdentityID, identities.StatusDisabled, client.ChangeStatus{Reason: "registration rejected"}, nil)
+}
+
+func (s *service) getPending(ctx context.Context, claims tmw.TumblerClaims, identityID string) (*client.Identity, error) {
+	identity, err := s.identities.GetIdentity(ctx, claims, identityID)
+	if err != nil {
+		return nil, err
+	}
+
+	if identity.Status != identities.StatusPending {
+		return nil, ErrIdentityNotPending
+	}
+
+	return identity, nil
+}
+
+// NotifyApprovers - Builds the emails telling the approvers of the tenant that a registration is waiting on them.
+// The emails are queued along with the registration. Tenants without a policy have nobody to notify.
+func (s *service) NotifyApprovers(ctx context.Context, tenantID string) (identities.Notify, error) {
+	id, err := uuid.Parse(tenantID)
+	if err != nil {
+		return nil, err
+	}
+
+	policy, err := s.repository.get(ctx, api.TenantID(id))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	reviewURL, err := renderURL(s.reviewURL, tenantID)
+	if err != nil {
+		return nil, err
+	}
+
+	return func(identity client.Identity) []identities.Notice {
+		request := notifications.NewApprovalRequestEmail(reviewURL, identity)
+
+		notices := []identities.Notice{}
+		for _, approver := range policy.ApproverEmails {
+			notices = append(notices, identities.Notice{To: approver, Email: &request})
+		}
+		return notices
+	}, nil
+}
+
+// Evaluate - Decides if a registration needs an invite and if it has to wait for approval.
+// Tenants without a policy keep the original behaviour where only the signup scope skips the invite.
+func (s *service) Evaluate(ctx context.Context, register client.Register, isSignup bool, emailVerified bool) (*Decision, error) {
+	tenantID, err := uuid.Parse(register.TenantID)
+	if err != nil {
+		return &Decision{RequireInvite: !isSignup}, nil
+	}
+
+	policy, err := s.repository.get(ctx, api.TenantID(tenantID))
+	if err == sql.ErrNoRows {
+		return &Decision{RequireInvite: !isSignup}, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	decision := Decision{}
+	switch policy.Mode {
+	case "signup":
+		decision.RequireInvite = false
+	case "domain":
+		decision.RequireInvite = !(emailVerified && allowedDomain(policy.AllowedDomains, register.Email))
+	default:
+		decision.RequireInvite = true
+	}
+
+	// Invited users were already vetted by whoever invited them.
+	decision.RequireApproval = policy.RequireApproval && !decision.RequireInvite
+
+	return &decision, nil
+}
+
+func renderURL(tmpl *template.Template, tenantID string) (string, error) {
+	data := struct {
+		TenantID string
+	}{tenantID}
+
+	rendered := strings.Builder{}
+	if err := tmpl.Execute(&rendered, data); err != nil {
+		return "", err
+	}
+
+	return rendered.String(), nil
+}
+
+func allowedDomain(domains []string, email string) bool {
+	at := strings.LastIndex(email, "@")
+	if at < 0 {
+		return false
+	}
+
+	domain := strings.ToLower(email[at+1:])
+	for _, d := range domains {
+		if d == domain {
+			return true
+		}
+	}
+
+	return false
+}
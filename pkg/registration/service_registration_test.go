@@ -0,0 +1,238 @@
+package registration_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/moov-io/identity/pkg/client"
+	"github.com/moov-io/identity/pkg/identities"
+	"github.com/moov-io/identity/pkg/notifications"
+	"github.com/moov-io/identity/pkg/outbox"
+	. "github.com/moov-io/identity/pkg/registration"
+)
+
+func Test_GetPolicy_Default(t *testing.T) {
+	a, s := Setup(t)
+
+	policy, err := s.service.GetPolicy(context.Background(), s.session)
+	a.Nil(err)
+	a.Equal("invite", policy.Mode)
+	a.Equal(s.session.TenantID.String(), policy.TenantID)
+	a.False(policy.RequireApproval)
+}
+
+func Test_UpdatePolicy(t *testing.T) {
+	a, s := Setup(t)
+
+	updated, err := s.service.UpdatePolicy(context.Background(), s.session, client.RegistrationPolicy{
+		Mode:            "domain",
+		AllowedDomains:  []string{"Example.com", "moov.io"},
+		RequireApproval: true,
+	})
+	a.Nil(err)
+	a.Equal(s.time.Now(), updated.LastUpdatedOn)
+	a.Equal(s.session.Subject, updated.LastUpdatedBy)
+
+	found, err := s.service.GetPolicy(context.Background(), s.session)
+	a.Nil(err)
+	a.Equal("domain", found.Mode)
+	a.Equal([]string{"example.com", "moov.io"}, found.AllowedDomains)
+	a.True(found.RequireApproval)
+
+	// replaces the domains
+	_, err = s.service.UpdatePolicy(context.Background(), s.session, client.RegistrationPolicy{Mode: "signup"})
+	a.Nil(err)
+
+	found, err = s.service.GetPolicy(context.Background(), s.session)
+	a.Nil(err)
+	a.Equal("signup", found.Mode)
+	a.Empty(found.AllowedDomains)
+	a.False(found.RequireApproval)
+}
+
+func Test_UpdatePolicy_Invalid(t *testing.T) {
+	a, s := Setup(t)
+
+	_, err := s.service.UpdatePolicy(context.Background(), s.session, client.RegistrationPolicy{Mode: "anyone"})
+	a.NotNil(err)
+
+	_, err = s.service.UpdatePolicy(context.Background(), s.session, client.RegistrationPolicy{Mode: "domain"})
+	a.NotNil(err)
+
+	_, err = s.service.UpdatePolicy(context.Background(), s.session, client.RegistrationPolicy{Mode: "domain", AllowedDomains: []string{"not a domain"}})
+	a.NotNil(err)
+}
+
+func Test_Evaluate(t *testing.T) {
+	a, s := Setup(t)
+
+	register := client.Register{
+		TenantID: s.session.TenantID.String(),
+		Email:    "someone@example.com",
+	}
+
+	// No policy keeps the signup scope behaviour
+	decision, err := s.service.Evaluate(context.Background(), register, false, false)
+	a.Nil(err)
+	a.True(decision.RequireInvite)
+
+	decision, err = s.service.Evaluate(context.Background(), register, true, false)
+	a.Nil(err)
+	a.False(decision.RequireInvite)
+
+	cases := []struct {
+		policy        client.RegistrationPolicy
+		isSignup      bool
+		emailVerified bool
+		invite        bool
+		approval      bool
+	}{
+		{client.RegistrationPolicy{Mode: "invite"}, true, true, true, false},
+		{client.RegistrationPolicy{Mode: "invite", RequireApproval: true}, false, false, true, false},
+		{client.RegistrationPolicy{Mode: "signup"}, false, false, false, false},
+		{client.RegistrationPolicy{Mode: "signup", RequireApproval: true}, false, false, false, true},
+		{client.RegistrationPolicy{Mode: "domain", AllowedDomains: []string{"example.com"}}, false, true, false, false},
+		{client.RegistrationPolicy{Mode: "domain", AllowedDomains: []string{"example.com"}}, false, false, true, false},
+		{client.RegistrationPolicy{Mode: "domain", AllowedDomains: []string{"moov.io"}}, false, true, true, false},
+		{client.RegistrationPolicy{Mode: "domain", AllowedDomains: []string{"example.com"}, RequireApproval: true}, false, true, false, true},
+	}
+
+	for i, c := range cases {
+		_, err := s.service.UpdatePolicy(context.Background(), s.session, c.policy)
+		a.Nil(err)
+
+		decision, err := s.service.Evaluate(context.Background(), register, c.isSignup, c.emailVerified)
+		a.Nil(err)
+		a.Equal(c.invite, decision.RequireInvite, fmt.Sprintf("case %d", i))
+		a.Equal(c.approval, decision.RequireApproval, fmt.Sprintf("case %d", i))
+	}
+}
+
+func Test_ListPending(t *testing.T) {
+	a, s := Setup(t)
+
+	for i := 0; i < 3; i++ {
+		status := identities.StatusPending
+		if i == 0 {
+			status = identities.StatusActive
+		}
+
+		_, err := s.identities.Register(context.Background(), client.Register{
+			CredentialID: uuid.New().String(),
+			TenantID:     s.session.TenantID.String(),
+			FirstName:    "John",
+			LastName:     "Doe",
+			Email:        fmt.Sprintf("john.%d@example.com", i),
+		}, nil, status, nil)
+		a.Nil(err)
+	}
+
+	pending, err := s.service.ListPending(context.Background(), s.session)
+	a.Nil(err)
+	a.Len(pending, 2)
+}
+
+func Test_Approve(t *testing.T) {
+	a, s := Setup(t)
+
+	pending := s.RegisterPending(a, "john@example.com")
+
+	approved, err := s.service.Approve(context.Background(), s.session, pending.IdentityID)
+	a.Nil(err)
+	a.Equal(identities.StatusActive, approved.Status)
+	a.Nil(approved.DisabledOn)
+
+	found, err := s.identities.GetIdentity(context.Background(), s.session, pending.IdentityID)
+	a.Nil(err)
+	a.Equal(identities.StatusActive, found.Status)
+
+	sent := s.Sent()
+	a.Len(sent, 1)
+	a.Equal("john@example.com", sent[0].To)
+
+	welcome, ok := sent[0].Email.(*notifications.WelcomeEmail)
+	a.True(ok)
+	a.Equal("https://local.moov.io/tenants/"+s.session.TenantID.String()+"/login", welcome.LoginURL)
+
+	// can't approve twice
+	_, err = s.service.Approve(context.Background(), s.session, pending.IdentityID)
+	a.Equal(ErrIdentityNotPending, err)
+}
+
+func Test_Approve_WelcomeEmailFails(t *testing.T) {
+	a, s := Setup(t)
+
+	pending := s.RegisterPending(a, "john@example.com")
+
+	s.sent.err = errors.New("smtp is down")
+
+	approved, err := s.service.Approve(context.Background(), s.session, pending.IdentityID)
+	a.Nil(err)
+	a.Equal(identities.StatusActive, approved.Status)
+
+	found, err := s.identities.GetIdentity(context.Background(), s.session, pending.IdentityID)
+	a.Nil(err)
+	a.Equal(identities.StatusActive, found.Status)
+
+	// the welcome stays in the outbox until it can be sent
+	a.Empty(s.Sent())
+
+	pendingMessages, err := s.outbox.ListMessages(context.Background(), outbox.StatusPending)
+	a.Nil(err)
+	a.Len(pendingMessages, 1)
+	a.Equal("john@example.com", pendingMessages[0].Recipient)
+}
+
+func Test_Reject(t *testing.T) {
+	a, s := Setup(t)
+
+	pending := s.RegisterPending(a, "john@example.com")
+
+	rejected, err := s.service.Reject(context.Background(), s.session, pending.IdentityID)
+	a.Nil(err)
+	a.Equal(identities.StatusDisabled, rejected.Status)
+	a.NotNil(rejected.DisabledOn)
+	a.Equal(s.session.Subject, *rejected.DisabledBy)
+	a.Empty(s.Sent())
+
+	_, err = s.service.Approve(context.Background(), s.session, pending.IdentityID)
+	a.Equal(ErrIdentityNotPending, err)
+}
+
+func Test_NotifyApprovers(t *testing.T) {
+	a, s := Setup(t)
+
+	// no policy so nobody to notify
+	notify, err := s.service.NotifyApprovers(context.Background(), s.session.TenantID.String())
+	a.Nil(err)
+	a.Nil(notify)
+
+	_, err = s.service.UpdatePolicy(context.Background(), s.session, client.RegistrationPolicy{
+		Mode:            "signup",
+		RequireApproval: true,
+		ApproverEmails:  []string{"admin1@example.com", "admin2@example.com"},
+	})
+	a.Nil(err)
+
+	notify, err = s.service.NotifyApprovers(context.Background(), s.session.TenantID.String())
+	a.Nil(err)
+
+	pending := s.RegisterPendingNotify(a, "john@example.com", notify)
+
+	// sent together so they can go out in any order
+	sent := map[string]notifications.SentEmail{}
+	for _, email := range s.Sent() {
+		sent[email.To] = email
+	}
+	a.Len(sent, 2)
+	a.Contains(sent, "admin1@example.com")
+	a.Contains(sent, "admin2@example.com")
+
+	request, ok := sent["admin1@example.com"].Email.(*notifications.ApprovalRequestEmail)
+	a.True(ok)
+	a.Equal(pending.IdentityID, request.Identity.IdentityID)
+	a.Equal("https://local.moov.io/tenants/"+s.session.TenantID.String()+"/pending", request.ReviewURL)
+}
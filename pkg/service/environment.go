@@ -3,6 +3,8 @@ package service
 import (
 	"context"
 	"database/sql"
+	"fmt"
+	"io"
 	"time"
 
 	"github.com/gorilla/mux"
@@ -10,13 +12,17 @@ import (
 	"github.com/moov-io/identity/pkg/api"
 	"github.com/moov-io/identity/pkg/authn"
 	authnclient "github.com/moov-io/identity/pkg/authn/client"
+	"github.com/moov-io/identity/pkg/bulk"
 	"github.com/moov-io/identity/pkg/config"
 	"github.com/moov-io/identity/pkg/credentials"
 	"github.com/moov-io/identity/pkg/database"
 	"github.com/moov-io/identity/pkg/identities"
 	"github.com/moov-io/identity/pkg/invites"
+	"github.com/moov-io/identity/pkg/jobs"
 	"github.com/moov-io/identity/pkg/logging"
 	"github.com/moov-io/identity/pkg/notifications"
+	"github.com/moov-io/identity/pkg/outbox"
+	"github.com/moov-io/identity/pkg/registration"
 	"github.com/moov-io/identity/pkg/session"
 	"github.com/moov-io/identity/pkg/stime"
 	"github.com/moov-io/tumbler/pkg/jwe"
@@ -31,6 +37,7 @@ type Environment struct {
 	TimeService  stime.TimeService
 	GatewayKeys  webkeys.WebKeysService
 	PublicRouter *mux.Router
+	AdminRouter  *mux.Router
 	Shutdown     func()
 
 	InviteService      invites.InvitesService
@@ -49,14 +56,12 @@ func NewEnvironment(env *Environment) (*Environment, error) {
 	}
 
 	if env.Config == nil {
-		ConfigService := config.NewConfigService(env.Logger)
-
-		global := &GlobalConfig{}
-		if err := ConfigService.Load(global); err != nil {
+		cfg, err := LoadConfig(env.Logger)
+		if err != nil {
 			return nil, err
 		}
 
-		env.Config = &global.Identity
+		env.Config = cfg
 	}
 
 	//db setup
@@ -83,18 +88,35 @@ func NewEnvironment(env *Environment) (*Environment, error) {
 	IdentityTokenJwe := jwe.NewJWEService(env.TimeService, env.Config.Session.Expiration, IdentityTokenKeys)
 	IdentityTokenService := session.NewTokenService(env.TimeService, IdentityTokenJwe, env.Config.Session)
 
-	templateService, err := notifications.NewTemplateRepository(env.Logger)
+	templateService, closeTemplates, err := initializeTemplates(env.Logger, env.Config.Templates)
 	if err != nil {
 		return nil, err
 	}
 
-	NotificationsService, err := notifications.NewNotificationsService(env.Logger, env.Config.Notifications, templateService)
+	TemplateOverridesRepository := notifications.NewTemplateOverridesRepository(db)
+	TemplatesService := notifications.NewTemplatesService(env.TimeService, templateService, TemplateOverridesRepository)
+	tenantTemplates := notifications.NewTenantTemplateRepository(templateService, TemplateOverridesRepository)
+
+	NotificationsService, err := notifications.NewNotificationsService(env.Logger, env.Config.Notifications, tenantTemplates)
 	if err != nil {
 		return nil, err
 	}
 
-	IdentityRepository := identities.NewIdentityRepository(db)
-	IdentitiesService := identities.NewIdentitiesService(env.TimeService, IdentityRepository)
+	// Emails are added to the outbox in the transaction of the change they're about.
+	OutboxRepository := outbox.NewOutboxRepository(db)
+	OutboxService := outbox.NewOutboxService(env.Config.Outbox, env.Logger, env.TimeService, OutboxRepository, NotificationsService)
+
+	IdentityRepository := identities.NewIdentityRepository(db, OutboxService)
+	if err := identities.BuildSearchIndex(context.Background(), IdentityRepository); err != nil {
+		return nil, env.Logger.Fatal().LogErrorF("Unable to build the identity search index - %w", err)
+	}
+
+	IdentitiesService, err := identities.NewIdentitiesService(env.Config.Identities, env.TimeService, IdentityRepository)
+	if err != nil {
+		return nil, err
+	}
+
+	OutboxService.Fill((&notifications.EmailChangeConfirmEmail{}).TemplateName(), IdentitiesService.FillEmail)
 
 	CredentialRepository := credentials.NewCredentialRepository(db)
 	CredentialsService := credentials.NewCredentialsService(env.TimeService, CredentialRepository)
@@ -106,18 +128,32 @@ func NewEnvironment(env *Environment) (*Environment, error) {
 		return nil, err
 	}
 
-	InvitesRepository := invites.NewInvitesRepository(db)
-	InvitesService, err := invites.NewInvitesService(env.Config.Invites, env.TimeService, InvitesRepository, NotificationsService, AuthnClient, IdentitiesService)
+	JobsRepository := jobs.NewJobsRepository(db)
+	JobsService := jobs.NewJobsService(env.Logger, env.TimeService, JobsRepository)
+
+	InvitesRepository := invites.NewInvitesRepository(db, OutboxService)
+	InvitesService, err := invites.NewInvitesService(env.Config.Invites, env.TimeService, InvitesRepository, AuthnClient, IdentitiesService, JobsService)
+	if err != nil {
+		return nil, err
+	}
+
+	OutboxService.Fill((&notifications.InviteEmail{}).TemplateName(), InvitesService.FillEmail)
+
+	RegistrationRepository := registration.NewRegistrationRepository(db)
+	RegistrationService, err := registration.NewRegistrationService(env.Config.Registration, env.TimeService, RegistrationRepository, IdentitiesService)
 	if err != nil {
 		return nil, err
 	}
 
-	AuthnService := authn.NewAuthnService(env.Logger, CredentialsService, IdentitiesService, IdentityTokenService, InvitesService)
+	BulkService := bulk.NewBulkService(IdentitiesService, InvitesService)
+
+	AuthnService := authn.NewAuthnService(env.Logger, CredentialsService, IdentitiesService, IdentityTokenService, InvitesService, RegistrationService)
 
 	// router
 	if env.PublicRouter == nil {
 		env.PublicRouter = mux.NewRouter()
 	}
+	env.PublicRouter.Use(api.RequestTimeout(env.Config.Database.RequestTimeout))
 
 	// public endpoint
 	jwksController := webkeys.NewJWKSController(IdentityTokenKeys)
@@ -143,22 +179,59 @@ func NewEnvironment(env *Environment) (*Environment, error) {
 	}
 
 	SessionController := session.NewSessionController(env.Logger, SessionService)
+	BulkController := bulk.NewBulkController(env.Logger, BulkService)
+	JobsController := jobs.NewJobsController(env.Logger, JobsService)
 	IdentitiesController := identities.NewIdentitiesController(env.Logger, IdentitiesService)
 	CredentialsController := credentials.NewCredentialsApiController(CredentialsService)
 	InvitesController := invites.NewInvitesController(env.Logger, InvitesService)
+	RegistrationController := registration.NewRegistrationController(env.Logger, RegistrationService)
+	TemplatesController := notifications.NewTemplatesController(env.Logger, TemplatesService)
 
 	authedRouter := env.PublicRouter.NewRoute().Subrouter()
-	authedRouter = api.AppendRouters(env.Logger, authedRouter, IdentitiesController, CredentialsController, InvitesController)
+	authedRouter = api.AppendRouters(env.Logger, authedRouter, BulkController, IdentitiesController, CredentialsController, InvitesController, RegistrationController, JobsController, TemplatesController)
 	SessionController.AppendRoutes(authedRouter)
 	authedRouter.Use(GatewayMiddleware.Handler)
 
+	// admin endpoints, these span all tenants so they're only served from the admin server
+	if env.AdminRouter == nil {
+		env.AdminRouter = mux.NewRouter()
+	}
+	env.AdminRouter.Use(api.RequestTimeout(env.Config.Database.RequestTimeout))
+
+	OutboxAdminController := outbox.NewOutboxAdminController(env.Logger, OutboxService)
+	api.AppendRouters(env.Logger, env.AdminRouter, OutboxAdminController)
+
+	// Lets the emails captured by the mock be browsed when running locally.
+	if mailbox, ok := NotificationsService.(notifications.MockNotificationsService); ok {
+		api.AppendRouters(env.Logger, env.AdminRouter, notifications.NewMailboxController(env.Logger, mailbox))
+	}
+
+	OutboxService.Start()
+
 	env.Shutdown = func() {
+		// Let the background jobs finish before pulling the database out from under them.
+		JobsService.Wait()
+		OutboxService.Stop()
+		if closer, ok := NotificationsService.(io.Closer); ok {
+			closer.Close()
+		}
+		closeTemplates()
 		close()
 	}
 
 	return env, nil
 }
 
+// initializeTemplates - The templates built into the binary unless a directory to load them from is configured.
+func initializeTemplates(logger logging.Logger, config notifications.TemplatesConfig) (notifications.TemplateRepository, func(), error) {
+	if config.Directory == "" {
+		templates, err := notifications.NewTemplateRepository(logger)
+		return templates, func() {}, err
+	}
+
+	return notifications.NewDirectoryTemplateRepository(logger, config)
+}
+
 func initializeDatabase(logger logging.Logger, config database.DatabaseConfig) (*sql.DB, func(), error) {
 	ctx, cancelFunc := context.WithCancel(context.Background())
 
@@ -176,7 +249,11 @@ func initializeDatabase(logger logging.Logger, config database.DatabaseConfig) (
 		}
 	}
 
-	if err := database.RunMigrations(logger, db, config); err != nil {
+	if config.DisableAutoMigrate {
+		if err := checkMigrations(logger, db, config); err != nil {
+			return nil, shutdown, err
+		}
+	} else if err := database.RunMigrations(logger, db, config); err != nil {
 		return nil, shutdown, logger.Fatal().LogError("Error running migrations", err)
 	}
 
@@ -184,3 +261,36 @@ func initializeDatabase(logger logging.Logger, config database.DatabaseConfig) (
 
 	return db, shutdown, err
 }
+
+// LoadConfig - Loads the config of the service from the defaults and the files and environment overriding them.
+func LoadConfig(logger logging.Logger) (*Config, error) {
+	ConfigService := config.NewConfigService(logger)
+
+	global := &GlobalConfig{}
+	if err := ConfigService.Load(global); err != nil {
+		return nil, err
+	}
+
+	return &global.Identity, nil
+}
+
+// checkMigrations - Without running the migrations at startup the service won't run against a database
+// that a migration failed partway through on.
+func checkMigrations(logger logging.Logger, db *sql.DB, config database.DatabaseConfig) error {
+	migrator, err := database.NewMigrator(logger, db, config)
+	if err != nil {
+		return err
+	}
+
+	version, dirty, err := migrator.Version()
+	if err != nil {
+		return logger.Fatal().LogError("Unable to read the migration version", err)
+	}
+
+	if dirty {
+		return logger.Fatal().LogErrorF("Database is dirty at migration %d, fix it and force the version with the migrate command", version)
+	}
+
+	logger.Info().WithKeyValue("version", fmt.Sprint(version)).Log("Automatic migrations are disabled")
+	return nil
+}
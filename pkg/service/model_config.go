@@ -3,8 +3,11 @@ package service
 import (
 	"github.com/moov-io/identity/pkg/authn"
 	"github.com/moov-io/identity/pkg/database"
+	"github.com/moov-io/identity/pkg/identities"
 	"github.com/moov-io/identity/pkg/invites"
 	"github.com/moov-io/identity/pkg/notifications"
+	"github.com/moov-io/identity/pkg/outbox"
+	"github.com/moov-io/identity/pkg/registration"
 	"github.com/moov-io/identity/pkg/session"
 	tmw "github.com/moov-io/tumbler/pkg/middleware"
 )
@@ -21,7 +24,11 @@ type Config struct {
 	Authentication authn.Config
 	Session        session.Config
 	Notifications  notifications.NotificationsConfig
+	Templates      notifications.TemplatesConfig
+	Outbox         outbox.Config
+	Identities     identities.Config
 	Invites        invites.Config
+	Registration   registration.Config
 	Services       ServicesConfig
 }
 
@@ -21,7 +21,7 @@ func (env *Environment) RunServers(await bool) func() {
 	// Listen for application termination.
 	terminationListener := newTerminationListener()
 
-	adminServer := bootAdminServer(terminationListener, env.Logger, env.Config.Servers.Admin)
+	adminServer := bootAdminServer(terminationListener, env.Logger, env.Config.Servers.Admin, env.AdminRouter)
 
 	_, shutdownPublicServer := bootHTTPServer("public", env.PublicRouter, terminationListener, env.Logger, env.Config.Servers.Public)
 
@@ -87,9 +87,22 @@ func bootHTTPServer(name string, routes *mux.Router, errs chan<- error, logger l
 	return serve, shutdownServer
 }
 
-func bootAdminServer(errs chan<- error, logger logging.Logger, config HTTPConfig) *admin.Server {
+func bootAdminServer(errs chan<- error, logger logging.Logger, config HTTPConfig, routes *mux.Router) *admin.Server {
 	adminServer := admin.NewServer(config.Bind.Address)
 
+	// The admin server only takes a handler per path, so each path goes to the router to match the method and vars.
+	if routes != nil {
+		added := map[string]bool{}
+		_ = routes.Walk(func(route *mux.Route, router *mux.Router, ancestors []*mux.Route) error {
+			path, err := route.GetPathTemplate()
+			if err == nil && !added[path] {
+				added[path] = true
+				adminServer.AddHandler(path, routes.ServeHTTP)
+			}
+			return nil
+		})
+	}
+
 	go func() {
 		logger.Info().Log(fmt.Sprintf("listening on %s", adminServer.BindAddr()))
 		if err := adminServer.Listen(); err != nil {
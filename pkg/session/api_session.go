@@ -1,11 +1,11 @@
 package session
 
 import (
-	"database/sql"
 	"encoding/json"
 	"net/http"
 
 	"github.com/gorilla/mux"
+	api "github.com/moov-io/identity/pkg/api"
 	"github.com/moov-io/identity/pkg/client"
 	"github.com/moov-io/identity/pkg/logging"
 	tmw "github.com/moov-io/tumbler/pkg/middleware"
@@ -52,22 +52,11 @@ func (c *sessionController) jsonResponse(w http.ResponseWriter, value interface{
 	e.Encode(value)
 }
 
-func (c *sessionController) errorResponse(w http.ResponseWriter, err error) {
-	switch err {
-	case ErrIdentityNotFound:
-		w.WriteHeader(404)
-	case sql.ErrNoRows:
-		w.WriteHeader(404)
-	default:
-		w.WriteHeader(500)
-	}
-}
-
 func (c *sessionController) getSessionHandler(w http.ResponseWriter, r *http.Request) {
 	tmw.WithClaimsFromRequest(w, r, func(claims tmw.TumblerClaims) {
-		details, err := c.service.GetDetails(claims)
+		details, err := c.service.GetDetails(r.Context(), claims)
 		if err != nil {
-			c.errorResponse(w, err)
+			api.EncodeError(w, err)
 			return
 		}
 
@@ -78,14 +67,14 @@ func (c *sessionController) getSessionHandler(w http.ResponseWriter, r *http.Req
 func (c *sessionController) changeTenantHandler(w http.ResponseWriter, r *http.Request) {
 	tmw.WithClaimsFromRequest(w, r, func(claims tmw.TumblerClaims) {
 		update := &client.ChangeSessionDetails{}
-		if err := json.NewDecoder(r.Body).Decode(&update); err != nil {
-			w.WriteHeader(400)
+		if err := api.DecodeJSONRequest(r, &update); err != nil {
+			api.EncodeError(w, err)
 			return
 		}
 
 		details, cookie, err := c.service.ChangeDetails(r, claims, *update)
 		if err != nil {
-			c.errorResponse(w, err)
+			api.EncodeError(w, err)
 			return
 		}
 
@@ -6,12 +6,13 @@ import (
 
 	"github.com/google/uuid"
 	"github.com/moov-io/identity/pkg/client"
+	"github.com/moov-io/identity/pkg/identities"
 )
 
 func Test_SessionEndpoint(t *testing.T) {
 	s := NewSessionScope(t)
 	imageUrl := "http://kittens.com/123.jpg"
-	identity, err := s.identities.Register(client.Register{
+	identity, err := s.identities.Register(context.Background(), client.Register{
 		CredentialID: uuid.New().String(),
 		TenantID:     s.claims.TenantID.String(),
 		FirstName:    "John",
@@ -19,7 +20,7 @@ func Test_SessionEndpoint(t *testing.T) {
 		NickName:     nil,
 		ImageUrl:     &imageUrl,
 		Email:        "john.doe@moov.io",
-	}, nil)
+	}, nil, identities.StatusActive, nil)
 	s.assert.Nil(err)
 
 	iid := uuid.MustParse(identity.IdentityID)
@@ -1,9 +1,9 @@
 package session
 
-import "errors"
+import "github.com/moov-io/identity/pkg/apperr"
 
 var (
-	ErrIdentityNotFound     = errors.New("identity not set or found")
-	ErrCredentialsNotSet    = errors.New("credentialID not set")
-	ErrPutSessionNotEnabled = errors.New("put session not enabled")
+	ErrIdentityNotFound     = apperr.NotFound("identity not set or found")
+	ErrCredentialsNotSet    = apperr.Forbidden("credentialID not set")
+	ErrPutSessionNotEnabled = apperr.Forbidden("put session not enabled")
 )
@@ -11,6 +11,8 @@ import (
 	"github.com/moov-io/identity/pkg/database"
 	"github.com/moov-io/identity/pkg/identities"
 	"github.com/moov-io/identity/pkg/logging"
+	"github.com/moov-io/identity/pkg/notifications"
+	"github.com/moov-io/identity/pkg/outbox"
 	"github.com/moov-io/identity/pkg/session"
 	"github.com/moov-io/identity/pkg/stime"
 	"github.com/moov-io/tumbler/pkg/jwe"
@@ -54,8 +56,18 @@ func NewSessionScope(t *testing.T) SessionScope {
 	credentialsRepo := credentials.NewCredentialRepository(db)
 	credentials := credentials.NewCredentialsService(times, credentialsRepo)
 
-	identitiesRepository := identities.NewIdentityRepository(db)
-	identities := identities.NewIdentitiesService(times, identitiesRepository)
+	templates, err := notifications.NewTemplateRepository(logging)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	outbox := outbox.NewOutboxService(outbox.Config{}, logging, times, outbox.NewOutboxRepository(db), notifications.NewMockNotificationsService(notifications.MockConfig{}, templates))
+
+	identitiesRepository := identities.NewIdentityRepository(db, outbox)
+	identities, err := identities.NewIdentitiesService(identities.Config{}, times, identitiesRepository)
+	if err != nil {
+		t.Error(err)
+	}
 
 	token := session.NewTokenService(times, jwe, config)
 	service := session.NewSessionService(logging, identities, token, credentials, config)
@@ -1,8 +1,11 @@
 package session
 
 import (
+	"context"
+	"errors"
 	"net/http"
 
+	validation "github.com/go-ozzo/ozzo-validation/v4"
 	"github.com/google/uuid"
 	"github.com/moov-io/identity/pkg/client"
 	"github.com/moov-io/identity/pkg/credentials"
@@ -13,7 +16,7 @@ import (
 
 // SessionService - Generates the tokens for their fully logged in session.
 type SessionService interface {
-	GetDetails(claims tmw.TumblerClaims) (*client.SessionDetails, error)
+	GetDetails(ctx context.Context, claims tmw.TumblerClaims) (*client.SessionDetails, error)
 	ChangeDetails(req *http.Request, claims tmw.TumblerClaims, updates client.ChangeSessionDetails) (*client.SessionDetails, *http.Cookie, error)
 }
 
@@ -36,13 +39,13 @@ func NewSessionService(logger logging.Logger, identities identities.Service, ser
 	}
 }
 
-func (s *sessionService) GetDetails(claims tmw.TumblerClaims) (*client.SessionDetails, error) {
+func (s *sessionService) GetDetails(ctx context.Context, claims tmw.TumblerClaims) (*client.SessionDetails, error) {
 	// Require people have used credentials to login
 	if err := s.ensureHuman(claims); err != nil {
 		return nil, err
 	}
 
-	identity, err := s.getIdentity(claims.IdentityID)
+	identity, err := s.getIdentity(ctx, claims.IdentityID)
 	if err != nil {
 		return nil, s.logger.Info().LogError("Unable to lookup identity", err)
 	}
@@ -75,20 +78,22 @@ func (s *sessionService) ChangeDetails(req *http.Request, claims tmw.TumblerClai
 	if update.TenantID != nil {
 		tid, err := uuid.Parse(*update.TenantID)
 		if err != nil {
-			return nil, nil, err
+			return nil, nil, validation.Errors{"tenantID": errors.New("must be a valid UUID")}
 		}
 
 		claims.TenantID = tid
 	}
 
+	ctx := req.Context()
+
 	// Get the new details after the changes
-	details, err := s.GetDetails(claims)
+	details, err := s.GetDetails(ctx, claims)
 	if err != nil {
 		return nil, nil, err
 	}
 
 	// Record the use of the credential to log into another tenant/identity
-	if err := s.credentials.Record(claims.CredentialID.String(), claims.TenantID.String(), claims.ID, claims.RemoteAddr); err != nil {
+	if err := s.credentials.Record(ctx, claims.CredentialID.String(), claims.TenantID.String(), claims.ID, claims.RemoteAddr); err != nil {
 		return nil, nil, s.logger.LogError("Already logged into this tenant, failing", err)
 	}
 
@@ -105,12 +110,12 @@ func (s *sessionService) ChangeDetails(req *http.Request, claims tmw.TumblerClai
 	return details, cookie, nil
 }
 
-func (s *sessionService) getIdentity(identityID *uuid.UUID) (*client.Identity, error) {
+func (s *sessionService) getIdentity(ctx context.Context, identityID *uuid.UUID) (*client.Identity, error) {
 	if identityID == nil {
 		return nil, ErrIdentityNotFound
 	}
 
-	identity, err := s.identities.GetIdentityByID(identityID.String())
+	identity, err := s.identities.GetIdentityByID(ctx, identityID.String())
 	if err != nil {
 		return nil, s.logger.Info().LogError("Unable to lookup identity", err)
 	}